@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 
+	"cmall_dd/internal/utils"
 	_ "github.com/lib/pq"
 )
 
@@ -149,7 +150,8 @@ func CreateTables(db *sql.DB) error {
 
 	log.Println("Successfully created cart table")
 
-	// Create cart_sessions table (binds anonymous cart session_id to client IP)
+	// Create cart_sessions table (anonymous cart sessions; client_ip/guest_cookie
+	// are only used to migrate sessions created before signed guest tokens)
 	createCartSessionsTableSQL := `
 	CREATE TABLE IF NOT EXISTS cart_sessions (
 		session_id VARCHAR(255) PRIMARY KEY,
@@ -434,5 +436,932 @@ func CreateTables(db *sql.DB) error {
 	}
 	log.Println("Successfully created analysis_requests table")
 
+	// KRX 종목 마스터 (관리자 CSV 임포트 — 분석 심볼 검증/자동완성)
+	createInstrumentsTableSQL := `
+	CREATE TABLE IF NOT EXISTS instruments (
+		code VARCHAR(12) PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		market VARCHAR(16) NOT NULL DEFAULT '',
+		sector VARCHAR(255) NOT NULL DEFAULT '',
+		is_active BOOLEAN NOT NULL DEFAULT TRUE,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_instruments_name ON instruments(name);
+	`
+	if _, err := db.Exec(createInstrumentsTableSQL); err != nil {
+		return fmt.Errorf("failed to create instruments table: %w", err)
+	}
+	log.Println("Successfully created instruments table")
+
+	// 관심종목 (사용자 소유, 예약 분석: schedule_request_type + schedule_hour KST)
+	// last_scheduled_at은 KST 예약 슬롯과 비교하므로 TIMESTAMPTZ
+	createWatchlistsTableSQL := `
+	CREATE TABLE IF NOT EXISTS watchlists (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+		name VARCHAR(100) NOT NULL,
+		schedule_request_type VARCHAR(64),
+		schedule_hour INTEGER CHECK (schedule_hour BETWEEN 0 AND 23),
+		last_scheduled_at TIMESTAMPTZ,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_watchlists_user_id ON watchlists(user_id);
+
+	CREATE TABLE IF NOT EXISTS watchlist_items (
+		watchlist_id INTEGER REFERENCES watchlists(id) ON DELETE CASCADE,
+		stock_code VARCHAR(12) NOT NULL,
+		position INTEGER NOT NULL DEFAULT 0,
+		added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (watchlist_id, stock_code)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_watchlist_items_stock_code ON watchlist_items(stock_code);
+
+	ALTER TABLE analysis_requests ADD COLUMN IF NOT EXISTS watchlist_id INTEGER REFERENCES watchlists(id) ON DELETE SET NULL;
+	CREATE INDEX IF NOT EXISTS idx_analysis_requests_watchlist_id ON analysis_requests(watchlist_id) WHERE watchlist_id IS NOT NULL;
+	`
+	if _, err := db.Exec(createWatchlistsTableSQL); err != nil {
+		return fmt.Errorf("failed to create watchlists tables: %w", err)
+	}
+	log.Println("Successfully created watchlists tables")
+
+	// 분석 결과 알림: 규칙(alert_rules) + 이력(alerts, 규칙·종목·KST 일자별 1건)
+	createAlertsTableSQL := `
+	CREATE TABLE IF NOT EXISTS alert_rules (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+		name VARCHAR(100) NOT NULL,
+		request_type VARCHAR(64) NOT NULL DEFAULT '',
+		min_score DOUBLE PRECISION NOT NULL DEFAULT 0,
+		watchlist_id INTEGER REFERENCES watchlists(id) ON DELETE CASCADE,
+		channels TEXT[] NOT NULL DEFAULT '{in_app}',
+		telegram_chat_id VARCHAR(64) NOT NULL DEFAULT '',
+		is_active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_alert_rules_user_id ON alert_rules(user_id);
+
+	CREATE TABLE IF NOT EXISTS alerts (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+		rule_id INTEGER REFERENCES alert_rules(id) ON DELETE SET NULL,
+		analysis_request_id INTEGER REFERENCES analysis_requests(id) ON DELETE CASCADE,
+		request_type VARCHAR(64) NOT NULL,
+		stock_code VARCHAR(12) NOT NULL,
+		stock_name VARCHAR(255) NOT NULL DEFAULT '',
+		score DOUBLE PRECISION NOT NULL,
+		message TEXT NOT NULL,
+		alert_date DATE NOT NULL,
+		delivered_channels TEXT[] NOT NULL DEFAULT '{}',
+		delivery_error TEXT,
+		read_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_alerts_rule_stock_date ON alerts(rule_id, stock_code, alert_date);
+	CREATE INDEX IF NOT EXISTS idx_alerts_user_created ON alerts(user_id, created_at DESC);
+
+	ALTER TABLE analysis_requests ADD COLUMN IF NOT EXISTS alerts_evaluated_at TIMESTAMP;
+	`
+	if _, err := db.Exec(createAlertsTableSQL); err != nil {
+		return fmt.Errorf("failed to create alerts tables: %w", err)
+	}
+	log.Println("Successfully created alerts tables")
+
+	// 카탈로그 정렬: 최신순 + 인기순(paid 결제 수) 인덱스 (평점 컬럼은 리뷰 테이블과 함께 아래에서)
+	alterProductsCatalogSQL := `
+	CREATE INDEX IF NOT EXISTS idx_products_active_created ON products(created_at DESC, id DESC) WHERE is_active = true;
+	CREATE INDEX IF NOT EXISTS idx_payments_order_paid ON payments(order_id) WHERE status = 'paid';
+	`
+	if _, err := db.Exec(alterProductsCatalogSQL); err != nil {
+		return fmt.Errorf("failed to create catalog indexes: %w", err)
+	}
+
+	// 시맨틱 검색용 상품 임베딩 (pgvector). 확장이 없으면 경고만 남기고 계속 (키워드 검색으로 대체됨)
+	alterProductsEmbeddingSQL := fmt.Sprintf(`
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS embedding vector(%d);
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS embedding_model VARCHAR(100);
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS embedded_at TIMESTAMP;
+	CREATE INDEX IF NOT EXISTS idx_products_embedding_hnsw ON products USING hnsw (embedding vector_cosine_ops);
+	`, utils.EmbeddingDimension())
+	if _, err := db.Exec(alterProductsEmbeddingSQL); err != nil {
+		log.Printf("Warning: Could not add embedding column to products (pgvector unavailable?): %v", err)
+	}
+
+	// 전문 검색: tsvector(트리거 유지) + 한글 바이그램 + pg_trgm(오타/조사 보정)
+	// 'simple' 구성은 형태소 분석을 하지 않으므로, 한글 연속 구간을 2글자 단위로 쪼갠
+	// 바이그램을 함께 색인해 "삼성전자" 질의가 "삼성전자를" 같은 어절에도 맞도록 한다.
+	if _, err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm"); err != nil {
+		log.Printf("Warning: Could not create pg_trgm extension (trigram matching disabled): %v", err)
+	}
+	createFullTextSearchSQL := `
+	CREATE OR REPLACE FUNCTION cmall_hangul_bigrams(input TEXT) RETURNS TEXT AS $$
+	DECLARE
+		result TEXT := '';
+		word TEXT;
+		i INTEGER;
+	BEGIN
+		FOREACH word IN ARRAY regexp_split_to_array(COALESCE(input, ''), '[^가-힣]+') LOOP
+			IF char_length(word) >= 2 THEN
+				FOR i IN 1..char_length(word) - 1 LOOP
+					result := result || ' ' || substr(word, i, 2);
+				END LOOP;
+			END IF;
+		END LOOP;
+		RETURN result;
+	END;
+	$$ LANGUAGE plpgsql IMMUTABLE;
+
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector;
+	CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING gin (search_vector);
+
+	CREATE OR REPLACE FUNCTION products_search_vector_update() RETURNS trigger AS $$
+	BEGIN
+		NEW.search_vector :=
+			setweight(to_tsvector('simple', COALESCE(NEW.name, '') || ' ' || cmall_hangul_bigrams(NEW.name)), 'A') ||
+			setweight(to_tsvector('simple', COALESCE(NEW.category, '')), 'B') ||
+			setweight(to_tsvector('simple', COALESCE(NEW.description, '') || ' ' || cmall_hangul_bigrams(NEW.description)), 'C');
+		RETURN NEW;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	DROP TRIGGER IF EXISTS trg_products_search_vector ON products;
+	CREATE TRIGGER trg_products_search_vector
+		BEFORE INSERT OR UPDATE OF name, category, description ON products
+		FOR EACH ROW EXECUTE FUNCTION products_search_vector_update();
+
+	ALTER TABLE community_posts ADD COLUMN IF NOT EXISTS search_vector tsvector;
+	CREATE INDEX IF NOT EXISTS idx_community_posts_search_vector ON community_posts USING gin (search_vector);
+
+	CREATE OR REPLACE FUNCTION community_posts_search_vector_update() RETURNS trigger AS $$
+	BEGIN
+		NEW.search_vector :=
+			setweight(to_tsvector('simple', COALESCE(NEW.title, '') || ' ' || cmall_hangul_bigrams(NEW.title)), 'A') ||
+			setweight(to_tsvector('simple', COALESCE(NEW.content, '') || ' ' || cmall_hangul_bigrams(NEW.content)), 'B');
+		RETURN NEW;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	DROP TRIGGER IF EXISTS trg_community_posts_search_vector ON community_posts;
+	CREATE TRIGGER trg_community_posts_search_vector
+		BEFORE INSERT OR UPDATE OF title, content ON community_posts
+		FOR EACH ROW EXECUTE FUNCTION community_posts_search_vector_update();
+
+	-- 기존 행 백필 (트리거가 search_vector를 채운다)
+	UPDATE products SET name = name WHERE search_vector IS NULL;
+	UPDATE community_posts SET title = title WHERE search_vector IS NULL;
+	`
+	if _, err := db.Exec(createFullTextSearchSQL); err != nil {
+		return fmt.Errorf("failed to set up full-text search: %w", err)
+	}
+	createTrigramIndexesSQL := `
+	CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING gin (name gin_trgm_ops);
+	CREATE INDEX IF NOT EXISTS idx_community_posts_title_trgm ON community_posts USING gin (title gin_trgm_ops);
+	`
+	if _, err := db.Exec(createTrigramIndexesSQL); err != nil {
+		log.Printf("Warning: Could not create trigram indexes: %v", err)
+	}
+
+	// 디지털 배송: 스토리지 아티팩트 + 구매별 다운로드 카운터
+	createDeliverySQL := `
+	CREATE TABLE IF NOT EXISTS artifacts (
+		id SERIAL PRIMARY KEY,
+		storage_key VARCHAR(512) UNIQUE NOT NULL,
+		filename VARCHAR(255) NOT NULL,
+		content_type VARCHAR(255) NOT NULL DEFAULT 'application/octet-stream',
+		size_bytes BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS artifact_id INTEGER REFERENCES artifacts(id) ON DELETE SET NULL;
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS download_limit INTEGER;
+
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS download_count INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS last_downloaded_at TIMESTAMP;
+	`
+	if _, err := db.Exec(createDeliverySQL); err != nil {
+		return fmt.Errorf("failed to create delivery tables: %w", err)
+	}
+	log.Println("Successfully created delivery tables")
+
+	// 판매자 업로드: 아티팩트 메타데이터(체크섬/썸네일) + 재개 가능 업로드(tus).
+	// 상품 대표 이미지는 image_artifact_id로 참조한다 (image에는 표시용 URL). 기존 URL 참조는 ID로 옮긴다.
+	createUploadsSQL := `
+	ALTER TABLE artifacts ADD COLUMN IF NOT EXISTS owner_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+	ALTER TABLE artifacts ADD COLUMN IF NOT EXISTS kind VARCHAR(16) NOT NULL DEFAULT 'file';
+	ALTER TABLE artifacts ADD COLUMN IF NOT EXISTS sha256 CHAR(64);
+	ALTER TABLE artifacts ADD COLUMN IF NOT EXISTS thumbnail_key VARCHAR(512);
+	CREATE INDEX IF NOT EXISTS idx_artifacts_owner ON artifacts(owner_id, created_at DESC);
+
+	CREATE TABLE IF NOT EXISTS uploads (
+		id VARCHAR(64) PRIMARY KEY,
+		owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		kind VARCHAR(16) NOT NULL,
+		filename VARCHAR(255) NOT NULL,
+		upload_length BIGINT NOT NULL,
+		upload_offset BIGINT NOT NULL DEFAULT 0,
+		artifact_id INTEGER REFERENCES artifacts(id) ON DELETE SET NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS upload_parts (
+		upload_id VARCHAR(64) NOT NULL REFERENCES uploads(id) ON DELETE CASCADE,
+		part_offset BIGINT NOT NULL,
+		size_bytes BIGINT NOT NULL,
+		storage_key VARCHAR(512) NOT NULL,
+		PRIMARY KEY (upload_id, part_offset)
+	);
+
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS image_artifact_id INTEGER REFERENCES artifacts(id) ON DELETE RESTRICT;
+	CREATE INDEX IF NOT EXISTS idx_products_image_artifact ON products(image_artifact_id) WHERE image_artifact_id IS NOT NULL;
+	UPDATE products p SET image_artifact_id = a.id
+	FROM artifacts a
+	WHERE p.image_artifact_id IS NULL AND p.image ~ '^/api/v1/images/[0-9]+$'
+	  AND a.id = substring(p.image from '[0-9]+$')::int AND a.kind = 'image';
+	`
+	if _, err := db.Exec(createUploadsSQL); err != nil {
+		return fmt.Errorf("failed to create uploads tables: %w", err)
+	}
+	log.Println("Successfully created uploads tables")
+
+	// 소프트웨어 릴리스 (semver + stable/beta 채널, 릴리스당 아티팩트 1개)
+	createReleasesSQL := `
+	CREATE TABLE IF NOT EXISTS product_releases (
+		id SERIAL PRIMARY KEY,
+		product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+		version VARCHAR(64) NOT NULL,
+		channel VARCHAR(16) NOT NULL DEFAULT 'stable' CHECK (channel IN ('stable', 'beta')),
+		changelog TEXT NOT NULL DEFAULT '',
+		artifact_id INTEGER REFERENCES artifacts(id) ON DELETE RESTRICT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (product_id, version)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_product_releases_product ON product_releases(product_id);
+	`
+	if _, err := db.Exec(createReleasesSQL); err != nil {
+		return fmt.Errorf("failed to create product_releases table: %w", err)
+	}
+	log.Println("Successfully created product_releases table")
+
+	// 구매별 라이선스 키 (Ed25519 서명) + 기기 활성화 좌석
+	// request_type은 AI 분석 상품에만 둔다 (라이선스 발급 제외 기준). 기본값이 'stock_report'이던 때
+	// 만들어진 다운로드형 상품은 1회만 비운다 (기본값이 바뀌었는지로 판별)
+	createLicensesSQL := `
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS max_activations INTEGER;
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS billing_interval_days INTEGER;
+
+	DO $$
+	BEGIN
+		IF EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = 'products'
+			  AND column_name = 'request_type' AND column_default LIKE '%stock_report%'
+		) THEN
+			UPDATE products p SET request_type = ''
+			WHERE p.request_type = 'stock_report'
+			  AND (COALESCE(p.download_url, '') <> '' OR EXISTS (SELECT 1 FROM product_releases r WHERE r.product_id = p.id));
+			ALTER TABLE products ALTER COLUMN request_type SET DEFAULT '';
+		END IF;
+	END $$;
+
+	CREATE TABLE IF NOT EXISTS licenses (
+		id SERIAL PRIMARY KEY,
+		payment_id INTEGER UNIQUE NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+		license_key TEXT UNIQUE NOT NULL,
+		max_activations INTEGER NOT NULL,
+		status VARCHAR(16) NOT NULL DEFAULT 'active',
+		revoked_at TIMESTAMP,
+		revoke_reason VARCHAR(64),
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_licenses_user ON licenses(user_id);
+
+	CREATE TABLE IF NOT EXISTS license_activations (
+		id SERIAL PRIMARY KEY,
+		license_id INTEGER NOT NULL REFERENCES licenses(id) ON DELETE CASCADE,
+		fingerprint_hash CHAR(64) NOT NULL,
+		machine_name VARCHAR(100) NOT NULL DEFAULT '',
+		activated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (license_id, fingerprint_hash)
+	);
+
+	-- 분석/구독 상품에 잘못 발급됐던 키 정리 (활성화 좌석은 CASCADE)
+	DELETE FROM licenses l USING products p
+	WHERE l.product_id = p.id AND (p.request_type <> '' OR p.billing_interval_days IS NOT NULL);
+	`
+	if _, err := db.Exec(createLicensesSQL); err != nil {
+		return fmt.Errorf("failed to create licenses tables: %w", err)
+	}
+	log.Println("Successfully created licenses tables")
+
+	// 상품 리뷰 (구매 확인된 사용자만, 상품당 1건). 집계는 products.rating_avg/rating_count
+	// (목록 sort=rating용 비정규화 — handlers/reviews.go refreshProductRating이 갱신)
+	createReviewsSQL := `
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS rating_avg NUMERIC(3,2) NOT NULL DEFAULT 0;
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS rating_count INTEGER NOT NULL DEFAULT 0;
+
+	CREATE TABLE IF NOT EXISTS product_reviews (
+		id SERIAL PRIMARY KEY,
+		product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+		content TEXT NOT NULL DEFAULT '',
+		seller_reply TEXT,
+		seller_replied_at TIMESTAMP,
+		status VARCHAR(16) NOT NULL DEFAULT 'visible',
+		moderation_note VARCHAR(500),
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (product_id, user_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_product_reviews_product ON product_reviews(product_id, status, created_at DESC);
+	`
+	if _, err := db.Exec(createReviewsSQL); err != nil {
+		return fmt.Errorf("failed to create product_reviews table: %w", err)
+	}
+	log.Println("Successfully created product_reviews table")
+
+	// 쿠폰 (관리자 발급). discount_value: percent면 1~100, fixed면 USDC 마이크로 단위.
+	// product_ids/categories가 비어 있으면 전체 상품 대상. 사용 기록은 coupon_redemptions에 결제당 1건.
+	// 사용 기간은 TIMESTAMPTZ — 관리자가 입력한 +09:00 시각이 벽시계 값으로 저장되지 않도록.
+	createCouponsSQL := `
+	CREATE TABLE IF NOT EXISTS coupons (
+		id SERIAL PRIMARY KEY,
+		code VARCHAR(64) UNIQUE NOT NULL,
+		description VARCHAR(255) NOT NULL DEFAULT '',
+		discount_type VARCHAR(16) NOT NULL CHECK (discount_type IN ('percent', 'fixed')),
+		discount_value BIGINT NOT NULL CHECK (discount_value > 0),
+		starts_at TIMESTAMPTZ,
+		expires_at TIMESTAMPTZ,
+		max_redemptions INTEGER,
+		per_user_limit INTEGER NOT NULL DEFAULT 1,
+		product_ids INTEGER[] NOT NULL DEFAULT '{}',
+		categories TEXT[] NOT NULL DEFAULT '{}',
+		first_purchase_only BOOLEAN NOT NULL DEFAULT FALSE,
+		is_active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS coupon_redemptions (
+		id SERIAL PRIMARY KEY,
+		coupon_id INTEGER NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		payment_id INTEGER UNIQUE NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+		original_amount_usdc BIGINT NOT NULL,
+		discount_usdc BIGINT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_coupon_user ON coupon_redemptions(coupon_id, user_id);
+
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS coupon_id INTEGER REFERENCES coupons(id) ON DELETE SET NULL;
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS discount_usdc BIGINT NOT NULL DEFAULT 0;
+	`
+	if _, err := db.Exec(createCouponsSQL); err != nil {
+		return fmt.Errorf("failed to create coupons tables: %w", err)
+	}
+	log.Println("Successfully created coupons tables")
+
+	// 환율 (KRW per 1 USDC). exchange_rates는 조회 캐시 겸 이력, overrides는 관리자 수동 고정값.
+	// products.price_sync: manual | krw (price → crypto_price_usdc 산출) | usdc (crypto_price_usdc → price 산출)
+	createExchangeRatesSQL := `
+	CREATE TABLE IF NOT EXISTS exchange_rates (
+		id SERIAL PRIMARY KEY,
+		pair VARCHAR(16) NOT NULL DEFAULT 'USDC/KRW',
+		rate NUMERIC(14,4) NOT NULL CHECK (rate > 0),
+		source VARCHAR(32) NOT NULL,
+		fetched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_exchange_rates_pair_fetched ON exchange_rates(pair, fetched_at DESC);
+
+	CREATE TABLE IF NOT EXISTS exchange_rate_overrides (
+		id SERIAL PRIMARY KEY,
+		pair VARCHAR(16) NOT NULL DEFAULT 'USDC/KRW',
+		rate NUMERIC(14,4) NOT NULL CHECK (rate > 0),
+		reason VARCHAR(255) NOT NULL DEFAULT '',
+		created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		expires_at TIMESTAMP,
+		cleared_at TIMESTAMP
+	);
+
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS price_sync VARCHAR(8) NOT NULL DEFAULT 'manual';
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS krw_per_usdc NUMERIC(14,4);
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS amount_krw BIGINT;
+	`
+	if _, err := db.Exec(createExchangeRatesSQL); err != nil {
+		return fmt.Errorf("failed to create exchange rate tables: %w", err)
+	}
+	log.Println("Successfully created exchange rate tables")
+
+	// 상품 라이프사이클: status + 예약 공개(publish_at) + soft delete(deleted_at).
+	// is_active는 공개 여부(published이고 삭제되지 않음)를 트리거로 파생 — 기존 공개 조회 조건을 그대로 쓴다.
+	// 기존 is_active=false 상품은 archived로 옮긴 뒤 트리거를 건다.
+	// publish_at은 TIMESTAMPTZ — 클라이언트가 보낸 오프셋(+09:00)을 잃으면 NOW() 비교가 9시간 어긋난다.
+	alterProductsLifecycleSQL := `
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'published'
+		CHECK (status IN ('draft', 'pending_review', 'published', 'archived'));
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+	UPDATE products SET status = 'archived'
+	WHERE is_active = false AND status = 'published' AND deleted_at IS NULL;
+
+	CREATE OR REPLACE FUNCTION products_visibility_update() RETURNS trigger AS $$
+	BEGIN
+		NEW.is_active := NEW.status = 'published' AND NEW.deleted_at IS NULL;
+		RETURN NEW;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	DROP TRIGGER IF EXISTS trg_products_visibility ON products;
+	CREATE TRIGGER trg_products_visibility
+		BEFORE INSERT OR UPDATE ON products
+		FOR EACH ROW EXECUTE FUNCTION products_visibility_update();
+
+	CREATE INDEX IF NOT EXISTS idx_products_seller_status ON products(seller_id, status) WHERE deleted_at IS NULL;
+	CREATE INDEX IF NOT EXISTS idx_products_publish_at ON products(publish_at) WHERE status = 'draft' AND publish_at IS NOT NULL;
+	`
+	if _, err := db.Exec(alterProductsLifecycleSQL); err != nil {
+		return fmt.Errorf("failed to add lifecycle columns to products: %w", err)
+	}
+	log.Println("Successfully added product lifecycle columns")
+
+	// 상품 심사: 판매자 상품은 pending_review로 들어와 관리자 결정을 기다린다.
+	// product_moderation_events는 제출/결정 감사 로그, products.review_note는 최근 결정 사유.
+	// notifications는 사용자 인앱 알림함 (심사 결과 등; 이메일은 SMTP 설정 시 함께 발송)
+	createModerationSQL := `
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS review_note TEXT;
+
+	CREATE TABLE IF NOT EXISTS product_moderation_events (
+		id SERIAL PRIMARY KEY,
+		product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+		actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+		action VARCHAR(24) NOT NULL CHECK (action IN ('submitted', 'approved', 'rejected', 'changes_requested')),
+		reason TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_product_moderation_events_product ON product_moderation_events(product_id, created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_products_pending_review ON products(updated_at) WHERE status = 'pending_review' AND deleted_at IS NULL;
+
+	CREATE TABLE IF NOT EXISTS notifications (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		kind VARCHAR(32) NOT NULL,
+		title VARCHAR(255) NOT NULL,
+		body TEXT NOT NULL DEFAULT '',
+		link VARCHAR(512) NOT NULL DEFAULT '',
+		read_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_notifications_user_created ON notifications(user_id, created_at DESC);
+	`
+	if _, err := db.Exec(createModerationSQL); err != nil {
+		return fmt.Errorf("failed to create moderation tables: %w", err)
+	}
+	log.Println("Successfully created moderation and notifications tables")
+
+	// 카탈로그 가져오기/내보내기: 숫자 ID 대신 안정적인 sku로 upsert 한다.
+	// 기존 상품과 sku 없이 생성되는 상품은 'p<id>'로 채운다 (BEFORE INSERT 시점엔 id 기본값이 이미 할당됨).
+	// product_import_jobs는 대용량 가져오기 비동기 처리 (payload = 원본 파일, result = 행별 diff/오류)
+	createProductImportSQL := `
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS sku VARCHAR(64) UNIQUE;
+	UPDATE products SET sku = 'p' || id WHERE sku IS NULL;
+
+	CREATE OR REPLACE FUNCTION products_sku_default() RETURNS trigger AS $$
+	BEGIN
+		IF NEW.sku IS NULL OR NEW.sku = '' THEN
+			NEW.sku := 'p' || NEW.id;
+		END IF;
+		RETURN NEW;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	DROP TRIGGER IF EXISTS trg_products_sku_default ON products;
+	CREATE TRIGGER trg_products_sku_default
+		BEFORE INSERT ON products
+		FOR EACH ROW EXECUTE FUNCTION products_sku_default();
+
+	CREATE TABLE IF NOT EXISTS product_import_jobs (
+		id SERIAL PRIMARY KEY,
+		admin_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+		format VARCHAR(8) NOT NULL,
+		dry_run BOOLEAN NOT NULL DEFAULT TRUE,
+		payload TEXT NOT NULL,
+		status VARCHAR(16) NOT NULL DEFAULT 'queued',
+		total_rows INTEGER NOT NULL DEFAULT 0,
+		result JSONB,
+		error TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		started_at TIMESTAMP,
+		finished_at TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_product_import_jobs_queued ON product_import_jobs(created_at) WHERE status = 'queued';
+	`
+	if _, err := db.Exec(createProductImportSQL); err != nil {
+		return fmt.Errorf("failed to create product import tables: %w", err)
+	}
+	log.Println("Successfully created product import tables")
+
+	// 카테고리/태그: products.category(자유 문자열)는 하위 호환용 표시 이름으로 남기고
+	// category_id가 정식 참조다. 트리거가 둘을 맞춘다 — category_id를 바꾸면 category에 이름을 채우고,
+	// 구 클라이언트가 category 문자열만 보내면 이름(또는 slug)이 같은 카테고리를 찾아 category_id를 채운다
+	// (같은 이름이 여럿이면 현재 category_id 우선 — 카테고리 이름 변경 시 products.category 갱신용).
+	// 기존 category 값은 최상위 카테고리로 옮긴다 (slug = 영숫자 부분 + 이름 해시 6자리, 관리자가 나중에 바꿀 수 있음).
+	// names는 로케일별 이름 ({"en": "AI Analysis"}), name은 기본(한국어) 이름.
+	createCategoriesSQL := `
+	CREATE TABLE IF NOT EXISTS categories (
+		id SERIAL PRIMARY KEY,
+		parent_id INTEGER REFERENCES categories(id) ON DELETE RESTRICT,
+		slug VARCHAR(100) NOT NULL UNIQUE,
+		name VARCHAR(100) NOT NULL,
+		names JSONB NOT NULL DEFAULT '{}',
+		sort_order INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_categories_parent ON categories(parent_id);
+
+	INSERT INTO categories (slug, name)
+	SELECT COALESCE(NULLIF(TRIM(BOTH '-' FROM LOWER(REGEXP_REPLACE(category, '[^a-zA-Z0-9]+', '-', 'g'))), ''), 'category')
+	       || '-' || SUBSTR(MD5(category), 1, 6), category
+	FROM (SELECT DISTINCT category FROM products WHERE COALESCE(category, '') <> '') legacy
+	WHERE NOT EXISTS (SELECT 1 FROM categories c WHERE c.name = legacy.category)
+	ON CONFLICT (slug) DO NOTHING;
+
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL;
+	CREATE INDEX IF NOT EXISTS idx_products_category_id ON products(category_id);
+	UPDATE products p SET category_id = c.id FROM categories c
+	WHERE p.category_id IS NULL AND c.name = p.category;
+
+	CREATE OR REPLACE FUNCTION products_category_sync() RETURNS trigger AS $$
+	DECLARE
+		cat RECORD;
+	BEGIN
+		IF NEW.category_id IS NOT NULL AND (TG_OP = 'INSERT' OR NEW.category_id IS DISTINCT FROM OLD.category_id) THEN
+			SELECT name INTO NEW.category FROM categories WHERE id = NEW.category_id;
+		ELSIF TG_OP = 'INSERT' OR NEW.category IS DISTINCT FROM OLD.category THEN
+			SELECT id, name INTO cat FROM categories
+			WHERE name = NEW.category OR slug = NEW.category
+			ORDER BY (id = NEW.category_id) DESC, (name = NEW.category) DESC, id LIMIT 1;
+			IF FOUND THEN
+				NEW.category_id := cat.id;
+				NEW.category := cat.name;
+			ELSE
+				NEW.category_id := NULL;
+			END IF;
+		END IF;
+		RETURN NEW;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	-- BEFORE 트리거는 이름순으로 실행된다: category_sync가 search_vector보다 먼저 category를 채운다
+	DROP TRIGGER IF EXISTS trg_products_category_sync ON products;
+	CREATE TRIGGER trg_products_category_sync
+		BEFORE INSERT OR UPDATE OF category, category_id ON products
+		FOR EACH ROW EXECUTE FUNCTION products_category_sync();
+
+	DROP TRIGGER IF EXISTS trg_products_search_vector ON products;
+	CREATE TRIGGER trg_products_search_vector
+		BEFORE INSERT OR UPDATE OF name, category, category_id, description ON products
+		FOR EACH ROW EXECUTE FUNCTION products_search_vector_update();
+
+	CREATE TABLE IF NOT EXISTS tags (
+		id SERIAL PRIMARY KEY,
+		slug VARCHAR(64) NOT NULL UNIQUE,
+		name VARCHAR(100) NOT NULL,
+		names JSONB NOT NULL DEFAULT '{}',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS product_tags (
+		product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+		tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (product_id, tag_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_product_tags_tag ON product_tags(tag_id);
+	`
+	if _, err := db.Exec(createCategoriesSQL); err != nil {
+		return fmt.Errorf("failed to create category and tag tables: %w", err)
+	}
+	log.Println("Successfully created category and tag tables")
+
+	// 기능 목록/시스템 요구사항을 검증된 JSONB로 전환 (TEXT였던 기존 값 변환):
+	// features — JSON 배열 문자열이면 원소별로 정규화(문자열·스키마에 맞지 않는 원소는 {title: 원문}), 아니면 줄 단위 텍스트를 [{title}]로.
+	// system_requirements — 스키마에 맞는 JSON 객체면 그대로, 아니면 {notes: 원문}. 빈 값은 NULL.
+	// 이미 JSONB로 바뀐 값도 기동 시마다 같은 규칙으로 정규화한다 (모델 Scan이 엄격하게 읽으므로).
+	createProductSpecsSQL := `
+	CREATE OR REPLACE FUNCTION cmall_features_normalize(j JSONB) RETURNS JSONB AS $$
+		SELECT CASE
+			WHEN j IS NULL OR jsonb_typeof(j) = 'null' THEN NULL
+			WHEN jsonb_typeof(j) <> 'array' THEN jsonb_build_array(jsonb_build_object('title',
+				CASE WHEN jsonb_typeof(j) = 'string' THEN j #>> '{}' ELSE j::text END))
+			ELSE (SELECT jsonb_agg(CASE
+					WHEN jsonb_typeof(e) = 'string' THEN jsonb_build_object('title', e #>> '{}')
+					WHEN jsonb_typeof(e) = 'object' AND jsonb_typeof(e -> 'title') = 'string'
+					     AND (NOT e ? 'description' OR jsonb_typeof(e -> 'description') = 'string') THEN e
+					ELSE jsonb_build_object('title', e::text)
+				END ORDER BY ord)
+				FROM jsonb_array_elements(j) WITH ORDINALITY AS x(e, ord)
+				WHERE jsonb_typeof(e) <> 'null')
+		END
+	$$ LANGUAGE sql IMMUTABLE;
+
+	CREATE OR REPLACE FUNCTION cmall_requirements_valid(j JSONB) RETURNS BOOLEAN AS $$
+		SELECT jsonb_typeof(j) = 'object' AND NOT EXISTS (
+			SELECT 1 FROM jsonb_each(j) kv
+			WHERE NOT CASE
+				WHEN kv.key IN ('os', 'cpuArch', 'brokers') THEN jsonb_typeof(kv.value) = 'array'
+					AND NOT EXISTS (SELECT 1 FROM jsonb_array_elements(kv.value) e WHERE jsonb_typeof(e) <> 'string')
+				WHEN kv.key IN ('minCpuCores', 'minRamMb', 'minDiskMb') THEN jsonb_typeof(kv.value) = 'number'
+					AND (kv.value #>> '{}') ~ '^[0-9]{1,9}$'
+				WHEN kv.key = 'notes' THEN jsonb_typeof(kv.value) = 'string'
+				ELSE FALSE
+			END
+		)
+	$$ LANGUAGE sql IMMUTABLE;
+
+	CREATE OR REPLACE FUNCTION cmall_requirements_normalize(j JSONB) RETURNS JSONB AS $$
+		SELECT CASE
+			WHEN j IS NULL OR jsonb_typeof(j) = 'null' THEN NULL
+			WHEN cmall_requirements_valid(j) THEN j
+			ELSE jsonb_build_object('notes', CASE WHEN jsonb_typeof(j) = 'string' THEN j #>> '{}' ELSE j::text END)
+		END
+	$$ LANGUAGE sql IMMUTABLE;
+
+	CREATE OR REPLACE FUNCTION cmall_features_from_text(t TEXT) RETURNS JSONB AS $$
+	DECLARE
+		j JSONB;
+	BEGIN
+		IF t IS NULL OR BTRIM(t) = '' THEN
+			RETURN NULL;
+		END IF;
+		BEGIN
+			j := t::jsonb;
+		EXCEPTION WHEN others THEN
+			j := NULL;
+		END;
+		IF j IS NOT NULL AND jsonb_typeof(j) = 'array' THEN
+			RETURN cmall_features_normalize(j);
+		END IF;
+		RETURN (SELECT jsonb_agg(jsonb_build_object('title', BTRIM(line)))
+		        FROM regexp_split_to_table(t, E'\\r?\\n') line
+		        WHERE BTRIM(line) <> '');
+	END;
+	$$ LANGUAGE plpgsql IMMUTABLE;
+
+	CREATE OR REPLACE FUNCTION cmall_requirements_from_text(t TEXT) RETURNS JSONB AS $$
+	DECLARE
+		j JSONB;
+	BEGIN
+		IF t IS NULL OR BTRIM(t) = '' THEN
+			RETURN NULL;
+		END IF;
+		BEGIN
+			j := t::jsonb;
+		EXCEPTION WHEN others THEN
+			j := NULL;
+		END;
+		IF j IS NOT NULL AND jsonb_typeof(j) = 'object' THEN
+			RETURN cmall_requirements_normalize(j);
+		END IF;
+		RETURN jsonb_build_object('notes', BTRIM(t));
+	END;
+	$$ LANGUAGE plpgsql IMMUTABLE;
+
+	DO $$
+	BEGIN
+		IF (SELECT data_type FROM information_schema.columns
+		    WHERE table_name = 'products' AND column_name = 'features') = 'text' THEN
+			ALTER TABLE products ALTER COLUMN features TYPE JSONB USING cmall_features_from_text(features);
+		END IF;
+		IF (SELECT data_type FROM information_schema.columns
+		    WHERE table_name = 'products' AND column_name = 'system_requirements') = 'text' THEN
+			ALTER TABLE products ALTER COLUMN system_requirements TYPE JSONB
+				USING cmall_requirements_from_text(system_requirements);
+		END IF;
+	END;
+	$$;
+
+	UPDATE products SET features = cmall_features_normalize(features)
+	WHERE features IS DISTINCT FROM cmall_features_normalize(features);
+	UPDATE products SET system_requirements = cmall_requirements_normalize(system_requirements)
+	WHERE system_requirements IS DISTINCT FROM cmall_requirements_normalize(system_requirements);
+
+	CREATE INDEX IF NOT EXISTS idx_products_system_requirements
+		ON products USING gin (system_requirements jsonb_path_ops);
+	`
+	if _, err := db.Exec(createProductSpecsSQL); err != nil {
+		return fmt.Errorf("failed to migrate product features/requirements to JSONB: %w", err)
+	}
+	log.Println("Successfully migrated product features and system requirements to JSONB")
+
+	// 위시리스트: 담을 때의 가격(price_at_add/crypto_price_at_add)을 기준으로 wishlist-alerts 잡이
+	// 가격 인하와 재판매(archived/draft에서 공개 복귀)를 알린다. notified_*는 마지막으로 알린 할인가 (같은 할인 중복 알림 방지,
+	// 기준가 이상으로 돌아오면 초기화), was_active는 직전 점검 시점에 판매 중지 상태가 아니었는지 여부.
+	createWishlistSQL := `
+	CREATE TABLE IF NOT EXISTS wishlist_items (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+		price_at_add INTEGER NOT NULL,
+		crypto_price_at_add BIGINT NOT NULL DEFAULT 0,
+		notified_price INTEGER,
+		notified_crypto_price BIGINT,
+		was_active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (user_id, product_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_wishlist_items_product ON wishlist_items(product_id);
+	`
+	if _, err := db.Exec(createWishlistSQL); err != nil {
+		return fmt.Errorf("failed to create wishlist table: %w", err)
+	}
+	log.Println("Successfully created wishlist table")
+
+	// 장바구니 유지보수: price_at_add/crypto_price_at_add는 담을 때의 가격 (GetCart가 가격 변동을 표시),
+	// reminded_at은 방치 장바구니 알림을 보낸 시각 (담기/수량 변경 시 초기화). cart_sessions.last_seen_at은
+	// 비로그인 세션의 마지막 사용 시각으로, cart-cleanup 잡이 CART_ANON_TTL_HOURS가 지난 세션과 장바구니를 지운다.
+	createCartMaintenanceSQL := `
+	ALTER TABLE cart ADD COLUMN IF NOT EXISTS price_at_add INTEGER;
+	ALTER TABLE cart ADD COLUMN IF NOT EXISTS crypto_price_at_add BIGINT;
+	ALTER TABLE cart ADD COLUMN IF NOT EXISTS reminded_at TIMESTAMP;
+	ALTER TABLE cart_sessions ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+	CREATE INDEX IF NOT EXISTS idx_cart_sessions_last_seen ON cart_sessions(last_seen_at);
+	CREATE INDEX IF NOT EXISTS idx_cart_anonymous_updated ON cart(updated_at) WHERE user_id IS NULL;
+	`
+	if _, err := db.Exec(createCartMaintenanceSQL); err != nil {
+		return fmt.Errorf("failed to add cart maintenance columns: %w", err)
+	}
+	log.Println("Successfully added cart maintenance columns")
+
+	// 비로그인 장바구니는 서명된 게스트 토큰으로 식별한다 (handlers/guest_tokens.go). token_rotation은
+	// 토큰의 회전번호로, 재발급/폐기 시 올린다. client_ip/guest_cookie는 토큰 도입 전 세션의 이전에만 쓴다.
+	alterCartSessionsTokenSQL := `
+	ALTER TABLE cart_sessions ADD COLUMN IF NOT EXISTS token_rotation INTEGER NOT NULL DEFAULT 0;
+	`
+	if _, err := db.Exec(alterCartSessionsTokenSQL); err != nil {
+		return fmt.Errorf("failed to add token_rotation column to cart_sessions: %w", err)
+	}
+	log.Println("Successfully added guest token rotation to cart_sessions")
+
+	// 장바구니 수량 규칙 (handlers/cart_rules.go): 디지털 상품은 기본 1개 한정, is_single_purchase=false면
+	// max_quantity까지 (NULL은 제한 없음). 장바구니는 사용자/게스트 세션별로 상품당 한 줄 — 중복 줄은 수량을
+	// 합쳐 정리한 뒤 유니크 인덱스로 막고, 담기/합치기는 ON CONFLICT로 수량을 더한다.
+	createCartRulesSQL := `
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS is_single_purchase BOOLEAN NOT NULL DEFAULT TRUE;
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS max_quantity INTEGER CHECK (max_quantity IS NULL OR max_quantity >= 1);
+
+	-- 중복 줄은 가장 오래된 줄에 수량을 합친 뒤 (상품 수량 규칙으로 상한) 나머지를 지운다
+	UPDATE cart s
+	SET quantity = LEAST(d.total, CASE WHEN p.is_single_purchase THEN 1 ELSE p.max_quantity END),
+	    updated_at = CURRENT_TIMESTAMP
+	FROM (
+		SELECT MIN(id) AS keep_id, SUM(quantity) AS total
+		FROM cart
+		WHERE user_id IS NOT NULL OR session_id IS NOT NULL
+		GROUP BY product_id, user_id, CASE WHEN user_id IS NULL THEN session_id END
+		HAVING COUNT(*) > 1
+	) d, products p
+	WHERE s.id = d.keep_id AND p.id = s.product_id;
+
+	DELETE FROM cart a USING cart b
+	WHERE a.product_id = b.product_id AND a.id > b.id
+	  AND ((a.user_id IS NOT NULL AND a.user_id = b.user_id)
+	    OR (a.user_id IS NULL AND b.user_id IS NULL AND a.session_id = b.session_id));
+
+	CREATE UNIQUE INDEX IF NOT EXISTS uq_cart_user_product ON cart(user_id, product_id);
+	CREATE UNIQUE INDEX IF NOT EXISTS uq_cart_session_product ON cart(session_id, product_id) WHERE user_id IS NULL;
+	`
+	if _, err := db.Exec(createCartRulesSQL); err != nil {
+		return fmt.Errorf("failed to add cart quantity rules: %w", err)
+	}
+	log.Println("Successfully added cart quantity rules")
+
+	// 로그인 세션 (handlers/sessions.go): 기기별 1행, 현재 리프레시 토큰의 SHA-256 해시만 저장한다.
+	// session_token_history는 교체된 토큰 해시 — 다시 제시되면 재사용(탈취)으로 보고 세션을 폐기한다.
+	createSessionsSQL := `
+	CREATE TABLE IF NOT EXISTS sessions (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		refresh_token_hash CHAR(64) NOT NULL UNIQUE,
+		wallet_address VARCHAR(42) NOT NULL DEFAULT '',
+		user_agent VARCHAR(500) NOT NULL DEFAULT '',
+		ip VARCHAR(64) NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		last_used_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		expires_at TIMESTAMP NOT NULL,
+		revoked_at TIMESTAMP,
+		revoked_reason VARCHAR(32)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id) WHERE revoked_at IS NULL;
+	CREATE INDEX IF NOT EXISTS idx_sessions_expires ON sessions(expires_at);
+
+	CREATE TABLE IF NOT EXISTS session_token_history (
+		token_hash CHAR(64) PRIMARY KEY,
+		session_id INTEGER NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+		rotated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_session_token_history_session ON session_token_history(session_id);
+	`
+	if _, err := db.Exec(createSessionsSQL); err != nil {
+		return fmt.Errorf("failed to create sessions tables: %w", err)
+	}
+	log.Println("Successfully created sessions tables")
+
+	// 액세스 토큰 폐기 목록 (handlers/jwt_keys.go): jti 단위로 관리자 폐기 / 세션 폐기를 기록한다.
+	// expires_at은 토큰 만료 시각 — 지나면 토큰이 어차피 거부되므로 정리 잡이 지운다.
+	// sessions.access_jti는 세션이 마지막으로 발급한 액세스 토큰 (세션 폐기 시 함께 폐기).
+	createRevokedTokensSQL := `
+	CREATE TABLE IF NOT EXISTS revoked_tokens (
+		jti VARCHAR(64) PRIMARY KEY,
+		user_id INTEGER,
+		expires_at TIMESTAMP NOT NULL,
+		reason VARCHAR(200) NOT NULL DEFAULT '',
+		revoked_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires ON revoked_tokens(expires_at);
+
+	ALTER TABLE sessions ADD COLUMN IF NOT EXISTS access_jti VARCHAR(64) NOT NULL DEFAULT '';
+	`
+	if _, err := db.Exec(createRevokedTokensSQL); err != nil {
+		return fmt.Errorf("failed to create revoked_tokens table: %w", err)
+	}
+	log.Println("Successfully created revoked_tokens table")
+
+	// 이메일 인증 / 비밀번호 재설정 (handlers/account_email.go): 토큰은 SHA-256 해시만 저장하는 1회용.
+	// email은 발급 당시 주소 — 사용자 이메일이 바뀌면 토큰이 무효가 된다.
+	createEmailTokensSQL := `
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP;
+	-- 이미 가입된 주소로 가입 요청 시 보내는 안내 메일의 마지막 발송 시각 (쿨다운)
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS registration_notice_at TIMESTAMP;
+
+	CREATE TABLE IF NOT EXISTS email_tokens (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		purpose VARCHAR(32) NOT NULL CHECK (purpose IN ('verify_email', 'password_reset')),
+		token_hash CHAR(64) NOT NULL UNIQUE,
+		email VARCHAR(255) NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		used_at TIMESTAMP,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_email_tokens_user ON email_tokens(user_id, purpose);
+	CREATE INDEX IF NOT EXISTS idx_email_tokens_expires ON email_tokens(expires_at);
+	`
+	if _, err := db.Exec(createEmailTokensSQL); err != nil {
+		return fmt.Errorf("failed to create email_tokens table: %w", err)
+	}
+	log.Println("Successfully created email_tokens table")
+
+	// 릴리스 심사: 관리자가 아닌 판매자의 릴리스는 승인 전까지 공개/상품 반영에서 빠진다.
+	// 기존 릴리스는 approved로 간주한다. 제출/결정은 product_moderation_events에 release_id와 함께 남긴다.
+	createReleaseReviewSQL := `
+	ALTER TABLE product_releases ADD COLUMN IF NOT EXISTS review_status VARCHAR(16) NOT NULL DEFAULT 'approved'
+		CHECK (review_status IN ('approved', 'pending_review', 'rejected'));
+	ALTER TABLE product_releases ADD COLUMN IF NOT EXISTS review_note TEXT;
+
+	CREATE INDEX IF NOT EXISTS idx_product_releases_pending ON product_releases(updated_at)
+		WHERE review_status = 'pending_review';
+
+	ALTER TABLE product_moderation_events ADD COLUMN IF NOT EXISTS release_id INTEGER
+		REFERENCES product_releases(id) ON DELETE SET NULL;
+	CREATE INDEX IF NOT EXISTS idx_product_moderation_events_release ON product_moderation_events(release_id)
+		WHERE release_id IS NOT NULL;
+	`
+	if _, err := db.Exec(createReleaseReviewSQL); err != nil {
+		return fmt.Errorf("failed to add product_releases review columns: %w", err)
+	}
+	log.Println("Successfully added product_releases review columns")
+
 	return nil
 }
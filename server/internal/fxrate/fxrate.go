@@ -0,0 +1,92 @@
+// Package fxrate provides the KRW/USDC exchange rate used to keep product
+// prices in both currencies consistent and to record the KRW equivalent of
+// USDC payments. USDC is treated as 1:1 with USD, so any USD/KRW source works.
+package fxrate
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pair is the only currency pair the platform prices in.
+const Pair = "USDC/KRW"
+
+// microPerUSDC is the number of on-chain micro-units in one USDC (6 decimals).
+const microPerUSDC = 1_000_000
+
+// ErrNoRate is returned when a provider has no rate to offer (unconfigured
+// static rate, no active override).
+var ErrNoRate = errors.New("exchange rate unavailable")
+
+// Rate is a KRW price for one USDC.
+type Rate struct {
+	KRWPerUSDC float64
+	Source     string
+	FetchedAt  time.Time
+}
+
+// RateProvider returns the current KRW per USDC rate.
+type RateProvider interface {
+	Name() string
+	Rate(ctx context.Context) (Rate, error)
+}
+
+// Static serves a fixed, configured rate.
+type Static struct {
+	KRWPerUSDC float64
+}
+
+func (s Static) Name() string { return "static" }
+
+func (s Static) Rate(ctx context.Context) (Rate, error) {
+	if !Valid(s.KRWPerUSDC) {
+		return Rate{}, ErrNoRate
+	}
+	return Rate{KRWPerUSDC: s.KRWPerUSDC, Source: s.Name(), FetchedAt: time.Now()}, nil
+}
+
+// FromEnv builds the configured provider.
+//
+//	FX_PROVIDER=static (default)  FX_STATIC_KRW_PER_USDC
+//	FX_PROVIDER=http              FX_HTTP_URL, FX_HTTP_JSON_PATH (default rates.KRW)
+func FromEnv() (RateProvider, error) {
+	switch strings.ToLower(os.Getenv("FX_PROVIDER")) {
+	case "", "static":
+		rate, _ := strconv.ParseFloat(os.Getenv("FX_STATIC_KRW_PER_USDC"), 64)
+		return Static{KRWPerUSDC: rate}, nil
+	case "http":
+		path := os.Getenv("FX_HTTP_JSON_PATH")
+		if path == "" {
+			path = "rates.KRW"
+		}
+		return NewHTTP(os.Getenv("FX_HTTP_URL"), path)
+	}
+	return nil, errors.New("FX_PROVIDER must be static or http")
+}
+
+// Valid reports whether rate is a usable KRW per USDC value.
+func Valid(rate float64) bool {
+	return rate > 0 && !math.IsInf(rate, 0) && !math.IsNaN(rate)
+}
+
+// KRWToUSDCMicro converts a KRW amount to USDC micro-units, rounded to the
+// nearest micro-unit.
+func KRWToUSDCMicro(krw int64, rate float64) int64 {
+	if !Valid(rate) {
+		return 0
+	}
+	return int64(math.Round(float64(krw) / rate * microPerUSDC))
+}
+
+// USDCMicroToKRW converts USDC micro-units to KRW, rounded to the nearest won.
+func USDCMicroToKRW(micro int64, rate float64) int64 {
+	if !Valid(rate) {
+		return 0
+	}
+	return int64(math.Round(float64(micro) * rate / microPerUSDC))
+}
@@ -0,0 +1,84 @@
+package fxrate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConversions(t *testing.T) {
+	const rate = 1350.5
+
+	if got := KRWToUSDCMicro(13505, rate); got != 10_000_000 {
+		t.Errorf("KRWToUSDCMicro = %d, want 10000000", got)
+	}
+	if got := USDCMicroToKRW(10_000_000, rate); got != 13505 {
+		t.Errorf("USDCMicroToKRW = %d, want 13505", got)
+	}
+	// 반올림: 1원 = 740.46... micro
+	if got := KRWToUSDCMicro(1, rate); got != 740 {
+		t.Errorf("KRWToUSDCMicro(1) = %d, want 740", got)
+	}
+	if KRWToUSDCMicro(1000, 0) != 0 || USDCMicroToKRW(1000, -1) != 0 {
+		t.Error("invalid rate should convert to 0")
+	}
+}
+
+func TestStatic(t *testing.T) {
+	if _, err := (Static{}).Rate(context.Background()); err != ErrNoRate {
+		t.Errorf("unconfigured static rate: err = %v, want ErrNoRate", err)
+	}
+	r, err := Static{KRWPerUSDC: 1400}.Rate(context.Background())
+	if err != nil || r.KRWPerUSDC != 1400 || r.Source != "static" {
+		t.Errorf("static rate = %+v, %v", r, err)
+	}
+}
+
+func TestHTTPProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/number":
+			w.Write([]byte(`{"result":"success","rates":{"USD":1,"KRW":1382.17}}`))
+		case "/string":
+			w.Write([]byte(`{"data":{"rate":"1390.5"}}`))
+		case "/missing":
+			w.Write([]byte(`{"rates":{"USD":1}}`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		path, field string
+		want        float64
+		ok          bool
+	}{
+		{"/number", "rates.KRW", 1382.17, true},
+		{"/string", "data.rate", 1390.5, true},
+		{"/missing", "rates.KRW", 0, false},
+		{"/down", "rates.KRW", 0, false},
+	}
+	for _, c := range cases {
+		p, err := NewHTTP(srv.URL+c.path, c.field)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := p.Rate(context.Background())
+		if (err == nil) != c.ok {
+			t.Errorf("%s: err = %v, want ok=%v", c.path, err, c.ok)
+			continue
+		}
+		if c.ok && r.KRWPerUSDC != c.want {
+			t.Errorf("%s: rate = %v, want %v", c.path, r.KRWPerUSDC, c.want)
+		}
+	}
+
+	if _, err := NewHTTP("file:///etc/passwd", "rates.KRW"); err == nil {
+		t.Error("non-http URL accepted")
+	}
+	if _, err := NewHTTP(srv.URL, "rates..KRW"); err == nil {
+		t.Error("empty path segment accepted")
+	}
+}
@@ -0,0 +1,92 @@
+package fxrate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTP fetches the rate from a JSON endpoint such as
+// https://open.er-api.com/v6/latest/USD, reading the number at a dotted
+// path ("rates.KRW"). The value may be a JSON number or a numeric string.
+type HTTP struct {
+	url    string
+	path   []string
+	client *http.Client
+}
+
+// NewHTTP validates the endpoint and path.
+func NewHTTP(endpoint, jsonPath string) (*HTTP, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("FX_HTTP_URL must be an absolute http(s) URL")
+	}
+	path := strings.Split(jsonPath, ".")
+	for _, p := range path {
+		if p == "" {
+			return nil, errors.New("FX_HTTP_JSON_PATH must be a dotted field path")
+		}
+	}
+	return &HTTP{url: endpoint, path: path, client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+func (h *HTTP) Name() string { return "http" }
+
+func (h *HTTP) Rate(ctx context.Context) (Rate, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return Rate{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return Rate{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Rate{}, fmt.Errorf("rate source returned %d", resp.StatusCode)
+	}
+
+	var doc interface{}
+	dec := json.NewDecoder(io.LimitReader(resp.Body, 1<<20))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return Rate{}, fmt.Errorf("rate source: %w", err)
+	}
+	rate, err := lookupNumber(doc, h.path)
+	if err != nil {
+		return Rate{}, err
+	}
+	if !Valid(rate) {
+		return Rate{}, fmt.Errorf("rate source returned invalid rate %v", rate)
+	}
+	return Rate{KRWPerUSDC: rate, Source: h.Name(), FetchedAt: time.Now()}, nil
+}
+
+// lookupNumber walks path through nested JSON objects.
+func lookupNumber(doc interface{}, path []string) (float64, error) {
+	cur := doc
+	for _, key := range path {
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return 0, fmt.Errorf("rate source: %q is not an object", key)
+		}
+		if cur, ok = obj[key]; !ok {
+			return 0, fmt.Errorf("rate source: field %q missing", key)
+		}
+	}
+	switch v := cur.(type) {
+	case json.Number:
+		return v.Float64()
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("rate source: %s is not a number", strings.Join(path, "."))
+}
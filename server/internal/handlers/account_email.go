@@ -0,0 +1,390 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ── 이메일 인증 / 비밀번호 재설정 ───────────────────────────────────────────
+// 가입 시 인증 메일을 보내고, 링크의 토큰을 POST /auth/email/verify로 제출하면 users.email_verified_at을 기록한다.
+// 비밀번호 재설정은 POST /auth/password/forgot → 메일 링크 → POST /auth/password/reset.
+// 토큰은 32바이트 난수이고 email_tokens에는 SHA-256 해시만 저장한다. 1회용(used_at)이며 TTL이 있고,
+// 새로 발급하면 같은 목적의 이전 토큰은 지운다. 토큰에는 발급 당시 이메일을 기록해 이메일이 바뀌면 무효.
+// CWE-203: forgot/가입 응답은 계정 존재 여부와 무관하게 같고, 메일(forgot은 계정 조회·토큰 발급까지)은
+// 비동기로 처리해 응답 시간도 같게 한다.
+// 링크는 프런트 페이지(APP_BASE_URL)를 가리키고, 페이지가 POST로 토큰을 제출한다 (메일 스캐너의 GET이 토큰을 쓰지 않도록).
+
+const (
+	emailTokenVerify = "verify_email"
+	emailTokenReset  = "password_reset"
+
+	sessionRevokedPasswordReset = "password_reset"
+
+	passwordResetRequestedMessage = "If an account exists for that email, a password reset link has been sent"
+)
+
+var errInvalidEmailToken = errors.New("invalid or expired token")
+
+func emailVerifyTTL() time.Duration {
+	return time.Duration(envInt("EMAIL_VERIFY_TTL_HOURS", 48)) * time.Hour
+}
+
+func passwordResetTTL() time.Duration {
+	return time.Duration(envInt("PASSWORD_RESET_TTL_MINUTES", 30)) * time.Minute
+}
+
+// emailTokenCooldown — 같은 목적의 메일 재발송 최소 간격 (메일 폭탄 방지)
+func emailTokenCooldown() time.Duration {
+	return time.Duration(envInt("EMAIL_TOKEN_COOLDOWN_SECONDS", 60)) * time.Second
+}
+
+// appBaseURL — 메일 링크가 가리킬 프런트엔드 주소
+func appBaseURL() string {
+	if v := strings.TrimSpace(os.Getenv("APP_BASE_URL")); v != "" {
+		return strings.TrimRight(v, "/")
+	}
+	return "http://localhost:5173"
+}
+
+// hashEmailToken — 저장/조회용 SHA-256 hex (토큰 자체가 256bit 난수라 솔트 불필요)
+func hashEmailToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueEmailToken — 새 토큰 발급 (같은 목적의 미사용 토큰은 삭제). 쿨다운 이내면 "" (발송 생략)
+// 시각은 모두 DB NOW() 기준으로 계산한다 (TIMESTAMP 컬럼과 앱 서버 시계/시간대가 어긋나지 않도록).
+func issueEmailToken(db *sql.DB, userID int, email, purpose string, ttl time.Duration) (string, error) {
+	token, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	// 같은 사용자의 동시 요청을 직렬화 (쿨다운 확인과 발급 사이 경합 방지)
+	if _, err := tx.Exec(`SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return "", err
+	}
+	var recent bool
+	if err := tx.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM email_tokens
+			WHERE user_id = $1 AND purpose = $2 AND created_at > NOW() - make_interval(secs => $3)
+		)
+	`, userID, purpose, int(emailTokenCooldown().Seconds())).Scan(&recent); err != nil {
+		return "", err
+	}
+	if recent {
+		return "", nil
+	}
+	if _, err := tx.Exec(`
+		DELETE FROM email_tokens WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL
+	`, userID, purpose); err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO email_tokens (user_id, purpose, token_hash, email, expires_at)
+		VALUES ($1, $2, $3, $4, NOW() + make_interval(secs => $5))
+	`, userID, purpose, hashEmailToken(token), email, int(ttl.Seconds())); err != nil {
+		return "", err
+	}
+	return token, tx.Commit()
+}
+
+// consumeEmailToken — 토큰을 사용 처리하고 대상 사용자 반환. 이메일이 바뀌었으면 무효.
+func consumeEmailToken(tx *sql.Tx, token, purpose string) (int, string, error) {
+	var userID int
+	var email string
+	err := tx.QueryRow(`
+		UPDATE email_tokens t SET used_at = NOW()
+		FROM users u
+		WHERE t.token_hash = $1 AND t.purpose = $2 AND t.used_at IS NULL AND t.expires_at > NOW()
+		  AND u.id = t.user_id AND u.email = t.email
+		RETURNING t.user_id, t.email
+	`, hashEmailToken(strings.TrimSpace(token)), purpose).Scan(&userID, &email)
+	if err == sql.ErrNoRows {
+		return 0, "", errInvalidEmailToken
+	}
+	return userID, email, err
+}
+
+// accountLink — 메일 본문 링크 (토큰은 쿼리 파라미터)
+func accountLink(path, token string) string {
+	return appBaseURL() + path + "?token=" + url.QueryEscape(token)
+}
+
+func verificationMail(to, token string) mailMessage {
+	return mailMessage{
+		To:      to,
+		Subject: "[cmall] 이메일 주소를 인증해 주세요",
+		Body: fmt.Sprintf("아래 링크에서 이메일 주소 인증을 완료해 주세요 (%d시간 동안 유효).\n\n%s\n\n"+
+			"가입한 적이 없다면 이 메일은 무시하셔도 됩니다.\n",
+			int(emailVerifyTTL().Hours()), accountLink("/verify-email", token)),
+	}
+}
+
+func passwordResetMail(to, token string) mailMessage {
+	return mailMessage{
+		To:      to,
+		Subject: "[cmall] 비밀번호 재설정",
+		Body: fmt.Sprintf("아래 링크에서 새 비밀번호를 설정해 주세요 (%d분 동안 유효, 1회만 사용 가능).\n\n%s\n\n"+
+			"요청하지 않았다면 이 메일은 무시하셔도 됩니다. 비밀번호는 바뀌지 않습니다.\n",
+			int(passwordResetTTL().Minutes()), accountLink("/reset-password", token)),
+	}
+}
+
+// alreadyRegisteredMail — 이미 가입된 주소로 다시 가입하면 응답 대신 메일로 알린다 (CWE-203)
+func alreadyRegisteredMail(to string) mailMessage {
+	return mailMessage{
+		To:      to,
+		Subject: "[cmall] 이미 가입된 이메일입니다",
+		Body: "이 이메일 주소로 가입 요청이 있었지만, 이미 계정이 있습니다.\n" +
+			"비밀번호를 잊으셨다면 여기서 재설정할 수 있습니다:\n\n" + appBaseURL() + "/reset-password\n\n" +
+			"요청하지 않았다면 이 메일은 무시하셔도 됩니다.\n",
+	}
+}
+
+// claimRegistrationNotice — 이미 가입된 주소 안내 메일도 쿨다운을 둔다 (반복 가입 요청으로 메일 폭탄 방지).
+// 조건부 UPDATE라 동시 요청 중 하나만 true.
+func claimRegistrationNotice(db *sql.DB, userID int) (bool, error) {
+	res, err := db.Exec(`
+		UPDATE users SET registration_notice_at = NOW()
+		WHERE id = $1 AND (registration_notice_at IS NULL
+		                   OR registration_notice_at <= NOW() - make_interval(secs => $2))
+	`, userID, int(emailTokenCooldown().Seconds()))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// sendAccountMail — 비동기 발송 (응답 시간으로 계정 존재 여부가 드러나지 않도록)
+func sendAccountMail(msg mailMessage) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[mail] account mail panicked: %v", r)
+			}
+		}()
+		if err := mailerFromEnv().Send(msg); err == errChannelNotConfigured {
+			log.Printf("[mail] no mail backend configured; dropped %q (set MAIL_BACKEND or SMTP_HOST)", msg.Subject)
+		} else if err != nil {
+			log.Printf("[mail] send failed (subject=%q): %v", msg.Subject, err)
+		}
+	}()
+}
+
+// startEmailVerification — 인증 토큰 발급 후 메일 발송. 이미 인증됐거나 쿨다운 중이면 보내지 않는다.
+func startEmailVerification(db *sql.DB, userID int, email string) error {
+	if !deliverableEmail(email) {
+		return nil
+	}
+	token, err := issueEmailToken(db, userID, email, emailTokenVerify, emailVerifyTTL())
+	if err != nil || token == "" {
+		return err
+	}
+	sendAccountMail(verificationMail(email, token))
+	return nil
+}
+
+// VerifyEmail — POST /api/v1/auth/email/verify. body: {token}
+func VerifyEmail(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Token string `json:"token" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer tx.Rollback()
+		userID, _, err := consumeEmailToken(tx, req.Token, emailTokenVerify)
+		if err == errInvalidEmailToken {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired token"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if _, err := tx.Exec(`
+			UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()) WHERE id = $1
+		`, userID); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+	}
+}
+
+// ResendVerificationEmail — POST /api/v1/me/email/verification (JWT). 인증 메일 재발송
+func ResendVerificationEmail(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		var email string
+		var verified bool
+		err := db.QueryRow(`SELECT email, email_verified_at IS NOT NULL FROM users WHERE id = $1`, userID).
+			Scan(&email, &verified)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if verified {
+			c.JSON(http.StatusOK, gin.H{"message": "Email already verified"})
+			return
+		}
+		if !deliverableEmail(email) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "This account has no email address to verify"})
+			return
+		}
+		if err := startEmailVerification(db, userID.(int), email); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent"})
+	}
+}
+
+// ForgotPassword — POST /api/v1/auth/password/forgot. body: {email}
+// 계정 유무와 관계없이 같은 202 응답 (CWE-203). 계정 조회와 토큰 발급도 비동기로 돌려
+// 응답 전에 하는 일이 두 경우 모두 같다.
+func ForgotPassword(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Email string `json:"email" binding:"required,email"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		go startPasswordReset(db, req.Email)
+		c.JSON(http.StatusAccepted, gin.H{"message": passwordResetRequestedMessage})
+	}
+}
+
+// startPasswordReset — 계정이 있으면 재설정 토큰 발급 후 메일 발송 (오류는 로그만)
+func startPasswordReset(db *sql.DB, address string) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[mail] password reset panicked: %v", r)
+		}
+	}()
+	var userID int
+	var email string
+	err := db.QueryRow(`SELECT id, email FROM users WHERE email = $1`, address).Scan(&userID, &email)
+	if err == sql.ErrNoRows || (err == nil && !deliverableEmail(email)) {
+		return
+	}
+	if err != nil {
+		log.Printf("[mail] password reset lookup failed: %v", err)
+		return
+	}
+	token, err := issueEmailToken(db, userID, email, emailTokenReset, passwordResetTTL())
+	if err != nil {
+		log.Printf("[mail] password reset token for user %d failed: %v", userID, err)
+		return
+	}
+	if token != "" {
+		sendAccountMail(passwordResetMail(email, token))
+	}
+}
+
+// ResetPassword — POST /api/v1/auth/password/reset. body: {token, password}
+// 비밀번호를 바꾸고 모든 로그인 세션(및 그 액세스 토큰)을 폐기한다. 메일함 소유가 확인됐으므로 이메일도 인증 처리.
+func ResetPassword(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Token    string `json:"token" binding:"required"`
+			Password string `json:"password" binding:"required,min=8"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+			return
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer tx.Rollback()
+		userID, email, err := consumeEmailToken(tx, req.Token, emailTokenReset)
+		if err == errInvalidEmailToken {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired token"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if _, err := tx.Exec(`
+			UPDATE users
+			SET password = $2, email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+		`, userID, string(hashed)); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		// 남은 재설정 토큰도 무효화
+		if _, err := tx.Exec(`
+			DELETE FROM email_tokens WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL
+		`, userID, emailTokenReset); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if err := revokeUserSessions(tx, userID, sessionRevokedPasswordReset); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		loginSuccess(email) // 로그인 잠금 해제
+		c.JSON(http.StatusOK, gin.H{"message": "Password has been reset"})
+	}
+}
+
+// runEmailTokenCleanup — 백그라운드 잡: 만료됐거나 사용된 지 하루 지난 토큰 삭제
+func runEmailTokenCleanup(db *sql.DB) error {
+	_, err := db.Exec(`
+		DELETE FROM email_tokens
+		WHERE expires_at < NOW() - INTERVAL '1 day' OR used_at < NOW() - INTERVAL '1 day'
+	`)
+	return err
+}
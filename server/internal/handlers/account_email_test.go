@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestAccountLinks(t *testing.T) {
+	t.Setenv("APP_BASE_URL", "https://shop.example.com/")
+	t.Setenv("PASSWORD_RESET_TTL_MINUTES", "20")
+
+	link := accountLink("/reset-password", "a b&c")
+	u, err := url.Parse(link)
+	if err != nil || u.Host != "shop.example.com" || u.Path != "/reset-password" || u.Query().Get("token") != "a b&c" {
+		t.Fatalf("accountLink = %q", link)
+	}
+
+	msg := passwordResetMail("a@example.com", "tok123")
+	if msg.To != "a@example.com" || !strings.Contains(msg.Body, "https://shop.example.com/reset-password?token=tok123") ||
+		!strings.Contains(msg.Body, "20분") {
+		t.Errorf("reset mail = %+v", msg)
+	}
+	if msg := verificationMail("a@example.com", "tok456"); !strings.Contains(msg.Body, "/verify-email?token=tok456") {
+		t.Errorf("verification mail = %+v", msg)
+	}
+	// 기존 계정 안내 메일에는 토큰이 없다
+	if msg := alreadyRegisteredMail("a@example.com"); strings.Contains(msg.Body, "token=") {
+		t.Errorf("already-registered mail leaks a token link: %q", msg.Body)
+	}
+}
+
+func TestEmailTokenHashing(t *testing.T) {
+	a := hashEmailToken("token")
+	if len(a) != 64 || a == hashEmailToken("token2") || a != hashEmailToken("token") {
+		t.Errorf("hashEmailToken = %q", a)
+	}
+	if deliverableEmail("0xabc@wallet.local") || deliverableEmail("") || !deliverableEmail("a@example.com") {
+		t.Error("deliverableEmail")
+	}
+}
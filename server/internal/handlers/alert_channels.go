@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ── 알림 전달 채널 ────────────────────────────────────────────────────────
+// 알림 규칙의 channels 값마다 하나의 alertChannel 구현이 대응한다.
+// in_app: alerts 테이블 레코드 자체가 인앱 알림함 (별도 전송 없음)
+// email: Mailer (mailer.go — SMTP, 개발은 MailHog 등 로컬 SMTP 또는 MAIL_BACKEND=file/log)
+// telegram: Bot API sendMessage (TELEGRAM_BOT_TOKEN, 테스트용 TELEGRAM_API_URL 재정의 가능)
+
+var errChannelNotConfigured = errors.New("channel not configured")
+
+// alertMessage — 채널 공통 메시지
+type alertMessage struct {
+	UserEmail      string
+	TelegramChatID string
+	Subject        string
+	Body           string
+}
+
+type alertChannel interface {
+	Deliver(msg alertMessage) error
+}
+
+// alertChannelNames — 규칙에서 허용하는 채널 이름 allowlist
+var alertChannelNames = map[string]bool{"in_app": true, "email": true, "telegram": true}
+
+// alertChannelFor — 채널 이름 → 구현 (env 설정은 호출 시점에 읽는다)
+func alertChannelFor(name string) alertChannel {
+	switch name {
+	case "in_app":
+		return inAppChannel{}
+	case "email":
+		return emailChannel{mailer: mailerFromEnv()}
+	case "telegram":
+		base := os.Getenv("TELEGRAM_API_URL")
+		if base == "" {
+			base = "https://api.telegram.org"
+		}
+		return telegramChannel{baseURL: base, token: os.Getenv("TELEGRAM_BOT_TOKEN")}
+	}
+	return nil
+}
+
+type inAppChannel struct{}
+
+func (inAppChannel) Deliver(alertMessage) error { return nil }
+
+// emailChannel — 메일 발송은 Mailer (mailer.go)
+type emailChannel struct {
+	mailer Mailer
+}
+
+func (ch emailChannel) Deliver(msg alertMessage) error {
+	return ch.mailer.Send(mailMessage{To: msg.UserEmail, Subject: msg.Subject, Body: msg.Body})
+}
+
+type telegramChannel struct {
+	baseURL string
+	token   string
+}
+
+func (ch telegramChannel) Deliver(msg alertMessage) error {
+	if ch.token == "" {
+		return errChannelNotConfigured
+	}
+	if msg.TelegramChatID == "" {
+		return errors.New("telegram chat id not set on rule")
+	}
+	body, _ := json.Marshal(map[string]string{
+		"chat_id": msg.TelegramChatID,
+		"text":    msg.Subject + "\n\n" + msg.Body,
+	})
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(ch.baseURL, "/")+"/bot"+ch.token+"/sendMessage", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		// URL에 봇 토큰이 포함되므로 원본 오류를 그대로 노출하지 않는다
+		return errors.New("telegram unreachable")
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("telegram returned %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
@@ -0,0 +1,666 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cmall_dd/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// ── 분석 결과 알림 (2026-10) ──────────────────────────────────────────────
+// done 처리된 swing_screener/close_screener 결과에 관심종목이 사용자 임계 점수 이상으로
+// 포함되면 알림을 보낸다. 평가는 analysis_requests가 done으로 바뀌는 시점에 하고, 알림 1건은
+// (rule_id, stock_code, alert_date) 중복 제거 행으로 선점한다 (같은 규칙·종목은 하루(KST) 1건).
+// alerts_evaluated_at은 평가가 끝까지 성공한 뒤에 기록하며, 중간에 실패한 분석은 alert-evaluation
+// 잡이 다시 평가한다 (이미 선점된 알림은 다시 보내지 않는다).
+// 전달 채널은 alert_channels.go (in_app / email / telegram).
+
+const maxAlertRulesPerUser = 20
+
+// alertRuleRequestTypes — 알림 대상 분석 유형 (후보 목록을 반환하는 스크리너만)
+var alertRuleRequestTypes = map[string]bool{
+	"swing_screener": true,
+	"close_screener": true,
+}
+
+// AlertRule — 사용자 정의 알림 규칙. RequestType이 빈 값이면 모든 스크리너,
+// WatchlistID가 nil이면 사용자의 전체 관심종목이 대상. 지정한 관심종목을 삭제하면
+// 규칙도 함께 삭제된다 (ON DELETE CASCADE — 전체 관심종목 대상으로 넓어지지 않도록).
+type AlertRule struct {
+	ID             int       `json:"id"`
+	UserID         int       `json:"userId"`
+	Name           string    `json:"name"`
+	RequestType    string    `json:"requestType"`
+	MinScore       float64   `json:"minScore"`
+	WatchlistID    *int      `json:"watchlistId,omitempty"`
+	Channels       []string  `json:"channels"`
+	TelegramChatID string    `json:"telegramChatId,omitempty"`
+	IsActive       bool      `json:"isActive"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// AlertRuleRequest — 생성/수정 공용 (수정 시 nil 필드는 유지)
+type AlertRuleRequest struct {
+	Name           *string   `json:"name,omitempty"`
+	RequestType    *string   `json:"requestType,omitempty"`
+	MinScore       *float64  `json:"minScore,omitempty"`
+	WatchlistID    *int      `json:"watchlistId,omitempty"`
+	Channels       *[]string `json:"channels,omitempty"`
+	TelegramChatID *string   `json:"telegramChatId,omitempty"`
+	IsActive       *bool     `json:"isActive,omitempty"`
+}
+
+// Alert — 알림 이력 (인앱 알림함 겸용)
+type Alert struct {
+	ID                int        `json:"id"`
+	RuleID            *int       `json:"ruleId,omitempty"`
+	AnalysisRequestID int        `json:"analysisRequestId"`
+	RequestType       string     `json:"requestType"`
+	StockCode         string     `json:"stockCode"`
+	StockName         string     `json:"stockName"`
+	Score             float64    `json:"score"`
+	Message           string     `json:"message"`
+	Channels          []string   `json:"channels"`
+	DeliveryError     string     `json:"deliveryError,omitempty"`
+	ReadAt            *time.Time `json:"readAt,omitempty"`
+	CreatedAt         time.Time  `json:"createdAt"`
+}
+
+const alertRuleColumns = `id, user_id, name, request_type, min_score, watchlist_id, channels, telegram_chat_id, is_active, created_at, updated_at`
+
+func scanAlertRule(row interface{ Scan(...interface{}) error }) (AlertRule, error) {
+	var r AlertRule
+	var channels pq.StringArray
+	err := row.Scan(&r.ID, &r.UserID, &r.Name, &r.RequestType, &r.MinScore, &r.WatchlistID,
+		&channels, &r.TelegramChatID, &r.IsActive, &r.CreatedAt, &r.UpdatedAt)
+	r.Channels = []string(channels)
+	if r.Channels == nil {
+		r.Channels = []string{}
+	}
+	return r, err
+}
+
+// validateAlertRule — 병합된 규칙 값 검증 (클라이언트 오류 메시지 반환)
+func validateAlertRule(db *sql.DB, userID interface{}, r *AlertRule) (string, error) {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" || utf8.RuneCountInString(r.Name) > 100 {
+		return "name must be 1-100 characters", nil
+	}
+	if r.RequestType != "" && !alertRuleRequestTypes[r.RequestType] {
+		return "requestType must be swing_screener, close_screener or empty (both)", nil
+	}
+	if r.MinScore < 0 || r.MinScore > 1000 {
+		return "minScore must be between 0 and 1000", nil
+	}
+	if len(r.Channels) == 0 {
+		return "at least one channel is required", nil
+	}
+	seen := map[string]bool{}
+	channels := make([]string, 0, len(r.Channels))
+	for _, ch := range r.Channels {
+		if !alertChannelNames[ch] {
+			return "unsupported channel: " + ch, nil
+		}
+		if !seen[ch] {
+			seen[ch] = true
+			channels = append(channels, ch)
+		}
+	}
+	r.Channels = channels
+	r.TelegramChatID = strings.TrimSpace(r.TelegramChatID)
+	if seen["telegram"] && r.TelegramChatID == "" {
+		return "telegramChatId is required for the telegram channel", nil
+	}
+	if len(r.TelegramChatID) > 64 {
+		return "telegramChatId must be at most 64 characters", nil
+	}
+	if r.WatchlistID != nil {
+		var owner int
+		err := db.QueryRow("SELECT user_id FROM watchlists WHERE id = $1", *r.WatchlistID).Scan(&owner)
+		if err == sql.ErrNoRows || (err == nil && owner != userID.(int)) {
+			return "watchlist not found", nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// GetAlertRules — GET /api/v1/alert-rules (JWT)
+func GetAlertRules(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		rows, err := db.Query("SELECT "+alertRuleColumns+" FROM alert_rules WHERE user_id = $1 ORDER BY id", userID)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer rows.Close()
+
+		rules := []AlertRule{}
+		for rows.Next() {
+			r, err := scanAlertRule(rows)
+			if err != nil {
+				respondDBError(c, err)
+				return
+			}
+			rules = append(rules, r)
+		}
+		c.JSON(http.StatusOK, gin.H{"rules": rules})
+	}
+}
+
+// CreateAlertRule — POST /api/v1/alert-rules (JWT)
+func CreateAlertRule(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		var req AlertRuleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		rule := AlertRule{Channels: []string{"in_app"}, IsActive: true}
+		mergeAlertRule(&rule, &req)
+		msg, err := validateAlertRule(db, userID, &rule)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM alert_rules WHERE user_id = $1", userID).Scan(&count); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if count >= maxAlertRulesPerUser {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d alert rules per user", maxAlertRulesPerUser)})
+			return
+		}
+
+		created, err := scanAlertRule(db.QueryRow(`
+			INSERT INTO alert_rules (user_id, name, request_type, min_score, watchlist_id, channels, telegram_chat_id, is_active)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING `+alertRuleColumns,
+			userID, rule.Name, rule.RequestType, rule.MinScore, rule.WatchlistID,
+			pq.Array(rule.Channels), rule.TelegramChatID, rule.IsActive,
+		))
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, created)
+	}
+}
+
+// UpdateAlertRule — PUT /api/v1/alert-rules/:id (JWT, 소유자)
+func UpdateAlertRule(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert rule ID"})
+			return
+		}
+		var req AlertRuleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		rule, err := scanAlertRule(db.QueryRow(
+			"SELECT "+alertRuleColumns+" FROM alert_rules WHERE id = $1 AND user_id = $2", id, userID,
+		))
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Alert rule not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+
+		mergeAlertRule(&rule, &req)
+		msg, err := validateAlertRule(db, userID, &rule)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		updated, err := scanAlertRule(db.QueryRow(`
+			UPDATE alert_rules
+			SET name = $1, request_type = $2, min_score = $3, watchlist_id = $4, channels = $5,
+			    telegram_chat_id = $6, is_active = $7, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $8 AND user_id = $9
+			RETURNING `+alertRuleColumns,
+			rule.Name, rule.RequestType, rule.MinScore, rule.WatchlistID, pq.Array(rule.Channels),
+			rule.TelegramChatID, rule.IsActive, id, userID,
+		))
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// DeleteAlertRule — DELETE /api/v1/alert-rules/:id (JWT, 소유자). 이력(alerts)은 보존된다.
+func DeleteAlertRule(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert rule ID"})
+			return
+		}
+		result, err := db.Exec("DELETE FROM alert_rules WHERE id = $1 AND user_id = $2", id, userID)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Alert rule not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Alert rule deleted"})
+	}
+}
+
+func mergeAlertRule(r *AlertRule, req *AlertRuleRequest) {
+	if req.Name != nil {
+		r.Name = *req.Name
+	}
+	if req.RequestType != nil {
+		r.RequestType = *req.RequestType
+	}
+	if req.MinScore != nil {
+		r.MinScore = *req.MinScore
+	}
+	if req.WatchlistID != nil {
+		// watchlistId: 0 → 전체 관심종목으로 되돌림
+		if *req.WatchlistID == 0 {
+			r.WatchlistID = nil
+		} else {
+			r.WatchlistID = req.WatchlistID
+		}
+	}
+	if req.Channels != nil {
+		r.Channels = *req.Channels
+	}
+	if req.TelegramChatID != nil {
+		r.TelegramChatID = *req.TelegramChatID
+	}
+	if req.IsActive != nil {
+		r.IsActive = *req.IsActive
+	}
+}
+
+// GetAlerts — GET /api/v1/alerts?unread=true (JWT) — 알림 이력/인앱 알림함 (최근 100건)
+func GetAlerts(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		query := `
+			SELECT id, rule_id, analysis_request_id, request_type, stock_code, stock_name, score, message,
+			       delivered_channels, COALESCE(delivery_error, ''), read_at, created_at
+			FROM alerts WHERE user_id = $1
+		`
+		if c.Query("unread") == "true" {
+			query += " AND read_at IS NULL"
+		}
+		query += " ORDER BY created_at DESC LIMIT 100"
+
+		rows, err := db.Query(query, userID)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer rows.Close()
+
+		alerts := []Alert{}
+		for rows.Next() {
+			var a Alert
+			var channels pq.StringArray
+			if err := rows.Scan(&a.ID, &a.RuleID, &a.AnalysisRequestID, &a.RequestType, &a.StockCode, &a.StockName,
+				&a.Score, &a.Message, &channels, &a.DeliveryError, &a.ReadAt, &a.CreatedAt); err != nil {
+				respondDBError(c, err)
+				return
+			}
+			a.Channels = []string(channels)
+			if a.Channels == nil {
+				a.Channels = []string{}
+			}
+			alerts = append(alerts, a)
+		}
+		c.JSON(http.StatusOK, gin.H{"alerts": alerts})
+	}
+}
+
+// MarkAlertRead — POST /api/v1/alerts/:id/read (JWT, 소유자)
+func MarkAlertRead(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+			return
+		}
+		result, err := db.Exec(
+			"UPDATE alerts SET read_at = COALESCE(read_at, NOW()) WHERE id = $1 AND user_id = $2", id, userID,
+		)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Alert not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Alert marked as read"})
+	}
+}
+
+// ── 평가 ──────────────────────────────────────────────────────────────────
+
+// alertCandidate — 스크리너 결과 후보 (result_json.candidates[])
+type alertCandidate struct {
+	StockCode string
+	StockName string
+	Score     float64
+	Reason    string
+}
+
+// parseAlertCandidates — result_json에서 후보 목록 추출 (순수 함수).
+// 코드가 KRX 형식이 아니거나 점수가 없는 후보는 건너뛴다.
+func parseAlertCandidates(resultJSON string) []alertCandidate {
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(resultJSON), &payload); err != nil {
+		return nil
+	}
+	raw, _ := payload["candidates"].([]interface{})
+	out := make([]alertCandidate, 0, len(raw))
+	for _, item := range raw {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		code, ok := normalizeKRXSymbol(fmt.Sprintf("%v", m["stock_code"]))
+		if !ok {
+			continue
+		}
+		var score float64
+		switch v := m["score"].(type) {
+		case float64:
+			score = v
+		case string:
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			score = parsed
+		default:
+			continue
+		}
+		name, _ := m["stock_name"].(string)
+		reason, _ := m["reason"].(string)
+		out = append(out, alertCandidate{StockCode: code, StockName: name, Score: score, Reason: reason})
+	}
+	return out
+}
+
+// notifyAnalysisDone — analysis_requests가 done으로 바뀐 직후 호출한다.
+// 평가/전달(SMTP·텔레그램)은 요청 응답을 지연시키지 않도록 비동기로 수행한다.
+func notifyAnalysisDone(db *sql.DB, rec *models.AnalysisRequest) {
+	if !alertRuleRequestTypes[rec.RequestType] {
+		return
+	}
+	go func(id int) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[alerts] evaluation panicked (analysis=%d): %v", id, r)
+			}
+		}()
+		if err := evaluateAnalysisAlerts(db, id); err != nil {
+			log.Printf("[alerts] evaluation failed (analysis=%d): %v", id, err)
+		}
+	}(rec.ID)
+}
+
+// alertEvaluationRetryWindow — 평가가 실패한 분석을 다시 평가하는 기간 (오래된 결과로 알림을 보내지 않도록)
+const alertEvaluationRetryWindow = 24 * time.Hour
+
+// evaluateAnalysisAlerts — 분석 1건에 대해 소유자의 알림 규칙을 평가하고 알림을 기록/전달한다.
+// 알림마다 중복 제거 행을 선점하므로 동시 폴링/재시도에서도 같은 알림은 1회만 전달된다.
+// 모든 알림을 처리한 뒤에야 alerts_evaluated_at을 기록한다.
+func evaluateAnalysisAlerts(db *sql.DB, analysisID int) error {
+	var userID int
+	var requestType, resultJSON string
+	err := db.QueryRow(`
+		SELECT user_id, request_type, COALESCE(result_json, '')
+		FROM analysis_requests
+		WHERE id = $1 AND status = 'done' AND alerts_evaluated_at IS NULL
+	`, analysisID).Scan(&userID, &requestType, &resultJSON)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := deliverAnalysisAlerts(db, analysisID, userID, requestType, resultJSON); err != nil {
+		return err
+	}
+	_, err = db.Exec(`UPDATE analysis_requests SET alerts_evaluated_at = NOW() WHERE id = $1`, analysisID)
+	return err
+}
+
+// deliverAnalysisAlerts — 규칙·후보별 알림 선점 + 전달. 한 알림의 오류는 나머지 알림을 막지 않고,
+// 마지막에 첫 오류를 돌려줘 분석이 재평가 대상으로 남게 한다.
+func deliverAnalysisAlerts(db *sql.DB, analysisID, userID int, requestType, resultJSON string) error {
+	candidates := parseAlertCandidates(resultJSON)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	rows, err := db.Query(
+		"SELECT "+alertRuleColumns+" FROM alert_rules WHERE user_id = $1 AND is_active = true AND (request_type = '' OR request_type = $2)",
+		userID, requestType,
+	)
+	if err != nil {
+		return err
+	}
+	var rules []AlertRule
+	for rows.Next() {
+		r, err := scanAlertRule(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		rules = append(rules, r)
+	}
+	rows.Close()
+	if len(rules) == 0 {
+		return nil
+	}
+
+	var email string
+	_ = db.QueryRow("SELECT email FROM users WHERE id = $1", userID).Scan(&email)
+	alertDate := time.Now().In(kst).Format("2006-01-02")
+
+	var firstErr error
+	for _, rule := range rules {
+		watched, err := alertRuleSymbols(db, userID, rule.WatchlistID)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		for _, cand := range candidates {
+			if !watched[cand.StockCode] || cand.Score < rule.MinScore {
+				continue
+			}
+			subject := fmt.Sprintf("[cmall] 관심종목 신호: %s(%s) 점수 %.1f", cand.StockName, cand.StockCode, cand.Score)
+			body := fmt.Sprintf("%s 결과에서 관심종목 %s(%s)이(가) 점수 %.1f로 규칙 '%s'(기준 %.1f)을 충족했습니다.",
+				requestType, cand.StockName, cand.StockCode, cand.Score, rule.Name, rule.MinScore)
+			if cand.Reason != "" {
+				body += "\n사유: " + cand.Reason
+			}
+
+			// 하루 1건 중복 제거 — (rule_id, stock_code, alert_date) UNIQUE
+			var alertID int
+			err := db.QueryRow(`
+				INSERT INTO alerts (user_id, rule_id, analysis_request_id, request_type, stock_code, stock_name, score, message, alert_date)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+				ON CONFLICT (rule_id, stock_code, alert_date) DO NOTHING
+				RETURNING id
+			`, userID, rule.ID, analysisID, requestType, cand.StockCode, cand.StockName, cand.Score,
+				subject+"\n"+body, alertDate).Scan(&alertID)
+			if err == sql.ErrNoRows {
+				continue
+			}
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+
+			msg := alertMessage{UserEmail: email, TelegramChatID: rule.TelegramChatID, Subject: subject, Body: body}
+			delivered, failures := deliverAlert(alertID, rule.Channels, msg)
+			_, _ = db.Exec(
+				"UPDATE alerts SET delivered_channels = $1, delivery_error = NULLIF($2, '') WHERE id = $3",
+				pq.Array(delivered), strings.Join(failures, "; "), alertID,
+			)
+		}
+	}
+	return firstErr
+}
+
+// deliverAlert — 채널별 전달. 채널 오류/panic은 기록만 하고 다음 채널로 넘어간다.
+func deliverAlert(alertID int, channels []string, msg alertMessage) (delivered, failures []string) {
+	delivered = []string{}
+	for _, name := range channels {
+		ch := alertChannelFor(name)
+		if ch == nil {
+			continue
+		}
+		err := func() (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("panic: %v", r)
+				}
+			}()
+			return ch.Deliver(msg)
+		}()
+		if err != nil {
+			log.Printf("[alerts] %s delivery failed (alert=%d): %v", name, alertID, err)
+			failures = append(failures, name+": "+err.Error())
+			continue
+		}
+		delivered = append(delivered, name)
+	}
+	return delivered, failures
+}
+
+// runAlertEvaluationRetry — 백그라운드 잡: done 이후 평가가 끝나지 않은 최근 분석을 다시 평가한다.
+func runAlertEvaluationRetry(db *sql.DB) error {
+	requestTypes := make([]string, 0, len(alertRuleRequestTypes))
+	for t := range alertRuleRequestTypes {
+		requestTypes = append(requestTypes, t)
+	}
+	rows, err := db.Query(`
+		SELECT id FROM analysis_requests
+		WHERE status = 'done' AND alerts_evaluated_at IS NULL AND request_type = ANY($1)
+		  AND updated_at > NOW() - make_interval(secs => $2)
+		ORDER BY id
+		LIMIT 50
+	`, pq.Array(requestTypes), alertEvaluationRetryWindow.Seconds())
+	if err != nil {
+		return err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	for _, id := range ids {
+		if err := evaluateAnalysisAlerts(db, id); err != nil {
+			log.Printf("[alerts] re-evaluation failed (analysis=%d): %v", id, err)
+		}
+	}
+	return nil
+}
+
+// alertRuleSymbols — 규칙 대상 종목 집합 (지정 관심종목 또는 사용자 전체 관심종목)
+func alertRuleSymbols(db *sql.DB, userID int, watchlistID *int) (map[string]bool, error) {
+	query := `
+		SELECT DISTINCT wi.stock_code FROM watchlist_items wi
+		JOIN watchlists w ON w.id = wi.watchlist_id
+		WHERE w.user_id = $1`
+	args := []interface{}{userID}
+	if watchlistID != nil {
+		query += " AND w.id = $2"
+		args = append(args, *watchlistID)
+	}
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	set := map[string]bool{}
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+		set[code] = true
+	}
+	return set, rows.Err()
+}
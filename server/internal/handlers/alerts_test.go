@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAlertCandidates(t *testing.T) {
+	cases := []struct {
+		name   string
+		result string
+		want   []alertCandidate
+	}{
+		{"invalid json", `{`, nil},
+		{"no candidates", `{"summary":"x"}`, []alertCandidate{}},
+		{
+			"normalizes codes and string scores",
+			`{"candidates":[
+				{"stock_code":"005930.KS","stock_name":"삼성전자","score":87.5,"reason":"돌파"},
+				{"stock_code":"A035720","stock_name":"카카오","score":"72"}
+			]}`,
+			[]alertCandidate{
+				{StockCode: "005930", StockName: "삼성전자", Score: 87.5, Reason: "돌파"},
+				{StockCode: "035720", StockName: "카카오", Score: 72},
+			},
+		},
+		{
+			"skips malformed entries",
+			`{"candidates":[
+				"005930",
+				{"stock_code":"12345","score":90},
+				{"stock_code":"000660","score":"high"},
+				{"stock_code":"000660","score":null},
+				{"stock_code":"000660","score":61}
+			]}`,
+			[]alertCandidate{{StockCode: "000660", Score: 61}},
+		},
+	}
+	for _, c := range cases {
+		if got := parseAlertCandidates(c.result); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %+v, want %+v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestValidateAlertRuleRejects(t *testing.T) {
+	valid := func() AlertRule {
+		return AlertRule{Name: "스윙 알림", RequestType: "swing_screener", MinScore: 70, Channels: []string{"in_app"}}
+	}
+	cases := []struct {
+		want   string
+		mutate func(r *AlertRule)
+	}{
+		{"name", func(r *AlertRule) { r.Name = "   " }},
+		{"name", func(r *AlertRule) { r.Name = strings.Repeat("가", 101) }},
+		{"requestType", func(r *AlertRule) { r.RequestType = "backtest" }},
+		{"minScore", func(r *AlertRule) { r.MinScore = -1 }},
+		{"minScore", func(r *AlertRule) { r.MinScore = 1001 }},
+		{"at least one channel", func(r *AlertRule) { r.Channels = nil }},
+		{"unsupported channel", func(r *AlertRule) { r.Channels = []string{"in_app", "sms"} }},
+		{"telegramChatId is required", func(r *AlertRule) { r.Channels = []string{"telegram"}; r.TelegramChatID = " " }},
+		{"telegramChatId must be at most", func(r *AlertRule) { r.TelegramChatID = strings.Repeat("1", 65) }},
+	}
+	for _, c := range cases {
+		r := valid()
+		c.mutate(&r)
+		msg, err := validateAlertRule(nil, 1, &r)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", c.want, err)
+		}
+		if !strings.Contains(msg, c.want) {
+			t.Errorf("rule %+v: msg = %q, want mention of %q", r, msg, c.want)
+		}
+	}
+
+	// 통과하는 규칙은 이름/채팅 ID 공백 제거 + 채널 중복 제거
+	r := valid()
+	r.Name = "  스윙 알림 "
+	r.Channels = []string{"email", "telegram", "email"}
+	r.TelegramChatID = " 12345 "
+	if msg, err := validateAlertRule(nil, 1, &r); msg != "" || err != nil {
+		t.Fatalf("valid rule rejected: %q, %v", msg, err)
+	}
+	if r.Name != "스윙 알림" || r.TelegramChatID != "12345" || !reflect.DeepEqual(r.Channels, []string{"email", "telegram"}) {
+		t.Errorf("normalized rule = %+v", r)
+	}
+}
+
+func TestMergeAlertRule(t *testing.T) {
+	watchlist := 7
+	zero := 0
+	name := "새 이름"
+	score := 80.0
+	channels := []string{"email"}
+	inactive := false
+
+	base := func() AlertRule {
+		return AlertRule{Name: "기존", RequestType: "close_screener", MinScore: 50, WatchlistID: &watchlist,
+			Channels: []string{"in_app"}, TelegramChatID: "1", IsActive: true}
+	}
+	cases := []struct {
+		name string
+		req  AlertRuleRequest
+		want func(r *AlertRule)
+	}{
+		{"empty request keeps everything", AlertRuleRequest{}, func(r *AlertRule) {}},
+		{"partial update", AlertRuleRequest{Name: &name, MinScore: &score, Channels: &channels, IsActive: &inactive},
+			func(r *AlertRule) { r.Name, r.MinScore, r.Channels, r.IsActive = name, score, channels, false }},
+		{"watchlistId 0 clears the filter", AlertRuleRequest{WatchlistID: &zero},
+			func(r *AlertRule) { r.WatchlistID = nil }},
+	}
+	for _, c := range cases {
+		got, want := base(), base()
+		mergeAlertRule(&got, &c.req)
+		c.want(&want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %+v, want %+v", c.name, got, want)
+		}
+	}
+}
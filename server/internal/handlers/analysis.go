@@ -77,7 +77,7 @@ var allowedAnalysisRequestTypes = map[string]bool{
 // userHasAnalysisEntitlement — 결제한 분석 상품이 요청한 request_type과 일치해야 함 (CWE-862:
 // "아무 paid 결제"로 모든 분석 기능이 열리는 것 방지 — 백테스트 결제로 팩터 리포트 요청 불가).
 // 주의: 상품의 product_type은 'software' 등으로 다양하므로 request_type 바인딩만으로 판별한다
-// (products.request_type 기본값 'stock_report' — 유료 분석 상품은 crypto_price_usdc > 0).
+// (products.request_type은 분석 상품에만 지정 — 유료 분석 상품은 crypto_price_usdc > 0).
 //
 // M6 (2026-08-15): 올액세스 구독(번들, billing_interval_days NOT NULL)이 활성이면
 // 모든 request_type 허용 — 월 $5 구독 = 전 서비스 무제한.
@@ -128,6 +128,18 @@ func CreateAnalysis(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
+		// 심볼 정규화 + KRX 종목 마스터 대조 (오타가 내부 잡 실패로만 드러나지 않도록)
+		symbol, msg, err := resolveAnalysisSymbol(db, req.Symbol)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		req.Symbol = symbol
+
 		// 결제 게이트 (M1: 분석 상품 유료 이력 — 요청한 request_type 상품 결제 필요, CWE-862)
 		if !userHasAnalysisEntitlement(db, userID, req.RequestType) {
 			c.JSON(http.StatusPaymentRequired, gin.H{"error": "payment required — paid order for this analysis type needed"})
@@ -136,7 +148,7 @@ func CreateAnalysis(db *sql.DB) gin.HandlerFunc {
 
 		// 요청 레코드 생성
 		var reqRec models.AnalysisRequest
-		err := db.QueryRow(`
+		err = db.QueryRow(`
 			INSERT INTO analysis_requests (user_id, request_type, symbol, status)
 			VALUES ($1, $2, $3, 'queued')
 			RETURNING id, user_id, request_type, symbol, status, COALESCE(result_json, '') AS result_json, COALESCE(internal_request_id, '') AS internal_request_id, COALESCE(error, '') AS error, created_at, updated_at
@@ -152,69 +164,7 @@ func CreateAnalysis(db *sql.DB) gin.HandlerFunc {
 
 		// analyist_dd 내부 API 호출 (M6 — 비동기 잡)
 		// stock_report는 즉시 done(result 포함), 나머지는 queued/running → GetAnalysis에서 폴링
-		if analyistURL() != "" {
-			internalResult, callErr := callAnalyistInternal(http.MethodPost, "/internal/analysis/"+req.RequestType, map[string]string{
-				"symbol": req.Symbol,
-			})
-
-			if callErr != nil {
-				if errors.Is(callErr, errAnalyistUnreachable) {
-					// 게이트웨이 일시 다운 — 실패로 끝내지 않고 queued 유지,
-					// GetAnalysis 폴링에서 자동 재제출 (웹은 영향 없음)
-					note := "제출 대기 중 (분석 서비스 재시작 중): " + callErr.Error()
-					_, _ = db.Exec(
-						"UPDATE analysis_requests SET status = 'queued', error = $1, updated_at = NOW() WHERE id = $2",
-						note, reqRec.ID,
-					)
-					reqRec.Status = "queued"
-					reqRec.Error = note
-				} else {
-					// 실제 오류 (잘못된 심볼 등) — 명확히 실패 처리
-					_, _ = db.Exec(
-						"UPDATE analysis_requests SET status = 'failed', error = $1, updated_at = NOW() WHERE id = $2",
-						callErr.Error(), reqRec.ID,
-					)
-					reqRec.Status = "failed"
-					reqRec.Error = callErr.Error()
-				}
-			} else {
-				internalID, _ := internalResult["request_id"].(string)
-				status, _ := internalResult["status"].(string)
-				if status == "" {
-					status = "queued"
-				}
-
-				if status == "done" {
-					// 즉시 완료 (stock_report 등) — result를 result_json으로 저장
-					if raw, ok := internalResult["result"]; ok {
-						if b, err := json.Marshal(raw); err == nil {
-							_, _ = db.Exec(
-								"UPDATE analysis_requests SET status = 'done', result_json = $1, internal_request_id = $2, updated_at = NOW() WHERE id = $3",
-								string(b), internalID, reqRec.ID,
-							)
-							reqRec.Status = "done"
-							reqRec.ResultJSON = string(b)
-							reqRec.InternalRequestID = internalID
-						}
-					} else {
-						_, _ = db.Exec(
-							"UPDATE analysis_requests SET status = 'done', internal_request_id = $1, updated_at = NOW() WHERE id = $2",
-							internalID, reqRec.ID,
-						)
-						reqRec.Status = "done"
-						reqRec.InternalRequestID = internalID
-					}
-				} else {
-					// queued/running — 내부 잡 ID 저장, GetAnalysis에서 상태 폴링
-					_, _ = db.Exec(
-						"UPDATE analysis_requests SET status = $1, internal_request_id = $2, updated_at = NOW() WHERE id = $3",
-						status, internalID, reqRec.ID,
-					)
-					reqRec.Status = status
-					reqRec.InternalRequestID = internalID
-				}
-			}
-		}
+		submitAnalysisRequest(db, &reqRec)
 
 		c.JSON(http.StatusCreated, reqRec)
 	}
@@ -248,7 +198,97 @@ func GetAnalysis(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		// 'submitting' 고착 복구: 프로세스가 제출 중 죽었으면 2분 뒤 queued로 되돌림
+		refreshAnalysisRequest(db, &rec)
+
+		c.JSON(http.StatusOK, rec)
+	}
+}
+
+// submitAnalysisRequest — queued 레코드를 analyist_dd 내부 API에 제출하고 결과를 반영한다.
+// CreateAnalysis와 관심종목 일괄/예약 분석(watchlists.go)이 공유한다.
+// 게이트웨이 미설정이면 아무것도 하지 않는다 (queued 유지 — 폴링 시 재제출).
+func submitAnalysisRequest(db *sql.DB, reqRec *models.AnalysisRequest) {
+	if analyistURL() == "" {
+		return
+	}
+	internalResult, callErr := callAnalyistInternal(http.MethodPost, "/internal/analysis/"+reqRec.RequestType, map[string]string{
+		"symbol": reqRec.Symbol,
+	})
+
+	if callErr != nil {
+		if errors.Is(callErr, errAnalyistUnreachable) {
+			// 게이트웨이 일시 다운 — 실패로 끝내지 않고 queued 유지,
+			// GetAnalysis 폴링에서 자동 재제출 (웹은 영향 없음)
+			note := "제출 대기 중 (분석 서비스 재시작 중): " + callErr.Error()
+			_, _ = db.Exec(
+				"UPDATE analysis_requests SET status = 'queued', error = $1, updated_at = NOW() WHERE id = $2",
+				note, reqRec.ID,
+			)
+			reqRec.Status = "queued"
+			reqRec.Error = note
+		} else {
+			// 실제 오류 (잘못된 심볼 등) — 명확히 실패 처리
+			_, _ = db.Exec(
+				"UPDATE analysis_requests SET status = 'failed', error = $1, updated_at = NOW() WHERE id = $2",
+				callErr.Error(), reqRec.ID,
+			)
+			reqRec.Status = "failed"
+			reqRec.Error = callErr.Error()
+		}
+	} else {
+		internalID, _ := internalResult["request_id"].(string)
+		status, _ := internalResult["status"].(string)
+		if status == "" {
+			status = "queued"
+		}
+
+		if status == "done" {
+			// 즉시 완료 (stock_report 등) — result를 result_json으로 저장
+			if raw, ok := internalResult["result"]; ok {
+				if b, err := json.Marshal(raw); err == nil {
+					_, _ = db.Exec(
+						"UPDATE analysis_requests SET status = 'done', result_json = $1, internal_request_id = $2, updated_at = NOW() WHERE id = $3",
+						string(b), internalID, reqRec.ID,
+					)
+					reqRec.Status = "done"
+					reqRec.ResultJSON = string(b)
+					reqRec.InternalRequestID = internalID
+				}
+			} else {
+				_, _ = db.Exec(
+					"UPDATE analysis_requests SET status = 'done', internal_request_id = $1, updated_at = NOW() WHERE id = $2",
+					internalID, reqRec.ID,
+				)
+				reqRec.Status = "done"
+				reqRec.InternalRequestID = internalID
+			}
+		} else {
+			// queued/running — 내부 잡 ID 저장, GetAnalysis에서 상태 폴링
+			_, _ = db.Exec(
+				"UPDATE analysis_requests SET status = $1, internal_request_id = $2, updated_at = NOW() WHERE id = $3",
+				status, internalID, reqRec.ID,
+			)
+			reqRec.Status = status
+			reqRec.InternalRequestID = internalID
+		}
+	}
+
+	if reqRec.Status == "done" {
+		notifyAnalysisDone(db, reqRec)
+	}
+}
+
+// refreshAnalysisRequest — 'submitting' 고착 복구, 지연 제출, 비동기 잡 폴링을 수행해 rec을 최신 상태로 갱신한다.
+// GetAnalysis(사용자 폴링)와 백그라운드 예약 분석 잡이 공유한다.
+func refreshAnalysisRequest(db *sql.DB, rec *models.AnalysisRequest) {
+	wasDone := rec.Status == "done"
+	defer func() {
+		if !wasDone && rec.Status == "done" {
+			notifyAnalysisDone(db, rec)
+		}
+	}()
+
+	// 'submitting' 고착 복구: 프로세스가 제출 중 죽었으면 2분 뒤 queued로 되돌림
 	if rec.Status == "submitting" && time.Since(rec.UpdatedAt) > 2*time.Minute {
 		_, _ = db.Exec("UPDATE analysis_requests SET status = 'queued', updated_at = NOW() WHERE id = $1", rec.ID)
 		rec.Status = "queued"
@@ -308,9 +348,9 @@ func GetAnalysis(db *sql.DB) gin.HandlerFunc {
 			}
 			// 갱신된 레코드 재조회
 			_ = db.QueryRow(`
-				SELECT id, user_id, request_type, symbol, status, COALESCE(result_json, '') AS result_json, COALESCE(internal_request_id, '') AS internal_request_id, COALESCE(error, '') AS error, created_at, updated_at
-				FROM analysis_requests WHERE id = $1
-			`, rec.ID).Scan(
+			SELECT id, user_id, request_type, symbol, status, COALESCE(result_json, '') AS result_json, COALESCE(internal_request_id, '') AS internal_request_id, COALESCE(error, '') AS error, created_at, updated_at
+			FROM analysis_requests WHERE id = $1
+		`, rec.ID).Scan(
 				&rec.ID, &rec.UserID, &rec.RequestType, &rec.Symbol, &rec.Status,
 				&rec.ResultJSON, &rec.InternalRequestID, &rec.Error,
 				&rec.CreatedAt, &rec.UpdatedAt,
@@ -319,40 +359,37 @@ func GetAnalysis(db *sql.DB) gin.HandlerFunc {
 	}
 
 	// 비동기 잡 폴링 (M6): queued/running + internal_request_id 있으면 job-runner 상태 조회
-		if (rec.Status == "queued" || rec.Status == "running") && rec.InternalRequestID != "" && analyistURL() != "" {
-			jobResult, pollErr := callAnalyistInternal(http.MethodGet, "/internal/analysis/"+rec.InternalRequestID, nil)
-			if pollErr == nil {
-				status, _ := jobResult["status"].(string)
-				switch status {
-				case "done":
-					if raw, ok := jobResult["result"]; ok {
-						if b, err := json.Marshal(raw); err == nil {
-							_, _ = db.Exec(
-								"UPDATE analysis_requests SET status = 'done', result_json = $1, updated_at = NOW() WHERE id = $2",
-								string(b), rec.ID,
-							)
-							rec.Status = "done"
-							rec.ResultJSON = string(b)
-						}
+	if (rec.Status == "queued" || rec.Status == "running") && rec.InternalRequestID != "" && analyistURL() != "" {
+		jobResult, pollErr := callAnalyistInternal(http.MethodGet, "/internal/analysis/"+rec.InternalRequestID, nil)
+		if pollErr == nil {
+			status, _ := jobResult["status"].(string)
+			switch status {
+			case "done":
+				if raw, ok := jobResult["result"]; ok {
+					if b, err := json.Marshal(raw); err == nil {
+						_, _ = db.Exec(
+							"UPDATE analysis_requests SET status = 'done', result_json = $1, updated_at = NOW() WHERE id = $2",
+							string(b), rec.ID,
+						)
+						rec.Status = "done"
+						rec.ResultJSON = string(b)
 					}
-				case "failed":
-					errMsg, _ := jobResult["error"].(string)
-					_, _ = db.Exec(
-						"UPDATE analysis_requests SET status = 'failed', error = $1, updated_at = NOW() WHERE id = $2",
-						errMsg, rec.ID,
-					)
-					rec.Status = "failed"
-					rec.Error = errMsg
-				case "running", "queued":
-					_, _ = db.Exec(
-						"UPDATE analysis_requests SET status = $1, updated_at = NOW() WHERE id = $2",
-						status, rec.ID,
-					)
-					rec.Status = status
 				}
+			case "failed":
+				errMsg, _ := jobResult["error"].(string)
+				_, _ = db.Exec(
+					"UPDATE analysis_requests SET status = 'failed', error = $1, updated_at = NOW() WHERE id = $2",
+					errMsg, rec.ID,
+				)
+				rec.Status = "failed"
+				rec.Error = errMsg
+			case "running", "queued":
+				_, _ = db.Exec(
+					"UPDATE analysis_requests SET status = $1, updated_at = NOW() WHERE id = $2",
+					status, rec.ID,
+				)
+				rec.Status = status
 			}
 		}
-
-		c.JSON(http.StatusOK, rec)
 	}
 }
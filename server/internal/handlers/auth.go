@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"database/sql"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -20,18 +21,13 @@ type Claims struct {
 	Email         string `json:"email"`
 	Role          string `json:"role"`
 	WalletAddress string `json:"walletAddress,omitempty"`
+	SessionID     int    `json:"sid,omitempty"` // sessions.id (sessions.go)
 	jwt.RegisteredClaims
 }
 
 // Generate JWT token
-func generateToken(user models.User) (string, error) {
-	claims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   user.Role,
-		RegisteredClaims: jwtRegisteredClaims(),
-	}
-	return signClaims(claims)
+func generateToken(user models.User, sessionID int) (string, error) {
+	return generateWalletToken(user, "", sessionID, "")
 }
 
 // Register creates a new user account
@@ -43,23 +39,33 @@ func Register(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
+		// Hash password — 기존 이메일 경로도 해시를 거쳐 응답 시간으로 계정 존재 여부가 드러나지 않게 한다
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+			return
+		}
+
 		// Check if email already exists — CWE-203: 가입 응답으로 계정 존재 여부를
 		// 노출하지 않기 위해 신규/기존 모두 동일한 201 + 메시지 본문을 반환한다.
 		// (이 쇼핑몰의 가입 흐름은 응답 토큰을 사용하지 않고 UI 상태만 전환)
+		// 기존 계정 주인에게는 메일로 알린다 (account_email.go).
 		var existingID int
-		err := db.QueryRow("SELECT id FROM users WHERE email = $1", req.Email).Scan(&existingID)
+		var existingEmail string
+		err = db.QueryRow("SELECT id, email FROM users WHERE email = $1", req.Email).Scan(&existingID, &existingEmail)
 		if err == nil {
+			if deliverableEmail(existingEmail) {
+				// DB 오류여도 응답은 같게 (CWE-203) — 안내 메일만 생략
+				if ok, err := claimRegistrationNotice(db, existingID); err != nil {
+					log.Printf("[auth] registration notice cooldown check failed (user=%d): %v", existingID, err)
+				} else if ok {
+					sendAccountMail(alreadyRegisteredMail(existingEmail))
+				}
+			}
 			c.JSON(http.StatusCreated, gin.H{"message": "Registration successful"})
 			return
 		}
 
-		// Hash password
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
-			return
-		}
-
 		// Insert user - admin 승격은 기존 admin의 SetUserAsAdmin 경로로만 (CWE-269:
 		// ADMIN_EMAIL 일치 가입 시 자동 admin 부여는 등록자가 관리자 계정을 선점할 수 있음)
 		var user models.User
@@ -88,12 +94,17 @@ func Register(db *sql.DB) gin.HandlerFunc {
 		// Generate token (세션 유지용 — 응답에는 포함하지 않음: 신규/기존 가입 응답을
 		// 완전히 동일하게 유지해 계정 열거(CWE-203)를 차단한다. 프론트는 가입 응답
 		// 토큰을 사용하지 않고 로그인/지갑 연결로 전환한다.)
-		_, err = generateToken(user)
+		_, err = generateToken(user, 0)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 			return
 		}
 
+		// 이메일 인증 메일 (실패해도 가입은 유지 — 재발송은 POST /me/email/verification)
+		if err := startEmailVerification(db, user.ID, user.Email); err != nil {
+			log.Printf("[auth] email verification for user %d failed: %v", user.ID, err)
+		}
+
 		c.JSON(http.StatusCreated, gin.H{"message": "Registration successful"})
 	}
 }
@@ -157,12 +168,12 @@ func Login(db *sql.DB) gin.HandlerFunc {
 		// Find user by email
 		var user models.User
 		query := `
-			SELECT id, email, password, name, role, avatar, bio, created_at, updated_at
+			SELECT id, email, password, name, role, avatar, bio, email_verified_at, created_at, updated_at
 			FROM users WHERE email = $1
 		`
 		err := db.QueryRow(query, req.Email).Scan(
 			&user.ID, &user.Email, &user.Password, &user.Name, &user.Role,
-			&user.Avatar, &user.Bio, &user.CreatedAt, &user.UpdatedAt,
+			&user.Avatar, &user.Bio, &user.EmailVerifiedAt, &user.CreatedAt, &user.UpdatedAt,
 		)
 		if err == sql.ErrNoRows {
 			loginFail(req.Email)
@@ -182,16 +193,16 @@ func Login(db *sql.DB) gin.HandlerFunc {
 		}
 		loginSuccess(req.Email)
 
-		// Generate token
-		token, err := generateToken(user)
+		// Start a session: short-lived access token + rotating refresh token (sessions.go)
+		tokens, err := startSession(c, db, user, "")
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 			return
 		}
 
 		c.JSON(http.StatusOK, models.AuthResponse{
-			Token: token,
-			User:  user,
+			AuthTokens: tokens,
+			User:       user,
 		})
 	}
 }
@@ -207,12 +218,12 @@ func GetCurrentUser(db *sql.DB) gin.HandlerFunc {
 
 		var user models.User
 		query := `
-			SELECT id, email, name, role, avatar, bio, created_at, updated_at
+			SELECT id, email, name, role, avatar, bio, email_verified_at, created_at, updated_at
 			FROM users WHERE id = $1
 		`
 		err := db.QueryRow(query, userID).Scan(
 			&user.ID, &user.Email, &user.Name, &user.Role,
-			&user.Avatar, &user.Bio, &user.CreatedAt, &user.UpdatedAt,
+			&user.Avatar, &user.Bio, &user.EmailVerifiedAt, &user.CreatedAt, &user.UpdatedAt,
 		)
 		if err != nil {
 			respondDBError(c, err)
@@ -2,9 +2,7 @@ package handlers
 
 import (
 	"context"
-	"crypto/rand"
 	"database/sql"
-	"encoding/hex"
 	"log"
 	"net/http"
 	"strconv"
@@ -33,7 +31,6 @@ func validSessionID(sessionID string) bool {
 
 func GetCart(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		sessionID := c.Query("sessionId")
 		userID, hasUserID := c.Get("userId")
 
 		var query string
@@ -45,31 +42,38 @@ func GetCart(db *sql.DB) gin.HandlerFunc {
 				SELECT c.id, c.product_id, c.quantity, COALESCE(c.session_id, ''), c.user_id, c.created_at, c.updated_at,
 				       p.id, p.seller_id, p.name, p.price, p.original_price, p.image, p.category, p.product_type,
 				       p.version, p.download_url, p.file_size, p.license_key, p.description, p.features,
-				       p.system_requirements, p.created_at, p.updated_at
+				       p.system_requirements, p.created_at, p.updated_at,
+				       c.price_at_add, c.crypto_price_at_add, p.crypto_price_usdc,
+				       (p.is_active AND p.deleted_at IS NULL)
 				FROM cart c
 				JOIN products p ON c.product_id = p.id
 				WHERE c.user_id = $1
 				ORDER BY c.created_at DESC
 			`
 			args = []interface{}{userID}
-		} else if sessionID != "" {
-			if !verifyAnonymousSessionIP(c, db, sessionID) {
+		} else {
+			// sessionId 쿼리는 토큰 도입 전 세션 이전용으로만 쓴다 (guest_tokens.go)
+			sessionID, ok := resolveGuestSession(c, db, c.Query("sessionId"), false)
+			if !ok {
+				return
+			}
+			if sessionID == "" {
+				c.JSON(http.StatusOK, []models.CartItem{})
 				return
 			}
 			query = `
 				SELECT c.id, c.product_id, c.quantity, COALESCE(c.session_id, ''), COALESCE(c.user_id, 0), c.created_at, c.updated_at,
 				       p.id, p.seller_id, p.name, p.price, p.original_price, p.image, p.category, p.product_type,
 				       p.version, p.download_url, p.file_size, p.license_key, p.description, p.features,
-				       p.system_requirements, p.created_at, p.updated_at
+				       p.system_requirements, p.created_at, p.updated_at,
+				       c.price_at_add, c.crypto_price_at_add, p.crypto_price_usdc,
+				       (p.is_active AND p.deleted_at IS NULL)
 				FROM cart c
 				JOIN products p ON c.product_id = p.id
 				WHERE c.session_id = $1 AND c.user_id IS NULL
 				ORDER BY c.created_at DESC
 			`
 			args = []interface{}{sessionID}
-		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "sessionId is required or authentication needed"})
-			return
 		}
 
 		rows, err := db.Query(query, args...)
@@ -85,6 +89,7 @@ func GetCart(db *sql.DB) gin.HandlerFunc {
 			var product models.Product
 			var sessionIDVal string
 			var userIDVal sql.NullInt64
+			var available bool
 
 			err := rows.Scan(
 				&item.ID, &item.ProductID, &item.Quantity, &sessionIDVal, &userIDVal,
@@ -93,6 +98,7 @@ func GetCart(db *sql.DB) gin.HandlerFunc {
 				&product.Image, &product.Category, &product.ProductType, &product.Version,
 				&product.DownloadURL, &product.FileSize, &product.LicenseKey, &product.Description,
 				&product.Features, &product.SystemReq, &product.CreatedAt, &product.UpdatedAt,
+				&item.PriceAtAdd, &item.CryptoPriceAtAdd, &product.CryptoPriceUsdc, &available,
 			)
 			if err != nil {
 				respondDBError(c, err)
@@ -109,6 +115,7 @@ func GetCart(db *sql.DB) gin.HandlerFunc {
 			// the product — even if the seller adds their own product to their
 			// cart (CWE-639).
 			sanitizePublicProduct(&product)
+			validateCartItem(&item, &product, available)
 			item.Product = &product
 			cartItems = append(cartItems, item)
 		}
@@ -133,66 +140,84 @@ func AddToCart(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
+		// 공개(published) 상품만 담을 수 있다 — draft/삭제 상품 ID 추측 방지
+		// 현재 가격은 담을 때의 가격으로 기록해 GetCart에서 가격 변동을 표시한다.
+		var price int
+		var cryptoPrice int64
+		var isSinglePurchase bool
+		var maxQuantity sql.NullInt64
+		err := db.QueryRow(`
+			SELECT price, crypto_price_usdc, is_single_purchase, max_quantity
+			FROM products WHERE id = $1 AND is_active = true
+		`, req.ProductID).Scan(&price, &cryptoPrice, &isSinglePurchase, &maxQuantity)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		limit := cartQuantityCap(isSinglePurchase, maxQuantity)
+		if msg := cartQuantityError(req.Quantity, limit); msg != "" {
+			c.JSON(http.StatusConflict, gin.H{"error": msg, "maxQuantity": limit})
+			return
+		}
+
 		userID, hasUserID := c.Get("userId")
 
-		// Check if item already exists in cart
-		var existingID int
+		// 라인은 (user_id, product_id) / (session_id, product_id)당 하나 — 이미 있으면 수량을 더하되
+		// 상한을 넘으면 갱신하지 않는다 (RETURNING 없음 → 409). xmax = 0이면 새로 삽입된 행.
 		var query string
 		var args []interface{}
-
 		if hasUserID {
-			query = "SELECT id FROM cart WHERE product_id = $1 AND user_id = $2"
-			args = []interface{}{req.ProductID, userID}
-		} else {
-			if !verifyAnonymousSessionIP(c, db, req.SessionID) {
+			purchased, err := userPurchasedProduct(db, userID, req.ProductID)
+			if err != nil {
+				respondDBError(c, err)
 				return
 			}
-			query = "SELECT id FROM cart WHERE product_id = $1 AND session_id = $2 AND user_id IS NULL"
-			args = []interface{}{req.ProductID, req.SessionID}
-		}
-
-		err := db.QueryRow(query, args...).Scan(&existingID)
-
-		if err == nil {
-			// Update existing item
-			updateQuery := `
-				UPDATE cart 
-				SET quantity = quantity + $1, updated_at = CURRENT_TIMESTAMP
-				WHERE id = $2
-				RETURNING id, product_id, quantity, session_id, user_id, created_at, updated_at
+			if purchased {
+				c.JSON(http.StatusConflict, gin.H{"error": "You have already purchased this product"})
+				return
+			}
+			query = `
+				INSERT INTO cart (product_id, quantity, user_id, price_at_add, crypto_price_at_add)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (user_id, product_id) DO UPDATE
+				SET quantity = cart.quantity + EXCLUDED.quantity, updated_at = CURRENT_TIMESTAMP, reminded_at = NULL
+				WHERE $6::int = 0 OR cart.quantity + EXCLUDED.quantity <= $6::int
+				RETURNING id, product_id, quantity, session_id, user_id, created_at, updated_at, xmax = 0
 			`
-			var item models.CartItem
-			err = db.QueryRow(updateQuery, req.Quantity, existingID).Scan(
-				&item.ID, &item.ProductID, &item.Quantity, &item.SessionID,
-				&item.UserID, &item.CreatedAt, &item.UpdatedAt,
-			)
-			if err != nil {
-				respondDBError(c, err)
+			args = []interface{}{req.ProductID, req.Quantity, userID, price, cryptoPrice, limit}
+		} else {
+			// 게스트 토큰이 없으면 새 세션을 발급한다
+			sessionID, ok := resolveGuestSession(c, db, req.SessionID, true)
+			if !ok {
 				return
 			}
-			c.JSON(http.StatusOK, item)
-			return
+			query = `
+				INSERT INTO cart (product_id, quantity, session_id, price_at_add, crypto_price_at_add)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (session_id, product_id) WHERE user_id IS NULL DO UPDATE
+				SET quantity = cart.quantity + EXCLUDED.quantity, updated_at = CURRENT_TIMESTAMP
+				WHERE $6::int = 0 OR cart.quantity + EXCLUDED.quantity <= $6::int
+				RETURNING id, product_id, quantity, session_id, user_id, created_at, updated_at, xmax = 0
+			`
+			args = []interface{}{req.ProductID, req.Quantity, sessionID, price, cryptoPrice, limit}
 		}
 
-		// Insert new item
-		insertQuery := `
-			INSERT INTO cart (product_id, quantity, session_id, user_id)
-			VALUES ($1, $2, $3, $4)
-			RETURNING id, product_id, quantity, session_id, user_id, created_at, updated_at
-		`
-
 		var item models.CartItem
-		var userIDPtr *int
-		if hasUserID {
-			uid := userID.(int)
-			userIDPtr = &uid
-		}
-
-		err = db.QueryRow(insertQuery, req.ProductID, req.Quantity, req.SessionID, userIDPtr).Scan(
-			&item.ID, &item.ProductID, &item.Quantity, &item.SessionID,
-			&item.UserID, &item.CreatedAt, &item.UpdatedAt,
+		var sessionIDVal sql.NullString
+		var inserted bool
+		err = db.QueryRow(query, args...).Scan(
+			&item.ID, &item.ProductID, &item.Quantity, &sessionIDVal,
+			&item.UserID, &item.CreatedAt, &item.UpdatedAt, &inserted,
 		)
-
+		if err == sql.ErrNoRows {
+			msg := cartQuantityError(limit+1, limit)
+			c.JSON(http.StatusConflict, gin.H{"error": msg, "maxQuantity": limit})
+			return
+		}
 		if err != nil {
 			// A foreign-key violation means the referenced product does not
 			// exist. Return a generic 400 to the client (no driver detail) and
@@ -205,8 +230,17 @@ func AddToCart(db *sql.DB) gin.HandlerFunc {
 			respondDBError(c, err)
 			return
 		}
+		item.SessionID = sessionIDVal.String
 
-		c.JSON(http.StatusCreated, item)
+		if inserted {
+			// 장바구니 상품은 추천 신호이자 추천 제외 대상
+			if hasUserID {
+				invalidateRecommendations(userID.(int))
+			}
+			c.JSON(http.StatusCreated, item)
+			return
+		}
+		c.JSON(http.StatusOK, item)
 	}
 }
 
@@ -253,19 +287,42 @@ func UpdateCartItem(db *sql.DB) gin.HandlerFunc {
 				return
 			}
 		} else {
-			if !verifyAnonymousSessionIP(c, db, c.Query("sessionId")) {
+			sessionID, ok := resolveGuestSession(c, db, c.Query("sessionId"), false)
+			if !ok {
 				return
 			}
-			if !verifyAnonymousCartOwnership(c, db, id) {
+			if !verifyAnonymousCartOwnership(c, db, id, sessionID) {
 				return
 			}
 		}
 
+		// 상품별 수량 상한 (cart_rules.go)
+		var isSinglePurchase bool
+		var maxQuantity sql.NullInt64
+		err = db.QueryRow(`
+			SELECT p.is_single_purchase, p.max_quantity
+			FROM cart c JOIN products p ON p.id = c.product_id
+			WHERE c.id = $1
+		`, id).Scan(&isSinglePurchase, &maxQuantity)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Cart item not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		limit := cartQuantityCap(isSinglePurchase, maxQuantity)
+		if msg := cartQuantityError(req.Quantity, limit); msg != "" {
+			c.JSON(http.StatusConflict, gin.H{"error": msg, "maxQuantity": limit})
+			return
+		}
+
 		query := `
 			UPDATE cart 
-			SET quantity = $1, updated_at = CURRENT_TIMESTAMP
+			SET quantity = $1, updated_at = CURRENT_TIMESTAMP, reminded_at = NULL
 			WHERE id = $2
-			RETURNING id, product_id, quantity, session_id, user_id, created_at, updated_at
+			RETURNING id, product_id, quantity, COALESCE(session_id, ''), user_id, created_at, updated_at
 		`
 
 		var item models.CartItem
@@ -313,10 +370,11 @@ func RemoveFromCart(db *sql.DB) gin.HandlerFunc {
 				return
 			}
 		} else {
-			if !verifyAnonymousSessionIP(c, db, c.Query("sessionId")) {
+			sessionID, ok := resolveGuestSession(c, db, c.Query("sessionId"), false)
+			if !ok {
 				return
 			}
-			if !verifyAnonymousCartOwnership(c, db, id) {
+			if !verifyAnonymousCartOwnership(c, db, id, sessionID) {
 				return
 			}
 		}
@@ -332,110 +390,21 @@ func RemoveFromCart(db *sql.DB) gin.HandlerFunc {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Cart item not found"})
 			return
 		}
+		if hasUserID {
+			invalidateRecommendations(userID.(int))
+		}
 
 		c.JSON(http.StatusOK, gin.H{"message": "Cart item removed successfully"})
 	}
 }
 
-// verifyAnonymousSessionIP ensures an unauthenticated request's sessionId is
-// bound to the caller's IP. On first access (no record) it upserts the current
-// IP and allows. On subsequent access the recorded IP must match c.ClientIP(),
-// otherwise 403. NOTE: NAT/mobile IP changes will break anonymous carts — an
-// accepted LOW-severity tradeoff for this mitigation.
-func verifyAnonymousSessionIP(c *gin.Context, db *sql.DB, sessionID string) bool {
-	if !validSessionID(sessionID) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "sessionId is required and must be at most 64 characters of alphanumerics, '_', or '-'"})
-		return false
-	}
-	var recordedIP string
-	var recordedGuestCookie string
-	err := db.QueryRow("SELECT client_ip, guest_cookie FROM cart_sessions WHERE session_id = $1", sessionID).Scan(&recordedIP, &recordedGuestCookie)
-	if err == sql.ErrNoRows {
-		// First access: issue a fresh HttpOnly guest cookie, record the
-		// caller's IP and cookie, and allow.
-		newValue, genErr := generateGuestCookie()
-		if genErr != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": genErr.Error()})
-			return false
-		}
-		setGuestCookie(c, newValue)
-		_, _ = db.Exec(`
-			INSERT INTO cart_sessions (session_id, client_ip, guest_cookie)
-			VALUES ($1, $2, $3)
-			ON CONFLICT (session_id) DO UPDATE SET client_ip = EXCLUDED.client_ip, guest_cookie = EXCLUDED.guest_cookie
-		`, sessionID, c.ClientIP(), newValue)
-		return true
-	}
-	if err != nil {
-		respondDBError(c, err)
-		return false
-	}
-	if recordedIP != c.ClientIP() {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Session cart does not belong to this client"})
-		return false
-	}
-	// Bind the server-issued HttpOnly guest cookie to the session as well.
-	// Real browsers (frontend) go through same-origin nginx, so the HttpOnly
-	// cookie is automatically maintained on every request and normal flows are
-	// unaffected. This binding only rejects clients that cannot present the
-	// cookie the server originally issued for this session.
-	ok, _ := verifyAnonymousSessionCookie(c, db, sessionID, recordedGuestCookie)
-	return ok
-}
-
-// verifyAnonymousSessionCookie binds the server-issued HttpOnly cmall_guest
-// cookie to an anonymous cart session. It returns (true, newValue) when the
-// request is allowed, and (false, "") after writing a 403 when it is not.
-//
-// - If the recorded guest_cookie is empty (a legacy row created before cookie
-//   binding existed), a new cookie is issued, the row is updated, and the
-//   request is allowed — a one-time migration path so existing anonymous carts
-//   do not break.
-// - If the request's cmall_guest cookie matches the recorded value, allow.
-// - Otherwise the cookie is missing or mismatched → 403.
-func verifyAnonymousSessionCookie(c *gin.Context, db *sql.DB, sessionID string, recordedGuestCookie string) (bool, string) {
-	reqCookie, err := c.Cookie("cmall_guest")
-	if recordedGuestCookie == "" {
-		// Legacy row: bind a fresh cookie and allow.
-		newValue, genErr := generateGuestCookie()
-		if genErr != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": genErr.Error()})
-			return false, ""
-		}
-		setGuestCookie(c, newValue)
-		_, _ = db.Exec("UPDATE cart_sessions SET guest_cookie = $1 WHERE session_id = $2", newValue, sessionID)
-		return true, newValue
-	}
-	if err != nil || reqCookie != recordedGuestCookie {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Session cart does not belong to this client"})
-		return false, ""
-	}
-	return true, ""
-}
-
-// generateGuestCookie returns a cryptographically random 64-hex-char value for
-// the cmall_guest cookie. crypto/rand is used (never math/rand) for security.
-func generateGuestCookie() (string, error) {
-	buf := make([]byte, 32)
-	if _, err := rand.Read(buf); err != nil {
-		return "", err
-	}
-	return hex.EncodeToString(buf), nil
-}
-
-// setGuestCookie writes the HttpOnly cmall_guest cookie on the response.
-func setGuestCookie(c *gin.Context, value string) {
-	c.SetSameSite(http.SameSiteLaxMode)
-	c.SetCookie("cmall_guest", value, 0, "/", "", false, true)
-}
-
 // verifyAnonymousCartOwnership ensures an unauthenticated request may only
-// modify a cart item that belongs to the provided session. Returns false if a
-// response has already been written.
-func verifyAnonymousCartOwnership(c *gin.Context, db *sql.DB, id int) bool {
+// modify a cart item that belongs to the caller's guest session (resolved from
+// the signed guest token). Returns false if a response has already been written.
+func verifyAnonymousCartOwnership(c *gin.Context, db *sql.DB, id int, guestSessionID string) bool {
 	var ownerID sql.NullInt64
 	var sessionID string
-	err := db.QueryRow("SELECT user_id, session_id FROM cart WHERE id = $1", id).Scan(&ownerID, &sessionID)
+	err := db.QueryRow("SELECT user_id, COALESCE(session_id, '') FROM cart WHERE id = $1", id).Scan(&ownerID, &sessionID)
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Cart item not found"})
 		return false
@@ -448,8 +417,7 @@ func verifyAnonymousCartOwnership(c *gin.Context, db *sql.DB, id int) bool {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You can only modify your own cart items"})
 		return false
 	}
-	reqSessionID := c.Query("sessionId")
-	if !validSessionID(reqSessionID) || reqSessionID != sessionID {
+	if guestSessionID == "" || guestSessionID != sessionID {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You can only modify your own cart items"})
 		return false
 	}
@@ -465,32 +433,13 @@ func MergeCart(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		sessionID := c.Query("sessionId")
-		if !validSessionID(sessionID) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "sessionId is required and must be at most 64 characters of alphanumerics, '_', or '-'"})
-			return
-		}
-
-		var recordedIP string
-		var recordedGuestCookie string
-		err := db.QueryRow("SELECT client_ip, guest_cookie FROM cart_sessions WHERE session_id = $1", sessionID).Scan(&recordedIP, &recordedGuestCookie)
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Session cart ownership could not be verified"})
+		// 게스트 토큰(또는 토큰 도입 전 sessionId + 쿠키)으로 확인된 세션만 합친다
+		sessionID, ok := resolveGuestSession(c, db, c.Query("sessionId"), false)
+		if !ok {
 			return
 		}
-		if err != nil {
-			respondDBError(c, err)
-			return
-		}
-		if recordedIP != c.ClientIP() {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Session cart does not belong to this client"})
-			return
-		}
-		// Verify the server-issued HttpOnly guest cookie matches the recorded
-		// value for this session. Real browsers (frontend) go through
-		// same-origin nginx so the HttpOnly cookie is automatically maintained
-		// and normal flows are unaffected.
-		if ok, _ := verifyAnonymousSessionCookie(c, db, sessionID, recordedGuestCookie); !ok {
+		if sessionID == "" {
+			c.JSON(http.StatusOK, gin.H{"message": "No guest cart to merge"})
 			return
 		}
 
@@ -516,12 +465,23 @@ func MergeCart(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
+		// Lines the user already has are combined by adding quantities, capped
+		// by the product's quantity rule (LEAST ignores a NULL cap = unlimited).
+		// Products the user has already purchased are dropped.
 		query := `
-			INSERT INTO cart (product_id, quantity, user_id)
-			SELECT product_id, quantity, $1
-			FROM cart
-			WHERE session_id = $2 AND user_id IS NULL
-			ON CONFLICT DO NOTHING
+			INSERT INTO cart (product_id, quantity, user_id, price_at_add, crypto_price_at_add)
+			SELECT g.product_id, LEAST(g.quantity, ` + cartQuantityCapSQL + `), $1, g.price_at_add, g.crypto_price_at_add
+			FROM cart g
+			JOIN products p ON p.id = g.product_id
+			WHERE g.session_id = $2 AND g.user_id IS NULL
+			  AND NOT EXISTS (
+				SELECT 1 FROM payments pm
+				WHERE pm.user_id = $1 AND pm.order_id = g.product_id AND pm.status = 'paid'
+			  )
+			ON CONFLICT (user_id, product_id) DO UPDATE
+			SET quantity = LEAST(cart.quantity + EXCLUDED.quantity,
+			                     (SELECT ` + cartQuantityCapSQL + ` FROM products p WHERE p.id = EXCLUDED.product_id)),
+			    updated_at = CURRENT_TIMESTAMP, reminded_at = NULL
 		`
 		_, err = tx.Exec(query, userID, sessionID)
 		if err != nil {
@@ -538,11 +498,21 @@ func MergeCart(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
+		// The guest session is consumed by the merge, so revoke its token.
+		_, err = tx.Exec("DELETE FROM cart_sessions WHERE session_id = $1", sessionID)
+		if err != nil {
+			tx.Rollback()
+			respondDBError(c, err)
+			return
+		}
+
 		if err := tx.Commit(); err != nil {
 			tx.Rollback()
 			respondDBError(c, err)
 			return
 		}
+		clearGuestToken(c)
+		invalidateRecommendations(userID.(int))
 
 		c.JSON(http.StatusOK, gin.H{"message": "Cart merged successfully"})
 	}
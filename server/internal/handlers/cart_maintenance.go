@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"cmall_dd/internal/models"
+)
+
+// ── 장바구니 검증/정리 ─────────────────────────────────────────────────────
+// 담을 때의 가격(cart.price_at_add)을 기록해 두고 GetCart가 판매 중지·삭제·가격 변동 항목을 표시한다.
+// cart-cleanup 잡은 CART_ANON_TTL_HOURS 동안 쓰이지 않은 비로그인 세션과 장바구니를 지우고,
+// 로그인 사용자의 장바구니가 CART_ABANDONED_HOURS 이상 방치되면 한 번 알림을 보낸다
+// (cart.reminded_at 선점, 담기/수량 변경 시 초기화되어 다음 방치 때 다시 알림).
+
+// 이보다 오래 방치된 장바구니에는 알림을 보내지 않는다 (기능 도입 직후 오래된 장바구니 일괄 발송 방지).
+const cartAbandonedMaxAgeHours = 30 * 24
+
+// validateCartItem — 담을 때 대비 상품 상태 표시. 가격이 기록되지 않은 항목은 가격 변동을 판단하지 않는다.
+func validateCartItem(item *models.CartItem, product *models.Product, available bool) {
+	item.Unavailable = !available
+	if item.PriceAtAdd != nil && *item.PriceAtAdd != product.Price {
+		item.PriceChanged = true
+	}
+	if item.CryptoPriceAtAdd != nil && *item.CryptoPriceAtAdd != product.CryptoPriceUsdc {
+		item.PriceChanged = true
+	}
+}
+
+// cartReminderMessage — 방치 장바구니 알림 제목/본문
+func cartReminderMessage(firstName string, itemCount int) (string, string) {
+	title := "장바구니에 담은 상품이 기다리고 있습니다"
+	if itemCount <= 1 {
+		return title, fmt.Sprintf("'%s' 상품이 아직 장바구니에 있습니다.", firstName)
+	}
+	return title, fmt.Sprintf("'%s' 외 %d개 상품이 아직 장바구니에 있습니다.", firstName, itemCount-1)
+}
+
+// runCartMaintenance — 백그라운드 잡: 비로그인 장바구니 만료 + 방치 장바구니 알림
+func runCartMaintenance(db *sql.DB) error {
+	ttl := envInt("CART_ANON_TTL_HOURS", 72)
+	if ttl > 0 {
+		// 세션이 만료됐거나 세션 기록이 없는 비로그인 장바구니 → 세션 순으로 지운다
+		res, err := db.Exec(`
+			DELETE FROM cart c
+			WHERE c.user_id IS NULL AND c.updated_at < NOW() - make_interval(hours => $1)
+			  AND NOT EXISTS (
+				SELECT 1 FROM cart_sessions s
+				WHERE s.session_id = c.session_id AND s.last_seen_at >= NOW() - make_interval(hours => $1)
+			  )
+		`, ttl)
+		if err != nil {
+			return err
+		}
+		carts, _ := res.RowsAffected()
+		res, err = db.Exec(`
+			DELETE FROM cart_sessions s
+			WHERE COALESCE(s.last_seen_at, s.created_at) < NOW() - make_interval(hours => $1)
+			  AND NOT EXISTS (SELECT 1 FROM cart c WHERE c.session_id = s.session_id AND c.user_id IS NULL)
+		`, ttl)
+		if err != nil {
+			return err
+		}
+		sessions, _ := res.RowsAffected()
+		if carts > 0 || sessions > 0 {
+			log.Printf("[cart] expired %d anonymous cart items and %d sessions", carts, sessions)
+		}
+	}
+
+	idle := envInt("CART_ABANDONED_HOURS", 24)
+	if idle <= 0 || !envBool("CART_ABANDONED_REMINDERS", true) {
+		return nil
+	}
+	// 마지막 변경 이후 idle시간이 지났고 아직 알리지 않은 항목이 있는 사용자의 장바구니를 선점한다.
+	// 판매 중인 상품이 하나도 없으면 알리지 않는다.
+	rows, err := db.Query(`
+		WITH idle AS (
+			SELECT c.user_id
+			FROM cart c
+			JOIN products p ON p.id = c.product_id
+			WHERE c.user_id IS NOT NULL
+			GROUP BY c.user_id
+			HAVING MAX(c.updated_at) < NOW() - make_interval(hours => $1)
+			   AND MAX(c.updated_at) > NOW() - make_interval(hours => $2)
+			   AND bool_or(c.reminded_at IS NULL)
+			   AND bool_or(p.is_active AND p.deleted_at IS NULL)
+		), claimed AS (
+			UPDATE cart c SET reminded_at = NOW()
+			FROM idle
+			WHERE c.user_id = idle.user_id AND c.reminded_at IS NULL
+			RETURNING c.user_id
+		)
+		SELECT cl.user_id, COUNT(c.id),
+		       (SELECT p.name FROM cart c2 JOIN products p ON p.id = c2.product_id
+		        WHERE c2.user_id = cl.user_id AND p.is_active AND p.deleted_at IS NULL
+		        ORDER BY c2.updated_at DESC, c2.id DESC LIMIT 1)
+		FROM (SELECT DISTINCT user_id FROM claimed) cl
+		JOIN cart c ON c.user_id = cl.user_id
+		JOIN products p ON p.id = c.product_id AND p.is_active AND p.deleted_at IS NULL
+		GROUP BY cl.user_id
+	`, idle, cartAbandonedMaxAgeHours)
+	if err != nil {
+		return err
+	}
+	type reminder struct {
+		userID, count int
+		name          string
+	}
+	var reminders []reminder
+	for rows.Next() {
+		var r reminder
+		if err := rows.Scan(&r.userID, &r.count, &r.name); err != nil {
+			rows.Close()
+			return err
+		}
+		reminders = append(reminders, r)
+	}
+	rows.Close()
+	for _, r := range reminders {
+		title, body := cartReminderMessage(r.name, r.count)
+		notifyUser(db, r.userID, notificationCartAbandoned, title, body, "/")
+	}
+	if len(reminders) > 0 {
+		log.Printf("[cart] sent %d abandoned cart reminders", len(reminders))
+	}
+	return nil
+}
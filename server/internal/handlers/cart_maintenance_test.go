@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"cmall_dd/internal/models"
+)
+
+func TestValidateCartItem(t *testing.T) {
+	price, crypto := 10000, int64(7_000_000)
+	product := &models.Product{Price: 10000, CryptoPriceUsdc: 7_000_000}
+
+	item := models.CartItem{PriceAtAdd: &price, CryptoPriceAtAdd: &crypto}
+	validateCartItem(&item, product, true)
+	if item.Unavailable || item.PriceChanged {
+		t.Errorf("unchanged item flagged: %+v", item)
+	}
+
+	item = models.CartItem{PriceAtAdd: &price, CryptoPriceAtAdd: &crypto}
+	validateCartItem(&item, &models.Product{Price: 9000, CryptoPriceUsdc: 7_000_000}, false)
+	if !item.Unavailable || !item.PriceChanged {
+		t.Errorf("inactive, repriced item not flagged: %+v", item)
+	}
+
+	item = models.CartItem{PriceAtAdd: &price, CryptoPriceAtAdd: &crypto}
+	validateCartItem(&item, &models.Product{Price: 10000, CryptoPriceUsdc: 6_500_000}, true)
+	if !item.PriceChanged {
+		t.Error("USDC price change not flagged")
+	}
+
+	// 가격 기록 이전에 담긴 항목은 비교하지 않는다
+	item = models.CartItem{}
+	validateCartItem(&item, product, true)
+	if item.PriceChanged {
+		t.Error("item without recorded price flagged as changed")
+	}
+}
+
+func TestCartReminderMessage(t *testing.T) {
+	if _, body := cartReminderMessage("자동매매 봇", 1); !strings.Contains(body, "'자동매매 봇' 상품") {
+		t.Errorf("single item body = %q", body)
+	}
+	if _, body := cartReminderMessage("자동매매 봇", 3); !strings.Contains(body, "외 2개") {
+		t.Errorf("multi item body = %q", body)
+	}
+}
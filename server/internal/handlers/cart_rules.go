@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// ── 장바구니 수량 규칙 ─────────────────────────────────────────────────────
+// 디지털 상품은 같은 라이선스를 여러 개 사는 일이 드물어 기본이 1개 한정(products.is_single_purchase)이다.
+// 여러 개를 팔 상품은 is_single_purchase=false로 두고 max_quantity로 상한을 정한다 (NULL은 제한 없음).
+// 장바구니는 (user_id, product_id) / 비로그인 (session_id, product_id)당 한 줄이며, 같은 상품을 다시 담거나
+// 로그인 후 합칠 때는 수량을 더하되 상한을 넘지 않는다. 이미 결제(paid)한 상품은 담을 수 없다.
+
+// cartQuantityCapSQL — 상품별 수량 상한 SQL 식 (products 별칭 p, NULL은 제한 없음)
+const cartQuantityCapSQL = `CASE WHEN p.is_single_purchase THEN 1 ELSE p.max_quantity END`
+
+// nullableMaxQuantity — 요청의 maxQuantity (0 이하는 제한 없음 → NULL)
+func nullableMaxQuantity(v *int) *int {
+	if v == nil || *v <= 0 {
+		return nil
+	}
+	return v
+}
+
+// cartQuantityCap — 상품별 수량 상한 (0은 제한 없음)
+func cartQuantityCap(isSinglePurchase bool, maxQuantity sql.NullInt64) int {
+	if isSinglePurchase {
+		return 1
+	}
+	if maxQuantity.Valid && maxQuantity.Int64 > 0 {
+		return int(maxQuantity.Int64)
+	}
+	return 0
+}
+
+// cartQuantityError — 상한 초과 응답 메시지 ("" = 허용)
+func cartQuantityError(quantity, limit int) string {
+	if limit <= 0 || quantity <= limit {
+		return ""
+	}
+	if limit == 1 {
+		return "This product is limited to one per customer"
+	}
+	return fmt.Sprintf("quantity must be at most %d for this product", limit)
+}
+
+// userPurchasedProduct — 결제 완료(paid)된 구매가 있는지
+func userPurchasedProduct(db *sql.DB, userID interface{}, productID int) (bool, error) {
+	var purchased bool
+	err := db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM payments WHERE user_id = $1 AND order_id = $2 AND status = 'paid')
+	`, userID, productID).Scan(&purchased)
+	return purchased, err
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestCartQuantityRules(t *testing.T) {
+	cases := []struct {
+		single bool
+		max    sql.NullInt64
+		want   int
+	}{
+		{true, sql.NullInt64{}, 1},
+		{true, sql.NullInt64{Int64: 5, Valid: true}, 1}, // 1개 한정이 우선
+		{false, sql.NullInt64{Int64: 5, Valid: true}, 5},
+		{false, sql.NullInt64{}, 0},
+	}
+	for _, tc := range cases {
+		if got := cartQuantityCap(tc.single, tc.max); got != tc.want {
+			t.Errorf("cartQuantityCap(%v, %v) = %d, want %d", tc.single, tc.max, got, tc.want)
+		}
+	}
+
+	if msg := cartQuantityError(1, 1); msg != "" {
+		t.Errorf("quantity at the limit rejected: %s", msg)
+	}
+	if msg := cartQuantityError(2, 1); msg == "" {
+		t.Error("second copy of a single-purchase product accepted")
+	}
+	if msg := cartQuantityError(6, 5); msg == "" {
+		t.Error("quantity above maxQuantity accepted")
+	}
+	if msg := cartQuantityError(1000, 0); msg != "" {
+		t.Errorf("unlimited product rejected: %s", msg)
+	}
+
+	zero, three := 0, 3
+	if nullableMaxQuantity(&zero) != nil || nullableMaxQuantity(nil) != nil {
+		t.Error("maxQuantity 0 should clear the limit")
+	}
+	if v := nullableMaxQuantity(&three); v == nil || *v != 3 {
+		t.Errorf("nullableMaxQuantity(3) = %v", v)
+	}
+}
@@ -0,0 +1,457 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ── 카테고리 ───────────────────────────────────────────────────────────────
+// 계층형 카테고리 (parent_id) + slug + 로케일별 이름(names). products.category_id가 정식 참조이고
+// products.category(이름 문자열)는 기존 프론트/쿠폰 범위/검색 가중치용으로 DB 트리거가 맞춰 둔다.
+// 공개 목록/검색은 ?categorySlug=로 하위 카테고리까지 포함해 거른다 (product_listing.go).
+
+var (
+	slugPattern   = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+	localePattern = regexp.MustCompile(`^[a-z]{2}$`)
+)
+
+// Category — 카테고리 노드. name은 요청 로케일 이름(없으면 기본 이름).
+// productCount는 이 카테고리에 직접 속한 공개 상품 수, totalCount는 하위 카테고리 포함.
+type Category struct {
+	ID           int               `json:"id"`
+	ParentID     *int              `json:"parentId,omitempty"`
+	Slug         string            `json:"slug"`
+	Name         string            `json:"name"`
+	Names        map[string]string `json:"names"`
+	SortOrder    int               `json:"sortOrder"`
+	ProductCount int               `json:"productCount"`
+	TotalCount   int               `json:"totalCount"`
+	Children     []*Category       `json:"children,omitempty"`
+	CreatedAt    time.Time         `json:"createdAt"`
+	UpdatedAt    time.Time         `json:"updatedAt"`
+}
+
+// requestLocale — ?lang= 또는 Accept-Language의 첫 언어 (기본 ko)
+func requestLocale(c *gin.Context) string {
+	lang := c.Query("lang")
+	if lang == "" {
+		lang = c.GetHeader("Accept-Language")
+	}
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_,;"); i >= 0 {
+		lang = lang[:i]
+	}
+	if !localePattern.MatchString(lang) {
+		return "ko"
+	}
+	return lang
+}
+
+// localizedName — names[locale]가 있으면 그 이름, 없으면 기본 이름
+func localizedName(name string, names map[string]string, locale string) string {
+	if v := names[locale]; v != "" {
+		return v
+	}
+	return name
+}
+
+// normalizeSlug — 소문자화 + 검증 (1-maxLen자, 영숫자와 단일 '-')
+func normalizeSlug(raw string, maxLen int) (string, bool) {
+	slug := strings.ToLower(strings.TrimSpace(raw))
+	return slug, len(slug) <= maxLen && slugPattern.MatchString(slug)
+}
+
+// validateLocalizedNames — 키는 2자 언어 코드, 값은 1-100자
+func validateLocalizedNames(names map[string]string) (map[string]string, string) {
+	out := make(map[string]string, len(names))
+	for k, v := range names {
+		k = strings.ToLower(strings.TrimSpace(k))
+		v = strings.TrimSpace(v)
+		if !localePattern.MatchString(k) {
+			return nil, "names keys must be 2-letter language codes"
+		}
+		if v == "" || utf8.RuneCountInString(v) > 100 {
+			return nil, "names values must be 1-100 characters"
+		}
+		out[k] = v
+	}
+	return out, ""
+}
+
+// buildCategoryTree — 평면 목록을 트리로 묶고 하위 포함 상품 수(totalCount)를 채운다.
+// 형제는 sortOrder → name 순. 부모를 찾을 수 없는 노드는 최상위로 둔다.
+func buildCategoryTree(flat []*Category) []*Category {
+	byID := make(map[int]*Category, len(flat))
+	for _, cat := range flat {
+		cat.Children = nil
+		byID[cat.ID] = cat
+	}
+	roots := []*Category{}
+	for _, cat := range flat {
+		if cat.ParentID != nil {
+			if parent, ok := byID[*cat.ParentID]; ok && parent != cat {
+				parent.Children = append(parent.Children, cat)
+				continue
+			}
+		}
+		roots = append(roots, cat)
+	}
+	var walk func(nodes []*Category) int
+	walk = func(nodes []*Category) int {
+		sort.SliceStable(nodes, func(i, j int) bool {
+			if nodes[i].SortOrder != nodes[j].SortOrder {
+				return nodes[i].SortOrder < nodes[j].SortOrder
+			}
+			return nodes[i].Name < nodes[j].Name
+		})
+		sum := 0
+		for _, n := range nodes {
+			n.TotalCount = n.ProductCount + walk(n.Children)
+			sum += n.TotalCount
+		}
+		return sum
+	}
+	walk(roots)
+	return roots
+}
+
+const categorySelect = `
+	SELECT c.id, c.parent_id, c.slug, c.name, c.names, c.sort_order, c.created_at, c.updated_at,
+	       (SELECT COUNT(*) FROM products p WHERE p.category_id = c.id AND p.is_active = true)
+	FROM categories c
+`
+
+func scanCategory(row interface{ Scan(...interface{}) error }, locale string) (*Category, error) {
+	var cat Category
+	var names []byte
+	if err := row.Scan(&cat.ID, &cat.ParentID, &cat.Slug, &cat.Name, &names, &cat.SortOrder,
+		&cat.CreatedAt, &cat.UpdatedAt, &cat.ProductCount); err != nil {
+		return nil, err
+	}
+	cat.Names = map[string]string{}
+	_ = json.Unmarshal(names, &cat.Names)
+	cat.Name = localizedName(cat.Name, cat.Names, locale)
+	return &cat, nil
+}
+
+// GetCategories — GET /api/v1/categories?lang=&flat=true (공개)
+// 기본은 트리 (children 중첩), flat=true면 평면 목록.
+func GetCategories(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := requestLocale(c)
+		rows, err := db.Query(categorySelect + " ORDER BY c.sort_order, c.name, c.id")
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer rows.Close()
+		flat := []*Category{}
+		for rows.Next() {
+			cat, err := scanCategory(rows, locale)
+			if err != nil {
+				respondDBError(c, err)
+				return
+			}
+			flat = append(flat, cat)
+		}
+		if err := rows.Err(); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		tree := buildCategoryTree(flat)
+		if c.Query("flat") == "true" {
+			for _, cat := range flat {
+				cat.Children = nil
+			}
+			c.JSON(http.StatusOK, flat)
+			return
+		}
+		c.JSON(http.StatusOK, tree)
+	}
+}
+
+type categoryRequest struct {
+	Slug      *string           `json:"slug"`
+	Name      *string           `json:"name"`
+	Names     map[string]string `json:"names"`
+	ParentID  *int              `json:"parentId"` // 수정 시 0 = 최상위로 이동
+	SortOrder *int              `json:"sortOrder"`
+}
+
+// categoryAncestorsSQL — $2(새 부모)와 그 조상들. UNION이라 기존 데이터에 순환이 있어도 끝난다.
+const categoryAncestorsSQL = `
+	WITH RECURSIVE anc AS (
+		SELECT id, parent_id FROM categories WHERE id = $2
+		UNION
+		SELECT c.id, c.parent_id FROM categories c JOIN anc ON c.id = anc.parent_id
+	)`
+
+// categoryParentValid — parentID가 존재하고 (수정 시) 자기 자신이나 하위 카테고리가 아닌지.
+// 수정(categoryID != 0)은 갱신과 같은 트랜잭션에서 호출한다: 옮길 카테고리와 새 부모의 조상 행을
+// 먼저 잠가, 두 카테고리를 동시에 서로의 아래로 옮기는 요청이 함께 통과해 순환을 만들지 못하게 한다.
+func categoryParentValid(q interface {
+	Exec(string, ...interface{}) (sql.Result, error)
+	QueryRow(string, ...interface{}) *sql.Row
+}, categoryID, parentID int) (bool, error) {
+	if categoryID != 0 {
+		if _, err := q.Exec(categoryAncestorsSQL+`
+			SELECT id FROM categories WHERE id = $1 OR id IN (SELECT id FROM anc) ORDER BY id FOR UPDATE
+		`, categoryID, parentID); err != nil {
+			return false, err
+		}
+	}
+	// 잠금 후 다시 조회해 그 사이 커밋된 이동까지 반영한다
+	var ok bool
+	err := q.QueryRow(categoryAncestorsSQL+`
+		SELECT EXISTS (SELECT 1 FROM anc) AND NOT EXISTS (SELECT 1 FROM anc WHERE id = $1)
+	`, categoryID, parentID).Scan(&ok)
+	return ok, err
+}
+
+// CreateCategory — POST /api/v1/admin/categories (관리자)
+func CreateCategory(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c, db) {
+			return
+		}
+		var req categoryRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Slug == nil || req.Name == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "slug and name are required"})
+			return
+		}
+		slug, ok := normalizeSlug(*req.Slug, 100)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "slug must be lowercase letters, digits and single hyphens (max 100)"})
+			return
+		}
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || utf8.RuneCountInString(name) > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name must be 1-100 characters"})
+			return
+		}
+		names, msg := validateLocalizedNames(req.Names)
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		var parentID *int
+		if req.ParentID != nil && *req.ParentID != 0 {
+			ok, err := categoryParentValid(db, 0, *req.ParentID)
+			if err != nil {
+				respondDBError(c, err)
+				return
+			}
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "parent category not found"})
+				return
+			}
+			parentID = req.ParentID
+		}
+		sortOrder := 0
+		if req.SortOrder != nil {
+			sortOrder = *req.SortOrder
+		}
+		namesJSON, _ := json.Marshal(names)
+
+		var id int
+		err := db.QueryRow(`
+			INSERT INTO categories (parent_id, slug, name, names, sort_order)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (slug) DO NOTHING
+			RETURNING id
+		`, parentID, slug, name, namesJSON, sortOrder).Scan(&id)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusConflict, gin.H{"error": "category slug already exists"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		cat, err := scanCategory(db.QueryRow(categorySelect+" WHERE c.id = $1", id), requestLocale(c))
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, cat)
+	}
+}
+
+// UpdateCategory — PUT /api/v1/admin/categories/:id (관리자)
+// 이름을 바꾸면 소속 상품의 products.category도 같은 트랜잭션에서 새 이름으로 맞춘다.
+func UpdateCategory(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c, db) {
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+			return
+		}
+		var req categoryRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		query := "UPDATE categories SET updated_at = NOW()"
+		args := []interface{}{}
+		if req.Slug != nil {
+			slug, ok := normalizeSlug(*req.Slug, 100)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "slug must be lowercase letters, digits and single hyphens (max 100)"})
+				return
+			}
+			query += ", slug = " + appendArg(&args, slug)
+		}
+		if req.Name != nil {
+			name := strings.TrimSpace(*req.Name)
+			if name == "" || utf8.RuneCountInString(name) > 100 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "name must be 1-100 characters"})
+				return
+			}
+			query += ", name = " + appendArg(&args, name)
+		}
+		if req.Names != nil {
+			names, msg := validateLocalizedNames(req.Names)
+			if msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+			namesJSON, _ := json.Marshal(names)
+			query += ", names = " + appendArg(&args, namesJSON)
+		}
+		if req.ParentID != nil {
+			if *req.ParentID == 0 {
+				query += ", parent_id = NULL"
+			} else {
+				query += ", parent_id = " + appendArg(&args, *req.ParentID)
+			}
+		}
+		if req.SortOrder != nil {
+			query += ", sort_order = " + appendArg(&args, *req.SortOrder)
+		}
+		query += " WHERE id = " + appendArg(&args, id) + " RETURNING name"
+
+		tx, err := db.Begin()
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer tx.Rollback()
+
+		if req.ParentID != nil && *req.ParentID != 0 {
+			ok, err := categoryParentValid(tx, id, *req.ParentID)
+			if err != nil {
+				respondDBError(c, err)
+				return
+			}
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "parent must be an existing category outside this category's subtree"})
+				return
+			}
+		}
+
+		var name string
+		err = tx.QueryRow(query, args...).Scan(&name)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+			return
+		}
+		if isUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "category slug already exists"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if req.Name != nil {
+			if _, err := tx.Exec(`
+				UPDATE products SET category = $2 WHERE category_id = $1 AND category IS DISTINCT FROM $2
+			`, id, name); err != nil {
+				respondDBError(c, err)
+				return
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		cat, err := scanCategory(db.QueryRow(categorySelect+" WHERE c.id = $1", id), requestLocale(c))
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, cat)
+	}
+}
+
+// DeleteCategory — DELETE /api/v1/admin/categories/:id (관리자)
+// 하위 카테고리가 있으면 409. 소속 상품은 미분류(category/category_id = NULL)가 된다.
+func DeleteCategory(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c, db) {
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+			return
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer tx.Rollback()
+
+		var hasChildren bool
+		if err := tx.QueryRow(
+			`SELECT EXISTS (SELECT 1 FROM categories WHERE parent_id = $1)`, id,
+		).Scan(&hasChildren); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if hasChildren {
+			c.JSON(http.StatusConflict, gin.H{"error": "category has subcategories; move or delete them first"})
+			return
+		}
+		res, err := tx.Exec(`
+			UPDATE products SET category = NULL, category_id = NULL WHERE category_id = $1
+		`, id)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		detached, _ := res.RowsAffected()
+		res, err = tx.Exec(`DELETE FROM categories WHERE id = $1`, id)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Category deleted", "uncategorizedProducts": detached})
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBuildCategoryTree(t *testing.T) {
+	id := func(v int) *int { return &v }
+	flat := []*Category{
+		{ID: 1, Slug: "analysis", Name: "분석", ProductCount: 2},
+		{ID: 2, ParentID: id(1), Slug: "ai-analysis", Name: "AI 분석", ProductCount: 3, SortOrder: 2},
+		{ID: 3, ParentID: id(1), Slug: "charts", Name: "차트", ProductCount: 1, SortOrder: 1},
+		{ID: 4, ParentID: id(2), Slug: "llm", Name: "LLM", ProductCount: 4},
+		{ID: 5, Slug: "ebooks", Name: "전자책"},
+		{ID: 6, ParentID: id(99), Slug: "orphan", Name: "고아"},
+	}
+	roots := buildCategoryTree(flat)
+	if len(roots) != 3 {
+		t.Fatalf("roots = %d, want 3", len(roots))
+	}
+	// 같은 sortOrder면 이름순: 고아 < 분석 < 전자책
+	if roots[0].Slug != "orphan" || roots[2].Slug != "ebooks" {
+		t.Errorf("roots order = %s, %s, %s", roots[0].Slug, roots[1].Slug, roots[2].Slug)
+	}
+	analysis := roots[1]
+	if analysis.Slug != "analysis" || analysis.TotalCount != 10 {
+		t.Errorf("analysis = %s total %d, want total 10", analysis.Slug, analysis.TotalCount)
+	}
+	if len(analysis.Children) != 2 || analysis.Children[0].Slug != "charts" {
+		t.Fatalf("children not sorted by sortOrder: %+v", analysis.Children)
+	}
+	if ai := analysis.Children[1]; ai.TotalCount != 7 || len(ai.Children) != 1 {
+		t.Errorf("ai-analysis total = %d, children = %d", ai.TotalCount, len(ai.Children))
+	}
+}
+
+func TestRequestLocale(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		query, header, want string
+	}{
+		{"", "", "ko"},
+		{"en", "ja", "en"},
+		{"", "en-US,en;q=0.9", "en"},
+		{"", "*", "ko"},
+		{"english", "", "ko"},
+	}
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/categories?lang="+tc.query, nil)
+		if tc.header != "" {
+			c.Request.Header.Set("Accept-Language", tc.header)
+		}
+		if got := requestLocale(c); got != tc.want {
+			t.Errorf("lang=%q header=%q: got %q, want %q", tc.query, tc.header, got, tc.want)
+		}
+	}
+	names := map[string]string{"en": "AI Analysis"}
+	if got := localizedName("AI 분석", names, "en"); got != "AI Analysis" {
+		t.Errorf("en name = %q", got)
+	}
+	if got := localizedName("AI 분석", names, "ja"); got != "AI 분석" {
+		t.Errorf("fallback name = %q", got)
+	}
+}
+
+func TestSlugValidation(t *testing.T) {
+	if slug, ok := normalizeSlug(" AI-Analysis ", 100); !ok || slug != "ai-analysis" {
+		t.Errorf("normalizeSlug = %q, %v", slug, ok)
+	}
+	for _, bad := range []string{"", "-ai", "ai--x", "ai_x", "분석"} {
+		if _, ok := normalizeSlug(bad, 100); ok {
+			t.Errorf("%q should be rejected", bad)
+		}
+	}
+	if _, msg := validateLocalizedNames(map[string]string{"EN": " Analysis "}); msg != "" {
+		t.Errorf("valid names rejected: %s", msg)
+	}
+	if _, msg := validateLocalizedNames(map[string]string{"eng": "x"}); msg == "" {
+		t.Error("3-letter locale should be rejected")
+	}
+
+	slugs, msg := normalizeTagSlugs([]string{"Python", "python", "ml"})
+	if msg != "" || len(slugs) != 2 || slugs[0] != "python" {
+		t.Errorf("normalizeTagSlugs = %v, %q", slugs, msg)
+	}
+	many := make([]string, maxTagsPerProduct+1)
+	for i := range many {
+		many[i] = "t" + string(rune('a'+i))
+	}
+	if _, msg := normalizeTagSlugs(many); msg == "" {
+		t.Error("too many tags should be rejected")
+	}
+}
@@ -3,8 +3,10 @@ package handlers
 import (
 	"database/sql"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 )
@@ -22,6 +24,7 @@ type CommunityPost struct {
 	Category     string    `json:"category"`
 	CreatedAt    time.Time `json:"createdAt"`
 	CommentCount int       `json:"commentCount"`
+	Highlight    string    `json:"highlight,omitempty"` // 검색 결과 본문 스니펫 (HTML, <mark>만 포함)
 }
 
 type CommunityComment struct {
@@ -78,6 +81,81 @@ func GetCommunityPosts(db *sql.DB) gin.HandlerFunc {
 	}
 }
 
+// SearchCommunityPosts — GET /api/v1/community/search?q=&category=&limit=&offset= (공개)
+// 제목·본문 전문 검색 (search.go), 관련도순 + 본문 스니펫.
+func SearchCommunityPosts(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := strings.TrimSpace(c.Query("q"))
+		if q == "" || utf8.RuneCountInString(q) > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "q must be 1-100 characters"})
+			return
+		}
+		text := newTextSearch(db, q, "title")
+		if text.tsquery == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "q has no searchable terms"})
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if err != nil || limit < 1 || limit > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative integer"})
+			return
+		}
+
+		args := []interface{}{}
+		where := text.where(&args)
+		if category := strings.TrimSpace(c.Query("category")); category != "" {
+			where += " AND p.category = " + appendArg(&args, category)
+		}
+
+		var total int
+		if err := db.QueryRow("SELECT COUNT(*) FROM community_posts p WHERE "+where, args...).Scan(&total); err != nil {
+			respondDBError(c, err)
+			return
+		}
+
+		rank := text.rank(&args)
+		rows, err := db.Query(`
+			SELECT p.id, p.user_id, COALESCE(u.name, 'Anonymous'), p.title, p.content, p.category, p.created_at,
+			       (SELECT COUNT(*) FROM community_comments cc WHERE cc.post_id = p.id) AS comment_count
+			FROM community_posts p
+			LEFT JOIN users u ON p.user_id = u.id
+			WHERE `+where+`
+			ORDER BY `+rank+` DESC, p.id DESC
+			LIMIT `+strconv.Itoa(limit)+` OFFSET `+strconv.Itoa(offset), args...)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer rows.Close()
+
+		posts := []CommunityPost{}
+		ids := []int{}
+		for rows.Next() {
+			var p CommunityPost
+			if err := rows.Scan(&p.ID, &p.UserID, &p.UserName, &p.Title, &p.Content, &p.Category, &p.CreatedAt, &p.CommentCount); err != nil {
+				continue
+			}
+			posts = append(posts, p)
+			ids = append(ids, p.ID)
+		}
+
+		_, snippets, err := text.highlights(db, "community_posts", "content", ids)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		for i := range posts {
+			posts[i].Highlight = snippets[posts[i].ID]
+		}
+		c.JSON(http.StatusOK, gin.H{"posts": posts, "total": total})
+	}
+}
+
 // GetCommunityPost — GET /api/v1/community/posts/:id (공개, 댓글 포함)
 func GetCommunityPost(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,519 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// ── 쿠폰 ──────────────────────────────────────────────────────────────────
+// 관리자가 코드를 발급하고, POST /payments/create의 couponCode로 적용한다.
+// 적용은 결제 생성과 같은 트랜잭션에서 쿠폰 행을 잠근 채(FOR UPDATE) 사용 횟수를 세고
+// coupon_redemptions에 기록하므로 동시 요청이 한도를 넘기지 못한다.
+// 사용 횟수에는 paid 결제와, 아직 결제 대기 중인 최근 pending 결제(COUPON_HOLD_MINUTES)가 포함된다 —
+// 버려진 pending 결제가 한도를 영구히 점유하지 않도록.
+
+const (
+	couponPercent = "percent"
+	couponFixed   = "fixed"
+)
+
+var couponCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,64}$`)
+
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func couponHoldMinutes() int {
+	return envInt("COUPON_HOLD_MINUTES", 30)
+}
+
+// Coupon — 관리자 조회/발급 응답
+type Coupon struct {
+	ID                int        `json:"id"`
+	Code              string     `json:"code"`
+	Description       string     `json:"description"`
+	DiscountType      string     `json:"discountType"`
+	DiscountValue     int64      `json:"discountValue"`
+	StartsAt          *time.Time `json:"startsAt,omitempty"`
+	ExpiresAt         *time.Time `json:"expiresAt,omitempty"`
+	MaxRedemptions    *int       `json:"maxRedemptions,omitempty"`
+	PerUserLimit      int        `json:"perUserLimit"`
+	ProductIDs        []int64    `json:"productIds"`
+	Categories        []string   `json:"categories"`
+	FirstPurchaseOnly bool       `json:"firstPurchaseOnly"`
+	IsActive          bool       `json:"isActive"`
+	Redemptions       int        `json:"redemptions"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+}
+
+const couponSelect = `
+	SELECT c.id, c.code, c.description, c.discount_type, c.discount_value, c.starts_at, c.expires_at,
+	       c.max_redemptions, c.per_user_limit, c.product_ids, c.categories, c.first_purchase_only,
+	       c.is_active, c.created_at, c.updated_at
+	FROM coupons c`
+
+func scanCoupon(row interface{ Scan(...interface{}) error }) (*Coupon, error) {
+	var cp Coupon
+	var productIDs pq.Int64Array
+	var categories pq.StringArray
+	err := row.Scan(&cp.ID, &cp.Code, &cp.Description, &cp.DiscountType, &cp.DiscountValue,
+		&cp.StartsAt, &cp.ExpiresAt, &cp.MaxRedemptions, &cp.PerUserLimit, &productIDs, &categories,
+		&cp.FirstPurchaseOnly, &cp.IsActive, &cp.CreatedAt, &cp.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	cp.ProductIDs = []int64(productIDs)
+	cp.Categories = []string(categories)
+	if cp.ProductIDs == nil {
+		cp.ProductIDs = []int64{}
+	}
+	if cp.Categories == nil {
+		cp.Categories = []string{}
+	}
+	return &cp, nil
+}
+
+// couponError — 쿠폰을 적용할 수 없는 사유 (클라이언트에 그대로 노출)
+type couponError struct {
+	status  int
+	message string
+}
+
+func (e *couponError) Error() string { return e.message }
+
+func newCouponError(status int, message string) *couponError {
+	return &couponError{status: status, message: message}
+}
+
+// computeCouponDiscount — 할인액 (USDC 마이크로 단위). 가격을 넘지 않는다.
+func computeCouponDiscount(discountType string, value, price int64) int64 {
+	var discount int64
+	switch discountType {
+	case couponPercent:
+		discount = price * value / 100
+	case couponFixed:
+		discount = value
+	}
+	if discount > price {
+		discount = price
+	}
+	if discount < 0 {
+		discount = 0
+	}
+	return discount
+}
+
+// checkCouponScope — 상품/카테고리 제한. 둘 다 비어 있으면 전체 상품, 하나라도 맞으면 적용.
+func checkCouponScope(cp *Coupon, productID int, category string) bool {
+	if len(cp.ProductIDs) == 0 && len(cp.Categories) == 0 {
+		return true
+	}
+	for _, id := range cp.ProductIDs {
+		if id == int64(productID) {
+			return true
+		}
+	}
+	for _, cat := range cp.Categories {
+		if category != "" && strings.EqualFold(cat, category) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCouponWindow — 활성/기간 검사
+func checkCouponWindow(cp *Coupon, now time.Time) *couponError {
+	if !cp.IsActive {
+		return newCouponError(http.StatusBadRequest, "사용할 수 없는 쿠폰입니다")
+	}
+	if cp.StartsAt != nil && now.Before(*cp.StartsAt) {
+		return newCouponError(http.StatusBadRequest, "아직 사용 기간이 아닌 쿠폰입니다")
+	}
+	if cp.ExpiresAt != nil && !now.Before(*cp.ExpiresAt) {
+		return newCouponError(http.StatusBadRequest, "만료된 쿠폰입니다")
+	}
+	return nil
+}
+
+// appliedCoupon — 결제에 적용될 쿠폰과 금액
+type appliedCoupon struct {
+	Coupon         *Coupon
+	OriginalAmount int64
+	Discount       int64
+}
+
+// applyCoupon — tx 안에서 쿠폰을 잠그고 자격을 검사해 할인액을 계산한다.
+// 자격 미달은 *couponError, 그 외는 DB 오류. 기록(recordCouponRedemption)은 결제 INSERT 후 호출자가 한다.
+func applyCoupon(tx *sql.Tx, code string, userID, productID int, category string, price int64) (*appliedCoupon, error) {
+	code = normalizeCouponCode(code)
+	if !couponCodePattern.MatchString(code) {
+		return nil, newCouponError(http.StatusNotFound, "쿠폰을 찾을 수 없습니다")
+	}
+	cp, err := scanCoupon(tx.QueryRow(couponSelect+` WHERE c.code = $1 FOR UPDATE`, code))
+	if err == sql.ErrNoRows {
+		return nil, newCouponError(http.StatusNotFound, "쿠폰을 찾을 수 없습니다")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cerr := checkCouponWindow(cp, time.Now()); cerr != nil {
+		return nil, cerr
+	}
+	if !checkCouponScope(cp, productID, category) {
+		return nil, newCouponError(http.StatusBadRequest, "이 상품에는 사용할 수 없는 쿠폰입니다")
+	}
+
+	if cp.FirstPurchaseOnly {
+		var purchased bool
+		if err := tx.QueryRow(
+			`SELECT EXISTS (SELECT 1 FROM payments WHERE user_id = $1 AND status = 'paid')`, userID,
+		).Scan(&purchased); err != nil {
+			return nil, err
+		}
+		if purchased {
+			return nil, newCouponError(http.StatusBadRequest, "첫 구매 전용 쿠폰입니다")
+		}
+	}
+
+	var total, mine int
+	err = tx.QueryRow(`
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE r.user_id = $2)
+		FROM coupon_redemptions r
+		JOIN payments p ON p.id = r.payment_id
+		WHERE r.coupon_id = $1
+		  AND (p.status = 'paid' OR (p.status = 'pending' AND p.created_at > NOW() - make_interval(mins => $3)))
+	`, cp.ID, userID, couponHoldMinutes()).Scan(&total, &mine)
+	if err != nil {
+		return nil, err
+	}
+	cp.Redemptions = total
+	if cp.MaxRedemptions != nil && total >= *cp.MaxRedemptions {
+		return nil, newCouponError(http.StatusConflict, "쿠폰 사용 한도가 모두 소진되었습니다")
+	}
+	if cp.PerUserLimit > 0 && mine >= cp.PerUserLimit {
+		return nil, newCouponError(http.StatusConflict, "이미 사용한 쿠폰입니다")
+	}
+
+	return &appliedCoupon{
+		Coupon:         cp,
+		OriginalAmount: price,
+		Discount:       computeCouponDiscount(cp.DiscountType, cp.DiscountValue, price),
+	}, nil
+}
+
+// recordCouponRedemption — 결제 INSERT와 같은 tx에서 사용 기록
+func recordCouponRedemption(tx *sql.Tx, applied *appliedCoupon, userID, paymentID int) error {
+	_, err := tx.Exec(`
+		INSERT INTO coupon_redemptions (coupon_id, user_id, payment_id, original_amount_usdc, discount_usdc)
+		VALUES ($1, $2, $3, $4, $5)
+	`, applied.Coupon.ID, userID, paymentID, applied.OriginalAmount, applied.Discount)
+	return err
+}
+
+// respondCouponError — couponError는 해당 상태로, 그 외는 DB 오류로 응답
+func respondCouponError(c *gin.Context, err error) {
+	if cerr, ok := err.(*couponError); ok {
+		c.JSON(cerr.status, gin.H{"error": cerr.message})
+		return
+	}
+	respondDBError(c, err)
+}
+
+// PreviewCoupon — POST /api/v1/coupons/preview (JWT)
+// body: {code, productId}. 결제 생성 없이 할인 결과만 계산한다 (트랜잭션은 롤백).
+func PreviewCoupon(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		var req struct {
+			Code      string `json:"code" binding:"required"`
+			ProductID int    `json:"productId" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var price int64
+		var category string
+		err := db.QueryRow(
+			`SELECT crypto_price_usdc, COALESCE(category, '') FROM products WHERE id = $1 AND is_active = true`, req.ProductID,
+		).Scan(&price, &category)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer tx.Rollback()
+		applied, err := applyCoupon(tx, req.Code, userID.(int), req.ProductID, category, price)
+		if err != nil {
+			respondCouponError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"code":               applied.Coupon.Code,
+			"description":        applied.Coupon.Description,
+			"originalAmountUsdc": applied.OriginalAmount,
+			"discountUsdc":       applied.Discount,
+			"amountUsdc":         applied.OriginalAmount - applied.Discount,
+		})
+	}
+}
+
+type couponRequest struct {
+	Code              string     `json:"code"`
+	Description       *string    `json:"description"`
+	DiscountType      string     `json:"discountType"`
+	DiscountValue     int64      `json:"discountValue"`
+	StartsAt          *time.Time `json:"startsAt"`
+	ExpiresAt         *time.Time `json:"expiresAt"`
+	MaxRedemptions    *int       `json:"maxRedemptions"`
+	PerUserLimit      *int       `json:"perUserLimit"`
+	ProductIDs        []int64    `json:"productIds"`
+	Categories        []string   `json:"categories"`
+	FirstPurchaseOnly bool       `json:"firstPurchaseOnly"`
+	IsActive          *bool      `json:"isActive"`
+}
+
+// validateCouponDiscount — percent는 1~100, fixed는 양수
+func validateCouponDiscount(discountType string, value int64) string {
+	switch discountType {
+	case couponPercent:
+		if value < 1 || value > 100 {
+			return "percent discountValue must be between 1 and 100"
+		}
+	case couponFixed:
+		if value <= 0 {
+			return "fixed discountValue must be a positive USDC micro-unit amount"
+		}
+	default:
+		return "discountType must be percent or fixed"
+	}
+	return ""
+}
+
+// requireAdmin — 관리자가 아니면 401/403 응답 후 false
+func requireAdmin(c *gin.Context, db *sql.DB) bool {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return false
+	}
+	if !isAdminUser(db, userID.(int)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return false
+	}
+	return true
+}
+
+// CreateCoupon — POST /api/v1/admin/coupons (관리자)
+func CreateCoupon(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c, db) {
+			return
+		}
+		userID, _ := c.Get("userId")
+		var req couponRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		code := normalizeCouponCode(req.Code)
+		if !couponCodePattern.MatchString(code) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "code must be 3-64 characters of A-Z, 0-9, '-' or '_'"})
+			return
+		}
+		if msg := validateCouponDiscount(req.DiscountType, req.DiscountValue); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		if req.StartsAt != nil && req.ExpiresAt != nil && !req.ExpiresAt.After(*req.StartsAt) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expiresAt must be after startsAt"})
+			return
+		}
+		if req.MaxRedemptions != nil && *req.MaxRedemptions <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "maxRedemptions must be positive"})
+			return
+		}
+		perUser := 1
+		if req.PerUserLimit != nil {
+			if *req.PerUserLimit < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "perUserLimit must be 0 (unlimited) or positive"})
+				return
+			}
+			perUser = *req.PerUserLimit
+		}
+		description := ""
+		if req.Description != nil {
+			description = strings.TrimSpace(*req.Description)
+		}
+		active := true
+		if req.IsActive != nil {
+			active = *req.IsActive
+		}
+		if req.ProductIDs == nil {
+			req.ProductIDs = []int64{}
+		}
+		categories := []string{}
+		for _, cat := range req.Categories {
+			if cat = strings.TrimSpace(cat); cat != "" {
+				categories = append(categories, cat)
+			}
+		}
+
+		var id int
+		err := db.QueryRow(`
+			INSERT INTO coupons (code, description, discount_type, discount_value, starts_at, expires_at,
+			                     max_redemptions, per_user_limit, product_ids, categories, first_purchase_only,
+			                     is_active, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			ON CONFLICT (code) DO NOTHING
+			RETURNING id
+		`, code, description, req.DiscountType, req.DiscountValue, req.StartsAt, req.ExpiresAt,
+			req.MaxRedemptions, perUser, pq.Array(req.ProductIDs), pq.Array(categories), req.FirstPurchaseOnly,
+			active, userID).Scan(&id)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusConflict, gin.H{"error": "coupon code already exists"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		cp, err := scanCoupon(db.QueryRow(couponSelect+` WHERE c.id = $1`, id))
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, cp)
+	}
+}
+
+// GetCoupons — GET /api/v1/admin/coupons (관리자). redemptions는 paid 결제 기준.
+func GetCoupons(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c, db) {
+			return
+		}
+		rows, err := db.Query(`
+			SELECT c.id, c.code, c.description, c.discount_type, c.discount_value, c.starts_at, c.expires_at,
+			       c.max_redemptions, c.per_user_limit, c.product_ids, c.categories, c.first_purchase_only,
+			       c.is_active, c.created_at, c.updated_at,
+			       (SELECT COUNT(*) FROM coupon_redemptions r JOIN payments p ON p.id = r.payment_id
+			        WHERE r.coupon_id = c.id AND p.status = 'paid')
+			FROM coupons c
+			ORDER BY c.created_at DESC
+		`)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer rows.Close()
+		coupons := []*Coupon{}
+		for rows.Next() {
+			var cp Coupon
+			var productIDs pq.Int64Array
+			var categories pq.StringArray
+			if err := rows.Scan(&cp.ID, &cp.Code, &cp.Description, &cp.DiscountType, &cp.DiscountValue,
+				&cp.StartsAt, &cp.ExpiresAt, &cp.MaxRedemptions, &cp.PerUserLimit, &productIDs, &categories,
+				&cp.FirstPurchaseOnly, &cp.IsActive, &cp.CreatedAt, &cp.UpdatedAt, &cp.Redemptions); err != nil {
+				respondDBError(c, err)
+				return
+			}
+			cp.ProductIDs = append([]int64{}, productIDs...)
+			cp.Categories = append([]string{}, categories...)
+			coupons = append(coupons, &cp)
+		}
+		c.JSON(http.StatusOK, coupons)
+	}
+}
+
+// UpdateCoupon — PUT /api/v1/admin/coupons/:id (관리자)
+// 할인 조건(code/discountType/discountValue)은 이미 사용된 기록과 어긋나지 않도록 바꿀 수 없고,
+// 설명·기간·한도·활성 여부만 수정한다.
+func UpdateCoupon(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c, db) {
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coupon ID"})
+			return
+		}
+		var req couponRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		query := "UPDATE coupons SET updated_at = NOW()"
+		args := []interface{}{}
+		if req.Description != nil {
+			query += ", description = " + appendArg(&args, strings.TrimSpace(*req.Description))
+		}
+		if req.StartsAt != nil {
+			query += ", starts_at = " + appendArg(&args, *req.StartsAt)
+		}
+		if req.ExpiresAt != nil {
+			query += ", expires_at = " + appendArg(&args, *req.ExpiresAt)
+		}
+		if req.MaxRedemptions != nil {
+			if *req.MaxRedemptions < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "maxRedemptions must be 0 (unlimited) or positive"})
+				return
+			}
+			if *req.MaxRedemptions == 0 {
+				query += ", max_redemptions = NULL"
+			} else {
+				query += ", max_redemptions = " + appendArg(&args, *req.MaxRedemptions)
+			}
+		}
+		if req.PerUserLimit != nil {
+			if *req.PerUserLimit < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "perUserLimit must be 0 (unlimited) or positive"})
+				return
+			}
+			query += ", per_user_limit = " + appendArg(&args, *req.PerUserLimit)
+		}
+		if req.IsActive != nil {
+			query += ", is_active = " + appendArg(&args, *req.IsActive)
+		}
+		query += " WHERE id = " + appendArg(&args, id)
+
+		res, err := db.Exec(query, args...)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Coupon not found"})
+			return
+		}
+		cp, err := scanCoupon(db.QueryRow(couponSelect+` WHERE c.id = $1`, id))
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, cp)
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeCouponDiscount(t *testing.T) {
+	cases := []struct {
+		kind  string
+		value int64
+		price int64
+		want  int64
+	}{
+		{couponPercent, 10, 5_000_000, 500_000},
+		{couponPercent, 100, 5_000_000, 5_000_000},
+		{couponPercent, 33, 10, 3}, // 내림
+		{couponFixed, 1_000_000, 5_000_000, 1_000_000},
+		{couponFixed, 9_000_000, 5_000_000, 5_000_000}, // 가격 초과 불가
+		{"bogus", 10, 5_000_000, 0},
+	}
+	for _, c := range cases {
+		if got := computeCouponDiscount(c.kind, c.value, c.price); got != c.want {
+			t.Errorf("computeCouponDiscount(%s, %d, %d) = %d, want %d", c.kind, c.value, c.price, got, c.want)
+		}
+	}
+}
+
+func TestCheckCouponScope(t *testing.T) {
+	global := &Coupon{}
+	if !checkCouponScope(global, 7, "") {
+		t.Error("unscoped coupon should apply to every product")
+	}
+	scoped := &Coupon{ProductIDs: []int64{3, 7}, Categories: []string{"Trading"}}
+	if !checkCouponScope(scoped, 7, "") {
+		t.Error("listed product should match")
+	}
+	if !checkCouponScope(scoped, 9, "trading") {
+		t.Error("category match should be case-insensitive")
+	}
+	if checkCouponScope(scoped, 9, "education") {
+		t.Error("unlisted product in another category should not match")
+	}
+}
+
+func TestCheckCouponWindow(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	if err := checkCouponWindow(&Coupon{IsActive: true}, now); err != nil {
+		t.Errorf("open coupon rejected: %v", err)
+	}
+	if checkCouponWindow(&Coupon{IsActive: false}, now) == nil {
+		t.Error("inactive coupon accepted")
+	}
+	if checkCouponWindow(&Coupon{IsActive: true, StartsAt: &future}, now) == nil {
+		t.Error("not-yet-started coupon accepted")
+	}
+	if checkCouponWindow(&Coupon{IsActive: true, ExpiresAt: &past}, now) == nil {
+		t.Error("expired coupon accepted")
+	}
+}
+
+func TestValidateCouponDiscount(t *testing.T) {
+	if validateCouponDiscount(couponPercent, 0) == "" || validateCouponDiscount(couponPercent, 101) == "" {
+		t.Error("percent outside 1-100 accepted")
+	}
+	if validateCouponDiscount(couponFixed, 0) == "" {
+		t.Error("zero fixed discount accepted")
+	}
+	if validateCouponDiscount("free", 1) == "" {
+		t.Error("unknown discount type accepted")
+	}
+	if validateCouponDiscount(couponPercent, 15) != "" || validateCouponDiscount(couponFixed, 1) != "" {
+		t.Error("valid discounts rejected")
+	}
+}
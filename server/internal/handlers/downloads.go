@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cmall_dd/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// ── 디지털 배송 ─────────────────────────────────────────────────────────────
+// 구매자는 GET /purchases/:referenceId/download 로 다운로드를 요청한다.
+// paid 결제 + 다운로드 한도를 확인하고 카운터를 올린 뒤, 짧게 유효한 HMAC 서명
+// 토큰(/downloads/:token)을 발급한다. 토큰 URL은 인증 헤더 없이 열 수 있어
+// 브라우저 다운로드/리다이렉트에 쓸 수 있다.
+// 배송 대상: products.artifact_id(스토리지 객체)가 우선, 없으면 download_url(외부 URL).
+// 외부 URL은 리다이렉트만 한다 — 서버가 대신 가져오지 않는다 (SSRF, CWE-918).
+
+// downloadTokenTTL — 서명 다운로드 토큰 / S3 presigned URL 유효 시간
+const downloadTokenTTL = 5 * time.Minute
+
+var (
+	artifactStorageOnce sync.Once
+	artifactStorage     storage.Storage
+	artifactStorageErr  error
+)
+
+// getArtifactStorage — STORAGE_BACKEND 설정의 스토리지 (최초 1회 생성)
+func getArtifactStorage() (storage.Storage, error) {
+	artifactStorageOnce.Do(func() {
+		artifactStorage, artifactStorageErr = storage.FromEnv()
+		if artifactStorageErr != nil {
+			log.Printf("[storage] backend unavailable: %v", artifactStorageErr)
+		}
+	})
+	return artifactStorage, artifactStorageErr
+}
+
+// downloadLimitDefault — 상품별 download_limit이 없을 때 구매 1건당 다운로드 횟수 (0 이하 = 무제한)
+func downloadLimitDefault() int {
+	return envInt("DOWNLOAD_LIMIT_PER_PURCHASE", 5)
+}
+
+// downloadURLSecret — 다운로드 토큰 서명 키. 미설정 시 JWT 시크릿에서 파생 (용도 분리)
+func downloadURLSecret() []byte {
+	if s := os.Getenv("DOWNLOAD_URL_SECRET"); s != "" {
+		return []byte(s)
+	}
+	mac := hmac.New(sha256.New, jwtSecret())
+	mac.Write([]byte("cmall_dd download url"))
+	return mac.Sum(nil)
+}
+
+// signDownloadToken — base64url("referenceId|릴리스버전|만료unix") + "." + base64url(HMAC-SHA256).
+// 버전이 ""이면 상품 기본 파일 (최신 stable 릴리스).
+func signDownloadToken(secret []byte, referenceID, version string, expiresAt time.Time) string {
+	payload := referenceID + "|" + version + "|" + strconv.FormatInt(expiresAt.Unix(), 10)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+var errInvalidDownloadToken = errors.New("invalid or expired download token")
+
+// verifyDownloadToken — 서명·만료 확인 후 referenceId, 릴리스 버전 반환
+func verifyDownloadToken(secret []byte, token string, now time.Time) (string, string, error) {
+	encPayload, encSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", "", errInvalidDownloadToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encPayload)
+	if err != nil {
+		return "", "", errInvalidDownloadToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encSig)
+	if err != nil {
+		return "", "", errInvalidDownloadToken
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return "", "", errInvalidDownloadToken
+	}
+	// 버전/만료는 '|'를 포함하지 않으므로 뒤에서부터 자른다
+	rest, expStr, _ := cutLast(string(payload), "|")
+	referenceID, version, ok := cutLast(rest, "|")
+	if !ok || referenceID == "" {
+		return "", "", errInvalidDownloadToken
+	}
+	exp, err := strconv.ParseInt(expStr, 10, 64)
+	if err != nil || now.Unix() > exp {
+		return "", "", errInvalidDownloadToken
+	}
+	return referenceID, version, nil
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// purchaseDelivery — 결제 1건의 배송 대상
+type purchaseDelivery struct {
+	UserID        int
+	Status        string
+	ProductName   string
+	StorageKey    string
+	Filename      string
+	ContentType   string
+	DownloadURL   string
+	DownloadLimit int
+	DownloadCount int
+	// 요청한 릴리스 버전이 없으면 false (버전 미지정 시 항상 true)
+	ReleaseFound bool
+}
+
+func (d *purchaseDelivery) hasArtifact() bool { return d.StorageKey != "" }
+
+// loadPurchaseDelivery — version이 주어지면 해당 릴리스의 아티팩트, 아니면 상품 기본 아티팩트
+func loadPurchaseDelivery(db *sql.DB, referenceID, version string) (*purchaseDelivery, error) {
+	var d purchaseDelivery
+	err := db.QueryRow(`
+		SELECT pm.user_id, pm.status, pr.name,
+		       COALESCE(a.storage_key, ''), COALESCE(a.filename, ''), COALESCE(a.content_type, ''),
+		       CASE WHEN $3 = '' THEN COALESCE(pr.download_url, '') ELSE '' END,
+		       COALESCE(pr.download_limit, $2), pm.download_count,
+		       $3 = '' OR r.id IS NOT NULL
+		FROM payments pm
+		JOIN products pr ON pr.id = pm.order_id
+		LEFT JOIN product_releases r ON r.product_id = pr.id AND r.version = $3 AND r.review_status = 'approved'
+		LEFT JOIN artifacts a ON a.id = CASE WHEN $3 = '' THEN pr.artifact_id ELSE r.artifact_id END
+		WHERE pm.reference_id = $1
+	`, referenceID, downloadLimitDefault(), version).Scan(&d.UserID, &d.Status, &d.ProductName,
+		&d.StorageKey, &d.Filename, &d.ContentType,
+		&d.DownloadURL, &d.DownloadLimit, &d.DownloadCount, &d.ReleaseFound)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// DownloadPurchase — GET /api/v1/purchases/:referenceId/download (JWT, 구매자만)
+// mode=redirect(기본): 서명 토큰 URL로 302 / mode=url: {url, expiresAt, remaining} /
+// mode=stream: 스토리지 객체를 이 응답으로 바로 스트리밍 (외부 URL 상품은 불가).
+// version=1.2.0 이면 해당 릴리스 파일 (기본: 최신 stable이 반영된 상품 파일).
+// 호출마다 다운로드 카운터가 1 증가한다 (한도 초과 시 429).
+func DownloadPurchase(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		referenceID := c.Param("referenceId")
+		mode := strings.ToLower(strings.TrimSpace(c.DefaultQuery("mode", "redirect")))
+		if mode != "redirect" && mode != "url" && mode != "stream" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be redirect, url or stream"})
+			return
+		}
+		version := strings.TrimPrefix(strings.TrimSpace(c.Query("version")), "v")
+		if _, ok := parseSemver(version); version != "" && !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "version must be a semantic version"})
+			return
+		}
+
+		d, err := loadPurchaseDelivery(db, referenceID, version)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "purchase not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if d.UserID != userID.(int) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "본인 구매 상품만 다운로드할 수 있습니다"})
+			return
+		}
+		if d.Status != paymentPaid {
+			c.JSON(http.StatusForbidden, gin.H{"error": "결제가 완료되지 않았습니다"})
+			return
+		}
+		if !d.ReleaseFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "release not found"})
+			return
+		}
+		if !d.hasArtifact() && d.DownloadURL == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "다운로드할 파일이 없는 상품입니다"})
+			return
+		}
+		if mode == "stream" && !d.hasArtifact() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "외부 링크 상품은 stream 모드를 지원하지 않습니다"})
+			return
+		}
+
+		// 한도 확인과 증가를 한 문장으로 — 동시 요청이 한도를 넘지 못하도록
+		var count int
+		err = db.QueryRow(`
+			UPDATE payments SET download_count = download_count + 1, last_downloaded_at = NOW()
+			WHERE reference_id = $1 AND status = 'paid' AND ($2 <= 0 OR download_count < $2)
+			RETURNING download_count
+		`, referenceID, d.DownloadLimit).Scan(&count)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "다운로드 횟수 한도를 초과했습니다", "limit": d.DownloadLimit})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		remaining := -1
+		if d.DownloadLimit > 0 {
+			remaining = d.DownloadLimit - count
+		}
+		c.Header("X-Downloads-Remaining", strconv.Itoa(remaining))
+
+		if mode == "stream" {
+			streamArtifact(c, d)
+			return
+		}
+
+		expiresAt := time.Now().Add(downloadTokenTTL)
+		url := "/api/v1/downloads/" + signDownloadToken(downloadURLSecret(), referenceID, version, expiresAt)
+		if mode == "url" {
+			c.JSON(http.StatusOK, gin.H{"url": url, "expiresAt": expiresAt, "remaining": remaining})
+			return
+		}
+		c.Redirect(http.StatusFound, url)
+	}
+}
+
+// RedeemDownloadToken — GET /api/v1/downloads/:token (공개, 서명 토큰)
+// 토큰 유효 시간 안에서만 동작하며, 결제가 여전히 paid인지 다시 확인한다 (환불 등).
+func RedeemDownloadToken(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		referenceID, version, err := verifyDownloadToken(downloadURLSecret(), c.Param("token"), time.Now())
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		d, err := loadPurchaseDelivery(db, referenceID, version)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "purchase not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if d.Status != paymentPaid {
+			c.JSON(http.StatusForbidden, gin.H{"error": "결제가 완료되지 않았습니다"})
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+
+		if !d.hasArtifact() {
+			if d.DownloadURL == "" || validateDownloadURL(d.DownloadURL) != "" {
+				c.JSON(http.StatusNotFound, gin.H{"error": "다운로드할 파일이 없는 상품입니다"})
+				return
+			}
+			c.Redirect(http.StatusFound, d.DownloadURL)
+			return
+		}
+
+		store, err := getArtifactStorage()
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "storage unavailable"})
+			return
+		}
+		presigned, err := store.PresignGet(d.StorageKey, downloadTokenTTL, d.downloadName())
+		if err != nil {
+			log.Printf("[downloads] presign %s failed: %v", d.StorageKey, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to prepare download"})
+			return
+		}
+		if presigned != "" {
+			c.Redirect(http.StatusFound, presigned)
+			return
+		}
+		streamArtifact(c, d)
+	}
+}
+
+func (d *purchaseDelivery) downloadName() string {
+	if d.Filename != "" {
+		return d.Filename
+	}
+	return d.ProductName
+}
+
+// streamArtifact — 스토리지 객체를 응답 본문으로 복사 (로컬 디스크 또는 stream 모드)
+func streamArtifact(c *gin.Context, d *purchaseDelivery) {
+	store, err := getArtifactStorage()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "storage unavailable"})
+		return
+	}
+	body, info, err := store.Get(d.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "파일을 찾을 수 없습니다"})
+		return
+	}
+	if err != nil {
+		log.Printf("[downloads] get %s failed: %v", d.StorageKey, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to read file"})
+		return
+	}
+	defer body.Close()
+
+	contentType := d.ContentType
+	if contentType == "" {
+		contentType = info.ContentType
+	}
+	c.Header("Cache-Control", "no-store")
+	c.DataFromReader(http.StatusOK, info.Size, contentType, body, map[string]string{
+		"Content-Disposition":    storage.ContentDisposition(d.downloadName()),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadToken(t *testing.T) {
+	secret := []byte("test-secret")
+	now := time.Unix(1_700_000_000, 0)
+	token := signDownloadToken(secret, "ref_abc|123", "1.2.0-beta.1", now.Add(5*time.Minute))
+
+	ref, version, err := verifyDownloadToken(secret, token, now)
+	if err != nil || ref != "ref_abc|123" || version != "1.2.0-beta.1" {
+		t.Fatalf("verify = %q, %q, %v", ref, version, err)
+	}
+	if _, _, err := verifyDownloadToken(secret, signDownloadToken(secret, "ref", "", now), now); err != nil {
+		t.Errorf("token without version rejected: %v", err)
+	}
+	if _, _, err := verifyDownloadToken(secret, token, now.Add(6*time.Minute)); err == nil {
+		t.Error("만료된 토큰이 통과함")
+	}
+	if _, _, err := verifyDownloadToken([]byte("other"), token, now); err == nil {
+		t.Error("다른 키로 서명된 토큰이 통과함")
+	}
+
+	// 페이로드 변조 (referenceId 바꿔치기)
+	_, sig, _ := strings.Cut(token, ".")
+	forged := signDownloadToken(secret, "ref_other", "", now.Add(5*time.Minute))
+	payload, _, _ := strings.Cut(forged, ".")
+	if _, _, err := verifyDownloadToken(secret, payload+"."+sig, now); err == nil {
+		t.Error("변조된 토큰이 통과함")
+	}
+	for _, bad := range []string{"", "nodot", "!!!.???"} {
+		if _, _, err := verifyDownloadToken(secret, bad, now); err == nil {
+			t.Errorf("verifyDownloadToken(%q) 통과", bad)
+		}
+	}
+}
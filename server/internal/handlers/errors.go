@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
 )
 
 // respondDBError logs the detailed database driver error to the server log and
@@ -14,3 +16,10 @@ func respondDBError(c *gin.Context, err error) {
 	log.Printf("database error: %v", err)
 	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 }
+
+// isUniqueViolation reports whether err is a PostgreSQL unique_violation
+// (23505), for UPDATEs where ON CONFLICT DO NOTHING is not available.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
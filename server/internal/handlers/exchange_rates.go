@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cmall_dd/internal/fxrate"
+	"cmall_dd/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ── 환율 (KRW ↔ USDC) ────────────────────────────────────────────────────
+// 우선순위: 관리자 수동 고정값(exchange_rate_overrides) → FX_CACHE_TTL_MINUTES 이내 캐시(exchange_rates)
+// → 설정된 공급자(FX_PROVIDER) 조회 후 이력에 기록 → 공급자 실패 시 마지막 캐시 (FX_MAX_STALE_HOURS 이내).
+// products.price_sync가 krw/usdc인 상품은 한쪽 가격을 기준으로 다른 쪽을 자동 산출하고,
+// exchange-rates 잡이 주기적으로 다시 맞춘다. 결제는 생성 시점 환율과 KRW 환산액을 기록한다.
+
+const (
+	priceSyncManual = "manual"
+	priceSyncKRW    = "krw"
+	priceSyncUSDC   = "usdc"
+)
+
+var (
+	fxProviderOnce sync.Once
+	fxProvider     fxrate.RateProvider
+	fxProviderErr  error
+)
+
+// getRateProvider — FX_PROVIDER 설정의 공급자 (최초 1회 생성)
+func getRateProvider() (fxrate.RateProvider, error) {
+	fxProviderOnce.Do(func() {
+		fxProvider, fxProviderErr = fxrate.FromEnv()
+		if fxProviderErr != nil {
+			log.Printf("[fx] provider unavailable: %v", fxProviderErr)
+		}
+	})
+	return fxProvider, fxProviderErr
+}
+
+// overrideRateProvider — 관리자가 고정한 환율 (만료/해제되지 않은 최신 1건)
+type overrideRateProvider struct {
+	db *sql.DB
+}
+
+func (p overrideRateProvider) Name() string { return "manual" }
+
+func (p overrideRateProvider) Rate(ctx context.Context) (fxrate.Rate, error) {
+	r := fxrate.Rate{Source: p.Name()}
+	err := p.db.QueryRowContext(ctx, `
+		SELECT rate, created_at FROM exchange_rate_overrides
+		WHERE pair = $1 AND cleared_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY created_at DESC, id DESC LIMIT 1
+	`, fxrate.Pair).Scan(&r.KRWPerUSDC, &r.FetchedAt)
+	if err == sql.ErrNoRows {
+		return fxrate.Rate{}, fxrate.ErrNoRate
+	}
+	if err != nil {
+		return fxrate.Rate{}, err
+	}
+	return r, nil
+}
+
+// latestCachedRate — exchange_rates의 최신 1건 (maxAge 이내)
+func latestCachedRate(db *sql.DB, maxAge time.Duration) (fxrate.Rate, error) {
+	var r fxrate.Rate
+	err := db.QueryRow(`
+		SELECT rate, source, fetched_at FROM exchange_rates
+		WHERE pair = $1 AND fetched_at > NOW() - make_interval(secs => $2)
+		ORDER BY fetched_at DESC, id DESC LIMIT 1
+	`, fxrate.Pair, maxAge.Seconds()).Scan(&r.KRWPerUSDC, &r.Source, &r.FetchedAt)
+	if err == sql.ErrNoRows {
+		return fxrate.Rate{}, fxrate.ErrNoRate
+	}
+	return r, err
+}
+
+// currentExchangeRate — 위 우선순위대로 현재 환율을 결정한다.
+// forceFetch면 캐시를 건너뛰고 공급자를 조회한다 (주기 갱신 잡).
+func currentExchangeRate(db *sql.DB, forceFetch bool) (fxrate.Rate, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	if r, err := (overrideRateProvider{db: db}).Rate(ctx); err == nil {
+		return r, nil
+	} else if !errors.Is(err, fxrate.ErrNoRate) {
+		return fxrate.Rate{}, err
+	}
+
+	ttl := time.Duration(envInt("FX_CACHE_TTL_MINUTES", 10)) * time.Minute
+	if !forceFetch {
+		if r, err := latestCachedRate(db, ttl); err == nil {
+			return r, nil
+		} else if !errors.Is(err, fxrate.ErrNoRate) {
+			return fxrate.Rate{}, err
+		}
+	}
+
+	provider, err := getRateProvider()
+	if err == nil {
+		var r fxrate.Rate
+		if r, err = provider.Rate(ctx); err == nil {
+			if _, err := db.Exec(
+				`INSERT INTO exchange_rates (pair, rate, source, fetched_at) VALUES ($1, $2, $3, $4)`,
+				fxrate.Pair, r.KRWPerUSDC, r.Source, r.FetchedAt,
+			); err != nil {
+				log.Printf("[fx] failed to record rate: %v", err)
+			}
+			return r, nil
+		}
+	}
+
+	// 공급자 장애 — 오래된 캐시라도 허용 범위 안이면 사용
+	maxStale := time.Duration(envInt("FX_MAX_STALE_HOURS", 24)) * time.Hour
+	if r, cacheErr := latestCachedRate(db, maxStale); cacheErr == nil {
+		log.Printf("[fx] provider failed (%v); using cached rate from %s", err, r.FetchedAt.Format(time.RFC3339))
+		return r, nil
+	}
+	return fxrate.Rate{}, fmt.Errorf("%w: %v", fxrate.ErrNoRate, err)
+}
+
+// normalizePriceSync — 요청의 priceSync 검증 (소문자화). 잘못된 값이면 ok=false
+func normalizePriceSync(v string) (string, bool) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	switch v {
+	case priceSyncManual, priceSyncKRW, priceSyncUSDC:
+		return v, true
+	}
+	return "", false
+}
+
+// applyProductPriceSync — price_sync가 krw/usdc인 상품의 반대쪽 가격을 현재 환율로 다시 산출해 p에 반영.
+// 환율을 못 구하면 기존 값을 두고 exchange-rates 잡이 나중에 맞춘다.
+func applyProductPriceSync(db *sql.DB, p *models.Product) {
+	if p.PriceSync == "" || p.PriceSync == priceSyncManual {
+		return
+	}
+	rate, err := currentExchangeRate(db, false)
+	if err != nil {
+		log.Printf("[fx] product %d price sync deferred: %v", p.ID, err)
+		return
+	}
+	switch p.PriceSync {
+	case priceSyncKRW:
+		p.CryptoPriceUsdc = fxrate.KRWToUSDCMicro(int64(p.Price), rate.KRWPerUSDC)
+		_, err = db.Exec(`UPDATE products SET crypto_price_usdc = $1 WHERE id = $2`, p.CryptoPriceUsdc, p.ID)
+	case priceSyncUSDC:
+		p.Price = int(fxrate.USDCMicroToKRW(p.CryptoPriceUsdc, rate.KRWPerUSDC))
+		_, err = db.Exec(`UPDATE products SET price = $1 WHERE id = $2`, p.Price, p.ID)
+	}
+	if err != nil {
+		log.Printf("[fx] product %d price sync failed: %v", p.ID, err)
+	}
+}
+
+// runExchangeRateRefresh — 백그라운드 잡: 공급자에서 환율을 갱신하고 자동 환산 상품 가격을 일괄 재계산.
+// 반올림은 fxrate 변환과 같은 half-away-from-zero (numeric ROUND).
+func runExchangeRateRefresh(db *sql.DB) error {
+	rate, err := currentExchangeRate(db, true)
+	if err != nil {
+		if errors.Is(err, fxrate.ErrNoRate) {
+			return nil // 환율 미설정 배포 — 조용히 건너뜀
+		}
+		return err
+	}
+	res, err := db.Exec(`
+		UPDATE products SET
+			crypto_price_usdc = CASE WHEN price_sync = 'krw'
+				THEN ROUND(price::numeric * 1000000 / $1::numeric)::bigint ELSE crypto_price_usdc END,
+			price = CASE WHEN price_sync = 'usdc'
+				THEN ROUND(crypto_price_usdc::numeric * $1::numeric / 1000000)::int ELSE price END
+		WHERE price_sync IN ('krw', 'usdc')
+		  AND (
+			(price_sync = 'krw' AND crypto_price_usdc <> ROUND(price::numeric * 1000000 / $1::numeric)::bigint) OR
+			(price_sync = 'usdc' AND price <> ROUND(crypto_price_usdc::numeric * $1::numeric / 1000000)::int)
+		  )
+	`, rate.KRWPerUSDC)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		log.Printf("[fx] re-derived %d product prices at %.4f KRW/USDC (%s)", n, rate.KRWPerUSDC, rate.Source)
+	}
+	return nil
+}
+
+func rateJSON(r fxrate.Rate) gin.H {
+	return gin.H{
+		"pair":       fxrate.Pair,
+		"krwPerUsdc": r.KRWPerUSDC,
+		"source":     r.Source,
+		"fetchedAt":  r.FetchedAt,
+	}
+}
+
+// GetExchangeRate — GET /api/v1/exchange-rate (공개)
+func GetExchangeRate(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r, err := currentExchangeRate(db, false)
+		if err != nil {
+			log.Printf("[fx] rate lookup failed: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "exchange rate unavailable"})
+			return
+		}
+		c.JSON(http.StatusOK, rateJSON(r))
+	}
+}
+
+// GetExchangeRateHistory — GET /api/v1/admin/exchange-rates?limit= (관리자)
+// 조회 이력과 수동 고정값 이력을 함께 돌려준다.
+func GetExchangeRateHistory(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c, db) {
+			return
+		}
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+		if limit <= 0 || limit > 1000 {
+			limit = 100
+		}
+		rows, err := db.Query(`
+			SELECT rate, source, fetched_at FROM exchange_rates
+			WHERE pair = $1 ORDER BY fetched_at DESC, id DESC LIMIT $2
+		`, fxrate.Pair, limit)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer rows.Close()
+		history := []gin.H{}
+		for rows.Next() {
+			var r fxrate.Rate
+			if err := rows.Scan(&r.KRWPerUSDC, &r.Source, &r.FetchedAt); err != nil {
+				respondDBError(c, err)
+				return
+			}
+			history = append(history, rateJSON(r))
+		}
+
+		orows, err := db.Query(`
+			SELECT id, rate, reason, created_by, created_at, expires_at, cleared_at
+			FROM exchange_rate_overrides WHERE pair = $1
+			ORDER BY created_at DESC, id DESC LIMIT 50
+		`, fxrate.Pair)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer orows.Close()
+		overrides := []gin.H{}
+		for orows.Next() {
+			var id int
+			var rate float64
+			var reason string
+			var createdBy *int
+			var createdAt time.Time
+			var expiresAt, clearedAt *time.Time
+			if err := orows.Scan(&id, &rate, &reason, &createdBy, &createdAt, &expiresAt, &clearedAt); err != nil {
+				respondDBError(c, err)
+				return
+			}
+			overrides = append(overrides, gin.H{
+				"id": id, "krwPerUsdc": rate, "reason": reason, "createdBy": createdBy,
+				"createdAt": createdAt, "expiresAt": expiresAt, "clearedAt": clearedAt,
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"history": history, "overrides": overrides})
+	}
+}
+
+// SetExchangeRateOverride — PUT /api/v1/admin/exchange-rate/override (관리자)
+// body: {krwPerUsdc, reason, expiresAt?}. 새 값이 기존 고정값을 대체하며, 자동 환산 상품은 즉시 재계산된다.
+func SetExchangeRateOverride(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c, db) {
+			return
+		}
+		userID, _ := c.Get("userId")
+		var req struct {
+			KRWPerUSDC float64    `json:"krwPerUsdc" binding:"required"`
+			Reason     string     `json:"reason"`
+			ExpiresAt  *time.Time `json:"expiresAt"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !fxrate.Valid(req.KRWPerUSDC) || req.KRWPerUSDC >= 1e10 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "krwPerUsdc must be a positive rate"})
+			return
+		}
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expiresAt must be in the future"})
+			return
+		}
+		reason := strings.TrimSpace(req.Reason)
+		if len(reason) > 255 {
+			reason = reason[:255]
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer tx.Rollback()
+		if _, err := tx.Exec(
+			`UPDATE exchange_rate_overrides SET cleared_at = NOW() WHERE pair = $1 AND cleared_at IS NULL`, fxrate.Pair,
+		); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO exchange_rate_overrides (pair, rate, reason, created_by, expires_at)
+			VALUES ($1, $2, $3, $4, $5)
+		`, fxrate.Pair, req.KRWPerUSDC, reason, userID, req.ExpiresAt); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			respondDBError(c, err)
+			return
+		}
+
+		if err := runExchangeRateRefresh(db); err != nil {
+			log.Printf("[fx] price re-derivation after override failed: %v", err)
+		}
+		r, err := currentExchangeRate(db, false)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, rateJSON(r))
+	}
+}
+
+// ClearExchangeRateOverride — DELETE /api/v1/admin/exchange-rate/override (관리자)
+func ClearExchangeRateOverride(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c, db) {
+			return
+		}
+		res, err := db.Exec(
+			`UPDATE exchange_rate_overrides SET cleared_at = NOW() WHERE pair = $1 AND cleared_at IS NULL`, fxrate.Pair,
+		)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no active override"})
+			return
+		}
+		if err := runExchangeRateRefresh(db); err != nil {
+			log.Printf("[fx] price re-derivation after override cleared failed: %v", err)
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "override cleared"})
+	}
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ── 비로그인 장바구니 게스트 토큰 ───────────────────────────────────────────
+// 서버가 발급한 서명 토큰으로 비로그인 장바구니 세션을 식별한다 (클라이언트가 고른 sessionId/IP 바인딩 대체).
+// 토큰 = base64url("세션ID|발급unix|회전번호") + "." + base64url(HMAC-SHA256).
+// HttpOnly cmall_guest 쿠키로 주고받고, 쿠키를 쓸 수 없는 클라이언트는 X-Guest-Token 헤더를 쓴다.
+// cart_sessions.token_rotation과 회전번호가 맞아야 유효하다 — GUEST_TOKEN_ROTATE_HOURS가 지나면 번호를
+// 올려 재발급하고, 동시 요청을 위해 직전 번호까지만 허용한다. 세션 행이 지워지면(cart-cleanup) 토큰도 무효.
+
+const (
+	guestTokenCookie = "cmall_guest"
+	guestTokenHeader = "X-Guest-Token"
+)
+
+var errInvalidGuestToken = errors.New("invalid guest token")
+
+type guestTokenClaims struct {
+	SessionID string
+	IssuedAt  time.Time
+	Rotation  int
+}
+
+// guestTokenSecret — 게스트 토큰 서명 키. 미설정 시 JWT 시크릿에서 파생 (용도 분리)
+func guestTokenSecret() []byte {
+	if s := os.Getenv("GUEST_TOKEN_SECRET"); s != "" {
+		return []byte(s)
+	}
+	mac := hmac.New(sha256.New, jwtSecret())
+	mac.Write([]byte("cmall_dd guest token"))
+	return mac.Sum(nil)
+}
+
+func signGuestToken(secret []byte, claims guestTokenClaims) string {
+	payload := claims.SessionID + "|" + strconv.FormatInt(claims.IssuedAt.Unix(), 10) + "|" + strconv.Itoa(claims.Rotation)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parseGuestToken — 서명 확인 후 클레임 반환 (회전번호/세션 존재 여부는 호출자가 DB로 확인)
+func parseGuestToken(secret []byte, token string) (guestTokenClaims, error) {
+	encPayload, encSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return guestTokenClaims{}, errInvalidGuestToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encPayload)
+	if err != nil {
+		return guestTokenClaims{}, errInvalidGuestToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encSig)
+	if err != nil {
+		return guestTokenClaims{}, errInvalidGuestToken
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return guestTokenClaims{}, errInvalidGuestToken
+	}
+	parts := strings.Split(string(payload), "|")
+	if len(parts) != 3 || !validSessionID(parts[0]) {
+		return guestTokenClaims{}, errInvalidGuestToken
+	}
+	iat, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return guestTokenClaims{}, errInvalidGuestToken
+	}
+	rotation, err := strconv.Atoi(parts[2])
+	if err != nil || rotation < 0 {
+		return guestTokenClaims{}, errInvalidGuestToken
+	}
+	return guestTokenClaims{SessionID: parts[0], IssuedAt: time.Unix(iat, 0), Rotation: rotation}, nil
+}
+
+// guestRotationAccepted — 현재 번호 또는 직전 번호 (회전 직후 다른 요청이 들고 있던 토큰)
+func guestRotationAccepted(tokenRotation, storedRotation int) bool {
+	return tokenRotation == storedRotation || tokenRotation+1 == storedRotation
+}
+
+func guestTokenRotateAfter() time.Duration {
+	return time.Duration(envInt("GUEST_TOKEN_ROTATE_HOURS", 24)) * time.Hour
+}
+
+// requestGuestToken — 헤더 우선, 없으면 쿠키
+func requestGuestToken(c *gin.Context) string {
+	if t := strings.TrimSpace(c.GetHeader(guestTokenHeader)); t != "" {
+		return t
+	}
+	t, _ := c.Cookie(guestTokenCookie)
+	return t
+}
+
+// issueGuestToken — 쿠키와 응답 헤더로 토큰 전달. 쿠키 수명은 비로그인 장바구니 TTL에 맞춘다.
+func issueGuestToken(c *gin.Context, claims guestTokenClaims) {
+	token := signGuestToken(guestTokenSecret(), claims)
+	maxAge := 0
+	if ttl := envInt("CART_ANON_TTL_HOURS", 72); ttl > 0 {
+		maxAge = ttl * 3600
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(guestTokenCookie, token, maxAge, "/", "", false, true)
+	c.Header(guestTokenHeader, token)
+}
+
+func clearGuestToken(c *gin.Context) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(guestTokenCookie, "", -1, "/", "", false, true)
+}
+
+// resolveGuestSession — 요청의 비로그인 장바구니 세션 ID.
+//  1. 유효한 게스트 토큰 → 해당 세션 (회전 주기가 지났으면 재발급)
+//  2. 토큰 도입 전 세션 (sessionId + 기존 cmall_guest 쿠키) → 같은 세션 ID로 토큰 발급 (1회성 이전 경로)
+//  3. create=true면 새 세션을 만들어 토큰 발급, 아니면 ""
+//
+// 오류 응답을 이미 쓴 경우 ok=false.
+func resolveGuestSession(c *gin.Context, db *sql.DB, legacySessionID string, create bool) (string, bool) {
+	now := time.Now()
+	raw := requestGuestToken(c)
+	if claims, err := parseGuestToken(guestTokenSecret(), raw); err == nil {
+		var stored int
+		err := db.QueryRow(`
+			UPDATE cart_sessions SET last_seen_at = CURRENT_TIMESTAMP
+			WHERE session_id = $1
+			RETURNING token_rotation
+		`, claims.SessionID).Scan(&stored)
+		if err != nil && err != sql.ErrNoRows {
+			respondDBError(c, err)
+			return "", false
+		}
+		if err == nil && guestRotationAccepted(claims.Rotation, stored) {
+			if claims.Rotation == stored && now.Sub(claims.IssuedAt) >= guestTokenRotateAfter() {
+				// 조건부 UPDATE — 동시에 회전한 요청이 있으면 그쪽 토큰이 최신이므로 그대로 둔다
+				var next int
+				err := db.QueryRow(`
+					UPDATE cart_sessions SET token_rotation = token_rotation + 1
+					WHERE session_id = $1 AND token_rotation = $2
+					RETURNING token_rotation
+				`, claims.SessionID, stored).Scan(&next)
+				if err == nil {
+					issueGuestToken(c, guestTokenClaims{SessionID: claims.SessionID, IssuedAt: now, Rotation: next})
+				} else if err != sql.ErrNoRows {
+					respondDBError(c, err)
+					return "", false
+				}
+			}
+			return claims.SessionID, true
+		}
+		// 만료(정리)됐거나 회전으로 폐기된 토큰 — 새 세션으로 취급
+	} else if legacySessionID != "" && validSessionID(legacySessionID) {
+		legacyCookie, _ := c.Cookie(guestTokenCookie)
+		if ok, err := migrateLegacyGuestSession(c, db, legacySessionID, legacyCookie); err != nil {
+			respondDBError(c, err)
+			return "", false
+		} else if ok {
+			return legacySessionID, true
+		}
+	}
+
+	if !create {
+		return "", true
+	}
+	sessionID, err := newGuestSessionID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return "", false
+	}
+	if _, err := db.Exec(`
+		INSERT INTO cart_sessions (session_id, client_ip, guest_cookie, token_rotation)
+		VALUES ($1, '', '', 0)
+	`, sessionID); err != nil {
+		respondDBError(c, err)
+		return "", false
+	}
+	issueGuestToken(c, guestTokenClaims{SessionID: sessionID, IssuedAt: now, Rotation: 0})
+	return sessionID, true
+}
+
+// migrateLegacyGuestSession — 토큰 도입 전 세션을 토큰으로 이전.
+// 기존 방식대로 기록된 cmall_guest 쿠키 값이 일치해야 하고, guest_cookie가 빈 쿠키 바인딩 이전 행은
+// 기록된 IP가 일치해야 한다. 이전 후에는 guest_cookie를 비워 같은 쿠키로 다시 이전할 수 없게 한다.
+func migrateLegacyGuestSession(c *gin.Context, db *sql.DB, sessionID, legacyCookie string) (bool, error) {
+	var recordedIP, recordedCookie string
+	err := db.QueryRow(`SELECT client_ip, guest_cookie FROM cart_sessions WHERE session_id = $1`, sessionID).
+		Scan(&recordedIP, &recordedCookie)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !legacyGuestSessionMatches(recordedIP, recordedCookie, c.ClientIP(), legacyCookie) {
+		return false, nil
+	}
+	var rotation int
+	err = db.QueryRow(`
+		UPDATE cart_sessions
+		SET guest_cookie = '', client_ip = '', token_rotation = token_rotation + 1, last_seen_at = CURRENT_TIMESTAMP
+		WHERE session_id = $1 AND guest_cookie = $2 AND client_ip = $3
+		RETURNING token_rotation
+	`, sessionID, recordedCookie, recordedIP).Scan(&rotation)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	issueGuestToken(c, guestTokenClaims{SessionID: sessionID, IssuedAt: time.Now(), Rotation: rotation})
+	return true, nil
+}
+
+// legacyGuestSessionMatches — 토큰 도입 전 세션의 소유 확인 (이미 이전된 행은 둘 다 빈 값이라 불일치)
+func legacyGuestSessionMatches(recordedIP, recordedCookie, clientIP, requestCookie string) bool {
+	if recordedCookie != "" {
+		return hmac.Equal([]byte(recordedCookie), []byte(requestCookie))
+	}
+	return recordedIP != "" && recordedIP == clientIP
+}
+
+// newGuestSessionID — 서버가 정하는 세션 ID ("g_" + 16바이트 난수 hex). crypto/rand만 쓴다.
+func newGuestSessionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "g_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGuestTokenRoundTrip(t *testing.T) {
+	secret := []byte("test-guest-secret")
+	issued := time.Unix(1_700_000_000, 0)
+	token := signGuestToken(secret, guestTokenClaims{SessionID: "g_0123abcd", IssuedAt: issued, Rotation: 3})
+
+	claims, err := parseGuestToken(secret, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.SessionID != "g_0123abcd" || !claims.IssuedAt.Equal(issued) || claims.Rotation != 3 {
+		t.Errorf("claims = %+v", claims)
+	}
+
+	if _, err := parseGuestToken([]byte("other-secret"), token); err == nil {
+		t.Error("token signed with another secret accepted")
+	}
+	payload, sig, _ := strings.Cut(token, ".")
+	forged := signGuestToken([]byte("attacker"), guestTokenClaims{SessionID: "g_victim", IssuedAt: issued})
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+	if _, err := parseGuestToken(secret, forgedPayload+"."+sig); err == nil {
+		t.Error("payload swap accepted")
+	}
+	// 토큰 도입 전 쿠키 (hex 난수) 는 토큰으로 해석되지 않아야 한다
+	for _, bad := range []string{"", payload, "0123456789abcdef", "a.b"} {
+		if _, err := parseGuestToken(secret, bad); err == nil {
+			t.Errorf("%q parsed as a guest token", bad)
+		}
+	}
+}
+
+func TestGuestRotationAndLegacyMigration(t *testing.T) {
+	if !guestRotationAccepted(2, 2) || !guestRotationAccepted(1, 2) {
+		t.Error("current and previous rotation should be accepted")
+	}
+	if guestRotationAccepted(0, 2) || guestRotationAccepted(3, 2) {
+		t.Error("stale or future rotation accepted")
+	}
+
+	if !legacyGuestSessionMatches("1.2.3.4", "cookie", "5.6.7.8", "cookie") {
+		t.Error("matching legacy cookie should migrate regardless of IP")
+	}
+	if legacyGuestSessionMatches("1.2.3.4", "cookie", "1.2.3.4", "other") {
+		t.Error("mismatched legacy cookie accepted")
+	}
+	if !legacyGuestSessionMatches("1.2.3.4", "", "1.2.3.4", "") {
+		t.Error("pre-cookie session should migrate on IP match")
+	}
+	if legacyGuestSessionMatches("", "", "", "") {
+		t.Error("already migrated session matched")
+	}
+
+	id, err := newGuestSessionID()
+	if err != nil || !validSessionID(id) || !strings.HasPrefix(id, "g_") {
+		t.Errorf("newGuestSessionID = %q, %v", id, err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -43,7 +44,13 @@ func envBool(name string, fallback bool) bool {
 	return b
 }
 
+// escapeLike — LIKE/ILIKE 메타문자 이스케이프 (사용자 입력 %/_ 가 와일드카드로 동작하지 않도록)
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
 // jwtSecret — JWT 시크릿. env 필수 (하드코딩 폴백 제거 — CWE-287, fail-closed).
+// JWT 서명 키(JWT_SIGNING_KEYS 미설정 시)와 다운로드/게스트 토큰 키의 파생 원천으로 쓴다.
 func jwtSecret() []byte {
 	secret := os.Getenv("JWT_SECRET")
 	if secret == "" {
@@ -53,17 +60,24 @@ func jwtSecret() []byte {
 }
 
 func jwtRegisteredClaims() jwt.RegisteredClaims {
-	expirationTime := time.Now().Add(24 * 7 * time.Hour) // 7 days
+	expirationTime := time.Now().Add(accessTokenTTL()) // 단기 액세스 토큰 — 갱신은 리프레시 토큰 (sessions.go)
 	return jwt.RegisteredClaims{
 		ExpiresAt: jwt.NewNumericDate(expirationTime),
 		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		Issuer:    "cmall_dd",
+		Issuer:    jwtIssuer,
 	}
 }
 
+// signClaims — 키링의 서명 키로 서명 (jwt_keys.go). jti가 비어 있으면 새로 정한다.
 func signClaims(claims *Claims) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret())
+	if claims.ID == "" {
+		jti, err := newTokenID()
+		if err != nil {
+			return "", err
+		}
+		claims.ID = jti
+	}
+	return currentJWTKeyring().sign(claims)
 }
 
 func hashPassword(password string) (string, error) {
@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// ── KRX 종목 마스터 (2026-10) ────────────────────────────────────────────
+// 분석 요청 심볼은 binding:"required" 외 검증이 없어 오타가 내부 잡 실패로만 드러났다.
+// 종목코드/종목명/업종을 로컬 instruments 테이블에 두고 (관리자 CSV 임포트),
+// CreateAnalysis·관심종목에서 심볼을 정규화/검증한다.
+
+// Instrument — KRX 상장 종목 (code = 6자리 단축코드)
+type Instrument struct {
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Market    string    `json:"market"`
+	Sector    string    `json:"sector"`
+	IsActive  bool      `json:"isActive"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// instrumentImportError — CSV 임포트 행 단위 오류 (line = CSV 물리 행 번호, 헤더 = 1)
+type instrumentImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+const maxInstrumentImportBytes = 8 << 20
+
+// normalizeKRXSymbol — 사용자 입력 심볼을 6자리 KRX 단축코드로 정규화한다.
+// "A005930"(표준 단축코드 접두), "005930.KS"/".KQ"(야후 표기), 소문자/공백 변형을 허용한다.
+// 2023년 이후 신규 코드는 영문이 섞일 수 있으므로(예: 0009K0) 대문자 영숫자 6자리를 허용.
+func normalizeKRXSymbol(raw string) (string, bool) {
+	s := strings.ToUpper(strings.TrimSpace(raw))
+	for _, suffix := range []string{".KS", ".KQ", ".KN"} {
+		s = strings.TrimSuffix(s, suffix)
+	}
+	if len(s) == 7 && s[0] == 'A' {
+		s = s[1:]
+	}
+	if len(s) != 6 {
+		return "", false
+	}
+	hasDigit := false
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			hasDigit = true
+		case r >= 'A' && r <= 'Z':
+		default:
+			return "", false
+		}
+	}
+	if !hasDigit {
+		return "", false
+	}
+	return s, true
+}
+
+// resolveAnalysisSymbol — 분석 심볼 정규화 + 종목 마스터 대조.
+// 반환 msg가 비어 있지 않으면 클라이언트 입력 오류(400), err는 DB 오류(500).
+// 마스터가 아직 임포트되지 않은(비어 있는) 환경에서는 형식 검증만 수행한다 —
+// 신규 배포에서 분석 기능 전체가 막히지 않도록 하기 위함.
+func resolveAnalysisSymbol(db *sql.DB, raw string) (code string, msg string, err error) {
+	code, ok := normalizeKRXSymbol(raw)
+	if !ok {
+		return "", "symbol must be a 6-character KRX code (e.g. 005930)", nil
+	}
+	var active bool
+	err = db.QueryRow("SELECT is_active FROM instruments WHERE code = $1", code).Scan(&active)
+	if err == nil {
+		if !active {
+			return "", "symbol is delisted or suspended: " + code, nil
+		}
+		return code, "", nil
+	}
+	if err != sql.ErrNoRows {
+		return "", "", err
+	}
+	var hasMaster bool
+	if err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM instruments)").Scan(&hasMaster); err != nil {
+		return "", "", err
+	}
+	if hasMaster {
+		return "", "unknown KRX symbol: " + code, nil
+	}
+	return code, "", nil
+}
+
+// SearchInstruments — GET /api/v1/instruments?q= (공개, 자동완성)
+// 코드 접두 일치 → 종목명 접두 일치 → 종목명 부분 일치 순으로 정렬한다.
+func SearchInstruments(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := strings.TrimSpace(c.Query("q"))
+		if q == "" {
+			c.JSON(http.StatusOK, gin.H{"instruments": []Instrument{}})
+			return
+		}
+		if utf8.RuneCountInString(q) > 50 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "q must be at most 50 characters"})
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if err != nil || limit < 1 || limit > 50 {
+			limit = 20
+		}
+
+		escaped := escapeLike(q)
+		codeQ := strings.ToUpper(escaped)
+		if code, ok := normalizeKRXSymbol(q); ok {
+			codeQ = code
+		}
+
+		rows, err := db.Query(`
+			SELECT code, name, market, sector, is_active, updated_at
+			FROM instruments
+			WHERE is_active = true
+			  AND (code LIKE $1 || '%' OR name ILIKE '%' || $2 || '%')
+			ORDER BY CASE
+			           WHEN code LIKE $1 || '%' THEN 0
+			           WHEN name ILIKE $2 || '%' THEN 1
+			           ELSE 2
+			         END, name
+			LIMIT $3
+		`, codeQ, escaped, limit)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer rows.Close()
+
+		instruments := []Instrument{}
+		for rows.Next() {
+			var in Instrument
+			if err := rows.Scan(&in.Code, &in.Name, &in.Market, &in.Sector, &in.IsActive, &in.UpdatedAt); err != nil {
+				respondDBError(c, err)
+				return
+			}
+			instruments = append(instruments, in)
+		}
+		c.JSON(http.StatusOK, gin.H{"instruments": instruments})
+	}
+}
+
+// ImportInstruments — POST /api/v1/admin/instruments/import (관리자, DB role 재검증)
+// multipart "file" 필드 또는 text/csv 본문. 헤더는 영문(code,name,market,sector) 또는
+// KRX 정보데이터시스템 한글 헤더(종목코드,종목명,시장구분,업종명)를 인식한다.
+// ?deactivateMissing=true 이면 파일에 없는 기존 종목을 is_active=false로 표시 (상장폐지 반영).
+// ?dryRun=true 이면 검증 결과만 반환하고 저장하지 않는다.
+func ImportInstruments(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		if !isAdminUser(db, userID.(int)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			return
+		}
+
+		var src io.Reader
+		if file, err := c.FormFile("file"); err == nil {
+			f, err := file.Open()
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read uploaded file"})
+				return
+			}
+			defer f.Close()
+			src = f
+		} else {
+			src = c.Request.Body
+		}
+		body, err := io.ReadAll(io.LimitReader(src, maxInstrumentImportBytes+1))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read CSV"})
+			return
+		}
+		if len(body) > maxInstrumentImportBytes {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "CSV exceeds 8MB"})
+			return
+		}
+
+		instruments, rowErrors, err := parseInstrumentCSV(body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if c.Query("dryRun") == "true" {
+			c.JSON(http.StatusOK, gin.H{"dryRun": true, "valid": len(instruments), "errors": rowErrors})
+			return
+		}
+		if len(instruments) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "no valid rows in CSV", "errors": rowErrors})
+			return
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer tx.Rollback()
+
+		stmt, err := tx.Prepare(`
+			INSERT INTO instruments (code, name, market, sector, is_active, updated_at)
+			VALUES ($1, $2, $3, $4, true, NOW())
+			ON CONFLICT (code) DO UPDATE SET
+				name = EXCLUDED.name, market = EXCLUDED.market, sector = EXCLUDED.sector,
+				is_active = true, updated_at = NOW()
+		`)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer stmt.Close()
+
+		codes := make([]string, 0, len(instruments))
+		for _, in := range instruments {
+			if _, err := stmt.Exec(in.Code, in.Name, in.Market, in.Sector); err != nil {
+				respondDBError(c, err)
+				return
+			}
+			codes = append(codes, in.Code)
+		}
+
+		var deactivated int64
+		if c.Query("deactivateMissing") == "true" {
+			res, err := tx.Exec(
+				"UPDATE instruments SET is_active = false, updated_at = NOW() WHERE is_active = true AND NOT (code = ANY($1))",
+				pq.Array(codes),
+			)
+			if err != nil {
+				respondDBError(c, err)
+				return
+			}
+			deactivated, _ = res.RowsAffected()
+		}
+
+		if err := tx.Commit(); err != nil {
+			respondDBError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"imported":    len(instruments),
+			"deactivated": deactivated,
+			"errors":      rowErrors,
+		})
+	}
+}
+
+// instrumentCSVColumns — 헤더 별칭 (소문자 비교)
+var instrumentCSVColumns = map[string]string{
+	"code": "code", "symbol": "code", "종목코드": "code", "단축코드": "code",
+	"name": "name", "종목명": "name", "한글 종목약명": "name", "한글종목약명": "name",
+	"market": "market", "시장구분": "market", "시장": "market",
+	"sector": "sector", "업종": "sector", "업종명": "sector",
+}
+
+// parseInstrumentCSV — 종목 마스터 CSV 파싱 (순수 함수 — 테스트 용이).
+// 형식 오류 행은 건너뛰고 rowErrors로 보고하며, 파일 자체가 읽을 수 없으면 err를 반환한다.
+func parseInstrumentCSV(data []byte) ([]Instrument, []instrumentImportError, error) {
+	if !utf8.Valid(data) {
+		return nil, nil, errors.New("CSV must be UTF-8 encoded (KRX exports are EUC-KR — convert before import)")
+	}
+	data = []byte(strings.TrimPrefix(string(data), "\ufeff"))
+
+	r := csv.NewReader(strings.NewReader(string(data)))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("CSV is empty")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV header: %v", err)
+	}
+	index := map[string]int{}
+	for i, h := range header {
+		if col, ok := instrumentCSVColumns[strings.ToLower(strings.TrimSpace(h))]; ok {
+			if _, dup := index[col]; !dup {
+				index[col] = i
+			}
+		}
+	}
+	if _, ok := index["code"]; !ok {
+		return nil, nil, errors.New("CSV header must include a code column (code or 종목코드)")
+	}
+	if _, ok := index["name"]; !ok {
+		return nil, nil, errors.New("CSV header must include a name column (name or 종목명)")
+	}
+
+	field := func(rec []string, col string) string {
+		i, ok := index[col]
+		if !ok || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+
+	var instruments []Instrument
+	var rowErrors []instrumentImportError
+	seen := map[string]int{}
+	line := 1
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			rowErrors = append(rowErrors, instrumentImportError{Line: line, Error: err.Error()})
+			continue
+		}
+		code, ok := normalizeKRXSymbol(field(rec, "code"))
+		if !ok {
+			rowErrors = append(rowErrors, instrumentImportError{Line: line, Error: "invalid code: " + field(rec, "code")})
+			continue
+		}
+		name := field(rec, "name")
+		if name == "" || utf8.RuneCountInString(name) > 255 {
+			rowErrors = append(rowErrors, instrumentImportError{Line: line, Error: "name must be 1-255 characters"})
+			continue
+		}
+		if prev, dup := seen[code]; dup {
+			rowErrors = append(rowErrors, instrumentImportError{Line: line, Error: fmt.Sprintf("duplicate code %s (first seen on line %d)", code, prev)})
+			continue
+		}
+		seen[code] = line
+		instruments = append(instruments, Instrument{
+			Code:   code,
+			Name:   name,
+			Market: strings.ToUpper(field(rec, "market")),
+			Sector: field(rec, "sector"),
+		})
+	}
+	if rowErrors == nil {
+		rowErrors = []instrumentImportError{}
+	}
+	return instruments, rowErrors, nil
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeKRXSymbol(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"005930", "005930", true},
+		{" 005930 ", "005930", true},
+		{"A005930", "005930", true},
+		{"005930.KS", "005930", true},
+		{"035720.kq", "035720", true},
+		{"0009k0", "0009K0", true}, // 신규 영숫자 코드
+		{"59300", "", false},
+		{"0059300", "", false},
+		{"SAMSNG", "", false}, // 숫자 없는 6자는 종목명 오타로 간주
+		{"005930;", "", false},
+		{"", "", false},
+	}
+	for _, c := range cases {
+		got, ok := normalizeKRXSymbol(c.in)
+		if got != c.want || ok != c.ok {
+			t.Errorf("normalizeKRXSymbol(%q) = (%q, %v), want (%q, %v)", c.in, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestParseInstrumentCSV(t *testing.T) {
+	// 한글 헤더 + BOM + 오류 행(잘못된 코드, 이름 없음, 중복)
+	in := "\ufeff종목코드,종목명,시장구분,업종명\n" +
+		"005930,삼성전자,KOSPI,전기전자\n" +
+		"A035720,카카오,kospi,서비스업\n" +
+		"12345,짧은코드,KOSDAQ,\n" +
+		"000660,,KOSPI,전기전자\n" +
+		"005930,삼성전자우선,KOSPI,전기전자\n"
+	instruments, rowErrors, err := parseInstrumentCSV([]byte(in))
+	if err != nil {
+		t.Fatalf("parseInstrumentCSV err: %v", err)
+	}
+	if len(instruments) != 2 {
+		t.Fatalf("유효 행 수 = %d, want 2", len(instruments))
+	}
+	if instruments[1].Code != "035720" || instruments[1].Market != "KOSPI" || instruments[1].Sector != "서비스업" {
+		t.Errorf("정규화 이상: %+v", instruments[1])
+	}
+	if len(rowErrors) != 3 {
+		t.Fatalf("오류 행 수 = %d, want 3: %+v", len(rowErrors), rowErrors)
+	}
+	if rowErrors[0].Line != 4 || rowErrors[2].Line != 6 {
+		t.Errorf("오류 행 번호 이상: %+v", rowErrors)
+	}
+
+	// 영문 헤더, 열 순서 무관
+	instruments, _, err = parseInstrumentCSV([]byte("name,code\nNAVER,035420\n"))
+	if err != nil || len(instruments) != 1 || instruments[0].Code != "035420" {
+		t.Errorf("영문 헤더 파싱 실패: %v %+v", err, instruments)
+	}
+
+	// 필수 헤더 없음 / EUC-KR(비 UTF-8) → 에러
+	if _, _, err := parseInstrumentCSV([]byte("name,sector\n삼성전자,전기전자\n")); err == nil {
+		t.Error("code 헤더 없음에도 에러 없음")
+	}
+	if _, _, err := parseInstrumentCSV([]byte{0xC1, 0xBE, 0xB8, 0xF1}); err == nil {
+		t.Error("비 UTF-8 입력에도 에러 없음")
+	}
+}
+
+func TestScheduledSlot(t *testing.T) {
+	// 2026-10-19 08:30 KST — 9시 슬롯은 아직 전이므로 전날 9시
+	now := time.Date(2026, 10, 19, 8, 30, 0, 0, kst)
+	if got, want := scheduledSlot(now, 9), time.Date(2026, 10, 18, 9, 0, 0, 0, kst); !got.Equal(want) {
+		t.Errorf("scheduledSlot before hour = %v, want %v", got, want)
+	}
+	// UTC로 주어져도 KST 기준 (2026-10-19 01:00 UTC = 10:00 KST)
+	now = time.Date(2026, 10, 19, 1, 0, 0, 0, time.UTC)
+	if got, want := scheduledSlot(now, 9), time.Date(2026, 10, 19, 9, 0, 0, 0, kst); !got.Equal(want) {
+		t.Errorf("scheduledSlot after hour = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+// ── 백그라운드 잡 ─────────────────────────────────────────────────────────
+// API 프로세스 안에서 주기적으로 실행되는 작업 (예약 분석 등).
+// 다중 인스턴스 배포에서도 안전하도록 각 잡은 조건부 UPDATE로 작업을 선점(claim)해야 한다.
+// BACKGROUND_JOBS_ENABLED=false 이면 이 인스턴스에서는 잡을 돌리지 않는다 (전용 워커 분리용).
+
+type backgroundJob struct {
+	name     string
+	interval time.Duration
+	run      func(db *sql.DB) error
+}
+
+// StartBackgroundJobs — main에서 CreateTables 이후 1회 호출.
+func StartBackgroundJobs(db *sql.DB) {
+	if !envBool("BACKGROUND_JOBS_ENABLED", true) {
+		log.Println("[jobs] background jobs disabled (BACKGROUND_JOBS_ENABLED=false)")
+		return
+	}
+
+	jobs := []backgroundJob{
+		{name: "watchlist-schedule", interval: time.Minute, run: runScheduledWatchlistAnalyses},
+		{name: "upload-cleanup", interval: time.Hour, run: runUploadCleanup},
+		{name: "license-issuance", interval: 5 * time.Minute, run: runLicenseIssuanceBackfill},
+		{name: "exchange-rates", interval: 10 * time.Minute, run: runExchangeRateRefresh},
+		{name: "product-publish", interval: time.Minute, run: runScheduledProductPublishing},
+		{name: "product-import", interval: 30 * time.Second, run: runProductImportJobs},
+		{name: "wishlist-alerts", interval: 10 * time.Minute, run: runWishlistAlerts},
+		{name: "alert-evaluation", interval: 5 * time.Minute, run: runAlertEvaluationRetry},
+		{name: "cart-cleanup", interval: 15 * time.Minute, run: runCartMaintenance},
+		{name: "session-cleanup", interval: time.Hour, run: runSessionCleanup},
+		{name: "revoked-token-cleanup", interval: time.Hour, run: runRevokedTokenCleanup},
+		{name: "email-token-cleanup", interval: time.Hour, run: runEmailTokenCleanup},
+	}
+	// pgvector가 없으면 임베딩 백필은 매번 실패만 하므로 등록하지 않는다
+	if hasVectorSupport(db) {
+		jobs = append(jobs, backgroundJob{name: "product-embeddings", interval: 5 * time.Minute, run: runProductEmbeddingBackfill})
+	} else {
+		log.Println("[jobs] products.embedding unavailable (pgvector missing); skipping product-embeddings")
+	}
+	for _, job := range jobs {
+		go runJobLoop(db, job)
+	}
+}
+
+func runJobLoop(db *sql.DB, job backgroundJob) {
+	ticker := time.NewTicker(job.interval)
+	defer ticker.Stop()
+	for {
+		runJobOnce(db, job)
+		<-ticker.C
+	}
+}
+
+// runJobOnce — 잡 1회 실행. panic/오류는 로그만 남기고 다음 주기에 재시도한다.
+func runJobOnce(db *sql.DB, job backgroundJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[jobs] %s panicked: %v", job.name, r)
+		}
+	}()
+	if err := job.run(db); err != nil {
+		log.Printf("[jobs] %s failed: %v", job.name, err)
+	}
+}
@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ── JWT 서명 키링 / 토큰 폐기 ───────────────────────────────────────────────
+// 액세스 토큰은 EdDSA(Ed25519)로 서명하고 헤더 kid로 검증 키를 고른다.
+// JWT_SIGNING_KEYS="kid:base64키,..." — 첫 항목이 서명 키, 나머지는 검증만 한다. 키 교체 순서:
+// 새 키를 뒤에 추가해 모든 인스턴스에 배포 → 맨 앞으로 옮겨 배포 → 액세스 토큰 수명이 지나면 이전 키 제거.
+// 미설정 시 JWT 시크릿에서 파생한 키 하나를 쓴다. 공개키는 GET /.well-known/jwks.json으로 공개해
+// 다른 서비스가 시크릿 없이 검증할 수 있다. 토큰마다 jti를 넣고, revoked_tokens에 있는 jti는 거부한다.
+// HS256 토큰은 받지 않는다 — 액세스 토큰 수명이 짧고 리프레시 토큰은 JWT가 아니라 배포 후 자동 갱신된다.
+
+const jwtIssuer = "cmall_dd"
+
+const tokenRevokedAdmin = "admin"
+
+var (
+	errUnknownJWTKey = errors.New("unknown signing key")
+	errMissingJTI    = errors.New("token has no jti")
+	// errInvalidAccessToken — 토큰 자체의 문제 (401). 그 외 verifyAccessToken 오류는 DB 오류
+	errInvalidAccessToken = errors.New("invalid or expired token")
+)
+
+type jwtSigningKey struct {
+	kid  string
+	priv ed25519.PrivateKey
+}
+
+// jwtKeyring — keys[0]이 서명 키
+type jwtKeyring struct {
+	keys []jwtSigningKey
+}
+
+// jwk — JWKS 항목 (RFC 8037 OKP)
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+}
+
+var (
+	jwtKeysOnce sync.Once
+	jwtKeys     *jwtKeyring
+)
+
+// jwtKeyID — kid를 지정하지 않은 키는 공개키 해시로 정한다
+func jwtKeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// decodeEd25519Key — base64 32바이트 seed 또는 64바이트 개인키
+func decodeEd25519Key(raw string) (ed25519.PrivateKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(raw))
+	switch {
+	case err == nil && len(b) == ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(b), nil
+	case err == nil && len(b) == ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(b), nil
+	default:
+		return nil, errors.New("must be a base64 Ed25519 seed (32 bytes) or private key (64 bytes)")
+	}
+}
+
+// parseJWTKeyring — JWT_SIGNING_KEYS 값 파싱. 항목은 "kid:키" 또는 "키" (kid 자동)
+func parseJWTKeyring(raw string) (*jwtKeyring, error) {
+	ring := &jwtKeyring{}
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, encoded, hasKid := strings.Cut(entry, ":")
+		if !hasKid {
+			kid, encoded = "", entry
+		}
+		priv, err := decodeEd25519Key(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("JWT_SIGNING_KEYS entry %d: %w", len(ring.keys)+1, err)
+		}
+		if kid = strings.TrimSpace(kid); kid == "" {
+			kid = jwtKeyID(priv.Public().(ed25519.PublicKey))
+		}
+		if seen[kid] {
+			return nil, fmt.Errorf("JWT_SIGNING_KEYS: duplicate kid %q", kid)
+		}
+		seen[kid] = true
+		ring.keys = append(ring.keys, jwtSigningKey{kid: kid, priv: priv})
+	}
+	if len(ring.keys) == 0 {
+		return nil, errors.New("JWT_SIGNING_KEYS has no keys")
+	}
+	return ring, nil
+}
+
+// derivedJWTKeyring — JWT 시크릿에서 파생한 단일 키 (용도 분리 라벨 포함)
+func derivedJWTKeyring(secret []byte) *jwtKeyring {
+	seed := sha256.Sum256(append([]byte("cmall_dd jwt signing key|"), secret...))
+	priv := ed25519.NewKeyFromSeed(seed[:])
+	return &jwtKeyring{keys: []jwtSigningKey{{kid: jwtKeyID(priv.Public().(ed25519.PublicKey)), priv: priv}}}
+}
+
+// currentJWTKeyring — 프로세스당 1회 로드. 설정 오류는 기동 시 드러나도록 panic (fail-closed)
+func currentJWTKeyring() *jwtKeyring {
+	jwtKeysOnce.Do(func() {
+		if raw := os.Getenv("JWT_SIGNING_KEYS"); strings.TrimSpace(raw) != "" {
+			ring, err := parseJWTKeyring(raw)
+			if err != nil {
+				panic(err.Error())
+			}
+			jwtKeys = ring
+			return
+		}
+		log.Println("[auth] JWT_SIGNING_KEYS not set; deriving signing key from JWT_SECRET")
+		jwtKeys = derivedJWTKeyring(jwtSecret())
+	})
+	return jwtKeys
+}
+
+// LoadSigningKeys — JWT/라이선스 서명 키를 기동 시 미리 로드한다.
+// 설정 오류를 첫 요청이 아닌 기동 시점에 error로 돌려준다.
+func LoadSigningKeys() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	currentJWTKeyring()
+	licenseSigningKey()
+	return nil
+}
+
+func (k *jwtKeyring) publicKey(kid string) (ed25519.PublicKey, bool) {
+	for _, key := range k.keys {
+		if key.kid == kid {
+			return key.priv.Public().(ed25519.PublicKey), true
+		}
+	}
+	return nil, false
+}
+
+// sign — 서명 키로 서명하고 kid 헤더를 붙인다
+func (k *jwtKeyring) sign(claims *Claims) (string, error) {
+	key := k.keys[0]
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.priv)
+}
+
+// parse — 서명/만료/발급자 확인 (폐기 여부는 verifyAccessToken)
+func (k *jwtKeyring) parse(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		if pub, ok := k.publicKey(kid); ok {
+			return pub, nil
+		}
+		return nil, errUnknownJWTKey
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}), jwt.WithIssuer(jwtIssuer), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	if claims.ID == "" {
+		return nil, errMissingJTI
+	}
+	return claims, nil
+}
+
+func (k *jwtKeyring) jwks() []jwk {
+	keys := make([]jwk, 0, len(k.keys))
+	for _, key := range k.keys {
+		keys = append(keys, jwk{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(key.priv.Public().(ed25519.PublicKey)),
+			Kid: key.kid,
+			Use: "sig",
+			Alg: jwt.SigningMethodEdDSA.Alg(),
+		})
+	}
+	return keys
+}
+
+// newTokenID — jti (16바이트 난수 hex)
+func newTokenID() (string, error) {
+	return randomHex(16)
+}
+
+// bearerToken — "Bearer <token>" 헤더의 토큰 ("" = 없음/형식 오류)
+func bearerToken(c *gin.Context) string {
+	parts := strings.Split(c.GetHeader("Authorization"), " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return ""
+	}
+	return parts[1]
+}
+
+// verifyAccessToken — 서명 검증 후 폐기 목록 확인. DB 오류는 그대로 반환한다 (AuthMiddleware는 fail-closed)
+func verifyAccessToken(db *sql.DB, tokenString string) (*Claims, error) {
+	claims, err := currentJWTKeyring().parse(tokenString)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidAccessToken, err)
+	}
+	var revoked bool
+	if err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)`, claims.ID).
+		Scan(&revoked); err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, fmt.Errorf("%w: jti %s revoked", errInvalidAccessToken, claims.ID)
+	}
+	return claims, nil
+}
+
+// revokeTokenID — jti를 폐기 목록에 추가 (이미 있으면 무시). expiresAt 이후에는 정리 잡이 지운다.
+func revokeTokenID(ex execer, jti string, userID int, expiresAt time.Time, reason string, revokedBy interface{}) error {
+	_, err := ex.Exec(`
+		INSERT INTO revoked_tokens (jti, user_id, expires_at, reason, revoked_by)
+		VALUES ($1, NULLIF($2, 0), $3, $4, $5)
+		ON CONFLICT (jti) DO NOTHING
+	`, jti, userID, expiresAt, reason, revokedBy)
+	return err
+}
+
+// revokeSessionAccessToken — 세션 폐기 시 그 세션의 마지막 액세스 토큰도 즉시 무효화
+func revokeSessionAccessToken(ex execer, sessionID int, reason string) error {
+	_, err := ex.Exec(`
+		INSERT INTO revoked_tokens (jti, user_id, expires_at, reason)
+		SELECT access_jti, user_id, $2, $3 FROM sessions WHERE id = $1 AND access_jti <> ''
+		ON CONFLICT (jti) DO NOTHING
+	`, sessionID, time.Now().Add(accessTokenTTL()), reason)
+	return err
+}
+
+// JWKS — GET /.well-known/jwks.json (public). 액세스 토큰 검증용 공개키 목록
+func JWKS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, gin.H{"keys": currentJWTKeyring().jwks()})
+	}
+}
+
+// RevokeAccessToken — POST /api/v1/admin/tokens/revoke (관리자)
+// body: {jti | token | sessionId, reason}. sessionId는 세션(리프레시 토큰)까지 폐기한다.
+func RevokeAccessToken(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c, db) {
+			return
+		}
+		adminID, _ := c.Get("userId")
+		var req struct {
+			JTI       string `json:"jti"`
+			Token     string `json:"token"`
+			SessionID int    `json:"sessionId"`
+			Reason    string `json:"reason"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		provided := 0
+		for _, set := range []bool{req.JTI != "", req.Token != "", req.SessionID > 0} {
+			if set {
+				provided++
+			}
+		}
+		if provided != 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "exactly one of jti, token or sessionId is required"})
+			return
+		}
+		reason := truncateRunes(strings.TrimSpace(req.Reason), 200)
+		if reason == "" {
+			reason = tokenRevokedAdmin
+		}
+
+		if req.SessionID > 0 {
+			tx, err := db.Begin()
+			if err != nil {
+				respondDBError(c, err)
+				return
+			}
+			defer tx.Rollback()
+			res, err := tx.Exec(`
+				UPDATE sessions SET revoked_at = COALESCE(revoked_at, NOW()), revoked_reason = COALESCE(revoked_reason, $2)
+				WHERE id = $1
+			`, req.SessionID, tokenRevokedAdmin)
+			if err != nil {
+				respondDBError(c, err)
+				return
+			}
+			if n, _ := res.RowsAffected(); n == 0 {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+				return
+			}
+			if err := revokeSessionAccessToken(tx, req.SessionID, reason); err != nil {
+				respondDBError(c, err)
+				return
+			}
+			if err := tx.Commit(); err != nil {
+				respondDBError(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"message": "Session revoked", "sessionId": req.SessionID})
+			return
+		}
+
+		jti, userID := strings.TrimSpace(req.JTI), 0
+		// 남은 수명을 모르면 액세스 토큰 최대 수명만큼 보관
+		expiresAt := time.Now().Add(accessTokenTTL())
+		if req.Token != "" {
+			claims, err := currentJWTKeyring().parse(strings.TrimSpace(req.Token))
+			if errors.Is(err, jwt.ErrTokenExpired) {
+				c.JSON(http.StatusOK, gin.H{"message": "Token already expired"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token"})
+				return
+			}
+			jti, userID, expiresAt = claims.ID, claims.UserID, claims.ExpiresAt.Time
+		}
+		if jti == "" || len(jti) > 64 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid jti"})
+			return
+		}
+		if err := revokeTokenID(db, jti, userID, expiresAt, reason, adminID); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		log.Printf("[auth] admin %v revoked token %s (%s)", adminID, jti, reason)
+		c.JSON(http.StatusOK, gin.H{"message": "Token revoked", "jti": jti, "expiresAt": expiresAt})
+	}
+}
+
+// runRevokedTokenCleanup — 백그라운드 잡: 만료가 지난 폐기 항목 삭제 (토큰 자체가 이미 거부됨)
+func runRevokedTokenCleanup(db *sql.DB) error {
+	res, err := db.Exec(`DELETE FROM revoked_tokens WHERE expires_at < NOW()`)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		log.Printf("[auth] removed %d expired token revocations", n)
+	}
+	return nil
+}
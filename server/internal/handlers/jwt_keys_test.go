@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testSeed(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), ed25519.SeedSize)))
+}
+
+func testClaims() *Claims {
+	return &Claims{
+		UserID: 3,
+		Role:   "buyer",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "jti-3",
+			Issuer:    jwtIssuer,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+}
+
+func TestParseJWTKeyring(t *testing.T) {
+	ring, err := parseJWTKeyring(" k2:" + testSeed('b') + ", " + testSeed('a') + " ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ring.keys) != 2 || ring.keys[0].kid != "k2" {
+		t.Fatalf("keys = %+v", ring.keys)
+	}
+	if kid := ring.keys[1].kid; kid != jwtKeyID(ring.keys[1].priv.Public().(ed25519.PublicKey)) {
+		t.Errorf("derived kid = %q", kid)
+	}
+
+	for _, bad := range []string{"", " , ", "k1:not-base64", "k1:" + base64.StdEncoding.EncodeToString([]byte("short")),
+		"k1:" + testSeed('a') + ",k1:" + testSeed('b')} {
+		if _, err := parseJWTKeyring(bad); err == nil {
+			t.Errorf("parseJWTKeyring(%q) accepted", bad)
+		}
+	}
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	oldRing, _ := parseJWTKeyring("old:" + testSeed('a'))
+	rotated, _ := parseJWTKeyring("new:" + testSeed('b') + ",old:" + testSeed('a'))
+	retired, _ := parseJWTKeyring("new:" + testSeed('b'))
+
+	oldToken, err := oldRing.sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 교체 중에는 이전 키로 서명된 토큰도 통과, 이전 키를 빼면 거부
+	if claims, err := rotated.parse(oldToken); err != nil || claims.UserID != 3 || claims.ID != "jti-3" {
+		t.Fatalf("rotated.parse(old) = %+v, %v", claims, err)
+	}
+	if _, err := retired.parse(oldToken); err == nil {
+		t.Error("token signed with a retired key accepted")
+	}
+
+	newToken, _ := rotated.sign(testClaims())
+	if _, err := retired.parse(newToken); err != nil {
+		t.Errorf("retired.parse(new) = %v", err)
+	}
+	if _, err := oldRing.parse(newToken); err == nil {
+		t.Error("token verified without its key")
+	}
+}
+
+func TestJWTParseRejects(t *testing.T) {
+	ring := derivedJWTKeyring([]byte("test-secret"))
+
+	// 이전 HS256 토큰 (alg 혼동 포함)은 거부
+	hs, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims()).SignedString([]byte("test-secret"))
+	if _, err := ring.parse(hs); err == nil {
+		t.Error("HS256 token accepted")
+	}
+
+	noJTI := testClaims()
+	noJTI.ID = ""
+	signed, _ := ring.sign(noJTI)
+	if _, err := ring.parse(signed); err != errMissingJTI {
+		t.Errorf("token without jti: err = %v", err)
+	}
+
+	expired := testClaims()
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	signed, _ = ring.sign(expired)
+	if _, err := ring.parse(signed); err == nil {
+		t.Error("expired token accepted")
+	}
+
+	wrongIssuer := testClaims()
+	wrongIssuer.Issuer = "other"
+	signed, _ = ring.sign(wrongIssuer)
+	if _, err := ring.parse(signed); err == nil {
+		t.Error("token from another issuer accepted")
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	ring, _ := parseJWTKeyring("k1:" + testSeed('a') + ",k2:" + testSeed('b'))
+	keys := ring.jwks()
+	if len(keys) != 2 || keys[0].Kid != "k1" || keys[0].Kty != "OKP" || keys[0].Crv != "Ed25519" || keys[0].Alg != "EdDSA" {
+		t.Fatalf("jwks = %+v", keys)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(keys[1].X)
+	pub, _ := ring.publicKey("k2")
+	if err != nil || !ed25519.PublicKey(x).Equal(pub) {
+		t.Errorf("jwks x = %q", keys[1].X)
+	}
+	// 공개 목록에 개인키가 섞이지 않는지
+	if len(x) != ed25519.PublicKeySize {
+		t.Errorf("jwks x has %d bytes", len(x))
+	}
+}
@@ -0,0 +1,570 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cmall_dd/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// ── 라이선스 키 / 온라인 활성화 ─────────────────────────────────────────────
+// 소프트웨어형 상품(licensedProductTypes)이 paid가 되면 구매 1건당 라이선스 키를 발급한다.
+// 키 형식: "CM1." + base64url(JSON 클레임) + "." + base64url(Ed25519 서명)
+// 서명 대상은 "CM1.<클레임>" 문자열 — 데스크톱 프로그램은 공개키(GET /licenses/public-key)로
+// 오프라인 검증할 수 있고, 온라인에서는 activate/validate로 좌석(기기 지문) 한도와
+// 폐기(환불) 여부를 확인한다. 기기 지문은 SHA-256 해시로만 저장한다.
+
+const licenseKeyPrefix = "CM1."
+
+const (
+	licenseStatusActive  = "active"
+	licenseStatusRevoked = "revoked"
+)
+
+// licensedProductTypes — 라이선스 키를 발급하는 상품 유형
+var licensedProductTypes = map[string]bool{"program": true, "software": true, "code": true}
+
+// licensedProductTypeList — licensedProductTypes를 SQL ANY($n) 인자로 (정렬된 목록)
+func licensedProductTypeList() []string {
+	types := make([]string, 0, len(licensedProductTypes))
+	for t := range licensedProductTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// productIssuesLicense — 라이선스 발급 대상 여부. AI 분석 상품(request_type 지정)과
+// 구독 상품(billing_interval_days 지정)은 product_type이 'software'여도 키를 발급하지 않는다.
+func productIssuesLicense(productType, requestType string, billingIntervalDays sql.NullInt64) bool {
+	return licensedProductTypes[strings.ToLower(productType)] &&
+		strings.TrimSpace(requestType) == "" && !billingIntervalDays.Valid
+}
+
+// licenseClaims — 키에 서명되어 들어가는 내용 (구매 참조 ID로 키가 구매마다 유일)
+type licenseClaims struct {
+	Version   int    `json:"v"`
+	Reference string `json:"ref"`
+	ProductID int    `json:"pid"`
+	IssuedAt  int64  `json:"iat"`
+}
+
+var (
+	licenseKeyOnce sync.Once
+	licenseKey     ed25519.PrivateKey
+)
+
+// licenseSigningKey — LICENSE_SIGNING_KEY (base64 32바이트 seed 또는 64바이트 개인키).
+// 미설정 시 JWT 시크릿에서 파생한다 — 운영에서는 반드시 별도 키를 설정할 것
+// (JWT 시크릿 교체 시 공개키가 바뀌어 기존 키의 오프라인 검증이 깨진다).
+func licenseSigningKey() ed25519.PrivateKey {
+	licenseKeyOnce.Do(func() {
+		raw := os.Getenv("LICENSE_SIGNING_KEY")
+		if strings.TrimSpace(raw) == "" {
+			log.Println("[licenses] LICENSE_SIGNING_KEY not set; deriving signing key from JWT_SECRET")
+		}
+		key, err := parseLicenseSigningKey(raw, jwtSecret())
+		if err != nil {
+			panic(err.Error())
+		}
+		licenseKey = key
+	})
+	return licenseKey
+}
+
+// parseLicenseSigningKey — LICENSE_SIGNING_KEY 값 파싱 (빈 값이면 secret에서 파생)
+func parseLicenseSigningKey(raw string, secret []byte) (ed25519.PrivateKey, error) {
+	if strings.TrimSpace(raw) == "" {
+		seed := sha256.Sum256(append([]byte("cmall_dd license signing key|"), secret...))
+		return ed25519.NewKeyFromSeed(seed[:]), nil
+	}
+	key, err := decodeEd25519Key(raw)
+	if err != nil {
+		return nil, fmt.Errorf("LICENSE_SIGNING_KEY %w", err)
+	}
+	return key, nil
+}
+
+func signLicenseKey(priv ed25519.PrivateKey, claims licenseClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signed := licenseKeyPrefix + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(ed25519.Sign(priv, []byte(signed))), nil
+}
+
+var errInvalidLicenseKey = errors.New("invalid license key")
+
+// verifyLicenseKey — 서명만 확인한다 (폐기 여부는 DB에서 확인)
+func verifyLicenseKey(pub ed25519.PublicKey, key string) (licenseClaims, error) {
+	var claims licenseClaims
+	key = strings.TrimSpace(key)
+	if !strings.HasPrefix(key, licenseKeyPrefix) {
+		return claims, errInvalidLicenseKey
+	}
+	dot := strings.LastIndexByte(key, '.')
+	if dot <= len(licenseKeyPrefix) {
+		return claims, errInvalidLicenseKey
+	}
+	signed, encSig := key[:dot], key[dot+1:]
+	sig, err := base64.RawURLEncoding.DecodeString(encSig)
+	if err != nil || !ed25519.Verify(pub, []byte(signed), sig) {
+		return claims, errInvalidLicenseKey
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(signed[len(licenseKeyPrefix):])
+	if err != nil || json.Unmarshal(payload, &claims) != nil || claims.Version != 1 || claims.Reference == "" {
+		return claims, errInvalidLicenseKey
+	}
+	return claims, nil
+}
+
+func licensePublicKey() ed25519.PublicKey {
+	return licenseSigningKey().Public().(ed25519.PublicKey)
+}
+
+// licenseMaxActivationsDefault — 상품별 max_activations가 없을 때 좌석 수
+func licenseMaxActivationsDefault() int {
+	return envInt("LICENSE_MAX_ACTIVATIONS", 3)
+}
+
+// issueLicenseForPayment — paid 결제에 라이선스 발급 (멱등: payment_id UNIQUE)
+func issueLicenseForPayment(db *sql.DB, payment *models.Payment) error {
+	var productType, requestType string
+	var billingIntervalDays sql.NullInt64
+	var maxActivations int
+	err := db.QueryRow(`
+		SELECT product_type, request_type, billing_interval_days, COALESCE(max_activations, $2)
+		FROM products WHERE id = $1
+	`, payment.OrderID, licenseMaxActivationsDefault()).Scan(&productType, &requestType, &billingIntervalDays, &maxActivations)
+	if err != nil {
+		return err
+	}
+	if !productIssuesLicense(productType, requestType, billingIntervalDays) {
+		return nil
+	}
+	key, err := signLicenseKey(licenseSigningKey(), licenseClaims{
+		Version:   1,
+		Reference: payment.ReferenceID,
+		ProductID: payment.OrderID,
+		IssuedAt:  time.Now().Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		INSERT INTO licenses (payment_id, user_id, product_id, license_key, max_activations)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (payment_id) DO NOTHING
+	`, payment.ID, payment.UserID, payment.OrderID, key, maxActivations)
+	return err
+}
+
+// runLicenseIssuanceBackfill — 발급이 실패했거나 기능 도입 전의 paid 결제에 라이선스 발급
+func runLicenseIssuanceBackfill(db *sql.DB) error {
+	rows, err := db.Query(`
+		SELECT pm.id, pm.user_id, pm.order_id, pm.reference_id
+		FROM payments pm
+		JOIN products pr ON pr.id = pm.order_id
+		WHERE pm.status = 'paid' AND LOWER(pr.product_type) = ANY($1)
+		  AND pr.request_type = '' AND pr.billing_interval_days IS NULL
+		  AND NOT EXISTS (SELECT 1 FROM licenses l WHERE l.payment_id = pm.id)
+		ORDER BY pm.id
+		LIMIT 200
+	`, pq.Array(licensedProductTypeList()))
+	if err != nil {
+		return err
+	}
+	var pending []models.Payment
+	for rows.Next() {
+		var p models.Payment
+		if err := rows.Scan(&p.ID, &p.UserID, &p.OrderID, &p.ReferenceID); err != nil {
+			rows.Close()
+			return err
+		}
+		pending = append(pending, p)
+	}
+	rows.Close()
+	for i := range pending {
+		if err := issueLicenseForPayment(db, &pending[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revokeLicensesForPayment — 환불 등으로 결제가 무효화되면 라이선스를 폐기한다
+func revokeLicensesForPayment(db *sql.DB, paymentID int, reason string) error {
+	_, err := db.Exec(`
+		UPDATE licenses SET status = 'revoked', revoked_at = NOW(), revoke_reason = $2
+		WHERE payment_id = $1 AND status = 'active'
+	`, paymentID, reason)
+	return err
+}
+
+// GetLicensePublicKey — GET /api/v1/licenses/public-key (공개, 오프라인 검증용)
+func GetLicensePublicKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"algorithm": "Ed25519",
+			"format":    "CM1.<base64url claims>.<base64url signature over \"CM1.<claims>\">",
+			"publicKey": base64.StdEncoding.EncodeToString(licensePublicKey()),
+		})
+	}
+}
+
+type licenseActivationRequest struct {
+	LicenseKey  string `json:"licenseKey" binding:"required"`
+	Fingerprint string `json:"fingerprint"`
+	MachineName string `json:"machineName"`
+}
+
+// fingerprintHash — 기기 지문 원문은 저장하지 않는다
+func fingerprintHash(fingerprint string) (string, bool) {
+	fingerprint = strings.TrimSpace(fingerprint)
+	if len(fingerprint) < 8 || len(fingerprint) > 256 {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(fingerprint))
+	return hex.EncodeToString(sum[:]), true
+}
+
+// licenseRecord — 키로 조회한 라이선스 상태
+type licenseRecord struct {
+	ID             int
+	ProductID      int
+	Status         string
+	MaxActivations int
+}
+
+// lookupLicense — 서명 검증 후 DB 조회. 서명이 틀리거나 행이 없으면 errInvalidLicenseKey.
+func lookupLicense(q interface {
+	QueryRow(string, ...interface{}) *sql.Row
+}, key string, forUpdate bool) (*licenseRecord, error) {
+	claims, err := verifyLicenseKey(licensePublicKey(), key)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT id, product_id, status, max_activations FROM licenses WHERE license_key = $1`
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
+	var l licenseRecord
+	err = q.QueryRow(query, strings.TrimSpace(key)).Scan(&l.ID, &l.ProductID, &l.Status, &l.MaxActivations)
+	if err == sql.ErrNoRows || (err == nil && l.ProductID != claims.ProductID) {
+		return nil, errInvalidLicenseKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// ActivateLicense — POST /api/v1/licenses/activate {licenseKey, fingerprint, machineName}
+// 이미 활성화된 기기는 좌석을 추가로 쓰지 않는다. 좌석이 다 찼으면 409.
+func ActivateLicense(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req licenseActivationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		hash, ok := fingerprintHash(req.Fingerprint)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "fingerprint must be 8-256 characters"})
+			return
+		}
+		machineName := strings.TrimSpace(req.MachineName)
+		if len([]rune(machineName)) > 100 {
+			machineName = string([]rune(machineName)[:100])
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer tx.Rollback()
+
+		// 라이선스 행 잠금 — 동시 활성화가 좌석 한도를 넘지 못하도록
+		l, err := lookupLicense(tx, req.LicenseKey, true)
+		if errors.Is(err, errInvalidLicenseKey) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if l.Status != licenseStatusActive {
+			c.JSON(http.StatusForbidden, gin.H{"error": "license has been revoked"})
+			return
+		}
+
+		var seatsUsed int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM license_activations WHERE license_id = $1`, l.ID).Scan(&seatsUsed); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		res, err := tx.Exec(`
+			UPDATE license_activations SET last_seen_at = NOW(), machine_name = COALESCE(NULLIF($3, ''), machine_name)
+			WHERE license_id = $1 AND fingerprint_hash = $2
+		`, l.ID, hash, machineName)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			if seatsUsed >= l.MaxActivations {
+				c.JSON(http.StatusConflict, gin.H{
+					"error":          "activation limit reached — deactivate another machine first",
+					"seatsUsed":      seatsUsed,
+					"maxActivations": l.MaxActivations,
+				})
+				return
+			}
+			if _, err := tx.Exec(`
+				INSERT INTO license_activations (license_id, fingerprint_hash, machine_name) VALUES ($1, $2, $3)
+			`, l.ID, hash, machineName); err != nil {
+				respondDBError(c, err)
+				return
+			}
+			seatsUsed++
+		}
+		if err := tx.Commit(); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"activated":      true,
+			"productId":      l.ProductID,
+			"seatsUsed":      seatsUsed,
+			"maxActivations": l.MaxActivations,
+		})
+	}
+}
+
+// DeactivateLicense — POST /api/v1/licenses/deactivate {licenseKey, fingerprint}
+// 폐기된 라이선스도 좌석 해제는 허용한다.
+func DeactivateLicense(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req licenseActivationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		hash, ok := fingerprintHash(req.Fingerprint)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "fingerprint must be 8-256 characters"})
+			return
+		}
+		l, err := lookupLicense(db, req.LicenseKey, false)
+		if errors.Is(err, errInvalidLicenseKey) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		res, err := db.Exec(`DELETE FROM license_activations WHERE license_id = $1 AND fingerprint_hash = $2`, l.ID, hash)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "this machine is not activated"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"deactivated": true})
+	}
+}
+
+// ValidateLicense — POST /api/v1/licenses/validate {licenseKey, fingerprint?}
+// 항상 200 + {valid, reason} (잘못된 요청 본문만 400). fingerprint를 주면 해당 기기의
+// 활성화 여부까지 확인하고 last_seen_at을 갱신한다.
+func ValidateLicense(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req licenseActivationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+		l, err := lookupLicense(db, req.LicenseKey, false)
+		if errors.Is(err, errInvalidLicenseKey) {
+			c.JSON(http.StatusOK, gin.H{"valid": false, "reason": "invalid"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		resp := gin.H{"valid": l.Status == licenseStatusActive, "status": l.Status, "productId": l.ProductID,
+			"maxActivations": l.MaxActivations}
+		if l.Status != licenseStatusActive {
+			resp["reason"] = "revoked"
+		}
+
+		var seatsUsed int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM license_activations WHERE license_id = $1`, l.ID).Scan(&seatsUsed); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		resp["seatsUsed"] = seatsUsed
+
+		if strings.TrimSpace(req.Fingerprint) != "" {
+			hash, ok := fingerprintHash(req.Fingerprint)
+			activated := false
+			if ok {
+				res, err := db.Exec(`
+					UPDATE license_activations SET last_seen_at = NOW() WHERE license_id = $1 AND fingerprint_hash = $2
+				`, l.ID, hash)
+				if err != nil {
+					respondDBError(c, err)
+					return
+				}
+				affected, _ := res.RowsAffected()
+				activated = affected > 0
+			}
+			resp["activated"] = activated
+			if l.Status == licenseStatusActive && !activated {
+				resp["valid"] = false
+				resp["reason"] = "not_activated"
+			}
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// licenseActivation / myLicense — GET /me/licenses 응답
+type licenseActivation struct {
+	ID          int       `json:"id"`
+	MachineName string    `json:"machineName"`
+	ActivatedAt time.Time `json:"activatedAt"`
+	LastSeenAt  time.Time `json:"lastSeenAt"`
+}
+
+type myLicense struct {
+	ID             int                 `json:"id"`
+	ProductID      int                 `json:"productId"`
+	ProductName    string              `json:"productName"`
+	ReferenceID    string              `json:"referenceId"`
+	LicenseKey     string              `json:"licenseKey"`
+	Status         string              `json:"status"`
+	MaxActivations int                 `json:"maxActivations"`
+	Activations    []licenseActivation `json:"activations"`
+	RevokedAt      *time.Time          `json:"revokedAt,omitempty"`
+	CreatedAt      time.Time           `json:"createdAt"`
+}
+
+// GetMyLicenses — GET /api/v1/me/licenses (JWT, 구매자 본인)
+func GetMyLicenses(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		rows, err := db.Query(`
+			SELECT l.id, l.product_id, pr.name, pm.reference_id, l.license_key, l.status,
+			       l.max_activations, l.revoked_at, l.created_at
+			FROM licenses l
+			JOIN products pr ON pr.id = l.product_id
+			JOIN payments pm ON pm.id = l.payment_id
+			WHERE l.user_id = $1
+			ORDER BY l.created_at DESC
+		`, userID)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		licenses := []*myLicense{}
+		byID := map[int]*myLicense{}
+		for rows.Next() {
+			l := &myLicense{Activations: []licenseActivation{}}
+			if err := rows.Scan(&l.ID, &l.ProductID, &l.ProductName, &l.ReferenceID, &l.LicenseKey, &l.Status,
+				&l.MaxActivations, &l.RevokedAt, &l.CreatedAt); err != nil {
+				rows.Close()
+				respondDBError(c, err)
+				return
+			}
+			licenses = append(licenses, l)
+			byID[l.ID] = l
+		}
+		rows.Close()
+
+		actRows, err := db.Query(`
+			SELECT a.license_id, a.id, a.machine_name, a.activated_at, a.last_seen_at
+			FROM license_activations a
+			JOIN licenses l ON l.id = a.license_id
+			WHERE l.user_id = $1
+			ORDER BY a.activated_at
+		`, userID)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer actRows.Close()
+		for actRows.Next() {
+			var licenseID int
+			var a licenseActivation
+			if err := actRows.Scan(&licenseID, &a.ID, &a.MachineName, &a.ActivatedAt, &a.LastSeenAt); err != nil {
+				respondDBError(c, err)
+				return
+			}
+			if l := byID[licenseID]; l != nil {
+				l.Activations = append(l.Activations, a)
+			}
+		}
+		c.JSON(http.StatusOK, licenses)
+	}
+}
+
+// DeleteMyLicenseActivation — DELETE /api/v1/me/licenses/:id/activations/:activationId (JWT)
+// 기기를 잃어버린 경우 웹에서 좌석을 해제한다.
+func DeleteMyLicenseActivation(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		licenseID, err1 := strconv.Atoi(c.Param("id"))
+		activationID, err2 := strconv.Atoi(c.Param("activationId"))
+		if err1 != nil || err2 != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid activation ID"})
+			return
+		}
+		res, err := db.Exec(`
+			DELETE FROM license_activations a
+			USING licenses l
+			WHERE a.id = $1 AND a.license_id = $2 AND l.id = a.license_id AND l.user_id = $3
+		`, activationID, licenseID, userID)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "activation not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Activation removed"})
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"database/sql"
+	"encoding/base64"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestLicenseKeySignVerify(t *testing.T) {
+	priv := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	pub := priv.Public().(ed25519.PublicKey)
+	claims := licenseClaims{Version: 1, Reference: "pay_abc", ProductID: 7, IssuedAt: 1_700_000_000}
+
+	key, err := signLicenseKey(priv, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(key, licenseKeyPrefix) {
+		t.Fatalf("key %q lacks prefix", key)
+	}
+	got, err := verifyLicenseKey(pub, "  "+key+"\n")
+	if err != nil || got != claims {
+		t.Fatalf("verify = %+v, %v", got, err)
+	}
+
+	// 다른 키로 서명 / 클레임 변조 / 형식 오류
+	other := ed25519.NewKeyFromSeed([]byte(strings.Repeat("x", ed25519.SeedSize)))
+	if _, err := verifyLicenseKey(other.Public().(ed25519.PublicKey), key); err == nil {
+		t.Error("key verified with the wrong public key")
+	}
+	forged, _ := signLicenseKey(other, licenseClaims{Version: 1, Reference: "pay_abc", ProductID: 8})
+	body := forged[:strings.LastIndexByte(forged, '.')]
+	sig := key[strings.LastIndexByte(key, '.'):]
+	if _, err := verifyLicenseKey(pub, body+sig); err == nil {
+		t.Error("tampered claims verified")
+	}
+	for _, bad := range []string{"", "CM1.", "CM1..", "ABC-123", "CM1.e30." + sig[1:]} {
+		if _, err := verifyLicenseKey(pub, bad); err == nil {
+			t.Errorf("verifyLicenseKey(%q) accepted", bad)
+		}
+	}
+}
+
+func TestFingerprintHash(t *testing.T) {
+	a, ok := fingerprintHash(" machine-guid-1234 ")
+	b, _ := fingerprintHash("machine-guid-1234")
+	if !ok || a != b || len(a) != 64 {
+		t.Errorf("fingerprintHash = %q, %v", a, ok)
+	}
+	if _, ok := fingerprintHash("short"); ok {
+		t.Error("short fingerprint accepted")
+	}
+}
+
+func TestParseLicenseSigningKey(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	seed[0] = 1
+	want := ed25519.NewKeyFromSeed(seed)
+	for _, raw := range []string{
+		base64.StdEncoding.EncodeToString(seed),
+		" " + base64.StdEncoding.EncodeToString(want) + "\n",
+	} {
+		if got, err := parseLicenseSigningKey(raw, nil); err != nil || !got.Equal(want) {
+			t.Errorf("parseLicenseSigningKey(%q) = %v", raw, err)
+		}
+	}
+
+	// 미설정이면 시크릿에서 결정적으로 파생
+	a, _ := parseLicenseSigningKey("", []byte("secret"))
+	b, _ := parseLicenseSigningKey("  ", []byte("secret"))
+	if a == nil || !a.Equal(b) {
+		t.Error("derived key must be deterministic")
+	}
+
+	for _, raw := range []string{"not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := parseLicenseSigningKey(raw, nil); err == nil || !strings.Contains(err.Error(), "LICENSE_SIGNING_KEY") {
+			t.Errorf("parseLicenseSigningKey(%q) err = %v", raw, err)
+		}
+	}
+}
+
+func TestProductIssuesLicense(t *testing.T) {
+	monthly := sql.NullInt64{Int64: 30, Valid: true}
+	cases := []struct {
+		name                     string
+		productType, requestType string
+		billing                  sql.NullInt64
+		want                     bool
+	}{
+		{"downloadable software", "Software", "", sql.NullInt64{}, true},
+		{"code", "code", " ", sql.NullInt64{}, true},
+		{"ebook", "ebook", "", sql.NullInt64{}, false},
+		// 시드 179/180/182 — product_type=software + request_type
+		{"analysis product", "software", "swing_screener", sql.NullInt64{}, false},
+		// 시드 190 — 월 구독 (갱신마다 결제 발생)
+		{"subscription", "software", "subscription_bundle", monthly, false},
+		{"subscription without request type", "program", "", monthly, false},
+	}
+	for _, c := range cases {
+		if got := productIssuesLicense(c.productType, c.requestType, c.billing); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+	types := licensedProductTypeList()
+	if len(types) != len(licensedProductTypes) || !sort.StringsAreSorted(types) {
+		t.Errorf("licensedProductTypeList() = %v", types)
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ── 메일 발송 ────────────────────────────────────────────────────────────
+// 계정 메일(이메일 인증 / 비밀번호 재설정)과 알림 email 채널이 같은 Mailer를 쓴다.
+// MAIL_BACKEND: smtp (SMTP_HOST 등) | file (MAIL_OUTBOX_DIR에 .eml 저장) | log (본문을 로그로 출력).
+// 미설정이면 SMTP_HOST가 있을 때만 smtp — file/log는 재설정 링크가 그대로 남으므로 개발에서만 명시적으로 켠다.
+
+var errUndeliverableEmail = errors.New("user has no deliverable email address")
+
+// mailMessage — 텍스트 메일 1통
+type mailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer — 메일 발송 구현 (smtp / 개발용 file·log 스탠드인)
+type Mailer interface {
+	Send(msg mailMessage) error
+}
+
+// mailerFromEnv — env 설정은 호출 시점에 읽는다 (alertChannelFor와 동일)
+func mailerFromEnv() Mailer {
+	backend := strings.ToLower(strings.TrimSpace(os.Getenv("MAIL_BACKEND")))
+	if backend == "" && os.Getenv("SMTP_HOST") != "" {
+		backend = "smtp"
+	}
+	switch backend {
+	case "smtp":
+		return smtpMailerFromEnv()
+	case "file":
+		dir := os.Getenv("MAIL_OUTBOX_DIR")
+		if dir == "" {
+			dir = "./data/outbox"
+		}
+		return fileMailer{dir: dir}
+	case "log":
+		return logMailer{}
+	}
+	return disabledMailer{}
+}
+
+func smtpMailerFromEnv() smtpMailer {
+	return smtpMailer{
+		host:     os.Getenv("SMTP_HOST"),
+		port:     envInt("SMTP_PORT", 1025),
+		from:     os.Getenv("SMTP_FROM"),
+		username: os.Getenv("SMTP_USERNAME"),
+		password: os.Getenv("SMTP_PASSWORD"),
+	}
+}
+
+// deliverableEmail — 지갑 전용 계정(@wallet.local)은 실제 메일함이 없다
+func deliverableEmail(addr string) bool {
+	return addr != "" && !strings.HasSuffix(strings.ToLower(addr), "@wallet.local")
+}
+
+func mailFrom(from string) string {
+	if from == "" {
+		return "no-reply@cmall.local"
+	}
+	return from
+}
+
+// formatMail — RFC 5322 텍스트 메일. 헤더 인젝션 방지 (CWE-93): 제목/주소의 개행 제거
+func formatMail(from string, msg mailMessage) []byte {
+	clean := strings.NewReplacer("\r", " ", "\n", " ")
+	var sb strings.Builder
+	sb.WriteString("From: " + clean.Replace(from) + "\r\n")
+	sb.WriteString("To: " + clean.Replace(msg.To) + "\r\n")
+	// 한글 제목은 RFC 2047 인코딩 (ASCII 제목은 그대로 나간다)
+	sb.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", clean.Replace(msg.Subject)) + "\r\n")
+	sb.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	sb.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	sb.WriteString(msg.Body)
+	return []byte(sb.String())
+}
+
+type smtpMailer struct {
+	host     string
+	port     int
+	from     string
+	username string
+	password string
+}
+
+func (m smtpMailer) Send(msg mailMessage) error {
+	if m.host == "" {
+		return errChannelNotConfigured
+	}
+	if !deliverableEmail(msg.To) {
+		return errUndeliverableEmail
+	}
+	from := mailFrom(m.from)
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+	addr := fmt.Sprintf("%s:%d", m.host, m.port)
+	return smtp.SendMail(addr, auth, from, []string{msg.To}, formatMail(from, msg))
+}
+
+// fileMailer — 개발용: 메일을 dir/<시각>-<수신자>.eml로 저장
+type fileMailer struct {
+	dir string
+}
+
+func (m fileMailer) Send(msg mailMessage) error {
+	if !deliverableEmail(msg.To) {
+		return errUndeliverableEmail
+	}
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return err
+	}
+	safe := strings.Map(func(r rune) rune {
+		if r == '@' || r == '.' || r == '-' || r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, msg.To)
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405.000000000"), safe)
+	return os.WriteFile(filepath.Join(m.dir, name), formatMail(mailFrom(os.Getenv("SMTP_FROM")), msg), 0o600)
+}
+
+// logMailer — 개발용: 메일 내용을 서버 로그로 출력
+type logMailer struct{}
+
+func (logMailer) Send(msg mailMessage) error {
+	if !deliverableEmail(msg.To) {
+		return errUndeliverableEmail
+	}
+	log.Printf("[mail] to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// disabledMailer — 발송 수단 미설정
+type disabledMailer struct{}
+
+func (disabledMailer) Send(mailMessage) error { return errChannelNotConfigured }
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMailerFromEnv(t *testing.T) {
+	cases := []struct {
+		backend, host string
+		want          Mailer
+	}{
+		{"", "", disabledMailer{}},
+		{"", "mail.local", smtpMailer{}},
+		{"log", "", logMailer{}},
+		{"FILE", "mail.local", fileMailer{}},
+		{"smtp", "", smtpMailer{}},
+	}
+	for _, tc := range cases {
+		t.Setenv("MAIL_BACKEND", tc.backend)
+		t.Setenv("SMTP_HOST", tc.host)
+		if got, want := fmt.Sprintf("%T", mailerFromEnv()), fmt.Sprintf("%T", tc.want); got != want {
+			t.Errorf("MAIL_BACKEND=%q SMTP_HOST=%q: got %s, want %s", tc.backend, tc.host, got, want)
+		}
+	}
+	// SMTP 백엔드라도 호스트가 없으면 미설정 오류
+	t.Setenv("MAIL_BACKEND", "smtp")
+	t.Setenv("SMTP_HOST", "")
+	if err := mailerFromEnv().Send(mailMessage{To: "a@example.com"}); err != errChannelNotConfigured {
+		t.Errorf("smtp without host: err = %v", err)
+	}
+}
+
+func TestFormatMailStripsHeaderInjection(t *testing.T) {
+	raw := string(formatMail("from@example.com", mailMessage{
+		To:      "a@example.com\r\nBcc: evil@example.com",
+		Subject: "hi\nBcc: evil@example.com",
+		Body:    "body",
+	}))
+	head, body, _ := strings.Cut(raw, "\r\n\r\n")
+	for _, line := range strings.Split(head, "\r\n") {
+		if strings.HasPrefix(line, "Bcc:") {
+			t.Fatalf("injected header line %q", line)
+		}
+	}
+	if body != "body" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	m := fileMailer{dir: dir}
+	if err := m.Send(mailMessage{To: "x@wallet.local", Subject: "s", Body: "b"}); err != errUndeliverableEmail {
+		t.Errorf("wallet address: err = %v", err)
+	}
+	if err := m.Send(mailMessage{To: "../a@example.com", Subject: "Reset", Body: "link"}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("outbox = %v, %v", entries, err)
+	}
+	name := entries[0].Name()
+	if !strings.HasSuffix(name, "-.._a@example.com.eml") || strings.Contains(name, "/") {
+		t.Errorf("file name = %q", name)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, name))
+	if !strings.Contains(string(data), "Subject: Reset\r\n") || !strings.HasSuffix(string(data), "link") {
+		t.Errorf("message = %q", data)
+	}
+}
+
+func TestFormatMailEncodesSubject(t *testing.T) {
+	raw := string(formatMail("from@example.com", mailMessage{To: "a@example.com", Subject: "[cmall] 이메일 인증", Body: "본문"}))
+	head, _, _ := strings.Cut(raw, "\r\n\r\n")
+	if !strings.Contains(head, "Subject: =?utf-8?q?") || strings.Contains(head, "이메일") {
+		t.Errorf("subject not RFC 2047 encoded: %q", head)
+	}
+	if !strings.HasSuffix(head, "\r\nContent-Transfer-Encoding: 8bit") {
+		t.Errorf("missing transfer encoding: %q", head)
+	}
+	if raw := string(formatMail("f@example.com", mailMessage{To: "a@example.com", Subject: "plain"})); !strings.Contains(raw, "Subject: plain\r\n") {
+		t.Errorf("ascii subject changed: %q", raw)
+	}
+}
@@ -1,84 +1,77 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
+	"log"
 	"net/http"
-	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-// AuthMiddleware validates JWT tokens
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware validates JWT tokens (signature by kid, expiry, jti denylist — jwt_keys.go)
+func AuthMiddleware(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
+		if c.GetHeader("Authorization") == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
 			c.Abort()
 			return
 		}
 
 		// Extract token from "Bearer <token>"
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
+		tokenString := bearerToken(c)
+		if tokenString == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
 			c.Abort()
 			return
 		}
 
-		tokenString := parts[1]
-
-		// Parse and validate token
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return jwtSecret(), nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := verifyAccessToken(db, tokenString)
+		if errors.Is(err, errInvalidAccessToken) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			c.Abort()
 			return
 		}
+		if err != nil {
+			// 폐기 목록을 확인할 수 없으면 거부 (fail-closed)
+			respondDBError(c, err)
+			c.Abort()
+			return
+		}
 
-		// Set user info in context
-		c.Set("userId", claims.UserID)
-		c.Set("userEmail", claims.Email)
-		c.Set("userRole", claims.Role)
-		c.Set("walletAddress", claims.WalletAddress)
-
+		setAuthContext(c, claims)
 		c.Next()
 	}
 }
 
 // OptionalAuthMiddleware extracts user info if token exists, but doesn't require it
-func OptionalAuthMiddleware() gin.HandlerFunc {
+func OptionalAuthMiddleware(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
+		tokenString := bearerToken(c)
+		if tokenString == "" {
 			c.Next()
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.Next()
-			return
-		}
-
-		tokenString := parts[1]
-
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return jwtSecret(), nil
-		})
-
-		if err == nil && token.Valid {
-			c.Set("userId", claims.UserID)
-			c.Set("userEmail", claims.Email)
-			c.Set("userRole", claims.Role)
-			c.Set("walletAddress", claims.WalletAddress)
+		claims, err := verifyAccessToken(db, tokenString)
+		if err == nil {
+			setAuthContext(c, claims)
+		} else if !errors.Is(err, errInvalidAccessToken) {
+			// 폐기 여부를 모르면 비로그인으로 처리
+			log.Printf("[auth] token denylist check failed: %v", err)
 		}
 
 		c.Next()
 	}
 }
+
+// setAuthContext — 인증된 사용자 정보를 컨텍스트에 저장
+func setAuthContext(c *gin.Context, claims *Claims) {
+	c.Set("userId", claims.UserID)
+	c.Set("userEmail", claims.Email)
+	c.Set("userRole", claims.Role)
+	c.Set("walletAddress", claims.WalletAddress)
+	if claims.SessionID > 0 {
+		c.Set("authSessionId", claims.SessionID)
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// ── 사용자 알림함 ──────────────────────────────────────────────────────────
+// 시스템이 사용자에게 보내는 알림 (상품 심사 결과, 위시리스트 가격 인하 등). notifications 레코드가 인앱 알림이고,
+// SMTP가 설정돼 있으면 같은 내용을 이메일로도 보낸다 (alert_channels.go의 email 채널 재사용).
+// 관심종목 신호는 규칙 기반이라 alerts 테이블을 따로 쓴다.
+
+const (
+	notificationProductModeration = "product_moderation"
+	notificationWishlistPriceDrop = "wishlist_price_drop"
+	notificationWishlistRestocked = "wishlist_restocked"
+	notificationCartAbandoned     = "cart_abandoned"
+)
+
+// Notification — 알림 1건
+type Notification struct {
+	ID        int        `json:"id"`
+	Kind      string     `json:"kind"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	Link      string     `json:"link,omitempty"`
+	ReadAt    *time.Time `json:"readAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// notifyUser — 알림 기록 + 이메일 비동기 발송. 실패는 로그만 남긴다 (호출자 흐름을 막지 않음).
+func notifyUser(db *sql.DB, userID int, kind, title, body, link string) {
+	if _, err := db.Exec(`
+		INSERT INTO notifications (user_id, kind, title, body, link) VALUES ($1, $2, $3, $4, $5)
+	`, userID, kind, title, body, link); err != nil {
+		log.Printf("[notifications] insert failed (user=%d, kind=%s): %v", userID, kind, err)
+		return
+	}
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[notifications] email panicked (user=%d): %v", userID, r)
+			}
+		}()
+		var email string
+		if err := db.QueryRow("SELECT email FROM users WHERE id = $1", userID).Scan(&email); err != nil {
+			return
+		}
+		msg := alertMessage{UserEmail: email, Subject: "[cmall] " + title, Body: body}
+		if err := alertChannelFor("email").Deliver(msg); err != nil && err != errChannelNotConfigured {
+			log.Printf("[notifications] email failed (user=%d, kind=%s): %v", userID, kind, err)
+		}
+	}()
+}
+
+// GetMyNotifications — GET /api/v1/me/notifications?unread=true&limit= (JWT)
+func GetMyNotifications(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if limit <= 0 || limit > 200 {
+			limit = 50
+		}
+		query := `SELECT id, kind, title, body, link, read_at, created_at FROM notifications WHERE user_id = $1`
+		if c.Query("unread") == "true" {
+			query += " AND read_at IS NULL"
+		}
+		rows, err := db.Query(query+" ORDER BY created_at DESC, id DESC LIMIT $2", userID, limit)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer rows.Close()
+		items := []Notification{}
+		for rows.Next() {
+			var n Notification
+			if err := rows.Scan(&n.ID, &n.Kind, &n.Title, &n.Body, &n.Link, &n.ReadAt, &n.CreatedAt); err != nil {
+				respondDBError(c, err)
+				return
+			}
+			items = append(items, n)
+		}
+		var unread int
+		if err := db.QueryRow(
+			"SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL", userID,
+		).Scan(&unread); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"notifications": items, "unread": unread})
+	}
+}
+
+// MarkNotificationsRead — PUT /api/v1/me/notifications/read (JWT)
+// body: {ids: [..]} — 비우면 전체 읽음 처리.
+func MarkNotificationsRead(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		var req struct {
+			IDs []int64 `json:"ids"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query := "UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL"
+		args := []interface{}{userID}
+		if len(req.IDs) > 0 {
+			query += " AND id = ANY(" + appendArg(&args, pq.Array(req.IDs)) + ")"
+		}
+		res, err := db.Exec(query, args...)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		n, _ := res.RowsAffected()
+		c.JSON(http.StatusOK, gin.H{"updated": n})
+	}
+}
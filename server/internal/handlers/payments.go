@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"cmall_dd/internal/fxrate"
 	"cmall_dd/internal/models"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
@@ -123,8 +124,63 @@ func registerWithGateway(referenceID, walletAddress string, amountUsdc int64) {
 	log.Printf("[payments] register OK (ref=%s, wallet=%s, amount=%d)", referenceID, walletAddress, amountUsdc)
 }
 
+// onPaymentPaid — 결제가 paid로 바뀐 직후 1회 호출되는 후처리
+// (관리자 무료 구매 생성, GetPayment의 pending→paid 승격).
+func onPaymentPaid(db *sql.DB, payment *models.Payment) {
+	invalidateRecommendations(payment.UserID)
+	if err := issueLicenseForPayment(db, payment); err != nil {
+		log.Printf("[payments] license issuance failed (ref=%s, backfill job will retry): %v", payment.ReferenceID, err)
+	}
+}
+
+// onPaymentRefunded — paid → refunded 전환 직후 후처리 (라이선스 폐기).
+// 다운로드/업데이트 확인은 status = 'paid'를 조건으로 하므로 자동으로 막힌다.
+func onPaymentRefunded(db *sql.DB, payment *models.Payment) {
+	invalidateRecommendations(payment.UserID)
+	if err := revokeLicensesForPayment(db, payment.ID, "refund"); err != nil {
+		log.Printf("[payments] license revocation failed (ref=%s): %v", payment.ReferenceID, err)
+	}
+}
+
+// RefundPayment — POST /api/v1/admin/payments/:referenceId/refund (관리자)
+// 온체인 USDC 반환은 운영자가 별도로 처리하고, 여기서는 상태를 refunded로 기록한다.
+func RefundPayment(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		if !isAdminUser(db, userID.(int)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			return
+		}
+		var payment models.Payment
+		err := db.QueryRow(`
+			UPDATE payments SET status = 'refunded', updated_at = NOW()
+			WHERE reference_id = $1 AND status = 'paid'
+			RETURNING id, user_id, order_id, reference_id, wallet_address, amount_usdc, status, COALESCE(tx_hash, ''), chain_id, created_at, updated_at
+		`, c.Param("referenceId")).Scan(
+			&payment.ID, &payment.UserID, &payment.OrderID, &payment.ReferenceID, &payment.WalletAddress,
+			&payment.AmountUsdc, &payment.Status, &payment.TxHash, &payment.ChainID,
+			&payment.CreatedAt, &payment.UpdatedAt,
+		)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "paid payment not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		onPaymentRefunded(db, &payment)
+		c.JSON(http.StatusOK, payment)
+	}
+}
+
 // CreatePayment — POST /api/v1/payments/create (JWT)
-// 상품 → 결제 레코드 생성 (pending). amount_usdc는 products.crypto_price_usdc 사용.
+// 상품 → 결제 레코드 생성 (pending). amount_usdc는 products.crypto_price_usdc 사용,
+// couponCode가 있으면 할인 후 금액 (coupons.go).
 func CreatePayment(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, exists := c.Get("userId")
@@ -142,9 +198,10 @@ func CreatePayment(db *sql.DB) gin.HandlerFunc {
 
 		// 상품 + USDC 가격 조회
 		var cryptoPrice int64
+		var category string
 		err := db.QueryRow(
-			"SELECT crypto_price_usdc FROM products WHERE id = $1", req.ProductID,
-		).Scan(&cryptoPrice)
+			"SELECT crypto_price_usdc, COALESCE(category, '') FROM products WHERE id = $1 AND is_active = true", req.ProductID,
+		).Scan(&cryptoPrice, &category)
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
 			return
@@ -184,6 +241,7 @@ func CreatePayment(db *sql.DB) gin.HandlerFunc {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create free payment"})
 				return
 			}
+			onPaymentPaid(db, &payment)
 			c.JSON(http.StatusCreated, models.PaymentResponse{
 				Payment:         payment,
 				ContractAddress: os.Getenv("PAYMENT_CONTRACT_ADDRESS"),
@@ -240,13 +298,55 @@ func CreatePayment(db *sql.DB) gin.HandlerFunc {
 		}
 		referenceID := "pay_" + ref
 
+		// 영수증용 환율 — 못 구해도 결제는 진행 (krw_per_usdc/amount_krw NULL)
+		var krwPerUSDC *float64
+		if rate, err := currentExchangeRate(db, false); err == nil {
+			krwPerUSDC = &rate.KRWPerUSDC
+		} else {
+			log.Printf("[payments] exchange rate unavailable (ref=%s): %v", referenceID, err)
+		}
+
+		// 쿠폰 적용과 결제 INSERT, 사용 기록은 한 트랜잭션 — 쿠폰 행 잠금으로 한도 초과를 막는다
+		tx, err := db.Begin()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create payment"})
+			return
+		}
+		defer tx.Rollback()
+
+		amount := cryptoPrice
+		var applied *appliedCoupon
+		var couponID *int
+		if strings.TrimSpace(req.CouponCode) != "" {
+			applied, err = applyCoupon(tx, req.CouponCode, userID.(int), req.ProductID, category, cryptoPrice)
+			if err != nil {
+				respondCouponError(c, err)
+				return
+			}
+			amount -= applied.Discount
+			couponID = &applied.Coupon.ID
+		}
+		// 100% 할인은 온체인 결제 없이 즉시 paid
+		status := "pending"
+		if amount == 0 {
+			status = "paid"
+		}
+
+		var amountKRW *int64
+		if krwPerUSDC != nil {
+			v := fxrate.USDCMicroToKRW(amount, *krwPerUSDC)
+			amountKRW = &v
+		}
+
 		var payment models.Payment
 		chainID := envInt("CHAIN_ID", 84532)
-		err = db.QueryRow(`
-			INSERT INTO payments (user_id, order_id, reference_id, wallet_address, amount_usdc, status, chain_id)
-			VALUES ($1, $2, $3, $4, $5, 'pending', $6)
+		err = tx.QueryRow(`
+			INSERT INTO payments (user_id, order_id, reference_id, wallet_address, amount_usdc, status, chain_id, coupon_id, discount_usdc,
+			                      krw_per_usdc, amount_krw)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 			RETURNING id, user_id, order_id, reference_id, wallet_address, amount_usdc, status, COALESCE(tx_hash, '') AS tx_hash, chain_id, created_at, updated_at
-		`, userID, req.ProductID, referenceID, strings.ToLower(wallet), cryptoPrice, chainID).Scan(
+		`, userID, req.ProductID, referenceID, strings.ToLower(wallet), amount, status, chainID, couponID, cryptoPrice-amount,
+			krwPerUSDC, amountKRW).Scan(
 			&payment.ID, &payment.UserID, &payment.OrderID, &payment.ReferenceID, &payment.WalletAddress,
 			&payment.AmountUsdc, &payment.Status, &payment.TxHash, &payment.ChainID,
 			&payment.CreatedAt, &payment.UpdatedAt,
@@ -255,15 +355,40 @@ func CreatePayment(db *sql.DB) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create payment"})
 			return
 		}
+		if applied != nil {
+			if err := recordCouponRedemption(tx, applied, payment.UserID, payment.ID); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create payment"})
+				return
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create payment"})
+			return
+		}
 
-		// 결제 주문 사전등록 (dev-mock 게이트웨이; 온체인 registerOrder는 signer 연동 후)
-		registerWithGateway(payment.ReferenceID, payment.WalletAddress, payment.AmountUsdc)
-
-		c.JSON(http.StatusCreated, models.PaymentResponse{
+		resp := models.PaymentResponse{
 			Payment:         payment,
 			ContractAddress: os.Getenv("PAYMENT_CONTRACT_ADDRESS"),
 			TokenAddress:    os.Getenv("USDC_TOKEN_ADDRESS"),
-		})
+		}
+		if krwPerUSDC != nil {
+			resp.KRWPerUSDC = *krwPerUSDC
+			resp.AmountKRW = *amountKRW
+		}
+		if applied != nil {
+			resp.CouponCode = applied.Coupon.Code
+			resp.OriginalAmountUsdc = applied.OriginalAmount
+			resp.DiscountUsdc = applied.Discount
+		}
+
+		if payment.Status == "paid" {
+			onPaymentPaid(db, &payment)
+		} else {
+			// 결제 주문 사전등록 (dev-mock 게이트웨이; 온체인 registerOrder는 signer 연동 후) — 할인 후 금액
+			registerWithGateway(payment.ReferenceID, payment.WalletAddress, payment.AmountUsdc)
+		}
+
+		c.JSON(http.StatusCreated, resp)
 	}
 }
 
@@ -311,8 +436,9 @@ func GetPayment(db *sql.DB) gin.HandlerFunc {
 					c.JSON(http.StatusOK, gin.H{"payment": payment, "verifyError": "on-chain amount or payer does not match the recorded payment"})
 					return
 				}
-				_, err = db.Exec(
-					"UPDATE payments SET status = 'paid', tx_hash = $1, updated_at = NOW() WHERE reference_id = $2",
+				// pending 조건부 승격 — 동시 폴링에서도 paid 후처리는 1회만 실행된다
+				res, err := db.Exec(
+					"UPDATE payments SET status = 'paid', tx_hash = $1, updated_at = NOW() WHERE reference_id = $2 AND status = 'pending'",
 					txHash, payment.ReferenceID,
 				)
 				if err != nil {
@@ -321,6 +447,9 @@ func GetPayment(db *sql.DB) gin.HandlerFunc {
 				}
 				payment.Status = paymentPaid
 				payment.TxHash = txHash
+				if n, _ := res.RowsAffected(); n == 1 {
+					onPaymentPaid(db, &payment)
+				}
 			}
 		}
 
@@ -339,7 +468,7 @@ func MyPurchases(db *sql.DB) gin.HandlerFunc {
 		}
 		rows, err := db.Query(`
 			SELECT p.reference_id, p.wallet_address, p.amount_usdc, p.status, COALESCE(p.tx_hash, ''),
-			       p.created_at,
+			       p.created_at, p.krw_per_usdc, p.amount_krw,
 			       pr.id, pr.name, pr.request_type,
 			       COALESCE(a.id, 0), COALESCE(a.status, ''), COALESCE(a.result_json, ''),
 			       COALESCE(a.updated_at, p.created_at)
@@ -367,6 +496,8 @@ func MyPurchases(db *sql.DB) gin.HandlerFunc {
 			Status          string    `json:"status"`
 			TxHash          string    `json:"txHash"`
 			PurchasedAt     time.Time `json:"purchasedAt"`
+			KRWPerUSDC      *float64  `json:"krwPerUsdc,omitempty"` // 결제 시점 환율
+			AmountKRW       *int64    `json:"amountKrw,omitempty"`
 			ProductID       int       `json:"productId"`
 			ProductName     string    `json:"productName"`
 			RequestType     string    `json:"requestType"`
@@ -379,7 +510,7 @@ func MyPurchases(db *sql.DB) gin.HandlerFunc {
 		for rows.Next() {
 			var it purchaseItem
 			if err := rows.Scan(&it.ReferenceID, &it.WalletAddress, &it.AmountUsdc, &it.Status, &it.TxHash,
-				&it.PurchasedAt, &it.ProductID, &it.ProductName, &it.RequestType,
+				&it.PurchasedAt, &it.KRWPerUSDC, &it.AmountKRW, &it.ProductID, &it.ProductName, &it.RequestType,
 				&it.AnalysisID, &it.AnalysisStatus, &it.ResultJSON, &it.AnalysisUpdated); err != nil {
 				log.Printf("[payments] my-purchases scan failed: %v", err)
 				continue
@@ -398,7 +529,7 @@ func GetAgents(db *sql.DB) gin.HandlerFunc {
 					SELECT id, seller_id, name, price, COALESCE(original_price, 0), COALESCE(image, ''),
 					       category, product_type, request_type, COALESCE(version, ''), COALESCE(download_url, ''),
 					       COALESCE(file_size, ''), COALESCE(license_key, ''), COALESCE(description, ''),
-					       COALESCE(features, ''), COALESCE(system_requirements, ''), crypto_price_usdc,
+					       COALESCE(features::text, ''), COALESCE(system_requirements::text, ''), crypto_price_usdc,
 					       created_at, updated_at
 					FROM products
 					WHERE crypto_price_usdc > 0 AND is_active = true
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"cmall_dd/internal/models"
+	"cmall_dd/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ── 상품 임베딩 / 시맨틱 검색 (pgvector) ───────────────────────────────────
+// products.embedding(vector(N), HNSW cosine)은 BuildEmbeddingText(이름·카테고리·설명)로 만든다.
+// 생성/수정 직후 비동기로 재임베딩하고, 실패·누락분은 백그라운드 잡이 채운다
+// (embedded_at이 updated_at과 다르거나 모델이 바뀐 행).
+// 임베더는 EMBEDDING_PROVIDER로 고른다 (utils.NewEmbedderFromEnv).
+
+// semanticBlendWeight — 시맨틱 점수 비중 (나머지는 키워드 일치 점수)
+const semanticBlendWeight = 0.7
+
+// semanticCandidateLimit — 벡터/키워드 각각에서 가져올 후보 수 (HNSW 인덱스 사용 구간)
+const semanticCandidateLimit = 200
+
+var (
+	productEmbedderOnce sync.Once
+	productEmbedder     utils.Embedder
+)
+
+func getProductEmbedder() utils.Embedder {
+	productEmbedderOnce.Do(func() {
+		productEmbedder = utils.NewEmbedderFromEnv()
+		log.Printf("[embeddings] using %s (dim=%d)", productEmbedder.Model(), productEmbedder.Dimension())
+	})
+	return productEmbedder
+}
+
+var (
+	vectorOnce      sync.Once
+	vectorAvailable bool
+)
+
+// hasVectorSupport — products.embedding 컬럼 존재 여부 (최초 1회 조회).
+// pgvector가 없으면 CreateTables가 컬럼을 만들지 못하므로 임베딩 작업을 건너뛴다.
+func hasVectorSupport(db *sql.DB) bool {
+	vectorOnce.Do(func() {
+		var n int
+		if err := db.QueryRow(`
+			SELECT COUNT(*) FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = 'products' AND column_name = 'embedding'
+		`).Scan(&n); err == nil {
+			vectorAvailable = n > 0
+		}
+	})
+	return vectorAvailable
+}
+
+// queueProductEmbedding — 상품 생성/수정 응답을 지연시키지 않도록 비동기 재임베딩
+func queueProductEmbedding(db *sql.DB, productID int) {
+	if !hasVectorSupport(db) {
+		return
+	}
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[embeddings] product %d panicked: %v", productID, r)
+			}
+		}()
+		if err := embedProduct(db, productID); err != nil {
+			log.Printf("[embeddings] product %d failed (backfill job will retry): %v", productID, err)
+		}
+	}()
+}
+
+// embedProduct — 상품 1건 임베딩. embedded_at에는 읽은 시점의 updated_at을 기록해,
+// 임베딩 도중 수정된 상품은 다음 백필에서 다시 처리되도록 한다.
+func embedProduct(db *sql.DB, productID int) error {
+	var name, category, description string
+	var updatedAt time.Time
+	err := db.QueryRow(`
+		SELECT name, COALESCE(category, ''), COALESCE(description, ''), updated_at
+		FROM products WHERE id = $1
+	`, productID).Scan(&name, &category, &description, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	embedder := getProductEmbedder()
+	vec, err := embedder.Embed(utils.BuildEmbeddingText(name, category, description, nil, nil, nil))
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(
+		"UPDATE products SET embedding = $1::vector, embedding_model = $2, embedded_at = $3 WHERE id = $4",
+		utils.EmbeddingToString(vec), embedder.Model(), updatedAt, productID,
+	)
+	return err
+}
+
+// runProductEmbeddingBackfill — 임베딩이 없거나 오래된 상품을 주기적으로 처리 (잡당 최대 50건)
+func runProductEmbeddingBackfill(db *sql.DB) error {
+	rows, err := db.Query(`
+		SELECT id FROM products
+		WHERE embedding IS NULL OR embedded_at IS DISTINCT FROM updated_at OR embedding_model IS DISTINCT FROM $1
+		ORDER BY updated_at DESC
+		LIMIT 50
+	`, getProductEmbedder().Model())
+	if err != nil {
+		return err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if err := embedProduct(db, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// respondSemanticProductSearch — /products/search?mode=semantic
+// 벡터 근접 후보와 전문 검색 후보를 합쳐 혼합 점수(0.7 × 코사인 유사도 +
+// 0.3 × 전문 검색 관련도, 최대 1)로 정렬한다.
+// 점수 정렬이므로 커서 대신 offset 페이지네이션만 지원한다.
+// 임베딩 제공자/pgvector를 쓸 수 없으면 키워드 검색으로 대체한다.
+func respondSemanticProductSearch(c *gin.Context, db *sql.DB, params productListParams) {
+	if params.Cursor != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cursor is not supported with mode=semantic; use offset"})
+		return
+	}
+	if params.Limit == 0 {
+		params.Limit = defaultProductPageSize
+	}
+
+	embedder := getProductEmbedder()
+	vec, err := embedder.Embed(params.Search)
+	if err != nil {
+		log.Printf("[embeddings] query embedding failed, falling back to keyword search: %v", err)
+		respondProductList(c, db, params)
+		return
+	}
+
+	filters := params
+	filters.Search, filters.text = "", nil
+	args := []interface{}{utils.EmbeddingToString(vec), embedder.Model()}
+	vecWhere := filters.whereClause(&args, false, false)
+	kwRank := params.text.rank(&args)
+	kwMatch := params.text.where(&args)
+	kwWhere := filters.whereClause(&args, false, false)
+	limit := strconv.Itoa(semanticCandidateLimit)
+	weight := strconv.FormatFloat(semanticBlendWeight, 'f', 2, 64)
+	invWeight := strconv.FormatFloat(1-semanticBlendWeight, 'f', 2, 64)
+
+	query := `
+		WITH vec AS (
+			SELECT p.id, 1 - (p.embedding <=> $1::vector) AS vscore
+			FROM products p
+			WHERE p.embedding IS NOT NULL AND p.embedding_model = $2 AND ` + vecWhere + `
+			ORDER BY p.embedding <=> $1::vector
+			LIMIT ` + limit + `
+		), kw AS (
+			SELECT p.id, LEAST(` + kwRank + `, 1) AS kscore
+			FROM products p
+			WHERE ` + kwMatch + ` AND ` + kwWhere + `
+			ORDER BY kscore DESC
+			LIMIT ` + limit + `
+		), cand AS (
+			SELECT COALESCE(vec.id, kw.id) AS id,
+			       ` + weight + ` * COALESCE(vec.vscore, 0) + ` + invWeight + ` * COALESCE(kw.kscore, 0) AS score
+			FROM vec FULL OUTER JOIN kw ON kw.id = vec.id
+		)
+	` + productListSelect + `
+		JOIN cand ON cand.id = p.id
+		ORDER BY cand.score DESC, p.id DESC
+		LIMIT ` + strconv.Itoa(params.Limit+1) + ` OFFSET ` + strconv.Itoa(params.Offset)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		log.Printf("[embeddings] semantic query failed, falling back to keyword search: %v", err)
+		respondProductList(c, db, params)
+		return
+	}
+	defer rows.Close()
+
+	products := []models.Product{}
+	for rows.Next() {
+		p, err := scanListedProduct(rows)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		sanitizePublicProduct(&p)
+		products = append(products, p)
+	}
+	if err := rows.Err(); err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	hasMore := len(products) > params.Limit
+	if hasMore {
+		products = products[:params.Limit]
+	}
+	if err := applySearchHighlights(db, params.text, products); err != nil {
+		respondDBError(c, err)
+		return
+	}
+	if !params.Envelope {
+		c.JSON(http.StatusOK, products)
+		return
+	}
+	// 시맨틱 모드: 후보 집합 기반이므로 total/facets 대신 다음 offset만 안내
+	resp := gin.H{"items": products, "nextCursor": nil, "mode": "semantic"}
+	if hasMore {
+		resp["nextOffset"] = params.Offset + params.Limit
+	}
+	c.JSON(http.StatusOK, resp)
+}
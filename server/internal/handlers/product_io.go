@@ -0,0 +1,877 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"cmall_dd/internal/models"
+	"cmall_dd/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// ── 카탈로그 가져오기/내보내기 ───────────────────────────────────────────
+// 관리자가 상품 카탈로그를 CSV/JSON으로 내보내고 다시 가져온다 (시드 SQL의 고정 ID/seller_id 대체).
+// 행은 숫자 ID가 아니라 sku로 식별하고, 판매자는 seller_email로 지정한다 (비우면 가져오는 관리자).
+// 가져오기는 기본이 dry-run — 행별 diff(create/update/unchanged)와 검증 오류만 돌려주고,
+// dryRun=false일 때 오류 없는 행을 한 트랜잭션으로 반영한다 (오류 행은 건너뜀).
+// PRODUCT_IMPORT_SYNC_MAX_ROWS를 넘거나 async=true면 product_import_jobs에 넣고 잡이 처리한다.
+// 파일에 없는 열은 기존 값을 유지하고, 있는 열의 빈 값은 NULL 가능 열이면 NULL로 저장한다.
+// license_key는 내보내지도 가져오지도 않는다.
+
+const (
+	ioText = iota
+	ioNullableText
+	ioInt
+	ioNullableInt
+	ioJSON // JSONB (features, system_requirements) — 빈 값은 NULL
+)
+
+type productIOColumn struct {
+	name   string
+	expr   string // 내보내기/비교용 SELECT 식 (text)
+	kind   int
+	maxLen int
+}
+
+// productIOColumns — 내보내기 열 순서이자 가져오기 허용 열 (sku, seller_email은 특수 처리)
+var productIOColumns = []productIOColumn{
+	{name: "sku", expr: "p.sku", kind: ioText, maxLen: 64},
+	{name: "name", expr: "p.name", kind: ioText, maxLen: 255},
+	{name: "seller_email", expr: "COALESCE(u.email, '')", kind: ioText, maxLen: 255},
+	{name: "product_type", expr: "p.product_type", kind: ioText, maxLen: 50},
+	{name: "request_type", expr: "p.request_type", kind: ioText, maxLen: 32},
+	{name: "category", expr: "COALESCE(p.category, '')", kind: ioNullableText, maxLen: 100},
+	{name: "status", expr: "p.status", kind: ioText, maxLen: 16},
+	{name: "price", expr: "p.price::text", kind: ioInt},
+	{name: "original_price", expr: "COALESCE(p.original_price::text, '')", kind: ioNullableInt},
+	{name: "crypto_price_usdc", expr: "p.crypto_price_usdc::text", kind: ioInt},
+	{name: "price_sync", expr: "p.price_sync", kind: ioText, maxLen: 8},
+	{name: "version", expr: "COALESCE(p.version, '')", kind: ioNullableText, maxLen: 50},
+	{name: "download_url", expr: "COALESCE(p.download_url, '')", kind: ioNullableText, maxLen: 500},
+	{name: "file_size", expr: "COALESCE(p.file_size, '')", kind: ioNullableText, maxLen: 50},
+	{name: "image", expr: "COALESCE(p.image, '')", kind: ioNullableText, maxLen: 500},
+	{name: "description", expr: "COALESCE(p.description, '')", kind: ioNullableText, maxLen: 100000},
+	{name: "features", expr: "COALESCE(p.features::text, '')", kind: ioJSON, maxLen: 100000},
+	{name: "system_requirements", expr: "COALESCE(p.system_requirements::text, '')", kind: ioJSON, maxLen: 100000},
+}
+
+var productIOColumnByName = func() map[string]productIOColumn {
+	m := make(map[string]productIOColumn, len(productIOColumns))
+	for _, col := range productIOColumns {
+		m[col.name] = col
+	}
+	return m
+}()
+
+// productTypeAllowlist — CreateProduct/UpdateProduct와 같은 타입 목록 (가져오기는 관리자 전용이라 admin-only 타입 포함)
+var productTypeAllowlist = map[string]bool{
+	"program": true, "code": true, "instruction": true,
+	"diary": true, "ebook": true, "software": true,
+}
+
+var (
+	skuPattern         = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+	autoSKUPattern     = regexp.MustCompile(`^p[0-9]+$`)
+	requestTypePattern = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+)
+
+func productIOSelect() string {
+	exprs := make([]string, len(productIOColumns))
+	for i, col := range productIOColumns {
+		exprs[i] = col.expr
+	}
+	return "SELECT " + strings.Join(exprs, ", ") + " FROM products p LEFT JOIN users u ON u.id = p.seller_id"
+}
+
+// ExportProducts — GET /api/v1/admin/products/export?format=csv|json&status=&includeDeleted= (관리자)
+func ExportProducts(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c, db) {
+			return
+		}
+		format := strings.ToLower(c.DefaultQuery("format", "csv"))
+		if format != "csv" && format != "json" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or json"})
+			return
+		}
+		query := productIOSelect() + " WHERE 1=1"
+		args := []interface{}{}
+		if c.Query("includeDeleted") != "true" {
+			query += " AND p.deleted_at IS NULL"
+		}
+		if status := c.Query("status"); status != "" {
+			if !productStatuses[status] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "status must be draft, pending_review, published or archived"})
+				return
+			}
+			query += " AND p.status = " + appendArg(&args, status)
+		}
+		rows, err := db.Query(query+" ORDER BY p.sku", args...)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer rows.Close()
+
+		records := [][]string{}
+		for rows.Next() {
+			rec := make([]string, len(productIOColumns))
+			ptrs := make([]interface{}, len(rec))
+			for i := range rec {
+				ptrs[i] = &rec[i]
+			}
+			if err := rows.Scan(ptrs...); err != nil {
+				respondDBError(c, err)
+				return
+			}
+			records = append(records, rec)
+		}
+		if err := rows.Err(); err != nil {
+			respondDBError(c, err)
+			return
+		}
+
+		filename := "products-" + time.Now().Format("20060102") + "." + format
+		c.Header("Content-Disposition", storage.ContentDisposition(filename))
+		if format == "json" {
+			out := make([]map[string]interface{}, 0, len(records))
+			for _, rec := range records {
+				out = append(out, exportJSONRecord(rec))
+			}
+			c.JSON(http.StatusOK, out)
+			return
+		}
+
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		header := make([]string, len(productIOColumns))
+		for i, col := range productIOColumns {
+			header[i] = col.name
+		}
+		_ = w.Write(header)
+		_ = w.WriteAll(records)
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+	}
+}
+
+// exportJSONRecord — 숫자 열은 number, JSONB 열은 배열/객체, 빈 NULL 가능 열은 null
+func exportJSONRecord(rec []string) map[string]interface{} {
+	obj := make(map[string]interface{}, len(rec))
+	for i, col := range productIOColumns {
+		v := rec[i]
+		switch {
+		case v == "" && (col.kind == ioNullableText || col.kind == ioNullableInt || col.kind == ioJSON):
+			obj[col.name] = nil
+		case col.kind == ioJSON:
+			obj[col.name] = json.RawMessage(v)
+		case col.kind == ioInt || col.kind == ioNullableInt:
+			n, _ := strconv.ParseInt(v, 10, 64)
+			obj[col.name] = n
+		default:
+			obj[col.name] = v
+		}
+	}
+	return obj
+}
+
+// importRecord — 파싱된 1행 (Line: CSV는 파일 줄 번호, JSON은 1부터 시작하는 배열 순번)
+type importRecord struct {
+	Line   int
+	Fields map[string]string
+}
+
+// parseProductImport — CSV(헤더 필수) 또는 JSON(배열 또는 {"products": [...]}) 파싱.
+// 파일 수준 오류(형식, 알 수 없는 열)만 error로 돌려주고 값 검증은 행별로 한다.
+func parseProductImport(format string, data []byte) ([]importRecord, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	switch format {
+	case "csv":
+		r := csv.NewReader(bytes.NewReader(data))
+		r.FieldsPerRecord = -1
+		header, err := r.Read()
+		if err == io.EOF {
+			return nil, errors.New("empty file")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		for i, h := range header {
+			header[i] = strings.ToLower(strings.TrimSpace(h))
+			if _, ok := productIOColumnByName[header[i]]; !ok {
+				return nil, fmt.Errorf("unknown column %q", h)
+			}
+		}
+		records := []importRecord{}
+		for {
+			row, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid CSV: %w", err)
+			}
+			line, _ := r.FieldPos(0)
+			if len(row) == 1 && strings.TrimSpace(row[0]) == "" {
+				continue
+			}
+			fields := make(map[string]string, len(header))
+			for i, h := range header {
+				if i < len(row) {
+					fields[h] = row[i]
+				}
+			}
+			records = append(records, importRecord{Line: line, Fields: fields})
+		}
+		return records, nil
+
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var raw interface{}
+		if err := dec.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		if obj, ok := raw.(map[string]interface{}); ok {
+			raw = obj["products"]
+		}
+		items, ok := raw.([]interface{})
+		if !ok {
+			return nil, errors.New(`JSON must be an array of products or {"products": [...]}`)
+		}
+		records := make([]importRecord, 0, len(items))
+		for i, item := range items {
+			obj, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("item %d is not an object", i+1)
+			}
+			fields := make(map[string]string, len(obj))
+			for k, v := range obj {
+				key := strings.ToLower(strings.TrimSpace(k))
+				if _, ok := productIOColumnByName[key]; !ok {
+					return nil, fmt.Errorf("item %d: unknown field %q", i+1, k)
+				}
+				switch val := v.(type) {
+				case nil:
+					fields[key] = ""
+				case string:
+					fields[key] = val
+				case json.Number:
+					fields[key] = val.String()
+				case []interface{}, map[string]interface{}:
+					if productIOColumnByName[key].kind != ioJSON {
+						return nil, fmt.Errorf("item %d: field %q must be a string or number", i+1, k)
+					}
+					b, _ := json.Marshal(val)
+					fields[key] = string(b)
+				default:
+					return nil, fmt.Errorf("item %d: field %q must be a string or number", i+1, k)
+				}
+			}
+			records = append(records, importRecord{Line: i + 1, Fields: fields})
+		}
+		return records, nil
+	}
+	return nil, errors.New("format must be csv or json")
+}
+
+// validateImportRecord — 값 정규화 + 검증. values에는 파일에 있는 열만 담긴다 (sku 포함).
+func validateImportRecord(rec importRecord) (map[string]string, []string) {
+	values := make(map[string]string, len(rec.Fields))
+	var errs []string
+	for name, raw := range rec.Fields {
+		col := productIOColumnByName[name]
+		v := strings.TrimSpace(raw)
+		if col.maxLen > 0 && len([]rune(v)) > col.maxLen {
+			errs = append(errs, fmt.Sprintf("%s must be at most %d characters", name, col.maxLen))
+			continue
+		}
+		switch col.kind {
+		case ioJSON:
+			canonical, err := canonicalSpecJSON(name, v)
+			if err != nil {
+				errs = append(errs, name+": "+err.Error())
+				continue
+			}
+			v = canonical
+		case ioInt, ioNullableInt:
+			if v == "" && col.kind == ioNullableInt {
+				break
+			}
+			n, err := strconv.ParseInt(v, 10, 64)
+			limit := int64(100000000) // products.price와 같은 상한 (KRW)
+			if name == "crypto_price_usdc" {
+				limit = 1000000000000
+			}
+			if err != nil || n < 0 || n > limit {
+				errs = append(errs, fmt.Sprintf("%s must be an integer between 0 and %d", name, limit))
+				continue
+			}
+			v = strconv.FormatInt(n, 10)
+		}
+
+		switch name {
+		case "sku":
+			v = strings.ToLower(v)
+			if !skuPattern.MatchString(v) {
+				errs = append(errs, "sku must be 1-64 characters of a-z, 0-9, '.', '_' or '-'")
+				continue
+			}
+		case "name":
+			if v == "" {
+				errs = append(errs, "name must not be empty")
+				continue
+			}
+		case "seller_email":
+			v = strings.ToLower(v)
+			if v != "" && !strings.Contains(v, "@") {
+				errs = append(errs, "seller_email must be an email address")
+				continue
+			}
+		case "product_type":
+			v = strings.ToLower(v)
+			if !productTypeAllowlist[v] {
+				errs = append(errs, "product_type must be one of program, code, instruction, diary, ebook, software")
+				continue
+			}
+		case "request_type":
+			if !requestTypePattern.MatchString(v) {
+				errs = append(errs, "request_type must be 1-32 characters of a-z, 0-9 or '_'")
+				continue
+			}
+		case "status":
+			v = strings.ToLower(v)
+			if !productStatuses[v] {
+				errs = append(errs, "status must be draft, pending_review, published or archived")
+				continue
+			}
+		case "price_sync":
+			if v == "" {
+				v = priceSyncManual
+			}
+			ps, ok := normalizePriceSync(v)
+			if !ok {
+				errs = append(errs, "price_sync must be manual, krw or usdc")
+				continue
+			}
+			v = ps
+		case "download_url":
+			if v != "" {
+				if msg := validateDownloadURL(v); msg != "" {
+					errs = append(errs, "download_url: "+msg)
+					continue
+				}
+			}
+		}
+		values[name] = v
+	}
+	if _, ok := rec.Fields["sku"]; !ok {
+		errs = append(errs, "sku is required")
+	}
+	return values, errs
+}
+
+type importChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// importRowResult — 행별 계획/결과
+type importRowResult struct {
+	Line      int                     `json:"line"`
+	SKU       string                  `json:"sku,omitempty"`
+	Action    string                  `json:"action"` // create | update | unchanged | error
+	ProductID int                     `json:"productId,omitempty"`
+	Changes   map[string]importChange `json:"changes,omitempty"`
+	Errors    []string                `json:"errors,omitempty"`
+
+	values   map[string]string
+	sellerID int
+}
+
+// importReport — 가져오기 응답 (dry-run이면 계획만)
+type importReport struct {
+	DryRun  bool               `json:"dryRun"`
+	Applied bool               `json:"applied"`
+	Summary map[string]int     `json:"summary"`
+	Rows    []*importRowResult `json:"rows"`
+}
+
+// diffImportRow — 기존 값(existing, productIOColumns 이름 기준)과 비교해 행의 action/변경 목록을 채운다.
+// existing == nil이면 신규 생성.
+func diffImportRow(row *importRowResult, existing map[string]string) {
+	row.Changes = map[string]importChange{}
+	if existing == nil {
+		for _, req := range []string{"name", "product_type", "price"} {
+			if _, ok := row.values[req]; !ok {
+				row.Errors = append(row.Errors, req+" is required for new products")
+			}
+		}
+		for name, v := range row.values {
+			if name != "sku" && v != "" {
+				row.Changes[name] = importChange{To: v}
+			}
+		}
+		row.Action = "create"
+		return
+	}
+	for name, v := range row.values {
+		if name == "sku" || (name == "seller_email" && v == "") {
+			continue
+		}
+		if old := existing[name]; old != v && !(name == "seller_email" && strings.EqualFold(old, v)) {
+			row.Changes[name] = importChange{From: old, To: v}
+		}
+	}
+	row.Action = "update"
+	if len(row.Changes) == 0 {
+		row.Action = "unchanged"
+		row.Changes = nil
+	}
+}
+
+// planProductImport — 검증 + 기존 상품/판매자 조회 + diff
+func planProductImport(db *sql.DB, records []importRecord, adminID int) (*importReport, error) {
+	report := &importReport{DryRun: true, Summary: map[string]int{"create": 0, "update": 0, "unchanged": 0, "error": 0}}
+	skus := []string{}
+	emails := []string{}
+	for _, rec := range records {
+		values, errs := validateImportRecord(rec)
+		row := &importRowResult{Line: rec.Line, SKU: values["sku"], Errors: errs, values: values, sellerID: adminID}
+		report.Rows = append(report.Rows, row)
+		if row.SKU != "" {
+			skus = append(skus, row.SKU)
+		}
+		if e := values["seller_email"]; e != "" {
+			emails = append(emails, e)
+		}
+	}
+
+	existing := map[string]map[string]string{}
+	existingIDs := map[string]int{}
+	deleted := map[string]bool{}
+	if len(skus) > 0 {
+		rows, err := db.Query(
+			strings.Replace(productIOSelect(), "SELECT ", "SELECT p.id, p.deleted_at IS NOT NULL, ", 1)+" WHERE p.sku = ANY($1)",
+			pq.Array(skus),
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id int
+			var isDeleted bool
+			rec := make([]string, len(productIOColumns))
+			ptrs := []interface{}{&id, &isDeleted}
+			for i := range rec {
+				ptrs = append(ptrs, &rec[i])
+			}
+			if err := rows.Scan(ptrs...); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			m := make(map[string]string, len(rec))
+			for i, col := range productIOColumns {
+				m[col.name] = rec[i]
+				if col.kind == ioJSON {
+					// 비교를 위해 가져오기 값과 같은 형태로 (검증 전 레거시 값이면 원문 유지)
+					if canonical, err := canonicalSpecJSON(col.name, rec[i]); err == nil {
+						m[col.name] = canonical
+					}
+				}
+			}
+			existing[m["sku"]] = m
+			existingIDs[m["sku"]] = id
+			deleted[m["sku"]] = isDeleted
+		}
+		rows.Close()
+	}
+
+	sellers := map[string]int{}
+	if len(emails) > 0 {
+		rows, err := db.Query(`SELECT id, LOWER(email) FROM users WHERE LOWER(email) = ANY($1)`, pq.Array(emails))
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id int
+			var email string
+			if err := rows.Scan(&id, &email); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			sellers[email] = id
+		}
+		rows.Close()
+	}
+
+	seen := map[string]int{}
+	for _, row := range report.Rows {
+		if row.SKU != "" {
+			if first, dup := seen[row.SKU]; dup {
+				row.Errors = append(row.Errors, fmt.Sprintf("duplicate sku (first at line %d)", first))
+			} else {
+				seen[row.SKU] = row.Line
+			}
+			if deleted[row.SKU] {
+				row.Errors = append(row.Errors, "sku belongs to a deleted product")
+			}
+			// 'p<id>'는 sku 없이 생성되는 상품의 자동 sku — 새 상품이 가져가면 이후 자동 생성이 UNIQUE 위반
+			if _, ok := existing[row.SKU]; !ok && autoSKUPattern.MatchString(row.SKU) {
+				row.Errors = append(row.Errors, "sku p<number> is reserved for auto-generated SKUs")
+			}
+		}
+		if e := row.values["seller_email"]; e != "" {
+			if id, ok := sellers[e]; ok {
+				row.sellerID = id
+			} else {
+				row.Errors = append(row.Errors, "seller_email does not match any user")
+			}
+		}
+		if len(row.Errors) == 0 {
+			diffImportRow(row, existing[row.SKU])
+			row.ProductID = existingIDs[row.SKU]
+		}
+		if len(row.Errors) > 0 {
+			row.Action = "error"
+			row.Changes = nil
+		}
+		report.Summary[row.Action]++
+	}
+	return report, nil
+}
+
+// importSQLValue — 열 종류에 맞는 DB 값 (NULL 가능 열의 빈 값은 NULL)
+func importSQLValue(col productIOColumn, v string) interface{} {
+	if v == "" && (col.kind == ioNullableText || col.kind == ioNullableInt || col.kind == ioJSON) {
+		return nil
+	}
+	if col.kind == ioInt || col.kind == ioNullableInt {
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	}
+	return v
+}
+
+// applyProductImport — 오류 없는 create/update 행을 한 트랜잭션으로 반영
+func applyProductImport(db *sql.DB, report *importReport) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, row := range report.Rows {
+		switch row.Action {
+		case "create":
+			cols := []string{"sku", "seller_id"}
+			args := []interface{}{row.SKU, row.sellerID}
+			placeholders := []string{"$1", "$2"}
+			hasStatus := false
+			for _, col := range productIOColumns {
+				v, ok := row.values[col.name]
+				if !ok || col.name == "sku" || col.name == "seller_email" {
+					continue
+				}
+				hasStatus = hasStatus || col.name == "status"
+				cols = append(cols, col.name)
+				placeholders = append(placeholders, appendArg(&args, importSQLValue(col, v)))
+			}
+			if !hasStatus {
+				cols = append(cols, "status")
+				placeholders = append(placeholders, appendArg(&args, productStatusPublished))
+			}
+			err := tx.QueryRow(
+				"INSERT INTO products ("+strings.Join(cols, ", ")+") VALUES ("+strings.Join(placeholders, ", ")+") RETURNING id",
+				args...,
+			).Scan(&row.ProductID)
+			if err != nil {
+				return fmt.Errorf("line %d (%s): %w", row.Line, row.SKU, err)
+			}
+		case "update":
+			query := "UPDATE products SET updated_at = NOW()"
+			args := []interface{}{}
+			for name, change := range row.Changes {
+				if name == "seller_email" {
+					query += ", seller_id = " + appendArg(&args, row.sellerID)
+					continue
+				}
+				query += ", " + name + " = " + appendArg(&args, importSQLValue(productIOColumnByName[name], change.To))
+				if name == "image" {
+					query += ", image_artifact_id = NULL" // URL을 직접 바꾸면 업로드 이미지 참조 해제
+				}
+			}
+			query += " WHERE id = " + appendArg(&args, row.ProductID) + " AND deleted_at IS NULL"
+			if _, err := tx.Exec(query, args...); err != nil {
+				return fmt.Errorf("line %d (%s): %w", row.Line, row.SKU, err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	report.DryRun = false
+	report.Applied = true
+	return nil
+}
+
+// runProductImport — 파싱 → 계획 → (dryRun이 아니면) 반영. 잡과 동기 경로가 공유한다.
+func runProductImport(db *sql.DB, format string, data []byte, dryRun bool, adminID int) (*importReport, error) {
+	records, err := parseProductImport(format, data)
+	if err != nil {
+		return nil, err
+	}
+	report, err := planProductImport(db, records, adminID)
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		if err := applyProductImport(db, report); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+func productImportMaxBytes() int64 {
+	return int64(envInt("PRODUCT_IMPORT_MAX_MB", 20)) << 20
+}
+
+// readImportUpload — multipart "file" 또는 요청 본문 그대로. format은 쿼리 → 파일 확장자 → Content-Type 순으로 결정.
+func readImportUpload(c *gin.Context) ([]byte, string, error) {
+	format := strings.ToLower(c.Query("format"))
+	limit := productImportMaxBytes()
+	var r io.Reader
+	if fh, err := c.FormFile("file"); err == nil {
+		f, err := fh.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		defer f.Close()
+		r = f
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fh.Filename)), ".")
+		}
+	} else {
+		r = c.Request.Body
+		if format == "" {
+			if strings.Contains(c.ContentType(), "json") {
+				format = "json"
+			} else {
+				format = "csv"
+			}
+		}
+	}
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(data)) > limit {
+		return nil, "", fmt.Errorf("file exceeds %d MB", limit>>20)
+	}
+	if format != "csv" && format != "json" {
+		return nil, "", errors.New("format must be csv or json")
+	}
+	return data, format, nil
+}
+
+// ImportProducts — POST /api/v1/admin/products/import?format=csv|json&dryRun=true|false&async= (관리자)
+// 본문: multipart file 또는 원본 CSV/JSON. 큰 파일은 202 + jobId (GET .../import/:jobId로 조회).
+func ImportProducts(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c, db) {
+			return
+		}
+		adminID, _ := c.Get("userId")
+		data, format, err := readImportUpload(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		dryRun := c.DefaultQuery("dryRun", "true") != "false"
+
+		records, err := parseProductImport(format, data)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if c.Query("async") == "true" || len(records) > envInt("PRODUCT_IMPORT_SYNC_MAX_ROWS", 200) {
+			var jobID int
+			err := db.QueryRow(`
+				INSERT INTO product_import_jobs (admin_id, format, dry_run, payload, total_rows)
+				VALUES ($1, $2, $3, $4, $5) RETURNING id
+			`, adminID, format, dryRun, string(data), len(records)).Scan(&jobID)
+			if err != nil {
+				respondDBError(c, err)
+				return
+			}
+			go func() {
+				if err := runProductImportJobs(db); err != nil {
+					log.Printf("[product-import] job run failed: %v", err)
+				}
+			}()
+			c.JSON(http.StatusAccepted, gin.H{"jobId": jobID, "status": "queued", "totalRows": len(records), "dryRun": dryRun})
+			return
+		}
+
+		report, err := planProductImport(db, records, adminID.(int))
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if !dryRun {
+			if err := applyProductImport(db, report); err != nil {
+				log.Printf("[product-import] apply failed: %v", err)
+				c.JSON(http.StatusConflict, gin.H{"error": "import failed and was rolled back: " + err.Error(), "report": report})
+				return
+			}
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// GetProductImportJob — GET /api/v1/admin/products/import/:jobId (관리자)
+func GetProductImportJob(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c, db) {
+			return
+		}
+		jobID, err := strconv.Atoi(c.Param("jobId"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+			return
+		}
+		var (
+			format, status      string
+			dryRun              bool
+			totalRows           int
+			result              []byte
+			jobErr              *string
+			createdAt           time.Time
+			startedAt, finished *time.Time
+		)
+		err = db.QueryRow(`
+			SELECT format, dry_run, status, total_rows, result, error, created_at, started_at, finished_at
+			FROM product_import_jobs WHERE id = $1
+		`, jobID).Scan(&format, &dryRun, &status, &totalRows, &result, &jobErr, &createdAt, &startedAt, &finished)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Import job not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		resp := gin.H{
+			"jobId": jobID, "format": format, "dryRun": dryRun, "status": status, "totalRows": totalRows,
+			"error": jobErr, "createdAt": createdAt, "startedAt": startedAt, "finishedAt": finished,
+		}
+		if len(result) > 0 {
+			resp["report"] = json.RawMessage(result)
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// runProductImportJobs — 백그라운드 잡: 대기 중인 가져오기를 하나씩 선점(SKIP LOCKED)해 처리.
+// 1시간 넘게 running인 잡(처리 중 프로세스 종료)은 다시 대기열로 돌린다.
+func runProductImportJobs(db *sql.DB) error {
+	if _, err := db.Exec(`
+		UPDATE product_import_jobs SET status = 'queued', started_at = NULL
+		WHERE status = 'running' AND started_at < NOW() - INTERVAL '1 hour'
+	`); err != nil {
+		return err
+	}
+	for {
+		var (
+			jobID   int
+			adminID sql.NullInt64
+			format  string
+			dryRun  bool
+			payload string
+		)
+		err := db.QueryRow(`
+			UPDATE product_import_jobs SET status = 'running', started_at = NOW()
+			WHERE id = (
+				SELECT id FROM product_import_jobs WHERE status = 'queued'
+				ORDER BY created_at, id LIMIT 1 FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, admin_id, format, dry_run, payload
+		`).Scan(&jobID, &adminID, &format, &dryRun, &payload)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		report, err := runProductImport(db, format, []byte(payload), dryRun, int(adminID.Int64))
+		if err != nil {
+			log.Printf("[product-import] job %d failed: %v", jobID, err)
+			if _, uerr := db.Exec(`
+				UPDATE product_import_jobs SET status = 'failed', error = $2, finished_at = NOW() WHERE id = $1
+			`, jobID, err.Error()); uerr != nil {
+				return uerr
+			}
+			continue
+		}
+		result, _ := json.Marshal(report)
+		// 반영이 끝난 원본은 보관할 필요가 없다 (결과에 행별 diff가 남는다)
+		if _, err := db.Exec(`
+			UPDATE product_import_jobs SET status = 'done', result = $2, payload = '', finished_at = NOW() WHERE id = $1
+		`, jobID, result); err != nil {
+			return err
+		}
+		log.Printf("[product-import] job %d done (dryRun=%v, %v)", jobID, dryRun, report.Summary)
+	}
+}
+
+// canonicalSpecJSON — features/system_requirements 셀 값을 검증하고 정규화된 JSON으로.
+// 셀은 JSON(배열/객체)이거나, 하위 호환으로 줄 단위 기능 목록/요구사항 메모 텍스트일 수 있다.
+func canonicalSpecJSON(name, v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+	var out interface{}
+	switch name {
+	case "features":
+		f, err := models.ParseProductFeatures(v)
+		if err != nil {
+			return "", err
+		}
+		if msg := validateProductFeatures(f); msg != "" {
+			return "", errors.New(msg)
+		}
+		if len(f) == 0 {
+			return "", nil
+		}
+		out = f
+	case "system_requirements":
+		r, err := models.ParseSystemRequirements(v)
+		if err != nil {
+			return "", err
+		}
+		if msg := validateSystemRequirements(r); msg != "" {
+			return "", errors.New(msg)
+		}
+		if r.IsZero() {
+			return "", nil
+		}
+		out = r
+	}
+	b, err := json.Marshal(out)
+	return string(b), err
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseProductImportCSV(t *testing.T) {
+	data := "\xef\xbb\xbfSKU,name,price\n" +
+		"ebook-1,\"Go, 입문\",15000\n" +
+		"\n" +
+		"ebook-2,Second,0\n"
+	records, err := parseProductImport("csv", []byte(data))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("records = %d, want 2", len(records))
+	}
+	if records[0].Line != 2 || records[0].Fields["name"] != "Go, 입문" || records[0].Fields["sku"] != "ebook-1" {
+		t.Errorf("record 0 = %+v", records[0])
+	}
+	if records[1].Line != 4 {
+		t.Errorf("record 1 line = %d, want 4", records[1].Line)
+	}
+
+	if _, err := parseProductImport("csv", []byte("sku,license_key\nx,y\n")); err == nil {
+		t.Error("unknown column should be rejected")
+	}
+	if _, err := parseProductImport("csv", nil); err == nil {
+		t.Error("empty file should be rejected")
+	}
+}
+
+func TestParseProductImportJSON(t *testing.T) {
+	for _, data := range []string{
+		`[{"sku":"a-1","price":1500,"category":null}]`,
+		`{"products":[{"sku":"a-1","price":1500,"category":null}]}`,
+	} {
+		records, err := parseProductImport("json", []byte(data))
+		if err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+		if len(records) != 1 || records[0].Fields["price"] != "1500" || records[0].Fields["category"] != "" {
+			t.Errorf("%s: records = %+v", data, records)
+		}
+	}
+	for _, data := range []string{`{"sku":"a"}`, `[1]`, `[{"sku":"a","bogus":1}]`, `[{"sku":["a"]}]`} {
+		if _, err := parseProductImport("json", []byte(data)); err == nil {
+			t.Errorf("%s: expected error", data)
+		}
+	}
+}
+
+func TestValidateImportRecord(t *testing.T) {
+	values, errs := validateImportRecord(importRecord{Line: 2, Fields: map[string]string{
+		"sku": " Ebook-1 ", "product_type": "EBOOK", "price": "015000", "original_price": "",
+		"price_sync": "", "download_url": "https://example.com/a.zip", "status": "Draft",
+	}})
+	if len(errs) != 0 {
+		t.Fatalf("errs = %v", errs)
+	}
+	want := map[string]string{
+		"sku": "ebook-1", "product_type": "ebook", "price": "15000", "original_price": "",
+		"price_sync": "manual", "download_url": "https://example.com/a.zip", "status": "draft",
+	}
+	for k, v := range want {
+		if values[k] != v {
+			t.Errorf("%s = %q, want %q", k, values[k], v)
+		}
+	}
+
+	cases := map[string]map[string]string{
+		"sku":               {"sku": "bad sku"},
+		"sku is required":   {"name": "x"},
+		"product_type":      {"sku": "a", "product_type": "malware"},
+		"status":            {"sku": "a", "status": "deleted"},
+		"price":             {"sku": "a", "price": "-1"},
+		"crypto_price_usdc": {"sku": "a", "crypto_price_usdc": "1.5"},
+		"price_sync":        {"sku": "a", "price_sync": "eur"},
+		"download_url":      {"sku": "a", "download_url": "javascript:alert(1)"},
+		"seller_email":      {"sku": "a", "seller_email": "nobody"},
+		"name":              {"sku": "a", "name": strings.Repeat("가", 256)},
+	}
+	for want, fields := range cases {
+		_, errs := validateImportRecord(importRecord{Fields: fields})
+		if len(errs) == 0 || !strings.Contains(strings.Join(errs, "; "), want) {
+			t.Errorf("%v: errs = %v, want mention of %q", fields, errs, want)
+		}
+	}
+}
+
+func TestDiffImportRow(t *testing.T) {
+	existing := map[string]string{"sku": "a", "name": "Old", "price": "100", "seller_email": "Seller@x.com"}
+
+	row := &importRowResult{values: map[string]string{"sku": "a", "name": "Old", "price": "200", "seller_email": "seller@x.com"}}
+	diffImportRow(row, existing)
+	if row.Action != "update" || len(row.Changes) != 1 || row.Changes["price"] != (importChange{From: "100", To: "200"}) {
+		t.Errorf("update: action=%s changes=%v", row.Action, row.Changes)
+	}
+
+	row = &importRowResult{values: map[string]string{"sku": "a", "name": "Old", "seller_email": ""}}
+	diffImportRow(row, existing)
+	if row.Action != "unchanged" || row.Changes != nil {
+		t.Errorf("unchanged: action=%s changes=%v", row.Action, row.Changes)
+	}
+
+	row = &importRowResult{values: map[string]string{"sku": "b", "name": "New"}}
+	diffImportRow(row, nil)
+	if row.Action != "create" || len(row.Errors) != 2 {
+		t.Errorf("create: action=%s errors=%v", row.Action, row.Errors)
+	}
+}
+
+func TestAutoSKUPattern(t *testing.T) {
+	for _, sku := range []string{"p1", "p42", "p0007"} {
+		if !autoSKUPattern.MatchString(sku) {
+			t.Errorf("%q should be reserved", sku)
+		}
+	}
+	for _, sku := range []string{"p", "p1a", "pro-1", "p-1", "sp1", "ebook-1"} {
+		if autoSKUPattern.MatchString(sku) {
+			t.Errorf("%q should be importable", sku)
+		}
+	}
+}
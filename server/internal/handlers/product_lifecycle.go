@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+)
+
+// ── 상품 라이프사이클 ─────────────────────────────────────────────────────
+// status: draft → (pending_review) → published → archived. 삭제는 deleted_at만 채우는 soft delete라
+// payments.order_id가 가리키는 구매 이력·다운로드·라이선스는 그대로 유지된다.
+// 공개 여부는 is_active(= published AND 미삭제, DB 트리거가 파생)로 판단하므로
+// 공개 조회는 기존처럼 is_active = true 조건만 쓴다.
+// 예약 공개: publish_at이 있는 draft는 product-publish 잡이 시각이 되면 published로 바꾼다.
+
+const (
+	productStatusDraft         = "draft"
+	productStatusPendingReview = "pending_review"
+	productStatusPublished     = "published"
+	productStatusArchived      = "archived"
+)
+
+var productStatuses = map[string]bool{
+	productStatusDraft:         true,
+	productStatusPendingReview: true,
+	productStatusPublished:     true,
+	productStatusArchived:      true,
+}
+
+// resolveProductLifecycle — 요청한 status/publishAt을 저장할 값으로 바꾼다.
+// published + 미래 publishAt은 draft + 예약, 지난 publishAt은 즉시 공개로 취급한다.
+// publishAt은 draft/pending_review에서만 의미가 있고 published/archived면 지운다.
+func resolveProductLifecycle(requested string, publishAt *time.Time, now time.Time) (string, *time.Time, string) {
+	status := strings.ToLower(strings.TrimSpace(requested))
+	if !productStatuses[status] {
+		return "", nil, "status must be draft, pending_review, published or archived"
+	}
+	if publishAt != nil && !publishAt.After(now) {
+		publishAt = nil
+	}
+	switch status {
+	case productStatusPublished:
+		if publishAt != nil {
+			return productStatusDraft, publishAt, ""
+		}
+		return productStatusPublished, nil, ""
+	case productStatusArchived:
+		return productStatusArchived, nil, ""
+	}
+	return status, publishAt, ""
+}
+
+// runScheduledProductPublishing — 백그라운드 잡: 예약 시각이 지난 draft를 공개.
+// 조건부 UPDATE라 여러 인스턴스가 동시에 돌아도 한 번만 바뀐다.
+func runScheduledProductPublishing(db *sql.DB) error {
+	res, err := db.Exec(`
+		UPDATE products SET status = 'published', publish_at = NULL, updated_at = NOW()
+		WHERE status = 'draft' AND publish_at IS NOT NULL AND publish_at <= NOW() AND deleted_at IS NULL
+	`)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		log.Printf("[products] published %d scheduled products", n)
+	}
+	return nil
+}
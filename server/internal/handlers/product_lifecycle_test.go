@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveProductLifecycle(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	future, past := now.Add(24*time.Hour), now.Add(-time.Hour)
+
+	cases := []struct {
+		status      string
+		publishAt   *time.Time
+		wantStatus  string
+		wantPublish *time.Time
+		wantErr     bool
+	}{
+		{"published", nil, "published", nil, false},
+		{" Published ", &future, "draft", &future, false}, // 예약 공개
+		{"published", &past, "published", nil, false},     // 지난 시각 = 즉시 공개
+		{"draft", &future, "draft", &future, false},
+		{"pending_review", nil, "pending_review", nil, false},
+		{"archived", &future, "archived", nil, false},
+		{"deleted", nil, "", nil, true},
+	}
+	for _, c := range cases {
+		status, publishAt, msg := resolveProductLifecycle(c.status, c.publishAt, now)
+		if (msg != "") != c.wantErr {
+			t.Errorf("%q: msg = %q, wantErr %v", c.status, msg, c.wantErr)
+			continue
+		}
+		if status != c.wantStatus {
+			t.Errorf("%q: status = %q, want %q", c.status, status, c.wantStatus)
+		}
+		if (publishAt == nil) != (c.wantPublish == nil) || (publishAt != nil && !publishAt.Equal(*c.wantPublish)) {
+			t.Errorf("%q: publishAt = %v, want %v", c.status, publishAt, c.wantPublish)
+		}
+	}
+}
@@ -0,0 +1,501 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"cmall_dd/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// ── 카탈로그 목록/검색 (페이지네이션·정렬·가격 범위·패싯) ─────────────────────
+// GET /products, GET /products/search 공용.
+// 응답 형식은 버전 플래그로 고른다:
+//   - 기본(v1): 기존과 같은 상품 배열 (limit을 주지 않으면 전체)
+//   - v2 (?v=2 또는 X-API-Version: 2): {items, nextCursor, total, facets} 봉투
+// nextCursor는 정렬 키 + id 기반 keyset 커서 (불투명 base64). offset도 허용하지만
+// 커서가 있으면 커서가 우선한다. q가 있으면 전문 검색(search.go)으로 거르고 기본 정렬은
+// 관련도(relevance), 각 항목에 relevance/highlight를 채운다.
+// categorySlug는 하위 카테고리까지 포함해 거르고 (categories.go), tag는 태그 slug 하나로 거른다.
+// os/arch/broker는 system_requirements에 명시된 호환 값, ramMb는 그 메모리로 실행 가능한 상품 (product_specs.go).
+
+const (
+	defaultProductPageSize = 20
+	maxProductPageSize     = 100
+)
+
+// productSortSpec — sort 파라미터별 정렬 식과 방향
+type productSortSpec struct {
+	expr string // SQL 정렬 식 (p = products, pop = 판매 집계)
+	desc bool
+}
+
+// productSortSpecs — price는 currency(krw|usdc)에 따라 price/crypto_price_usdc로 치환된다.
+// rating은 리뷰 집계(products.rating_avg, reviews.go)를 쓴다.
+var productSortSpecs = map[string]productSortSpec{
+	"newest":     {expr: "p.created_at", desc: true},
+	"price":      {expr: "{price}", desc: false},
+	"price_desc": {expr: "{price}", desc: true},
+	"popularity": {expr: "COALESCE(pop.sales, 0)", desc: true},
+	"rating":     {expr: "p.rating_avg", desc: true},
+	"relevance":  {expr: "{rank}", desc: true},
+}
+
+// productListParams — 쿼리 파라미터 파싱 결과
+type productListParams struct {
+	Search       string
+	ProductType  string
+	Category     string
+	CategorySlug string // 하위 카테고리 포함
+	Tag          string
+	OS           string // system_requirements 호환 필터
+	CPUArch      string
+	Broker       string
+	RAMMB        *int
+	Currency     string // krw (products.price) | usdc (products.crypto_price_usdc, micro-units)
+	MinPrice     *int64
+	MaxPrice     *int64
+	Sort         string
+	Limit        int // 0 = 제한 없음 (v1 하위 호환)
+	Offset       int
+	Cursor       *productCursor
+	Envelope     bool
+
+	text *textSearch // Search가 있을 때만
+}
+
+// productCursor — keyset 커서. V는 정렬 키 값의 문자열 표현.
+type productCursor struct {
+	Sort     string `json:"s"`
+	Currency string `json:"c,omitempty"`
+	V        string `json:"v"`
+	ID       int    `json:"id"`
+}
+
+func encodeProductCursor(cur productCursor) string {
+	b, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeProductCursor(raw string) (*productCursor, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, false
+	}
+	var cur productCursor
+	if err := json.Unmarshal(b, &cur); err != nil || cur.ID <= 0 || cur.V == "" {
+		return nil, false
+	}
+	if _, ok := productSortSpecs[cur.Sort]; !ok {
+		return nil, false
+	}
+	return &cur, true
+}
+
+// wantsProductEnvelope — v2 봉투 응답 여부 (쿼리 v=2 또는 X-API-Version: 2)
+func wantsProductEnvelope(c *gin.Context) bool {
+	return c.Query("v") == "2" || c.GetHeader("X-API-Version") == "2"
+}
+
+// parseProductListParams — 잘못된 값은 클라이언트 오류 메시지로 반환한다.
+// allowSearch=false(목록)이면 q를 무시한다.
+func parseProductListParams(c *gin.Context, db *sql.DB, allowSearch bool) (productListParams, string) {
+	p := productListParams{
+		ProductType:  strings.ToLower(strings.TrimSpace(c.Query("productType"))),
+		Category:     strings.TrimSpace(c.Query("category")),
+		CategorySlug: strings.ToLower(strings.TrimSpace(c.Query("categorySlug"))),
+		Tag:          strings.ToLower(strings.TrimSpace(c.Query("tag"))),
+		OS:           strings.ToLower(strings.TrimSpace(c.Query("os"))),
+		CPUArch:      strings.ToLower(strings.TrimSpace(c.Query("arch"))),
+		Broker:       strings.ToLower(strings.TrimSpace(c.Query("broker"))),
+		Currency:     strings.ToLower(c.DefaultQuery("currency", "krw")),
+		Sort:         c.Query("sort"),
+		Envelope:     wantsProductEnvelope(c),
+	}
+	if allowSearch {
+		p.Search = strings.TrimSpace(c.Query("q"))
+	}
+	if p.Search != "" {
+		if utf8.RuneCountInString(p.Search) > 100 {
+			return p, "q must be at most 100 characters"
+		}
+		p.text = newTextSearch(db, p.Search, "name")
+		if p.text.tsquery == "" {
+			return p, "q has no searchable terms"
+		}
+	}
+	if p.Sort == "" {
+		p.Sort = "newest"
+		if p.text != nil {
+			p.Sort = "relevance"
+		}
+	}
+	// 구 파라미터명 (SearchProducts의 type=)
+	if p.ProductType == "" {
+		p.ProductType = strings.ToLower(strings.TrimSpace(c.Query("type")))
+	}
+	if p.CategorySlug != "" && !slugPattern.MatchString(p.CategorySlug) {
+		return p, "categorySlug must be a category slug"
+	}
+	if p.Tag != "" && !slugPattern.MatchString(p.Tag) {
+		return p, "tag must be a tag slug"
+	}
+	if p.OS != "" && !specOSValues[p.OS] {
+		return p, "os must be one of windows, macos, linux, android, ios, web"
+	}
+	if p.CPUArch != "" && !specCPUArchValues[p.CPUArch] {
+		return p, "arch must be one of x86, x64, arm64"
+	}
+	if p.Broker != "" && !specBrokerValues[p.Broker] {
+		return p, "broker is not a supported broker API (see /products/compatibility)"
+	}
+	if raw := c.Query("ramMb"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return p, "ramMb must be a positive integer"
+		}
+		p.RAMMB = &n
+	}
+	if p.Currency != "krw" && p.Currency != "usdc" {
+		return p, "currency must be krw or usdc"
+	}
+	if _, ok := productSortSpecs[p.Sort]; !ok {
+		return p, "sort must be one of newest, price, price_desc, popularity, rating, relevance"
+	}
+	if p.Sort == "relevance" && p.text == nil {
+		return p, "sort=relevance requires q"
+	}
+
+	for _, bound := range []struct {
+		name string
+		dst  **int64
+	}{{"minPrice", &p.MinPrice}, {"maxPrice", &p.MaxPrice}} {
+		raw := c.Query(bound.name)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || v < 0 {
+			return p, bound.name + " must be a non-negative integer"
+		}
+		*bound.dst = &v
+	}
+	if p.MinPrice != nil && p.MaxPrice != nil && *p.MinPrice > *p.MaxPrice {
+		return p, "minPrice must not exceed maxPrice"
+	}
+
+	if p.Envelope {
+		p.Limit = defaultProductPageSize
+	}
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxProductPageSize {
+			return p, "limit must be between 1 and 100"
+		}
+		p.Limit = n
+	}
+	if raw := c.Query("offset"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return p, "offset must be a non-negative integer"
+		}
+		p.Offset = n
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		cur, ok := decodeProductCursor(raw)
+		if !ok {
+			return p, "invalid cursor"
+		}
+		if cur.Sort != p.Sort || (cur.Currency != "" && cur.Currency != p.Currency) {
+			return p, "cursor does not match sort/currency"
+		}
+		if p.Limit == 0 {
+			p.Limit = defaultProductPageSize
+		}
+		p.Cursor = cur
+		p.Offset = 0
+	}
+	return p, ""
+}
+
+func (p productListParams) priceColumn() string {
+	if p.Currency == "usdc" {
+		return "p.crypto_price_usdc"
+	}
+	return "p.price"
+}
+
+// sortExpr — 정렬 식. relevance는 검색 인자를 args에 추가한다.
+func (p productListParams) sortExpr(args *[]interface{}) (string, bool) {
+	spec := productSortSpecs[p.Sort]
+	expr := strings.ReplaceAll(spec.expr, "{price}", p.priceColumn())
+	if expr == "{rank}" {
+		expr = p.text.rank(args)
+	}
+	return expr, spec.desc
+}
+
+// whereClause — 공개 카탈로그 필터. 패싯 집계는 자기 자신의 필터를 빼고 계산한다
+// (productType 패싯은 productType 필터 제외, category 패싯은 category 필터 제외).
+func (p productListParams) whereClause(args *[]interface{}, skipType, skipCategory bool) string {
+	arg := func(v interface{}) string {
+		return appendArg(args, v)
+	}
+	where := "p.is_active = true"
+	if p.text != nil {
+		where += " AND " + p.text.where(args)
+	}
+	if p.ProductType != "" && !skipType {
+		where += " AND p.product_type = " + arg(p.ProductType)
+	}
+	if p.Category != "" && !skipCategory {
+		where += " AND p.category = " + arg(p.Category)
+	}
+	if p.CategorySlug != "" && !skipCategory {
+		where += ` AND p.category_id IN (
+			WITH RECURSIVE sub AS (
+				SELECT id FROM categories WHERE slug = ` + arg(p.CategorySlug) + `
+				UNION
+				SELECT c.id FROM categories c JOIN sub ON c.parent_id = sub.id
+			)
+			SELECT id FROM sub
+		)`
+	}
+	if p.Tag != "" {
+		where += " AND EXISTS (SELECT 1 FROM product_tags pt JOIN tags t ON t.id = pt.tag_id" +
+			" WHERE pt.product_id = p.id AND t.slug = " + arg(p.Tag) + ")"
+	}
+	for _, spec := range []struct{ key, value string }{
+		{"os", p.OS}, {"cpuArch", p.CPUArch}, {"brokers", p.Broker},
+	} {
+		if spec.value != "" {
+			where += " AND p.system_requirements @> jsonb_build_object('" + spec.key +
+				"', jsonb_build_array(" + arg(spec.value) + "::text))"
+		}
+	}
+	if p.RAMMB != nil {
+		// 정규화 이전 값이 남아 있어도 캐스트 오류로 쿼리 전체가 실패하지 않도록 숫자일 때만 비교
+		where += " AND COALESCE(CASE WHEN jsonb_typeof(p.system_requirements -> 'minRamMb') = 'number'" +
+			" THEN (p.system_requirements ->> 'minRamMb')::numeric END, 0) <= " + arg(*p.RAMMB)
+	}
+	col := p.priceColumn()
+	if p.Currency == "usdc" {
+		// USDC 가격 미설정(0) 상품은 USDC 기준 정렬/범위에서 제외
+		where += " AND p.crypto_price_usdc > 0"
+	}
+	if p.MinPrice != nil {
+		where += " AND " + col + " >= " + arg(*p.MinPrice)
+	}
+	if p.MaxPrice != nil {
+		where += " AND " + col + " <= " + arg(*p.MaxPrice)
+	}
+	return where
+}
+
+// productFacet — 패싯 값별 상품 수
+type productFacet struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+const productListSelect = `
+	SELECT p.id, p.seller_id, p.name, p.price, COALESCE(p.original_price, 0), COALESCE(p.image, ''),
+	       COALESCE(p.category, ''), p.product_type,
+	       COALESCE(p.version, ''), COALESCE(p.download_url, ''), COALESCE(p.file_size, ''),
+	       COALESCE(p.license_key, ''), COALESCE(p.description, ''), p.features,
+	       p.system_requirements, p.crypto_price_usdc, p.rating_avg, p.rating_count,
+	       COALESCE(pop.sales, 0), p.created_at, p.updated_at
+	FROM products p
+	LEFT JOIN (
+		SELECT order_id, COUNT(*) AS sales FROM payments WHERE status = 'paid' GROUP BY order_id
+	) pop ON pop.order_id = p.id
+`
+
+// scanListedProduct — productListSelect 한 행
+func scanListedProduct(rows *sql.Rows) (models.Product, error) {
+	var p models.Product
+	err := rows.Scan(
+		&p.ID, &p.SellerID, &p.Name, &p.Price, &p.OriginalPrice, &p.Image,
+		&p.Category, &p.ProductType, &p.Version, &p.DownloadURL, &p.FileSize,
+		&p.LicenseKey, &p.Description, &p.Features, &p.SystemReq,
+		&p.CryptoPriceUsdc, &p.RatingAvg, &p.RatingCount, &p.SalesCount,
+		&p.CreatedAt, &p.UpdatedAt,
+	)
+	return p, err
+}
+
+// applySearchHighlights — 검색 결과 페이지에 관련도/설명 스니펫을 채운다
+func applySearchHighlights(db *sql.DB, text *textSearch, products []models.Product) error {
+	ids := make([]int, len(products))
+	for i := range products {
+		ids[i] = products[i].ID
+	}
+	ranks, snippets, err := text.highlights(db, "products", "description", ids)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		products[i].Relevance = ranks[products[i].ID]
+		products[i].Highlight = snippets[products[i].ID]
+	}
+	return nil
+}
+
+// respondProductList — 공개 목록/검색 공용 응답
+func respondProductList(c *gin.Context, db *sql.DB, params productListParams) {
+	args := []interface{}{}
+	where := params.whereClause(&args, false, false)
+	// total은 커서 조건 없이 필터만으로 집계
+	filterWhere, filterArgs := where, append([]interface{}{}, args...)
+
+	sortExpr, desc := params.sortExpr(&args)
+	dir, cmp := "ASC", ">"
+	if desc {
+		dir, cmp = "DESC", "<"
+	}
+	if params.Cursor != nil {
+		cast := "::numeric"
+		switch params.Sort {
+		case "newest":
+			cast = "::timestamp"
+		case "relevance":
+			cast = "::float8"
+		}
+		args = append(args, params.Cursor.V, params.Cursor.ID)
+		where += " AND (" + sortExpr + ", p.id) " + cmp + " ($" + strconv.Itoa(len(args)-1) + cast + ", $" + strconv.Itoa(len(args)) + ")"
+	}
+
+	query := productListSelect + " WHERE " + where + " ORDER BY " + sortExpr + " " + dir + ", p.id " + dir
+	if params.Limit > 0 {
+		// 다음 페이지 존재 여부 확인용으로 1건 더 조회
+		query += " LIMIT " + strconv.Itoa(params.Limit+1)
+	}
+	if params.Offset > 0 {
+		query += " OFFSET " + strconv.Itoa(params.Offset)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		respondDBError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	products := []models.Product{}
+	for rows.Next() {
+		p, err := scanListedProduct(rows)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		// Public listing: never expose downloadUrl/licenseKey (CWE-639).
+		sanitizePublicProduct(&p)
+		products = append(products, p)
+	}
+	if err := rows.Err(); err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	hasMore := params.Limit > 0 && len(products) > params.Limit
+	if hasMore {
+		products = products[:params.Limit]
+	}
+	if params.text != nil {
+		if err := applySearchHighlights(db, params.text, products); err != nil {
+			respondDBError(c, err)
+			return
+		}
+	}
+
+	if !params.Envelope {
+		c.JSON(http.StatusOK, products)
+		return
+	}
+
+	var nextCursor *string
+	if hasMore {
+		last := products[len(products)-1]
+		cur := encodeProductCursor(productCursor{
+			Sort:     params.Sort,
+			Currency: params.Currency,
+			V:        productSortValue(params, last),
+			ID:       last.ID,
+		})
+		nextCursor = &cur
+	}
+
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM products p WHERE "+filterWhere, filterArgs...).Scan(&total); err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	typeFacets, err := productFacets(db, params, "p.product_type", true, false)
+	if err != nil {
+		respondDBError(c, err)
+		return
+	}
+	categoryFacets, err := productFacets(db, params, "COALESCE(p.category, '')", false, true)
+	if err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":      products,
+		"nextCursor": nextCursor,
+		"total":      total,
+		"facets": gin.H{
+			"productType": typeFacets,
+			"category":    categoryFacets,
+		},
+	})
+}
+
+// productSortValue — 커서에 기록할 정렬 키 값
+func productSortValue(params productListParams, p models.Product) string {
+	switch params.Sort {
+	case "newest":
+		return p.CreatedAt.Format("2006-01-02 15:04:05.999999")
+	case "price", "price_desc":
+		if params.Currency == "usdc" {
+			return strconv.FormatInt(p.CryptoPriceUsdc, 10)
+		}
+		return strconv.Itoa(p.Price)
+	case "popularity":
+		return strconv.Itoa(p.SalesCount)
+	case "rating":
+		return strconv.FormatFloat(p.RatingAvg, 'f', -1, 64)
+	case "relevance":
+		return strconv.FormatFloat(p.Relevance, 'f', -1, 64)
+	}
+	return ""
+}
+
+func productFacets(db *sql.DB, params productListParams, column string, skipType, skipCategory bool) ([]productFacet, error) {
+	args := []interface{}{}
+	where := params.whereClause(&args, skipType, skipCategory)
+	rows, err := db.Query(
+		"SELECT "+column+" AS value, COUNT(*) FROM products p WHERE "+where+" GROUP BY 1 ORDER BY 2 DESC, 1",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	facets := []productFacet{}
+	for rows.Next() {
+		var f productFacet
+		if err := rows.Scan(&f.Value, &f.Count); err != nil {
+			return nil, err
+		}
+		facets = append(facets, f)
+	}
+	return facets, rows.Err()
+}
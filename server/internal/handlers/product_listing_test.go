@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cmall_dd/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func listParams(t *testing.T, query string, header map[string]string) (productListParams, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/products?"+query, nil)
+	for k, v := range header {
+		c.Request.Header.Set(k, v)
+	}
+	return parseProductListParams(c, nil, false)
+}
+
+func TestParseProductListParams(t *testing.T) {
+	p, msg := listParams(t, "", nil)
+	if msg != "" || p.Sort != "newest" || p.Currency != "krw" || p.Limit != 0 || p.Envelope {
+		t.Fatalf("defaults = %+v, %q", p, msg)
+	}
+
+	p, msg = listParams(t, "type=EBOOK&minPrice=1000&maxPrice=5000&sort=price_desc&currency=USDC&q=ignored",
+		map[string]string{"X-API-Version": "2"})
+	if msg != "" {
+		t.Fatal(msg)
+	}
+	if p.ProductType != "ebook" || *p.MinPrice != 1000 || *p.MaxPrice != 5000 || p.Sort != "price_desc" ||
+		p.Currency != "usdc" || p.Search != "" || !p.Envelope || p.Limit != defaultProductPageSize {
+		t.Errorf("parsed = %+v", p)
+	}
+
+	// 커서가 있으면 offset은 무시, limit 기본값 적용
+	cur := encodeProductCursor(productCursor{Sort: "price", Currency: "krw", V: "1500", ID: 9})
+	p, msg = listParams(t, "sort=price&offset=40&cursor="+cur, nil)
+	if msg != "" || p.Cursor == nil || p.Cursor.ID != 9 || p.Offset != 0 || p.Limit != defaultProductPageSize {
+		t.Errorf("cursor params = %+v, %q", p, msg)
+	}
+
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{"sort=cheapest", "sort must be one of"},
+		{"sort=relevance", "sort=relevance requires q"},
+		{"currency=eur", "currency must be krw or usdc"},
+		{"minPrice=-1", "minPrice must be a non-negative integer"},
+		{"maxPrice=abc", "maxPrice must be a non-negative integer"},
+		{"minPrice=10&maxPrice=5", "minPrice must not exceed maxPrice"},
+		{"limit=0", "limit must be between 1 and 100"},
+		{"limit=101", "limit must be between 1 and 100"},
+		{"offset=-3", "offset must be a non-negative integer"},
+		{"ramMb=0", "ramMb must be a positive integer"},
+		{"os=beos", "os must be one of"},
+		{"categorySlug=Not%20A%20Slug", "categorySlug must be a category slug"},
+		{"cursor=bm90LWpzb24", "invalid cursor"},
+		{"sort=newest&cursor=" + cur, "cursor does not match sort/currency"},
+		{"sort=price&currency=usdc&cursor=" + cur, "cursor does not match sort/currency"},
+	}
+	for _, c := range cases {
+		if _, msg := listParams(t, c.query, nil); !strings.Contains(msg, c.want) {
+			t.Errorf("%s: msg = %q, want %q", c.query, msg, c.want)
+		}
+	}
+}
+
+func TestProductCursorRoundTrip(t *testing.T) {
+	in := productCursor{Sort: "newest", V: "2026-03-01 09:30:00.123456", ID: 42}
+	out, ok := decodeProductCursor(encodeProductCursor(in))
+	if !ok || *out != in {
+		t.Fatalf("round trip = %+v, %v", out, ok)
+	}
+	for _, bad := range []string{
+		"",
+		"not base64!",
+		encodeProductCursor(productCursor{Sort: "newest", V: "x", ID: 0}),
+		encodeProductCursor(productCursor{Sort: "newest", V: "", ID: 1}),
+		encodeProductCursor(productCursor{Sort: "random", V: "x", ID: 1}),
+	} {
+		if _, ok := decodeProductCursor(bad); ok {
+			t.Errorf("decodeProductCursor(%q) accepted", bad)
+		}
+	}
+}
+
+func TestProductSortValueTieBreaking(t *testing.T) {
+	created := time.Date(2026, 3, 1, 9, 30, 0, 123456789, time.UTC)
+	a := models.Product{ID: 1, Price: 1500, CryptoPriceUsdc: 2_000_000, CreatedAt: created}
+	b := models.Product{ID: 2, Price: 1500, CryptoPriceUsdc: 1_000_000, CreatedAt: created.Add(time.Microsecond)}
+
+	// 같은 정렬 값이면 커서는 id로만 구분된다 ((값, id) keyset)
+	krw := productListParams{Sort: "price", Currency: "krw"}
+	if va, vb := productSortValue(krw, a), productSortValue(krw, b); va != "1500" || va != vb {
+		t.Errorf("krw price values = %q, %q", va, vb)
+	}
+	ca := encodeProductCursor(productCursor{Sort: krw.Sort, Currency: krw.Currency, V: productSortValue(krw, a), ID: a.ID})
+	cb := encodeProductCursor(productCursor{Sort: krw.Sort, Currency: krw.Currency, V: productSortValue(krw, b), ID: b.ID})
+	if ca == cb {
+		t.Error("tied products must produce distinct cursors")
+	}
+
+	usdc := productListParams{Sort: "price_desc", Currency: "usdc"}
+	if va, vb := productSortValue(usdc, a), productSortValue(usdc, b); va != "2000000" || vb != "1000000" {
+		t.Errorf("usdc price values = %q, %q", va, vb)
+	}
+
+	// newest는 마이크로초까지 남겨 같은 초에 생성된 상품끼리도 순서가 유지된다
+	newest := productListParams{Sort: "newest"}
+	va, vb := productSortValue(newest, a), productSortValue(newest, b)
+	if va != "2026-03-01 09:30:00.123456" || va == vb {
+		t.Errorf("newest values = %q, %q", va, vb)
+	}
+}
@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cmall_dd/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ── 상품 심사 ──────────────────────────────────────────────────────────────
+// 관리자가 아닌 판매자가 상품을 공개(또는 예약 공개)하거나 공개 중인 상품 내용을 고치면
+// status가 pending_review가 되어 공개 목록에서 빠지고 심사 대기열에 들어간다.
+// 관리자 결정: approve → published (예약 시각이 남았으면 draft + publish_at),
+// request_changes → draft, reject → archived. 사유는 products.review_note에 남고
+// 제출/결정은 모두 product_moderation_events에 기록되며, 결정은 판매자에게 알림으로 간다.
+
+const (
+	moderationSubmitted        = "submitted"
+	moderationApproved         = "approved"
+	moderationRejected         = "rejected"
+	moderationChangesRequested = "changes_requested"
+)
+
+// ModerationEvent — 심사 감사 로그 1건
+type ModerationEvent struct {
+	ID        int       `json:"id"`
+	ProductID int       `json:"productId"`
+	ReleaseID *int      `json:"releaseId,omitempty"` // 릴리스 심사 이벤트
+	ActorID   *int      `json:"actorId,omitempty"`
+	ActorName string    `json:"actorName,omitempty"`
+	Action    string    `json:"action"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func recordModerationEvent(q execer, productID, actorID int, action, reason string) error {
+	_, err := q.Exec(`
+		INSERT INTO product_moderation_events (product_id, actor_id, action, reason) VALUES ($1, $2, $3, $4)
+	`, productID, actorID, action, reason)
+	return err
+}
+
+// recordReleaseModerationEvent — 릴리스 심사 제출/결정 (상품 이력에 release_id와 함께 남는다)
+func recordReleaseModerationEvent(q execer, productID, releaseID, actorID int, action, reason string) error {
+	_, err := q.Exec(`
+		INSERT INTO product_moderation_events (product_id, release_id, actor_id, action, reason)
+		VALUES ($1, $2, $3, $4, $5)
+	`, productID, releaseID, actorID, action, reason)
+	return err
+}
+
+// requiresModeration — 관리자 외 판매자의 공개 요청은 심사를 거친다 (role은 DB에서 재조회)
+func requiresModeration(db *sql.DB, userID int) bool {
+	return !isAdminUser(db, userID)
+}
+
+// moderatedStatus — 판매자가 요청한 (resolve된) 상태를 심사 정책에 맞게 바꾼다.
+// 공개와 예약 공개(draft + publishAt)는 pending_review로 대기한다.
+func moderatedStatus(status string, publishAt *time.Time) string {
+	if status == productStatusPublished || (status == productStatusDraft && publishAt != nil) {
+		return productStatusPendingReview
+	}
+	return status
+}
+
+// updateChangesListing — 공개 내용을 바꾸는 수정인지 (status/publishAt만 바꾸는 요청은 제외)
+func updateChangesListing(req *models.UpdateProductRequest) bool {
+	return req.Name != nil || req.Price != nil || req.OriginalPrice != nil || req.Image != nil ||
+		req.Category != nil || req.ProductType != nil || req.Version != nil || req.DownloadURL != nil ||
+		req.FileSize != nil || req.LicenseKey != nil || req.Description != nil || req.Features != nil ||
+		req.SystemReq != nil || req.ArtifactID != nil || req.ImageArtifactID != nil ||
+		req.CryptoPriceUsdc != nil || req.PriceSync != nil
+}
+
+// GetModerationQueue — GET /api/v1/admin/moderation/products?status=pending_review (관리자)
+// 기본은 심사 대기 상품을 오래된 순으로.
+func GetModerationQueue(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c, db) {
+			return
+		}
+		status := c.DefaultQuery("status", productStatusPendingReview)
+		if !productStatuses[status] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be draft, pending_review, published or archived"})
+			return
+		}
+		rows, err := db.Query(`
+			SELECT p.id, p.seller_id, COALESCE(u.name, ''), p.name, p.price, p.crypto_price_usdc,
+			       COALESCE(p.category, ''), p.product_type, COALESCE(p.image, ''), COALESCE(p.description, ''),
+			       COALESCE(p.download_url, ''), p.artifact_id, p.status, p.publish_at, p.review_note, p.updated_at,
+			       (SELECT MAX(e.created_at) FROM product_moderation_events e
+			        WHERE e.product_id = p.id AND e.release_id IS NULL AND e.action = 'submitted')
+			FROM products p
+			LEFT JOIN users u ON u.id = p.seller_id
+			WHERE p.status = $1 AND p.deleted_at IS NULL
+			ORDER BY p.updated_at ASC, p.id ASC
+			LIMIT 200
+		`, status)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer rows.Close()
+
+		type queueItem struct {
+			models.Product
+			SellerName  string     `json:"sellerName"`
+			SubmittedAt *time.Time `json:"submittedAt,omitempty"`
+		}
+		items := []queueItem{}
+		for rows.Next() {
+			var it queueItem
+			if err := rows.Scan(&it.ID, &it.SellerID, &it.SellerName, &it.Name, &it.Price, &it.CryptoPriceUsdc,
+				&it.Category, &it.ProductType, &it.Image, &it.Description,
+				&it.DownloadURL, &it.ArtifactID, &it.Status, &it.PublishAt, &it.ReviewNote, &it.UpdatedAt,
+				&it.SubmittedAt); err != nil {
+				respondDBError(c, err)
+				return
+			}
+			items = append(items, it)
+		}
+		c.JSON(http.StatusOK, items)
+	}
+}
+
+// moderationDecisions — 결정 → 결과 상태 SQL, 사유 필수 여부, 알림 문구
+var moderationDecisions = map[string]struct {
+	statusSQL      string
+	reasonRequired bool
+	title          string
+}{
+	moderationApproved: {
+		statusSQL: "CASE WHEN publish_at > NOW() THEN 'draft' ELSE 'published' END",
+		title:     "상품이 승인되었습니다",
+	},
+	moderationChangesRequested: {
+		statusSQL:      "'draft'",
+		reasonRequired: true,
+		title:          "상품 수정이 필요합니다",
+	},
+	moderationRejected: {
+		statusSQL:      "'archived'",
+		reasonRequired: true,
+		title:          "상품이 반려되었습니다",
+	},
+}
+
+// DecideProductModeration — 관리자 결정 핸들러 (관리자, body: {reason})
+//
+//	POST /api/v1/admin/moderation/products/:id/approve
+//	POST /api/v1/admin/moderation/products/:id/request-changes
+//	POST /api/v1/admin/moderation/products/:id/reject
+//
+// pending_review 상품만 결정할 수 있다 (동시 결정은 조건부 UPDATE로 한 건만 성공, 나머지 409).
+func DecideProductModeration(db *sql.DB, action string) gin.HandlerFunc {
+	decision, ok := moderationDecisions[action]
+	if !ok {
+		panic("unknown moderation action: " + action)
+	}
+	return func(c *gin.Context) {
+		if !requireAdmin(c, db) {
+			return
+		}
+		adminID, _ := c.Get("userId")
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+		var req struct {
+			Reason string `json:"reason"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil && decision.reasonRequired {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		reason := strings.TrimSpace(req.Reason)
+		if decision.reasonRequired && reason == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+			return
+		}
+		if len([]rune(reason)) > 2000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reason must be at most 2000 characters"})
+			return
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer tx.Rollback()
+
+		var sellerID int
+		var name, status string
+		err = tx.QueryRow(`
+			UPDATE products SET status = `+decision.statusSQL+`,
+			       publish_at = CASE WHEN $2 = 'approved' AND publish_at > NOW() THEN publish_at ELSE NULL END,
+			       review_note = NULLIF($3, ''), updated_at = NOW()
+			WHERE id = $1 AND status = 'pending_review' AND deleted_at IS NULL
+			RETURNING seller_id, name, status
+		`, id, action, reason).Scan(&sellerID, &name, &status)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusConflict, gin.H{"error": "product is not awaiting review"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if err := recordModerationEvent(tx, id, adminID.(int), action, reason); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			respondDBError(c, err)
+			return
+		}
+
+		body := fmt.Sprintf("'%s' 심사 결과: %s", name, decision.title)
+		if reason != "" {
+			body += "\n사유: " + reason
+		}
+		notifyUser(db, sellerID, notificationProductModeration, decision.title, body, fmt.Sprintf("/my/products/%d", id))
+		log.Printf("[moderation] product %d %s by admin %v", id, action, adminID)
+
+		c.JSON(http.StatusOK, gin.H{"productId": id, "action": action, "status": status, "reason": reason})
+	}
+}
+
+// GetProductModerationHistory — GET /api/v1/products/:id/moderation (JWT, 판매자 본인 또는 관리자)
+func GetProductModerationHistory(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+		var sellerID int
+		err = db.QueryRow(`SELECT seller_id FROM products WHERE id = $1`, id).Scan(&sellerID)
+		if err == sql.ErrNoRows || (err == nil && sellerID != userID.(int) && !isAdminUser(db, userID.(int))) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found or not authorized"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		rows, err := db.Query(`
+			SELECT e.id, e.product_id, e.release_id, e.actor_id, COALESCE(u.name, ''), e.action, e.reason, e.created_at
+			FROM product_moderation_events e
+			LEFT JOIN users u ON u.id = e.actor_id
+			WHERE e.product_id = $1
+			ORDER BY e.created_at DESC, e.id DESC
+		`, id)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer rows.Close()
+		events := []ModerationEvent{}
+		for rows.Next() {
+			var e ModerationEvent
+			if err := rows.Scan(&e.ID, &e.ProductID, &e.ReleaseID, &e.ActorID, &e.ActorName, &e.Action, &e.Reason, &e.CreatedAt); err != nil {
+				respondDBError(c, err)
+				return
+			}
+			events = append(events, e)
+		}
+		c.JSON(http.StatusOK, events)
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"cmall_dd/internal/models"
+)
+
+func TestModeratedStatus(t *testing.T) {
+	at := time.Now().Add(time.Hour)
+	cases := []struct {
+		status    string
+		publishAt *time.Time
+		want      string
+	}{
+		{productStatusPublished, nil, productStatusPendingReview},
+		{productStatusDraft, &at, productStatusPendingReview}, // 예약 공개도 심사 대상
+		{productStatusDraft, nil, productStatusDraft},
+		{productStatusArchived, nil, productStatusArchived},
+		{productStatusPendingReview, nil, productStatusPendingReview},
+	}
+	for _, c := range cases {
+		if got := moderatedStatus(c.status, c.publishAt); got != c.want {
+			t.Errorf("moderatedStatus(%s, %v) = %s, want %s", c.status, c.publishAt != nil, got, c.want)
+		}
+	}
+}
+
+func TestUpdateChangesListing(t *testing.T) {
+	status := "archived"
+	if updateChangesListing(&models.UpdateProductRequest{Status: &status}) {
+		t.Error("status-only update should not count as a listing change")
+	}
+	name := "새 이름"
+	if !updateChangesListing(&models.UpdateProductRequest{Name: &name}) {
+		t.Error("name change should require review")
+	}
+	var price int64 = 1_000_000
+	if !updateChangesListing(&models.UpdateProductRequest{CryptoPriceUsdc: &price}) {
+		t.Error("price change should require review")
+	}
+}
+
+func TestModerationDecisionsRequireReason(t *testing.T) {
+	if moderationDecisions[moderationApproved].reasonRequired {
+		t.Error("approval should not require a reason")
+	}
+	for _, action := range []string{moderationRejected, moderationChangesRequested} {
+		if !moderationDecisions[action].reasonRequired {
+			t.Errorf("%s should require a reason", action)
+		}
+	}
+}
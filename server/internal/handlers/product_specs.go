@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"cmall_dd/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ── 상품 기능/시스템 요구사항 ───────────────────────────────────────────────
+// features(기능 목록)와 system_requirements(OS/CPU/RAM/증권사 API 호환 매트릭스)는 JSONB로 저장하고
+// 생성/수정 시 아래 허용 값으로 검증한다. 공개 목록/검색은 ?os=&arch=&broker=&ramMb=로 호환 여부를 거른다
+// (product_listing.go). 허용 값 목록은 GET /products/compatibility로 프론트에 제공한다.
+
+const (
+	maxProductFeatures       = 30
+	maxFeatureTitleRunes     = 200
+	maxFeatureDescRunes      = 1000
+	maxRequirementNotesRunes = 2000
+)
+
+type specOption struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+var specOSOptions = []specOption{
+	{"windows", "Windows"},
+	{"macos", "macOS"},
+	{"linux", "Linux"},
+	{"android", "Android"},
+	{"ios", "iOS"},
+	{"web", "웹 브라우저"},
+}
+
+var specCPUArchOptions = []specOption{
+	{"x86", "x86 (32bit)"},
+	{"x64", "x86-64"},
+	{"arm64", "ARM64 (Apple Silicon 등)"},
+}
+
+// specBrokerOptions — 지원 증권사/거래 API. 새 API는 여기에 추가한다.
+var specBrokerOptions = []specOption{
+	{"kiwoom-openapi", "키움증권 OpenAPI+"},
+	{"kiwoom-rest", "키움증권 REST API"},
+	{"kis-openapi", "한국투자증권 KIS Developers"},
+	{"ls-xingapi", "LS증권 xingAPI"},
+	{"ls-openapi", "LS증권 OPEN API"},
+	{"daishin-creon", "대신증권 CYBOS Plus (CREON)"},
+	{"nh-qv-openapi", "NH투자증권 QV OpenAPI"},
+	{"ibkr-tws", "Interactive Brokers TWS API"},
+	{"alpaca", "Alpaca"},
+}
+
+func specValueSet(options []specOption) map[string]bool {
+	m := make(map[string]bool, len(options))
+	for _, o := range options {
+		m[o.Value] = true
+	}
+	return m
+}
+
+var (
+	specOSValues      = specValueSet(specOSOptions)
+	specCPUArchValues = specValueSet(specCPUArchOptions)
+	specBrokerValues  = specValueSet(specBrokerOptions)
+)
+
+// normalizeSpecList — 소문자화 + 중복 제거 + 허용 값 검증
+func normalizeSpecList(field string, values []string, allowed map[string]bool) ([]string, string) {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if !allowed[v] {
+			return nil, fmt.Sprintf("systemRequirements.%s has unsupported value %q", field, v)
+		}
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out, ""
+}
+
+// validateProductFeatures — 제목 필수, 길이 제한. 앞뒤 공백을 정리한다.
+func validateProductFeatures(f models.ProductFeatures) string {
+	if len(f) > maxProductFeatures {
+		return fmt.Sprintf("features must have at most %d items", maxProductFeatures)
+	}
+	for i := range f {
+		f[i].Title = strings.TrimSpace(f[i].Title)
+		f[i].Description = strings.TrimSpace(f[i].Description)
+		if f[i].Title == "" || utf8.RuneCountInString(f[i].Title) > maxFeatureTitleRunes {
+			return fmt.Sprintf("features[%d].title must be 1-%d characters", i, maxFeatureTitleRunes)
+		}
+		if utf8.RuneCountInString(f[i].Description) > maxFeatureDescRunes {
+			return fmt.Sprintf("features[%d].description must be at most %d characters", i, maxFeatureDescRunes)
+		}
+	}
+	return ""
+}
+
+// validateSystemRequirements — 허용 값/범위 검증 (r을 정규화된 값으로 바꾼다)
+func validateSystemRequirements(r *models.SystemRequirements) string {
+	var msg string
+	if r.OS, msg = normalizeSpecList("os", r.OS, specOSValues); msg != "" {
+		return msg
+	}
+	if r.CPUArch, msg = normalizeSpecList("cpuArch", r.CPUArch, specCPUArchValues); msg != "" {
+		return msg
+	}
+	if r.Brokers, msg = normalizeSpecList("brokers", r.Brokers, specBrokerValues); msg != "" {
+		return msg
+	}
+	for _, n := range []struct {
+		field  string
+		v, max int
+	}{
+		{"minCpuCores", r.MinCPUCores, 256},
+		{"minRamMb", r.MinRAMMB, 1 << 20},
+		{"minDiskMb", r.MinDiskMB, 1 << 24},
+	} {
+		if n.v < 0 || n.v > n.max {
+			return fmt.Sprintf("systemRequirements.%s must be between 0 and %d", n.field, n.max)
+		}
+	}
+	r.Notes = strings.TrimSpace(r.Notes)
+	if utf8.RuneCountInString(r.Notes) > maxRequirementNotesRunes {
+		return fmt.Sprintf("systemRequirements.notes must be at most %d characters", maxRequirementNotesRunes)
+	}
+	return ""
+}
+
+// validateProductSpecs — 생성/수정 요청 공용 (nil은 변경 없음)
+func validateProductSpecs(features *models.ProductFeatures, req *models.SystemRequirements) string {
+	if features != nil {
+		if msg := validateProductFeatures(*features); msg != "" {
+			return msg
+		}
+	}
+	if req != nil {
+		if msg := validateSystemRequirements(req); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+// GetCompatibilityOptions — GET /api/v1/products/compatibility (공개)
+// 요구사항 입력 폼과 호환성 필터에 쓰는 허용 값 + 값별 공개 상품 수.
+func GetCompatibilityOptions(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		counts := map[string]map[string]int{"os": {}, "cpuArch": {}, "brokers": {}}
+		rows, err := db.Query(`
+			SELECT k.key, v.value, COUNT(*)
+			FROM products p
+			CROSS JOIN LATERAL (VALUES ('os'), ('cpuArch'), ('brokers')) k(key)
+			CROSS JOIN LATERAL jsonb_array_elements_text(
+				CASE WHEN jsonb_typeof(p.system_requirements -> k.key) = 'array'
+				     THEN p.system_requirements -> k.key ELSE '[]' END
+			) v(value)
+			WHERE p.is_active = true
+			GROUP BY 1, 2
+		`)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var key, value string
+			var n int
+			if err := rows.Scan(&key, &value, &n); err != nil {
+				respondDBError(c, err)
+				return
+			}
+			counts[key][value] = n
+		}
+		withCounts := func(key string, options []specOption) []gin.H {
+			out := make([]gin.H, 0, len(options))
+			for _, o := range options {
+				out = append(out, gin.H{"value": o.Value, "label": o.Label, "count": counts[key][o.Value]})
+			}
+			return out
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"os":      withCounts("os", specOSOptions),
+			"cpuArch": withCounts("cpuArch", specCPUArchOptions),
+			"brokers": withCounts("brokers", specBrokerOptions),
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"cmall_dd/internal/models"
+)
+
+func TestProductFeaturesUnmarshal(t *testing.T) {
+	cases := map[string]int{
+		`[{"title":"실시간 시세","description":"1초 갱신"},"자동 매매"]`: 2,
+		`"- 실시간 시세\n\n* 자동 매매\n"`:                            2, // 레거시: 줄 단위 텍스트
+		`"[\"a\", \"b\", \"c\"]"`:                            3, // 레거시: JSON 배열 문자열
+		`[]`:                                                 0,
+	}
+	for in, want := range cases {
+		var f models.ProductFeatures
+		if err := json.Unmarshal([]byte(in), &f); err != nil {
+			t.Errorf("%s: %v", in, err)
+			continue
+		}
+		if len(f) != want {
+			t.Errorf("%s: %d features, want %d (%+v)", in, len(f), want, f)
+		}
+	}
+	var f models.ProductFeatures
+	if err := json.Unmarshal([]byte(`[1]`), &f); err == nil {
+		t.Error("numeric feature should be rejected")
+	}
+	if err := json.Unmarshal([]byte(`{"title":"x"}`), &f); err == nil {
+		t.Error("object instead of array should be rejected")
+	}
+}
+
+func TestSystemRequirementsUnmarshal(t *testing.T) {
+	var r models.SystemRequirements
+	if err := json.Unmarshal([]byte(`"Windows 10 이상, 8GB RAM"`), &r); err != nil || r.Notes != "Windows 10 이상, 8GB RAM" {
+		t.Errorf("legacy text: %+v, %v", r, err)
+	}
+	r = models.SystemRequirements{}
+	if err := json.Unmarshal([]byte(`{"os":["Windows"],"minRamMb":8192,"brokers":["kiwoom-openapi"]}`), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.MinRAMMB != 8192 || len(r.OS) != 1 || len(r.Brokers) != 1 {
+		t.Errorf("object: %+v", r)
+	}
+	if msg := validateSystemRequirements(&r); msg != "" || r.OS[0] != "windows" {
+		t.Errorf("validate: %q, os = %v", msg, r.OS)
+	}
+	if v, err := (&models.SystemRequirements{}).Value(); err != nil || v != nil {
+		t.Errorf("empty requirements should store NULL, got %v", v)
+	}
+}
+
+func TestProductSpecsScanLegacyValues(t *testing.T) {
+	// 스키마에 맞지 않는 예전 JSONB 값도 조회가 실패하지 않아야 한다
+	var r models.SystemRequirements
+	if err := r.Scan([]byte(`{"os":"Windows","minRamMb":"8GB"}`)); err != nil || r.Notes != `{"os":"Windows","minRamMb":"8GB"}` {
+		t.Errorf("requirements fallback: %+v, %v", r, err)
+	}
+	if err := r.Scan([]byte(`{"os":["windows"],"minRamMb":4096}`)); err != nil || r.MinRAMMB != 4096 || r.Notes != "" {
+		t.Errorf("valid requirements: %+v, %v", r, err)
+	}
+
+	var f models.ProductFeatures
+	if err := f.Scan([]byte(`[{"title":"a"},"b",1,null,{"name":"c"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a", "b", "1", `{"name":"c"}`}
+	if len(f) != len(want) {
+		t.Fatalf("features fallback: %+v", f)
+	}
+	for i, title := range want {
+		if f[i].Title != title {
+			t.Errorf("features[%d].Title = %q, want %q", i, f[i].Title, title)
+		}
+	}
+	if err := f.Scan([]byte(`"단일 기능"`)); err != nil || len(f) != 1 || f[0].Title != "단일 기능" {
+		t.Errorf("non-array features: %+v, %v", f, err)
+	}
+}
+
+func TestValidateProductSpecs(t *testing.T) {
+	bad := []*models.SystemRequirements{
+		{OS: []string{"beos"}},
+		{CPUArch: []string{"mips"}},
+		{Brokers: []string{"unknown-broker"}},
+		{MinRAMMB: -1},
+		{MinCPUCores: 1000},
+	}
+	for _, r := range bad {
+		if msg := validateProductSpecs(nil, r); msg == "" {
+			t.Errorf("%+v should be rejected", r)
+		}
+	}
+	empty := models.ProductFeatures{{Title: "  "}}
+	if msg := validateProductSpecs(&empty, nil); msg == "" {
+		t.Error("blank feature title should be rejected")
+	}
+	ok := models.ProductFeatures{{Title: " 백테스트 "}}
+	if msg := validateProductSpecs(&ok, &models.SystemRequirements{Brokers: []string{"KIS-OpenAPI", "kis-openapi"}}); msg != "" {
+		t.Errorf("valid specs rejected: %s", msg)
+	}
+	if ok[0].Title != "백테스트" {
+		t.Errorf("title not trimmed: %q", ok[0].Title)
+	}
+
+	canonical, err := canonicalSpecJSON("system_requirements", `{"brokers":["KIWOOM-OPENAPI"],"os":["windows"]}`)
+	if err != nil || canonical != `{"os":["windows"],"brokers":["kiwoom-openapi"]}` {
+		t.Errorf("canonical = %s, %v", canonical, err)
+	}
+	if _, err := canonicalSpecJSON("features", `[{"title":""}]`); err == nil {
+		t.Error("invalid features cell should be rejected")
+	}
+}
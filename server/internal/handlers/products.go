@@ -2,13 +2,16 @@ package handlers
 
 import (
 	"database/sql"
+	"log"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"cmall_dd/internal/models"
 	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
 )
 
 // validateDownloadURL rejects downloadUrl values whose scheme is not http or
@@ -36,47 +39,18 @@ func sanitizePublicProduct(p *models.Product) {
 	p.LicenseKey = nil
 }
 
-// GetProducts returns all products
+// GetProducts returns active products. Supports limit/cursor/offset, sort,
+// price range and productType/category filters; see product_listing.go for
+// the v2 envelope response.
 func GetProducts(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		query := `
-			SELECT id, seller_id, name, price, COALESCE(original_price, 0), COALESCE(image, ''),
-			       COALESCE(category, ''), product_type,
-			       COALESCE(version, ''), COALESCE(download_url, ''), COALESCE(file_size, ''),
-			       COALESCE(license_key, ''), COALESCE(description, ''), COALESCE(features, ''),
-			       COALESCE(system_requirements, ''), created_at, updated_at
-			FROM products
-			WHERE is_active = true
-			ORDER BY created_at DESC
-		`
-
-		rows, err := db.Query(query)
-		if err != nil {
-			respondDBError(c, err)
+		// 목록은 키워드 검색을 하지 않는다 (/products/search 사용)
+		params, msg := parseProductListParams(c, db, false)
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
 			return
 		}
-		defer rows.Close()
-
-		products := []models.Product{}
-		for rows.Next() {
-			var p models.Product
-			err := rows.Scan(
-				&p.ID, &p.SellerID, &p.Name, &p.Price, &p.OriginalPrice, &p.Image,
-				&p.Category, &p.ProductType, &p.Version, &p.DownloadURL, &p.FileSize,
-				&p.LicenseKey, &p.Description, &p.Features, &p.SystemReq,
-				&p.CreatedAt, &p.UpdatedAt,
-			)
-			if err != nil {
-				respondDBError(c, err)
-				return
-			}
-			// Public listing: never expose downloadUrl/licenseKey to
-			// unauthenticated callers (CWE-639).
-			sanitizePublicProduct(&p)
-			products = append(products, p)
-		}
-
-		c.JSON(http.StatusOK, products)
+		respondProductList(c, db, params)
 	}
 }
 
@@ -93,19 +67,25 @@ func GetProduct(db *sql.DB) gin.HandlerFunc {
 			SELECT id, seller_id, name, price, COALESCE(original_price, 0), COALESCE(image, ''),
 			       COALESCE(category, ''), product_type,
 			       COALESCE(version, ''), COALESCE(download_url, ''), COALESCE(file_size, ''),
-			       COALESCE(license_key, ''), COALESCE(description, ''), COALESCE(features, ''),
-			       COALESCE(system_requirements, ''), created_at, updated_at
+			       COALESCE(license_key, ''), COALESCE(description, ''), features,
+			       system_requirements, created_at, updated_at, category_id,
+			       ARRAY(SELECT t.slug FROM product_tags pt JOIN tags t ON t.id = pt.tag_id
+			             WHERE pt.product_id = products.id ORDER BY t.slug),
+			       is_single_purchase, max_quantity
 			FROM products
 			WHERE id = $1 AND is_active = true
 		`
 
 		var p models.Product
+		var tags pq.StringArray
 		err = db.QueryRow(query, id).Scan(
 			&p.ID, &p.SellerID, &p.Name, &p.Price, &p.OriginalPrice, &p.Image,
 			&p.Category, &p.ProductType, &p.Version, &p.DownloadURL, &p.FileSize,
 			&p.LicenseKey, &p.Description, &p.Features, &p.SystemReq,
-			&p.CreatedAt, &p.UpdatedAt,
+			&p.CreatedAt, &p.UpdatedAt, &p.CategoryID, &tags,
+			&p.IsSinglePurchase, &p.MaxQuantity,
 		)
+		p.Tags = tags
 
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
@@ -152,6 +132,10 @@ func CreateProduct(db *sql.DB) gin.HandlerFunc {
 				return
 			}
 		}
+		if msg := validateProductSpecs(req.Features, req.SystemReq); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
 
 		// Validate product type and role restrictions. Normalize the type
 		// (lowercase + trim) so case/whitespace variants like "Program",
@@ -166,26 +150,100 @@ func CreateProduct(db *sql.DB) gin.HandlerFunc {
 			}
 		}
 
+		priceSync := priceSyncManual
+		if req.PriceSync != nil {
+			v, ok := normalizePriceSync(*req.PriceSync)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "priceSync must be manual, krw or usdc"})
+				return
+			}
+			priceSync = v
+		}
+		var cryptoPrice int64
+		if req.CryptoPriceUsdc != nil {
+			cryptoPrice = *req.CryptoPriceUsdc
+		}
+		if priceSync == priceSyncUSDC && cryptoPrice <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "priceSync=usdc requires cryptoPriceUsdc"})
+			return
+		}
+
+		// 라이프사이클 — 기본은 즉시 공개 (status=draft로 초안 저장, publishAt으로 예약)
+		requestedStatus := productStatusPublished
+		if req.Status != nil {
+			requestedStatus = *req.Status
+		}
+		status, publishAt, msg := resolveProductLifecycle(requestedStatus, req.PublishAt, time.Now())
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		// 관리자 외 판매자의 공개/예약 공개는 심사 대기 (product_moderation.go)
+		if requiresModeration(db, sellerID.(int)) {
+			status = moderatedStatus(status, publishAt)
+		}
+
+		// 장바구니 수량 규칙 — 디지털 상품은 기본 1개 (cart_rules.go)
+		isSinglePurchase := true
+		if req.IsSinglePurchase != nil {
+			isSinglePurchase = *req.IsSinglePurchase
+		}
+		maxQuantity := nullableMaxQuantity(req.MaxQuantity)
+
+		// 업로드 아티팩트 연결 — fileSize/image는 아티팩트에서 계산한다 (본인 업로드만)
+		if req.ArtifactID != nil {
+			a, err := loadOwnedArtifact(db, sellerID.(int), *req.ArtifactID, artifactKindFile)
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "artifactId not found"})
+				return
+			}
+			if err != nil {
+				respondDBError(c, err)
+				return
+			}
+			req.FileSize = &a.FileSize
+		}
+		if req.ImageArtifactID != nil {
+			a, err := loadOwnedArtifact(db, sellerID.(int), *req.ImageArtifactID, artifactKindImage)
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "imageArtifactId not found"})
+				return
+			}
+			if err != nil {
+				respondDBError(c, err)
+				return
+			}
+			req.Image = a.ImageURL
+		}
+
 		query := `
 			INSERT INTO products (seller_id, name, price, original_price, image, category,
 			                      product_type, version, download_url, file_size, license_key,
-			                      description, features, system_requirements)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+			                      description, features, system_requirements, artifact_id,
+			                      crypto_price_usdc, price_sync, status, publish_at,
+			                      is_single_purchase, max_quantity, image_artifact_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
 			RETURNING id, seller_id, name, price, original_price, image, category, product_type,
 			          version, download_url, file_size, license_key, description, features,
-			          system_requirements, created_at, updated_at
+			          system_requirements, created_at, updated_at, artifact_id,
+			          crypto_price_usdc, price_sync, status, publish_at,
+			          is_single_purchase, max_quantity
 		`
 
 		var p models.Product
 		err := db.QueryRow(query,
 			sellerID, req.Name, req.Price, req.OriginalPrice, req.Image, req.Category,
 			normalizedType, req.Version, req.DownloadURL, req.FileSize, req.LicenseKey,
-			req.Description, req.Features, req.SystemReq,
+			req.Description, req.Features, req.SystemReq, req.ArtifactID,
+			cryptoPrice, priceSync, status, publishAt,
+			isSinglePurchase, maxQuantity, req.ImageArtifactID,
 		).Scan(
 			&p.ID, &p.SellerID, &p.Name, &p.Price, &p.OriginalPrice, &p.Image,
 			&p.Category, &p.ProductType, &p.Version, &p.DownloadURL, &p.FileSize,
 			&p.LicenseKey, &p.Description, &p.Features, &p.SystemReq,
-			&p.CreatedAt, &p.UpdatedAt,
+			&p.CreatedAt, &p.UpdatedAt, &p.ArtifactID,
+			&p.CryptoPriceUsdc, &p.PriceSync, &p.Status, &p.PublishAt,
+			&p.IsSinglePurchase, &p.MaxQuantity,
 		)
 
 		if err != nil {
@@ -193,6 +251,14 @@ func CreateProduct(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
+		applyProductPriceSync(db, &p)
+		queueProductEmbedding(db, p.ID)
+		if p.Status == productStatusPendingReview {
+			if err := recordModerationEvent(db, p.ID, sellerID.(int), moderationSubmitted, ""); err != nil {
+				log.Printf("[moderation] failed to record submission (product=%d): %v", p.ID, err)
+			}
+		}
+
 		c.JSON(http.StatusCreated, p)
 	}
 }
@@ -225,6 +291,45 @@ func UpdateProduct(db *sql.DB) gin.HandlerFunc {
 				return
 			}
 		}
+		if msg := validateProductSpecs(req.Features, req.SystemReq); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		// 업로드 아티팩트 연결 (0 = 연결 해제). fileSize/image를 아티팩트 기준으로 덮어쓴다.
+		var artifactID *int
+		if req.ArtifactID != nil && *req.ArtifactID != 0 {
+			a, err := loadOwnedArtifact(db, sellerID.(int), *req.ArtifactID, artifactKindFile)
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "artifactId not found"})
+				return
+			}
+			if err != nil {
+				respondDBError(c, err)
+				return
+			}
+			artifactID = &a.ID
+			req.FileSize = &a.FileSize
+		}
+		// image를 직접 바꾸면 아티팩트 참조는 끊긴다 (imageArtifactID nil)
+		var imageArtifactID *int
+		if req.ImageArtifactID != nil {
+			image := ""
+			if *req.ImageArtifactID != 0 {
+				a, err := loadOwnedArtifact(db, sellerID.(int), *req.ImageArtifactID, artifactKindImage)
+				if err == sql.ErrNoRows {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "imageArtifactId not found"})
+					return
+				}
+				if err != nil {
+					respondDBError(c, err)
+					return
+				}
+				image = a.ImageURL
+				imageArtifactID = &a.ID
+			}
+			req.Image = &image
+		}
 
 		// Build dynamic update query
 		query := "UPDATE products SET updated_at = CURRENT_TIMESTAMP"
@@ -241,15 +346,51 @@ func UpdateProduct(db *sql.DB) gin.HandlerFunc {
 			args = append(args, *req.Price)
 			argIndex++
 		}
+		if req.CryptoPriceUsdc != nil {
+			query += ", crypto_price_usdc = $" + strconv.Itoa(argIndex)
+			args = append(args, *req.CryptoPriceUsdc)
+			argIndex++
+		}
+		if req.PriceSync != nil {
+			priceSync, ok := normalizePriceSync(*req.PriceSync)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "priceSync must be manual, krw or usdc"})
+				return
+			}
+			query += ", price_sync = $" + strconv.Itoa(argIndex)
+			args = append(args, priceSync)
+			argIndex++
+		}
+		// 관리자 외 판매자: 공개 요청이나 공개(예약) 중인 상품의 내용 수정은 다시 심사 대기로
+		moderated := requiresModeration(db, sellerID.(int))
+		listingChanged := updateChangesListing(&req)
+		if req.Status != nil {
+			status, publishAt, msg := resolveProductLifecycle(*req.Status, req.PublishAt, time.Now())
+			if msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+			if moderated {
+				status = moderatedStatus(status, publishAt)
+			}
+			query += ", status = $" + strconv.Itoa(argIndex) + ", publish_at = $" + strconv.Itoa(argIndex+1)
+			args = append(args, status, publishAt)
+			argIndex += 2
+		} else if req.PublishAt != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "publishAt requires status"})
+			return
+		} else if moderated && listingChanged {
+			query += ", status = CASE WHEN status = 'published' OR (status = 'draft' AND publish_at IS NOT NULL) THEN 'pending_review' ELSE status END"
+		}
 		if req.OriginalPrice != nil {
 			query += ", original_price = $" + strconv.Itoa(argIndex)
 			args = append(args, *req.OriginalPrice)
 			argIndex++
 		}
 		if req.Image != nil {
-			query += ", image = $" + strconv.Itoa(argIndex)
-			args = append(args, *req.Image)
-			argIndex++
+			query += ", image = $" + strconv.Itoa(argIndex) + ", image_artifact_id = $" + strconv.Itoa(argIndex+1)
+			args = append(args, *req.Image, imageArtifactID)
+			argIndex += 2
 		}
 		if req.Category != nil {
 			query += ", category = $" + strconv.Itoa(argIndex)
@@ -284,7 +425,15 @@ func UpdateProduct(db *sql.DB) gin.HandlerFunc {
 			args = append(args, *req.DownloadURL)
 			argIndex++
 		}
-		if req.FileSize != nil {
+		if req.ArtifactID != nil {
+			query += ", artifact_id = $" + strconv.Itoa(argIndex)
+			args = append(args, artifactID)
+			argIndex++
+			if artifactID == nil {
+				query += ", file_size = NULL"
+			}
+		}
+		if req.FileSize != nil && (req.ArtifactID == nil || artifactID != nil) {
 			query += ", file_size = $" + strconv.Itoa(argIndex)
 			args = append(args, *req.FileSize)
 			argIndex++
@@ -306,12 +455,22 @@ func UpdateProduct(db *sql.DB) gin.HandlerFunc {
 		}
 		if req.SystemReq != nil {
 			query += ", system_requirements = $" + strconv.Itoa(argIndex)
-			args = append(args, *req.SystemReq)
+			args = append(args, req.SystemReq)
+			argIndex++
+		}
+		if req.IsSinglePurchase != nil {
+			query += ", is_single_purchase = $" + strconv.Itoa(argIndex)
+			args = append(args, *req.IsSinglePurchase)
+			argIndex++
+		}
+		if req.MaxQuantity != nil {
+			query += ", max_quantity = $" + strconv.Itoa(argIndex)
+			args = append(args, nullableMaxQuantity(req.MaxQuantity))
 			argIndex++
 		}
 
-		query += " WHERE id = $" + strconv.Itoa(argIndex) + " AND seller_id = $" + strconv.Itoa(argIndex+1)
-		query += " RETURNING id, seller_id, name, price, original_price, image, category, product_type, version, download_url, file_size, license_key, description, features, system_requirements, created_at, updated_at"
+		query += " WHERE id = $" + strconv.Itoa(argIndex) + " AND seller_id = $" + strconv.Itoa(argIndex+1) + " AND deleted_at IS NULL"
+		query += " RETURNING id, seller_id, name, price, original_price, image, category, product_type, version, download_url, file_size, license_key, description, features, system_requirements, created_at, updated_at, artifact_id, crypto_price_usdc, price_sync, status, publish_at, is_single_purchase, max_quantity"
 		args = append(args, id, sellerID)
 
 		var p models.Product
@@ -319,7 +478,8 @@ func UpdateProduct(db *sql.DB) gin.HandlerFunc {
 			&p.ID, &p.SellerID, &p.Name, &p.Price, &p.OriginalPrice, &p.Image,
 			&p.Category, &p.ProductType, &p.Version, &p.DownloadURL, &p.FileSize,
 			&p.LicenseKey, &p.Description, &p.Features, &p.SystemReq,
-			&p.CreatedAt, &p.UpdatedAt,
+			&p.CreatedAt, &p.UpdatedAt, &p.ArtifactID, &p.CryptoPriceUsdc, &p.PriceSync,
+			&p.Status, &p.PublishAt, &p.IsSinglePurchase, &p.MaxQuantity,
 		)
 
 		if err == sql.ErrNoRows {
@@ -331,11 +491,21 @@ func UpdateProduct(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
+		applyProductPriceSync(db, &p)
+		queueProductEmbedding(db, p.ID)
+		if p.Status == productStatusPendingReview && (req.Status != nil || listingChanged) {
+			if err := recordModerationEvent(db, p.ID, sellerID.(int), moderationSubmitted, ""); err != nil {
+				log.Printf("[moderation] failed to record submission (product=%d): %v", p.ID, err)
+			}
+		}
+
 		c.JSON(http.StatusOK, p)
 	}
 }
 
-// DeleteProduct deletes a product (seller only, own products)
+// DeleteProduct soft-deletes a product (seller only, own products). The row
+// stays so payments that reference it keep their purchase history, downloads
+// and licenses; it just disappears from public listings and GetMyProducts.
 func DeleteProduct(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		sellerID, exists := c.Get("userId")
@@ -350,7 +520,10 @@ func DeleteProduct(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		result, err := db.Exec("DELETE FROM products WHERE id = $1 AND seller_id = $2", id, sellerID)
+		result, err := db.Exec(
+			"UPDATE products SET deleted_at = NOW(), publish_at = NULL, updated_at = NOW() WHERE id = $1 AND seller_id = $2 AND deleted_at IS NULL",
+			id, sellerID,
+		)
 		if err != nil {
 			respondDBError(c, err)
 			return
@@ -362,11 +535,18 @@ func DeleteProduct(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
+		// 하드 삭제 시 CASCADE로 지워지던 장바구니 항목은 직접 정리한다
+		if _, err := db.Exec("DELETE FROM cart WHERE product_id = $1", id); err != nil {
+			respondDBError(c, err)
+			return
+		}
+
 		c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
 	}
 }
 
-// GetMyProducts returns products owned by the authenticated seller
+// GetMyProducts returns products owned by the authenticated seller in every
+// lifecycle state (?status= narrows to one). Deleted products are omitted.
 func GetMyProducts(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		sellerID, exists := c.Get("userId")
@@ -378,13 +558,21 @@ func GetMyProducts(db *sql.DB) gin.HandlerFunc {
 		query := `
 			SELECT id, seller_id, name, price, original_price, image, category, product_type,
 			       version, download_url, file_size, license_key, description, features,
-			       system_requirements, created_at, updated_at
+			       system_requirements, created_at, updated_at, artifact_id, status, publish_at, review_note
 			FROM products
-			WHERE seller_id = $1
-			ORDER BY created_at DESC
+			WHERE seller_id = $1 AND deleted_at IS NULL
 		`
+		args := []interface{}{sellerID}
+		if status := c.Query("status"); status != "" {
+			if !productStatuses[status] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "status must be draft, pending_review, published or archived"})
+				return
+			}
+			query += " AND status = " + appendArg(&args, status)
+		}
+		query += " ORDER BY created_at DESC"
 
-		rows, err := db.Query(query, sellerID)
+		rows, err := db.Query(query, args...)
 		if err != nil {
 			respondDBError(c, err)
 			return
@@ -398,7 +586,7 @@ func GetMyProducts(db *sql.DB) gin.HandlerFunc {
 				&p.ID, &p.SellerID, &p.Name, &p.Price, &p.OriginalPrice, &p.Image,
 				&p.Category, &p.ProductType, &p.Version, &p.DownloadURL, &p.FileSize,
 				&p.LicenseKey, &p.Description, &p.Features, &p.SystemReq,
-				&p.CreatedAt, &p.UpdatedAt,
+				&p.CreatedAt, &p.UpdatedAt, &p.ArtifactID, &p.Status, &p.PublishAt, &p.ReviewNote,
 			)
 			if err != nil {
 				respondDBError(c, err)
@@ -411,67 +599,21 @@ func GetMyProducts(db *sql.DB) gin.HandlerFunc {
 	}
 }
 
-// SearchProducts searches products by name or description
+// SearchProducts full-text searches active products (name, category and
+// description; ranked, with highlighted snippets), with the same
+// paging/sort/filter parameters as GetProducts. mode=semantic blends in
+// vector similarity.
 func SearchProducts(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		search := c.Query("q")
-		productType := c.Query("type")
-		category := c.Query("category")
-
-		query := `
-			SELECT id, seller_id, name, price, original_price, image, category, product_type,
-			       version, download_url, file_size, license_key, description, features,
-			       system_requirements, created_at, updated_at
-			FROM products
-			WHERE 1=1
-		`
-		args := []interface{}{}
-		argIndex := 1
-
-		if search != "" {
-			query += " AND (LOWER(name) LIKE $" + strconv.Itoa(argIndex) + " OR LOWER(description) LIKE $" + strconv.Itoa(argIndex) + ")"
-			args = append(args, "%"+strings.ToLower(search)+"%")
-			argIndex++
-		}
-		if productType != "" {
-			query += " AND product_type = $" + strconv.Itoa(argIndex)
-			args = append(args, productType)
-			argIndex++
-		}
-		if category != "" {
-			query += " AND category = $" + strconv.Itoa(argIndex)
-			args = append(args, category)
-			argIndex++
-		}
-
-		query += " ORDER BY created_at DESC"
-
-		rows, err := db.Query(query, args...)
-		if err != nil {
-			respondDBError(c, err)
+		params, msg := parseProductListParams(c, db, true)
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
 			return
 		}
-		defer rows.Close()
-
-		products := []models.Product{}
-		for rows.Next() {
-			var p models.Product
-			err := rows.Scan(
-				&p.ID, &p.SellerID, &p.Name, &p.Price, &p.OriginalPrice, &p.Image,
-				&p.Category, &p.ProductType, &p.Version, &p.DownloadURL, &p.FileSize,
-				&p.LicenseKey, &p.Description, &p.Features, &p.SystemReq,
-				&p.CreatedAt, &p.UpdatedAt,
-			)
-			if err != nil {
-				respondDBError(c, err)
-				return
-			}
-			// Public search endpoint: never expose downloadUrl/licenseKey to
-			// any caller (CWE-639).
-			sanitizePublicProduct(&p)
-			products = append(products, p)
+		if c.Query("mode") == "semantic" && params.Search != "" {
+			respondSemanticProductSearch(c, db, params)
+			return
 		}
-
-		c.JSON(http.StatusOK, products)
+		respondProductList(c, db, params)
 	}
 }
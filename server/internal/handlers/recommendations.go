@@ -0,0 +1,451 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"cmall_dd/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// ── 추천 ──────────────────────────────────────────────────────────────────
+// GET /products/:id/similar — 임베딩 최근접 이웃 (pgvector 없으면 같은 카테고리 인기순)
+// GET /recommendations — 로그인 사용자 개인화:
+//   신호: 구매(paid 결제) · 장바구니 · 최근 90일 분석 request_type 사용량
+//   점수: 0.5 × 프로필 임베딩 유사도 + 0.2 × 카테고리 선호 + 0.2 × 분석 유형 선호 + 0.1 × 인기
+//   신호가 없으면(콜드 스타트) 인기순. 이미 구매한 상품은 항상 제외.
+// 결과는 사용자별로 인스턴스 메모리에 캐시하고(RECOMMENDATION_CACHE_TTL_SECONDS, 기본 120),
+// 결제가 paid로 바뀌거나 (onPaymentPaid) 로그인 사용자의 장바구니 라인이 추가/삭제/병합되면 무효화한다.
+// 무효화는 그 요청을 처리한 인스턴스에만 적용된다 — 다중 인스턴스 배포에서 다른 인스턴스의 캐시는
+// TTL이 지날 때까지 남으므로 TTL을 짧게 유지한다.
+
+const (
+	maxRecommendations       = 20
+	recommendationPoolSize   = 200
+	recommendationVectorPool = 100
+)
+
+// Recommendation — 추천 항목 (reason: similar | category | analysis | popular)
+type Recommendation struct {
+	Product models.Product `json:"product"`
+	Score   float64        `json:"score"`
+	Reason  string         `json:"reason"`
+}
+
+type recommendationCacheEntry struct {
+	items     []Recommendation
+	source    string
+	expiresAt time.Time
+}
+
+var (
+	recommendationCacheMu sync.Mutex
+	recommendationCache   = map[int]recommendationCacheEntry{}
+)
+
+// invalidateRecommendations — 구매 등으로 추천이 바뀌어야 할 때 사용자 캐시 삭제 (이 인스턴스만)
+func invalidateRecommendations(userID int) {
+	recommendationCacheMu.Lock()
+	defer recommendationCacheMu.Unlock()
+	delete(recommendationCache, userID)
+}
+
+// GetSimilarProducts — GET /api/v1/products/:id/similar?limit= (공개)
+func GetSimilarProducts(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+		if err != nil || limit < 1 || limit > maxRecommendations {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 20"})
+			return
+		}
+
+		var category string
+		err = db.QueryRow("SELECT COALESCE(category, '') FROM products WHERE id = $1 AND is_active = true", id).Scan(&category)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+
+		products, err := queryListedProducts(db, `
+			WHERE p.is_active = true AND p.id <> $1 AND p.embedding IS NOT NULL
+			  AND p.embedding_model = (SELECT embedding_model FROM products WHERE id = $1)
+			ORDER BY p.embedding <=> (SELECT embedding FROM products WHERE id = $1)
+			LIMIT $2
+		`, id, limit)
+		if err != nil {
+			// pgvector 미설치 등 — 카테고리 인기순으로 대체
+			log.Printf("[recommendations] similar query failed, using category fallback: %v", err)
+			products = nil
+		}
+		if len(products) == 0 {
+			products, err = queryListedProducts(db, `
+				WHERE p.is_active = true AND p.id <> $1 AND COALESCE(p.category, '') = $2
+				ORDER BY COALESCE(pop.sales, 0) DESC, p.created_at DESC
+				LIMIT $3
+			`, id, category, limit)
+			if err != nil {
+				respondDBError(c, err)
+				return
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"items": products})
+	}
+}
+
+// GetRecommendations — GET /api/v1/recommendations?limit= (JWT)
+func GetRecommendations(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDValue, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		userID := userIDValue.(int)
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+		if err != nil || limit < 1 || limit > maxRecommendations {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 20"})
+			return
+		}
+
+		recommendationCacheMu.Lock()
+		entry, ok := recommendationCache[userID]
+		recommendationCacheMu.Unlock()
+		if !ok || time.Now().After(entry.expiresAt) {
+			items, source, err := buildRecommendations(db, userID)
+			if err != nil {
+				respondDBError(c, err)
+				return
+			}
+			ttl := time.Duration(envInt("RECOMMENDATION_CACHE_TTL_SECONDS", 120)) * time.Second
+			entry = recommendationCacheEntry{items: items, source: source, expiresAt: time.Now().Add(ttl)}
+			recommendationCacheMu.Lock()
+			if len(recommendationCache) >= 10000 {
+				for id, e := range recommendationCache {
+					if time.Now().After(e.expiresAt) {
+						delete(recommendationCache, id)
+					}
+				}
+			}
+			recommendationCache[userID] = entry
+			recommendationCacheMu.Unlock()
+		}
+
+		items := entry.items
+		if len(items) > limit {
+			items = items[:limit]
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items, "source": entry.source})
+	}
+}
+
+// recommendationSignals — 사용자 선호 신호
+type recommendationSignals struct {
+	purchased     map[int]bool
+	inCart        map[int]bool
+	seeds         []int              // 프로필 임베딩 대상 (구매 + 장바구니)
+	categoryShare map[string]float64 // seed 상품 카테고리 비율
+	requestShare  map[string]float64 // 분석 request_type 사용 비율
+}
+
+func (s recommendationSignals) cold() bool {
+	return len(s.seeds) == 0 && len(s.requestShare) == 0
+}
+
+func loadRecommendationSignals(db *sql.DB, userID int) (recommendationSignals, error) {
+	s := recommendationSignals{
+		purchased:     map[int]bool{},
+		inCart:        map[int]bool{},
+		categoryShare: map[string]float64{},
+		requestShare:  map[string]float64{},
+	}
+
+	rows, err := db.Query(`
+		SELECT pr.id, COALESCE(pr.category, ''), 'purchase' FROM payments pay
+		JOIN products pr ON pr.id = pay.order_id
+		WHERE pay.user_id = $1 AND pay.status = 'paid'
+		UNION ALL
+		SELECT pr.id, COALESCE(pr.category, ''), 'cart' FROM cart ct
+		JOIN products pr ON pr.id = ct.product_id
+		WHERE ct.user_id = $1
+	`, userID)
+	if err != nil {
+		return s, err
+	}
+	seen := map[int]bool{}
+	categoryCount := map[string]int{}
+	for rows.Next() {
+		var id int
+		var category, source string
+		if err := rows.Scan(&id, &category, &source); err != nil {
+			rows.Close()
+			return s, err
+		}
+		if source == "purchase" {
+			s.purchased[id] = true
+		} else {
+			s.inCart[id] = true
+		}
+		if !seen[id] {
+			seen[id] = true
+			s.seeds = append(s.seeds, id)
+			if category != "" {
+				categoryCount[category]++
+			}
+		}
+	}
+	rows.Close()
+	for category, n := range categoryCount {
+		s.categoryShare[category] = float64(n) / float64(len(s.seeds))
+	}
+
+	rows, err = db.Query(`
+		SELECT request_type, COUNT(*) FROM analysis_requests
+		WHERE user_id = $1 AND created_at > NOW() - INTERVAL '90 days'
+		GROUP BY request_type
+	`, userID)
+	if err != nil {
+		return s, err
+	}
+	defer rows.Close()
+	total := 0
+	counts := map[string]int{}
+	for rows.Next() {
+		var requestType string
+		var n int
+		if err := rows.Scan(&requestType, &n); err != nil {
+			return s, err
+		}
+		counts[requestType] = n
+		total += n
+	}
+	for requestType, n := range counts {
+		s.requestShare[requestType] = float64(n) / float64(total)
+	}
+	return s, rows.Err()
+}
+
+// recommendationCandidate — 점수 계산 입력
+type recommendationCandidate struct {
+	product     models.Product
+	requestType string
+	similarity  float64 // 프로필 임베딩 코사인 유사도 (없으면 0)
+}
+
+// scoreRecommendations — 후보 점수화/정렬 (순수 함수). 구매했거나 장바구니에 있는 상품은 제외한다.
+func scoreRecommendations(cands []recommendationCandidate, s recommendationSignals, limit int) []Recommendation {
+	maxSales := 0
+	for _, cand := range cands {
+		if cand.product.SalesCount > maxSales {
+			maxSales = cand.product.SalesCount
+		}
+	}
+
+	out := make([]Recommendation, 0, len(cands))
+	for _, cand := range cands {
+		if s.purchased[cand.product.ID] || s.inCart[cand.product.ID] {
+			continue
+		}
+		popularity := 0.0
+		if maxSales > 0 {
+			popularity = float64(cand.product.SalesCount) / float64(maxSales)
+		}
+		if s.cold() {
+			out = append(out, Recommendation{Product: cand.product, Score: popularity, Reason: "popular"})
+			continue
+		}
+
+		components := map[string]float64{
+			"similar":  0.5 * cand.similarity,
+			"category": 0.2 * s.categoryShare[cand.product.Category],
+			"analysis": 0.2 * s.requestShare[cand.requestType],
+			"popular":  0.1 * popularity,
+		}
+		var score, best float64
+		reason := "popular"
+		for _, name := range []string{"similar", "category", "analysis", "popular"} {
+			score += components[name]
+			if components[name] > best {
+				best, reason = components[name], name
+			}
+		}
+		out = append(out, Recommendation{Product: cand.product, Score: score, Reason: reason})
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].Product.ID > out[j].Product.ID
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// buildRecommendations — 신호 로드 → 후보 풀(인기 상위 + 프로필 최근접) → 점수화
+func buildRecommendations(db *sql.DB, userID int) ([]Recommendation, string, error) {
+	signals, err := loadRecommendationSignals(db, userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	purchased := make([]int, 0, len(signals.purchased))
+	for id := range signals.purchased {
+		purchased = append(purchased, id)
+	}
+
+	requestTypes := make([]string, 0, len(signals.requestShare))
+	for requestType := range signals.requestShare {
+		requestTypes = append(requestTypes, requestType)
+	}
+
+	// 후보 풀: 인기 상위 + 사용자가 쓰는 분석 유형의 유료 분석 상품
+	pool, err := queryListedProducts(db, `
+		WHERE p.is_active = true AND NOT (p.id = ANY($1))
+		ORDER BY COALESCE(pop.sales, 0) DESC, p.created_at DESC
+		LIMIT `+strconv.Itoa(recommendationPoolSize), pq.Array(purchased))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(requestTypes) > 0 {
+		analysisProducts, err := queryListedProducts(db, `
+			WHERE p.is_active = true AND NOT (p.id = ANY($1))
+			  AND p.crypto_price_usdc > 0 AND p.request_type = ANY($2)
+			LIMIT 50`, pq.Array(purchased), pq.Array(requestTypes))
+		if err != nil {
+			return nil, "", err
+		}
+		pool = append(pool, analysisProducts...)
+	}
+	cands := map[int]*recommendationCandidate{}
+	for _, p := range pool {
+		cands[p.ID] = &recommendationCandidate{product: p}
+	}
+
+	if len(signals.seeds) > 0 {
+		if err := addProfileSimilarities(db, cands, signals.seeds, purchased); err != nil {
+			// pgvector 미설치 등 — 유사도 없이 카테고리/분석 신호만 사용
+			log.Printf("[recommendations] profile similarity unavailable: %v", err)
+		}
+	}
+	if err := loadCandidateRequestTypes(db, cands); err != nil {
+		return nil, "", err
+	}
+
+	list := make([]recommendationCandidate, 0, len(cands))
+	for _, cand := range cands {
+		list = append(list, *cand)
+	}
+	source := "personalized"
+	if signals.cold() {
+		source = "popular"
+	}
+	return scoreRecommendations(list, signals, maxRecommendations), source, nil
+}
+
+// addProfileSimilarities — seed 임베딩 평균(프로필)과의 유사도. 풀에 없던 근접 상품도 후보에 추가한다.
+func addProfileSimilarities(db *sql.DB, cands map[int]*recommendationCandidate, seeds, purchased []int) error {
+	rows, err := db.Query(`
+		WITH profile AS (
+			SELECT AVG(embedding) AS v, MIN(embedding_model) AS model
+			FROM products WHERE id = ANY($1) AND embedding IS NOT NULL
+		)
+		SELECT p.id, 1 - (p.embedding <=> profile.v)
+		FROM products p, profile
+		WHERE profile.v IS NOT NULL AND p.is_active = true AND p.embedding IS NOT NULL
+		  AND p.embedding_model = profile.model AND NOT (p.id = ANY($2))
+		ORDER BY p.embedding <=> profile.v
+		LIMIT `+strconv.Itoa(recommendationVectorPool), pq.Array(seeds), pq.Array(purchased))
+	if err != nil {
+		return err
+	}
+	similarities := map[int]float64{}
+	var missing []int
+	for rows.Next() {
+		var id int
+		var sim float64
+		if err := rows.Scan(&id, &sim); err != nil {
+			rows.Close()
+			return err
+		}
+		similarities[id] = sim
+		if cands[id] == nil {
+			missing = append(missing, id)
+		}
+	}
+	rows.Close()
+
+	if len(missing) > 0 {
+		products, err := queryListedProducts(db, "WHERE p.id = ANY($1)", pq.Array(missing))
+		if err != nil {
+			return err
+		}
+		for _, p := range products {
+			cands[p.ID] = &recommendationCandidate{product: p}
+		}
+	}
+	for id, sim := range similarities {
+		if cand := cands[id]; cand != nil {
+			cand.similarity = sim
+		}
+	}
+	return nil
+}
+
+func loadCandidateRequestTypes(db *sql.DB, cands map[int]*recommendationCandidate) error {
+	ids := make([]int, 0, len(cands))
+	for id := range cands {
+		ids = append(ids, id)
+	}
+	rows, err := db.Query(
+		"SELECT id, request_type FROM products WHERE id = ANY($1) AND crypto_price_usdc > 0", pq.Array(ids),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var requestType string
+		if err := rows.Scan(&id, &requestType); err != nil {
+			return err
+		}
+		cands[id].requestType = requestType
+	}
+	return rows.Err()
+}
+
+// queryListedProducts — productListSelect + 조건(WHERE/ORDER/LIMIT) 조회, 공개 필드만
+func queryListedProducts(db *sql.DB, tail string, args ...interface{}) ([]models.Product, error) {
+	rows, err := db.Query(productListSelect+tail, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	products := []models.Product{}
+	for rows.Next() {
+		p, err := scanListedProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		sanitizePublicProduct(&p)
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
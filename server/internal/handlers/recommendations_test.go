@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"testing"
+
+	"cmall_dd/internal/models"
+)
+
+func recommendationIDs(recs []Recommendation) []int {
+	ids := make([]int, len(recs))
+	for i, r := range recs {
+		ids[i] = r.Product.ID
+	}
+	return ids
+}
+
+func TestScoreRecommendations(t *testing.T) {
+	cands := []recommendationCandidate{
+		{product: models.Product{ID: 1, Category: "차트", SalesCount: 10}, similarity: 0.9},
+		{product: models.Product{ID: 2, Category: "전자책", SalesCount: 100}},
+		{product: models.Product{ID: 3, Category: "분석"}, requestType: "swing_screener"},
+		{product: models.Product{ID: 4, Category: "차트", SalesCount: 50}},
+	}
+	signals := recommendationSignals{
+		purchased:     map[int]bool{},
+		inCart:        map[int]bool{},
+		seeds:         []int{9},
+		categoryShare: map[string]float64{"차트": 1},
+		requestShare:  map[string]float64{"swing_screener": 1},
+	}
+	got := scoreRecommendations(cands, signals, 10)
+
+	// 1: 0.45 + 0.2 + 0.01 / 4: 0.2 + 0.05 / 3: 0.2 / 2: 0.1
+	want := []int{1, 4, 3, 2}
+	if ids := recommendationIDs(got); len(ids) != len(want) {
+		t.Fatalf("ids = %v, want %v", ids, want)
+	} else {
+		for i := range want {
+			if ids[i] != want[i] {
+				t.Fatalf("ids = %v, want %v", ids, want)
+			}
+		}
+	}
+	reasons := map[int]string{1: "similar", 4: "category", 3: "analysis", 2: "popular"}
+	for _, r := range got {
+		if r.Reason != reasons[r.Product.ID] {
+			t.Errorf("product %d reason = %s, want %s", r.Product.ID, r.Reason, reasons[r.Product.ID])
+		}
+	}
+	if len(scoreRecommendations(cands, signals, 2)) != 2 {
+		t.Error("limit not applied")
+	}
+}
+
+func TestScoreRecommendationsColdStart(t *testing.T) {
+	cands := []recommendationCandidate{
+		{product: models.Product{ID: 1, SalesCount: 5}, similarity: 0.99},
+		{product: models.Product{ID: 2, SalesCount: 20}},
+		{product: models.Product{ID: 3, SalesCount: 20}},
+	}
+	signals := recommendationSignals{purchased: map[int]bool{}, inCart: map[int]bool{}}
+	if !signals.cold() {
+		t.Fatal("signals without seeds or analysis history should be cold")
+	}
+	got := scoreRecommendations(cands, signals, 10)
+	// 인기순만 사용, 동점이면 id 내림차순
+	ids := recommendationIDs(got)
+	if len(ids) != 3 || ids[0] != 3 || ids[1] != 2 || ids[2] != 1 {
+		t.Fatalf("ids = %v", ids)
+	}
+	for _, r := range got {
+		if r.Reason != "popular" {
+			t.Errorf("product %d reason = %s, want popular", r.Product.ID, r.Reason)
+		}
+	}
+	if got[0].Score != 1 || got[2].Score != 0.25 {
+		t.Errorf("popularity scores = %v, %v", got[0].Score, got[2].Score)
+	}
+}
+
+func TestScoreRecommendationsExcludesOwned(t *testing.T) {
+	cands := []recommendationCandidate{
+		{product: models.Product{ID: 1, Category: "차트", SalesCount: 10}, similarity: 1},
+		{product: models.Product{ID: 2, Category: "차트", SalesCount: 10}, similarity: 1},
+		{product: models.Product{ID: 3, Category: "전자책", SalesCount: 1}},
+	}
+	signals := recommendationSignals{
+		purchased:     map[int]bool{1: true},
+		inCart:        map[int]bool{2: true},
+		seeds:         []int{1, 2},
+		categoryShare: map[string]float64{"차트": 1},
+		requestShare:  map[string]float64{},
+	}
+	ids := recommendationIDs(scoreRecommendations(cands, signals, 10))
+	if len(ids) != 1 || ids[0] != 3 {
+		t.Errorf("ids = %v, want only the unowned product", ids)
+	}
+
+	// 콜드 스타트(인기순) 경로에서도 구매 상품은 빠진다
+	signals = recommendationSignals{purchased: map[int]bool{3: true}, inCart: map[int]bool{}}
+	ids = recommendationIDs(scoreRecommendations(cands, signals, 10))
+	for _, id := range ids {
+		if id == 3 {
+			t.Errorf("purchased product recommended in cold start: %v", ids)
+		}
+	}
+}
@@ -0,0 +1,692 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ── 소프트웨어 릴리스 / 업데이트 확인 ────────────────────────────────────────
+// product_releases: 상품별 semver 버전 + 채널(stable/beta) + 변경 내역 + 아티팩트 1개.
+// 가장 높은 stable 릴리스가 products.version/artifact_id/file_size에 반영되어
+// 기존 상품 응답과 구매 다운로드(기본값)가 최신 정식 버전을 가리킨다.
+// 관리자가 아닌 판매자의 릴리스 등록/수정은 review_status=pending_review로 대기하고, 관리자 승인
+// 전까지는 공개 목록·업데이트 확인·버전별 다운로드·products 반영에서 모두 빠진다.
+// 데스크톱 매매 프로그램은 GET /products/:id/latest?current=&channel= 로 업데이트를 확인한다
+// (라이선스 키 또는 구매자 JWT 필요).
+
+const (
+	releaseChannelStable = "stable"
+	releaseChannelBeta   = "beta"
+
+	releaseReviewApproved = "approved"
+	releaseReviewPending  = "pending_review"
+	releaseReviewRejected = "rejected"
+)
+
+// ProductRelease — 릴리스 1건 (아티팩트 메타데이터 포함)
+type ProductRelease struct {
+	ID         int       `json:"id"`
+	ProductID  int       `json:"productId"`
+	Version    string    `json:"version"`
+	Channel    string    `json:"channel"`
+	Changelog  string    `json:"changelog"`
+	ArtifactID *int      `json:"artifactId,omitempty"`
+	FileSize   string    `json:"fileSize,omitempty"`
+	SHA256     string    `json:"sha256,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	// 심사 상태 — 공개 응답에는 approved만 나간다
+	ReviewStatus string  `json:"reviewStatus"`
+	ReviewNote   *string `json:"reviewNote,omitempty"`
+
+	semver semver
+}
+
+type releaseRequest struct {
+	Version    string  `json:"version"`
+	Channel    *string `json:"channel,omitempty"`
+	Changelog  *string `json:"changelog,omitempty"`
+	ArtifactID *int    `json:"artifactId,omitempty"`
+}
+
+func normalizeReleaseChannel(raw string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", releaseChannelStable:
+		return releaseChannelStable, true
+	case releaseChannelBeta:
+		return releaseChannelBeta, true
+	}
+	return "", false
+}
+
+// releaseReviewStatus — 새로 등록/수정된 릴리스의 심사 상태
+func releaseReviewStatus(moderated bool) string {
+	if moderated {
+		return releaseReviewPending
+	}
+	return releaseReviewApproved
+}
+
+// approvedReleases — 심사를 통과한 릴리스만 (순서 유지)
+func approvedReleases(releases []ProductRelease) []ProductRelease {
+	out := make([]ProductRelease, 0, len(releases))
+	for _, r := range releases {
+		if r.ReviewStatus == releaseReviewApproved {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// loadProductReleases — semver 내림차순. channel=stable이면 정식만, beta면 전체 (beta 구독자는
+// 더 새로운 stable도 받는다), ""이면 전체. 심사 상태와 무관하게 모두 반환하므로 공개 경로는
+// approvedReleases로 거른다.
+func loadProductReleases(db *sql.DB, productID int, channel string) ([]ProductRelease, error) {
+	query := `
+		SELECT r.id, r.product_id, r.version, r.channel, r.changelog, r.artifact_id,
+		       COALESCE(a.size_bytes, -1), COALESCE(a.sha256, ''), r.created_at,
+		       r.review_status, r.review_note
+		FROM product_releases r
+		LEFT JOIN artifacts a ON a.id = r.artifact_id
+		WHERE r.product_id = $1`
+	if channel == releaseChannelStable {
+		query += ` AND r.channel = 'stable'`
+	}
+	rows, err := db.Query(query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	releases := []ProductRelease{}
+	for rows.Next() {
+		var r ProductRelease
+		var artifactID sql.NullInt64
+		var size int64
+		if err := rows.Scan(&r.ID, &r.ProductID, &r.Version, &r.Channel, &r.Changelog, &artifactID,
+			&size, &r.SHA256, &r.CreatedAt, &r.ReviewStatus, &r.ReviewNote); err != nil {
+			return nil, err
+		}
+		if artifactID.Valid {
+			id := int(artifactID.Int64)
+			r.ArtifactID = &id
+		}
+		if size >= 0 {
+			r.FileSize = formatFileSize(size)
+		}
+		v, ok := parseSemver(r.Version)
+		if !ok {
+			continue // 저장 시 검증하므로 도달하지 않음
+		}
+		r.semver = v
+		releases = append(releases, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(releases, func(i, j int) bool {
+		return compareSemver(releases[i].semver, releases[j].semver) > 0
+	})
+	return releases, nil
+}
+
+// syncProductLatestRelease — 승인된 최고 stable 릴리스를 products 컬럼에 반영
+func syncProductLatestRelease(db *sql.DB, productID int) error {
+	releases, err := loadProductReleases(db, productID, releaseChannelStable)
+	if err != nil {
+		return err
+	}
+	releases = approvedReleases(releases)
+	if len(releases) == 0 {
+		return nil
+	}
+	top := releases[0]
+	var fileSize *string
+	if top.ArtifactID != nil {
+		fileSize = &top.FileSize
+	}
+	_, err = db.Exec(`
+		UPDATE products SET version = $2, artifact_id = COALESCE($3::int, artifact_id),
+		       file_size = COALESCE($4, file_size)
+		WHERE id = $1
+	`, productID, top.Version, top.ArtifactID, fileSize)
+	return err
+}
+
+// requireProductSeller — 상품 판매자 본인인지 확인. false면 응답이 이미 쓰였다.
+func requireProductSeller(c *gin.Context, db *sql.DB, productID, userID int) bool {
+	var sellerID int
+	err := db.QueryRow(`SELECT seller_id FROM products WHERE id = $1 AND deleted_at IS NULL`, productID).Scan(&sellerID)
+	if err == sql.ErrNoRows || (err == nil && sellerID != userID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found or not authorized"})
+		return false
+	}
+	if err != nil {
+		respondDBError(c, err)
+		return false
+	}
+	return true
+}
+
+// GetProductReleases — GET /api/v1/products/:id/releases?channel= (공개, 선택적 JWT)
+// 판매자 본인에게는 심사 대기/반려 릴리스도 보인다. 비공개 상품은 판매자 외에는 404, 삭제된 상품은 404.
+func GetProductReleases(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		productID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+		channel := ""
+		if raw := c.Query("channel"); raw != "" {
+			var ok bool
+			if channel, ok = normalizeReleaseChannel(raw); !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "channel must be stable or beta"})
+				return
+			}
+		}
+		var sellerID int
+		var isActive bool
+		err = db.QueryRow(`
+			SELECT seller_id, is_active FROM products WHERE id = $1 AND deleted_at IS NULL
+		`, productID).Scan(&sellerID, &isActive)
+		if err != nil && err != sql.ErrNoRows {
+			respondDBError(c, err)
+			return
+		}
+		// 판매자 본인은 초안/심사 대기/보관 상품의 릴리스도 본다
+		userID, ok := c.Get("userId")
+		isSeller := err == nil && ok && userID.(int) == sellerID
+		if err == sql.ErrNoRows || (!isActive && !isSeller) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		releases, err := loadProductReleases(db, productID, channel)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if !isSeller {
+			releases = approvedReleases(releases)
+		}
+		c.JSON(http.StatusOK, releases)
+	}
+}
+
+// CreateProductRelease — POST /api/v1/products/:id/releases (JWT, 판매자 본인)
+// body: {version(semver), channel(stable|beta), changelog, artifactId}
+// 관리자가 아니면 pending_review로 등록되어 승인 후에야 상품 파일에 반영된다.
+func CreateProductRelease(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		productID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+		var req releaseRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if _, ok := parseSemver(req.Version); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "version must be a semantic version (e.g. 1.2.0 or 1.3.0-beta.1)"})
+			return
+		}
+		version := strings.TrimPrefix(strings.TrimSpace(req.Version), "v")
+		channel := releaseChannelStable
+		if req.Channel != nil {
+			var ok bool
+			if channel, ok = normalizeReleaseChannel(*req.Channel); !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "channel must be stable or beta"})
+				return
+			}
+		}
+		changelog := ""
+		if req.Changelog != nil {
+			changelog = *req.Changelog
+		}
+		if !requireProductSeller(c, db, productID, userID.(int)) {
+			return
+		}
+		if req.ArtifactID != nil {
+			if _, err := loadOwnedArtifact(db, userID.(int), *req.ArtifactID, artifactKindFile); err == sql.ErrNoRows {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "artifactId not found"})
+				return
+			} else if err != nil {
+				respondDBError(c, err)
+				return
+			}
+		}
+
+		moderated := requiresModeration(db, userID.(int))
+		tx, err := db.Begin()
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer tx.Rollback()
+		var id int
+		err = tx.QueryRow(`
+			INSERT INTO product_releases (product_id, version, channel, changelog, artifact_id, review_status)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (product_id, version) DO NOTHING
+			RETURNING id
+		`, productID, version, channel, changelog, req.ArtifactID, releaseReviewStatus(moderated)).Scan(&id)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusConflict, gin.H{"error": "이미 등록된 버전입니다"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if moderated {
+			if err := recordReleaseModerationEvent(tx, productID, id, userID.(int), moderationSubmitted, ""); err != nil {
+				respondDBError(c, err)
+				return
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		respondReleaseChange(c, db, productID, id, http.StatusCreated)
+	}
+}
+
+// UpdateProductRelease — PUT /api/v1/products/:id/releases/:releaseId (JWT, 판매자 본인)
+// 버전은 바꿀 수 없다. beta → stable 승격, 변경 내역/아티팩트 수정.
+// 관리자가 아니면 수정된 릴리스는 다시 심사 대기가 된다 (그동안 이전 승인 릴리스가 반영된다).
+func UpdateProductRelease(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		productID, err1 := strconv.Atoi(c.Param("id"))
+		releaseID, err2 := strconv.Atoi(c.Param("releaseId"))
+		if err1 != nil || err2 != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+			return
+		}
+		var req releaseRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !requireProductSeller(c, db, productID, userID.(int)) {
+			return
+		}
+
+		query := "UPDATE product_releases SET updated_at = NOW()"
+		args := []interface{}{}
+		if req.Channel != nil {
+			channel, ok := normalizeReleaseChannel(*req.Channel)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "channel must be stable or beta"})
+				return
+			}
+			query += ", channel = " + appendArg(&args, channel)
+		}
+		if req.Changelog != nil {
+			query += ", changelog = " + appendArg(&args, *req.Changelog)
+		}
+		if req.ArtifactID != nil {
+			if _, err := loadOwnedArtifact(db, userID.(int), *req.ArtifactID, artifactKindFile); err == sql.ErrNoRows {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "artifactId not found"})
+				return
+			} else if err != nil {
+				respondDBError(c, err)
+				return
+			}
+			query += ", artifact_id = " + appendArg(&args, *req.ArtifactID)
+		}
+		resubmitted := len(args) > 0 && requiresModeration(db, userID.(int))
+		if len(args) > 0 {
+			query += ", review_status = " + appendArg(&args, releaseReviewStatus(resubmitted)) + ", review_note = NULL"
+		}
+		query += " WHERE id = " + appendArg(&args, releaseID) + " AND product_id = " + appendArg(&args, productID)
+
+		tx, err := db.Begin()
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer tx.Rollback()
+		res, err := tx.Exec(query, args...)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "release not found"})
+			return
+		}
+		if resubmitted {
+			if err := recordReleaseModerationEvent(tx, productID, releaseID, userID.(int), moderationSubmitted, ""); err != nil {
+				respondDBError(c, err)
+				return
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		respondReleaseChange(c, db, productID, releaseID, http.StatusOK)
+	}
+}
+
+// DeleteProductRelease — DELETE /api/v1/products/:id/releases/:releaseId (JWT, 판매자 본인)
+func DeleteProductRelease(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		productID, err1 := strconv.Atoi(c.Param("id"))
+		releaseID, err2 := strconv.Atoi(c.Param("releaseId"))
+		if err1 != nil || err2 != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+			return
+		}
+		if !requireProductSeller(c, db, productID, userID.(int)) {
+			return
+		}
+		res, err := db.Exec(`DELETE FROM product_releases WHERE id = $1 AND product_id = $2`, releaseID, productID)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "release not found"})
+			return
+		}
+		if err := syncProductLatestRelease(db, productID); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Release deleted successfully"})
+	}
+}
+
+// respondReleaseChange — 변경 후 products 동기화 + 해당 릴리스 응답
+func respondReleaseChange(c *gin.Context, db *sql.DB, productID, releaseID, status int) {
+	if err := syncProductLatestRelease(db, productID); err != nil {
+		respondDBError(c, err)
+		return
+	}
+	releases, err := loadProductReleases(db, productID, "")
+	if err != nil {
+		respondDBError(c, err)
+		return
+	}
+	for _, r := range releases {
+		if r.ID == releaseID {
+			c.JSON(status, r)
+			return
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "release not found"})
+}
+
+// releaseLicenseKey — X-License-Key 헤더 또는 licenseKey 쿼리
+func releaseLicenseKey(c *gin.Context) string {
+	if key := strings.TrimSpace(c.GetHeader("X-License-Key")); key != "" {
+		return key
+	}
+	return strings.TrimSpace(c.Query("licenseKey"))
+}
+
+// authorizeUpdateCheck — 라이선스 키(구매별 키 또는 상품 license_key) 또는 JWT의
+// 판매자/구매자만 허용. false면 응답이 이미 쓰였다.
+func authorizeUpdateCheck(c *gin.Context, db *sql.DB, productID int) bool {
+	var sellerID int
+	var productKey string
+	err := db.QueryRow(`SELECT seller_id, COALESCE(license_key, '') FROM products WHERE id = $1`, productID).
+		Scan(&sellerID, &productKey)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return false
+	}
+	if err != nil {
+		respondDBError(c, err)
+		return false
+	}
+
+	if key := releaseLicenseKey(c); key != "" {
+		// 구매별 서명 키(CM1.) 우선, 아니면 상품 공용 license_key (구형 상품)
+		if strings.HasPrefix(key, licenseKeyPrefix) {
+			l, err := lookupLicense(db, key, false)
+			if err == nil && l.ProductID == productID && l.Status == licenseStatusActive {
+				return true
+			}
+			if err != nil && !errors.Is(err, errInvalidLicenseKey) {
+				respondDBError(c, err)
+				return false
+			}
+		} else if productKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(productKey)) == 1 {
+			return true
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid license key"})
+		return false
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "license key or login required"})
+		return false
+	}
+	if userID.(int) == sellerID {
+		return true
+	}
+	var purchased bool
+	if err := db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM payments WHERE user_id = $1 AND order_id = $2 AND status = 'paid')
+	`, userID, productID).Scan(&purchased); err != nil {
+		respondDBError(c, err)
+		return false
+	}
+	if !purchased {
+		c.JSON(http.StatusForbidden, gin.H{"error": "구매한 상품만 업데이트를 확인할 수 있습니다"})
+		return false
+	}
+	return true
+}
+
+// CheckProductUpdate — GET /api/v1/products/:id/latest?current=1.0.0&channel=stable
+// (라이선스 키 또는 JWT). 응답: {updateAvailable, current, channel, latest}
+func CheckProductUpdate(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		productID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+		channel, ok := normalizeReleaseChannel(c.Query("channel"))
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "channel must be stable or beta"})
+			return
+		}
+		current := strings.TrimSpace(c.Query("current"))
+		var currentVer semver
+		if current != "" {
+			if currentVer, ok = parseSemver(current); !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "current must be a semantic version"})
+				return
+			}
+		}
+		if !authorizeUpdateCheck(c, db, productID) {
+			return
+		}
+
+		releases, err := loadProductReleases(db, productID, channel)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		releases = approvedReleases(releases)
+		resp := gin.H{"updateAvailable": false, "current": current, "channel": channel, "latest": nil}
+		if len(releases) > 0 {
+			latest := releases[0]
+			resp["latest"] = latest
+			resp["updateAvailable"] = current == "" || compareSemver(latest.semver, currentVer) > 0
+		}
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// GetReleaseModerationQueue — GET /api/v1/admin/moderation/releases (관리자)
+// 심사 대기 릴리스를 오래된 순으로.
+func GetReleaseModerationQueue(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c, db) {
+			return
+		}
+		rows, err := db.Query(`
+			SELECT r.id, r.product_id, p.name, p.seller_id, COALESCE(u.name, ''), r.version, r.channel,
+			       r.changelog, r.artifact_id, COALESCE(a.sha256, ''), r.updated_at
+			FROM product_releases r
+			JOIN products p ON p.id = r.product_id
+			LEFT JOIN users u ON u.id = p.seller_id
+			LEFT JOIN artifacts a ON a.id = r.artifact_id
+			WHERE r.review_status = 'pending_review' AND p.deleted_at IS NULL
+			ORDER BY r.updated_at ASC, r.id ASC
+			LIMIT 200
+		`)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer rows.Close()
+
+		type queueItem struct {
+			ID          int       `json:"id"`
+			ProductID   int       `json:"productId"`
+			ProductName string    `json:"productName"`
+			SellerID    int       `json:"sellerId"`
+			SellerName  string    `json:"sellerName"`
+			Version     string    `json:"version"`
+			Channel     string    `json:"channel"`
+			Changelog   string    `json:"changelog"`
+			ArtifactID  *int      `json:"artifactId,omitempty"`
+			SHA256      string    `json:"sha256,omitempty"`
+			SubmittedAt time.Time `json:"submittedAt"`
+		}
+		items := []queueItem{}
+		for rows.Next() {
+			var it queueItem
+			if err := rows.Scan(&it.ID, &it.ProductID, &it.ProductName, &it.SellerID, &it.SellerName, &it.Version,
+				&it.Channel, &it.Changelog, &it.ArtifactID, &it.SHA256, &it.SubmittedAt); err != nil {
+				respondDBError(c, err)
+				return
+			}
+			items = append(items, it)
+		}
+		c.JSON(http.StatusOK, items)
+	}
+}
+
+// DecideReleaseModeration — 관리자 릴리스 심사 결정 (approved | rejected)
+//
+//	POST /api/v1/admin/moderation/releases/:releaseId/approve
+//	POST /api/v1/admin/moderation/releases/:releaseId/reject   (body: {reason} 필수)
+//
+// 결정은 product_moderation_events에 남고, 승인되면 products 반영을 다시 계산한다.
+// pending_review 릴리스만 결정할 수 있다 (아니면 409).
+func DecideReleaseModeration(db *sql.DB, action string) gin.HandlerFunc {
+	if action != releaseReviewApproved && action != releaseReviewRejected {
+		panic("unknown release moderation action: " + action)
+	}
+	return func(c *gin.Context) {
+		if !requireAdmin(c, db) {
+			return
+		}
+		adminID, _ := c.Get("userId")
+		releaseID, err := strconv.Atoi(c.Param("releaseId"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+			return
+		}
+		var req struct {
+			Reason string `json:"reason"`
+		}
+		_ = c.ShouldBindJSON(&req)
+		reason := strings.TrimSpace(req.Reason)
+		if action == releaseReviewRejected && reason == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+			return
+		}
+		if len([]rune(reason)) > 2000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reason must be at most 2000 characters"})
+			return
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer tx.Rollback()
+
+		var productID, sellerID int
+		var name, version string
+		err = tx.QueryRow(`
+			UPDATE product_releases r SET review_status = $2, review_note = NULLIF($3, ''), updated_at = NOW()
+			FROM products p
+			WHERE r.id = $1 AND r.review_status = 'pending_review' AND p.id = r.product_id
+			RETURNING r.product_id, p.seller_id, p.name, r.version
+		`, releaseID, action, reason).Scan(&productID, &sellerID, &name, &version)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusConflict, gin.H{"error": "release is not awaiting review"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if err := recordReleaseModerationEvent(tx, productID, releaseID, adminID.(int), action, reason); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if action == releaseReviewApproved {
+			if err := syncProductLatestRelease(db, productID); err != nil {
+				respondDBError(c, err)
+				return
+			}
+		}
+
+		title := "릴리스가 승인되었습니다"
+		if action == releaseReviewRejected {
+			title = "릴리스가 반려되었습니다"
+		}
+		body := fmt.Sprintf("'%s' v%s 심사 결과: %s", name, version, title)
+		if reason != "" {
+			body += "\n사유: " + reason
+		}
+		notifyUser(db, sellerID, notificationProductModeration, title, body, fmt.Sprintf("/my/products/%d", productID))
+		log.Printf("[moderation] release %d (product %d v%s) %s by admin %v", releaseID, productID, version, action, adminID)
+
+		c.JSON(http.StatusOK, gin.H{"releaseId": releaseID, "productId": productID, "reviewStatus": action, "reason": reason})
+	}
+}
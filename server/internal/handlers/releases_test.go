@@ -0,0 +1,28 @@
+package handlers
+
+import "testing"
+
+func TestReleaseReviewStatus(t *testing.T) {
+	if got := releaseReviewStatus(true); got != releaseReviewPending {
+		t.Errorf("seller release status = %s, want %s", got, releaseReviewPending)
+	}
+	if got := releaseReviewStatus(false); got != releaseReviewApproved {
+		t.Errorf("admin release status = %s, want %s", got, releaseReviewApproved)
+	}
+}
+
+func TestApprovedReleasesSkipUnreviewed(t *testing.T) {
+	// semver 내림차순 — 심사 대기/반려된 더 높은 버전은 products에 반영되지 않아야 한다
+	releases := []ProductRelease{
+		{ID: 3, Version: "2.0.0", ReviewStatus: releaseReviewPending},
+		{ID: 2, Version: "1.1.0", ReviewStatus: releaseReviewRejected},
+		{ID: 1, Version: "1.0.0", ReviewStatus: releaseReviewApproved},
+	}
+	got := approvedReleases(releases)
+	if len(got) != 1 || got[0].ID != 1 {
+		t.Fatalf("approvedReleases = %+v", got)
+	}
+	if len(approvedReleases(releases[:2])) != 0 {
+		t.Error("pending/rejected releases must not be public")
+	}
+}
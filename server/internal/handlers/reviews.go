@@ -0,0 +1,517 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ── 상품 리뷰 ──────────────────────────────────────────────────────────────
+// 해당 상품의 paid 결제가 있는 사용자만 작성할 수 있다 (상품당 1건, 판매자 본인 제외).
+// 판매자는 답글 1개, 관리자는 숨김/복구로 모더레이션한다.
+// 공개(visible) 리뷰의 평균/개수는 products.rating_avg/rating_count에 비정규화해
+// 목록의 sort=rating에 쓴다 — 리뷰가 바뀔 때마다 refreshProductRating으로 다시 계산한다.
+
+const (
+	reviewStatusVisible = "visible"
+	reviewStatusHidden  = "hidden"
+)
+
+const maxReviewLength = 5000
+
+// ProductReview — 리뷰 1건
+type ProductReview struct {
+	ID              int        `json:"id"`
+	ProductID       int        `json:"productId"`
+	UserID          int        `json:"userId"`
+	UserName        string     `json:"userName"`
+	Rating          int        `json:"rating"`
+	Content         string     `json:"content"`
+	SellerReply     *string    `json:"sellerReply,omitempty"`
+	SellerRepliedAt *time.Time `json:"sellerRepliedAt,omitempty"`
+	Status          string     `json:"status"`
+	ModerationNote  *string    `json:"moderationNote,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+const reviewSelect = `
+	SELECT r.id, r.product_id, r.user_id, COALESCE(u.name, 'Anonymous'), r.rating, r.content,
+	       r.seller_reply, r.seller_replied_at, r.status, r.moderation_note, r.created_at, r.updated_at
+	FROM product_reviews r
+	LEFT JOIN users u ON u.id = r.user_id`
+
+func scanReview(row interface{ Scan(...interface{}) error }) (*ProductReview, error) {
+	var r ProductReview
+	err := row.Scan(&r.ID, &r.ProductID, &r.UserID, &r.UserName, &r.Rating, &r.Content,
+		&r.SellerReply, &r.SellerRepliedAt, &r.Status, &r.ModerationNote, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func loadReview(db *sql.DB, id int) (*ProductReview, error) {
+	return scanReview(db.QueryRow(reviewSelect+` WHERE r.id = $1`, id))
+}
+
+// refreshProductRating — 공개 리뷰 기준으로 상품 평점 집계를 다시 계산
+func refreshProductRating(db *sql.DB, productID int) error {
+	_, err := db.Exec(`
+		UPDATE products p SET rating_avg = agg.avg, rating_count = agg.cnt
+		FROM (
+			SELECT COALESCE(ROUND(AVG(rating)::numeric, 2), 0) AS avg, COUNT(*) AS cnt
+			FROM product_reviews WHERE product_id = $1 AND status = 'visible'
+		) agg
+		WHERE p.id = $1
+	`, productID)
+	return err
+}
+
+var reviewSorts = map[string]string{
+	"newest":      "r.created_at DESC, r.id DESC",
+	"rating_desc": "r.rating DESC, r.created_at DESC, r.id DESC",
+	"rating_asc":  "r.rating ASC, r.created_at DESC, r.id DESC",
+}
+
+// GetProductReviews — GET /api/v1/products/:id/reviews?sort=&limit=&offset= (공개)
+// 응답: {reviews, total, ratingAvg, ratingCount, distribution{"1".."5"}}. 비활성/삭제된 상품은 404.
+func GetProductReviews(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		productID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+		order, ok := reviewSorts[c.DefaultQuery("sort", "newest")]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be newest, rating_desc or rating_asc"})
+			return
+		}
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if limit <= 0 || limit > 100 {
+			limit = 20
+		}
+		offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if offset < 0 {
+			offset = 0
+		}
+
+		var ratingAvg float64
+		var ratingCount int
+		err = db.QueryRow(`
+			SELECT rating_avg, rating_count FROM products WHERE id = $1 AND is_active = true AND deleted_at IS NULL
+		`, productID).Scan(&ratingAvg, &ratingCount)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+
+		distribution := map[string]int{"1": 0, "2": 0, "3": 0, "4": 0, "5": 0}
+		distRows, err := db.Query(`
+			SELECT rating, COUNT(*) FROM product_reviews
+			WHERE product_id = $1 AND status = 'visible' GROUP BY rating
+		`, productID)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		total := 0
+		for distRows.Next() {
+			var rating, n int
+			if err := distRows.Scan(&rating, &n); err != nil {
+				distRows.Close()
+				respondDBError(c, err)
+				return
+			}
+			distribution[strconv.Itoa(rating)] = n
+			total += n
+		}
+		distRows.Close()
+
+		rows, err := db.Query(reviewSelect+`
+			WHERE r.product_id = $1 AND r.status = 'visible'
+			ORDER BY `+order+`
+			LIMIT $2 OFFSET $3
+		`, productID, limit, offset)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer rows.Close()
+		reviews := []*ProductReview{}
+		for rows.Next() {
+			r, err := scanReview(rows)
+			if err != nil {
+				respondDBError(c, err)
+				return
+			}
+			r.ModerationNote = nil
+			reviews = append(reviews, r)
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"reviews":      reviews,
+			"total":        total,
+			"ratingAvg":    ratingAvg,
+			"ratingCount":  ratingCount,
+			"distribution": distribution,
+		})
+	}
+}
+
+type reviewRequest struct {
+	Rating  *int    `json:"rating"`
+	Content *string `json:"content"`
+}
+
+// validate — 작성 시 rating 필수, 수정 시 보낸 필드만 검사
+func (r *reviewRequest) validate(create bool) string {
+	if r.Rating == nil && create {
+		return "rating is required"
+	}
+	if r.Rating != nil && (*r.Rating < 1 || *r.Rating > 5) {
+		return "rating must be between 1 and 5"
+	}
+	if r.Content != nil {
+		trimmed := strings.TrimSpace(*r.Content)
+		if len([]rune(trimmed)) > maxReviewLength {
+			return "content must be at most 5000 characters"
+		}
+		r.Content = &trimmed
+	}
+	return ""
+}
+
+// CreateProductReview — POST /api/v1/products/:id/reviews (JWT, 구매 확인)
+func CreateProductReview(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		productID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+		var req reviewRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if msg := req.validate(true); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		content := ""
+		if req.Content != nil {
+			content = *req.Content
+		}
+
+		var sellerID int
+		var purchased bool
+		err = db.QueryRow(`
+			SELECT p.seller_id,
+			       EXISTS (SELECT 1 FROM payments pm WHERE pm.order_id = p.id AND pm.user_id = $2 AND pm.status = 'paid')
+			FROM products p WHERE p.id = $1
+		`, productID, userID).Scan(&sellerID, &purchased)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if sellerID == userID.(int) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "본인 상품에는 리뷰를 작성할 수 없습니다"})
+			return
+		}
+		if !purchased {
+			c.JSON(http.StatusForbidden, gin.H{"error": "구매한 상품에만 리뷰를 작성할 수 있습니다"})
+			return
+		}
+
+		var id int
+		err = db.QueryRow(`
+			INSERT INTO product_reviews (product_id, user_id, rating, content)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (product_id, user_id) DO NOTHING
+			RETURNING id
+		`, productID, userID, *req.Rating, content).Scan(&id)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusConflict, gin.H{"error": "이미 리뷰를 작성했습니다 — 기존 리뷰를 수정하세요"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if err := refreshProductRating(db, productID); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		r, err := loadReview(db, id)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, r)
+	}
+}
+
+// UpdateProductReview — PUT /api/v1/reviews/:id (JWT, 작성자 본인)
+func UpdateProductReview(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+			return
+		}
+		var req reviewRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if msg := req.validate(false); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		query := "UPDATE product_reviews SET updated_at = NOW()"
+		args := []interface{}{}
+		if req.Rating != nil {
+			query += ", rating = " + appendArg(&args, *req.Rating)
+		}
+		if req.Content != nil {
+			query += ", content = " + appendArg(&args, *req.Content)
+		}
+		query += " WHERE id = " + appendArg(&args, id) + " AND user_id = " + appendArg(&args, userID) + " RETURNING product_id"
+
+		var productID int
+		err = db.QueryRow(query, args...).Scan(&productID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review not found or not authorized"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if err := refreshProductRating(db, productID); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		r, err := loadReview(db, id)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, r)
+	}
+}
+
+// DeleteProductReview — DELETE /api/v1/reviews/:id (JWT, 작성자 또는 관리자)
+func DeleteProductReview(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+			return
+		}
+		var authorID, productID int
+		err = db.QueryRow(`SELECT user_id, product_id FROM product_reviews WHERE id = $1`, id).Scan(&authorID, &productID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if authorID != userID.(int) && !isAdminUser(db, userID.(int)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "작성자 또는 관리자만 삭제할 수 있습니다"})
+			return
+		}
+		if _, err := db.Exec(`DELETE FROM product_reviews WHERE id = $1`, id); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if err := refreshProductRating(db, productID); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Review deleted"})
+	}
+}
+
+// ReplyToProductReview — PUT /api/v1/reviews/:id/reply (JWT, 상품 판매자)
+// body: {reply}. 빈 문자열이면 답글 삭제.
+func ReplyToProductReview(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+			return
+		}
+		var req struct {
+			Reply string `json:"reply"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		reply := strings.TrimSpace(req.Reply)
+		if len([]rune(reply)) > maxReviewLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reply must be at most 5000 characters"})
+			return
+		}
+
+		res, err := db.Exec(`
+			UPDATE product_reviews r
+			SET seller_reply = NULLIF($3, ''),
+			    seller_replied_at = CASE WHEN $3 = '' THEN NULL ELSE NOW() END
+			FROM products p
+			WHERE r.id = $1 AND p.id = r.product_id AND p.seller_id = $2
+		`, id, userID, reply)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review not found or not authorized"})
+			return
+		}
+		r, err := loadReview(db, id)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, r)
+	}
+}
+
+// GetReviewsForModeration — GET /api/v1/admin/reviews?status=hidden|visible (관리자, 최근 100건)
+func GetReviewsForModeration(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		if !isAdminUser(db, userID.(int)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			return
+		}
+		query := reviewSelect
+		args := []interface{}{}
+		if status := c.Query("status"); status != "" {
+			if status != reviewStatusVisible && status != reviewStatusHidden {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "status must be visible or hidden"})
+				return
+			}
+			query += " WHERE r.status = " + appendArg(&args, status)
+		}
+		rows, err := db.Query(query+" ORDER BY r.updated_at DESC LIMIT 100", args...)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer rows.Close()
+		reviews := []*ProductReview{}
+		for rows.Next() {
+			r, err := scanReview(rows)
+			if err != nil {
+				respondDBError(c, err)
+				return
+			}
+			reviews = append(reviews, r)
+		}
+		c.JSON(http.StatusOK, reviews)
+	}
+}
+
+// ModerateProductReview — PUT /api/v1/admin/reviews/:id (관리자)
+// body: {status: visible|hidden, note}. 숨긴 리뷰는 목록과 평점 집계에서 빠진다.
+func ModerateProductReview(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		if !isAdminUser(db, userID.(int)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+			return
+		}
+		var req struct {
+			Status string `json:"status" binding:"required"`
+			Note   string `json:"note"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Status != reviewStatusVisible && req.Status != reviewStatusHidden {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be visible or hidden"})
+			return
+		}
+		note := strings.TrimSpace(req.Note)
+		if len([]rune(note)) > 500 {
+			note = string([]rune(note)[:500])
+		}
+
+		var productID int
+		err = db.QueryRow(`
+			UPDATE product_reviews SET status = $2, moderation_note = NULLIF($3, '')
+			WHERE id = $1 RETURNING product_id
+		`, id, req.Status, note).Scan(&productID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if err := refreshProductRating(db, productID); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		r, err := loadReview(db, id)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, r)
+	}
+}
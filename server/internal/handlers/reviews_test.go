@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReviewRequestValidate(t *testing.T) {
+	intp := func(n int) *int { return &n }
+	strp := func(s string) *string { return &s }
+
+	cases := []struct {
+		req    reviewRequest
+		create bool
+		ok     bool
+	}{
+		{reviewRequest{Rating: intp(5), Content: strp("좋아요")}, true, true},
+		{reviewRequest{Content: strp("별점 없음")}, true, false},
+		{reviewRequest{Content: strp("내용만 수정")}, false, true},
+		{reviewRequest{Rating: intp(0)}, false, false},
+		{reviewRequest{Rating: intp(6)}, true, false},
+		{reviewRequest{Rating: intp(3), Content: strp(strings.Repeat("가", maxReviewLength+1))}, true, false},
+	}
+	for i, c := range cases {
+		if msg := c.req.validate(c.create); (msg == "") != c.ok {
+			t.Errorf("case %d: validate = %q, want ok=%v", i, msg, c.ok)
+		}
+	}
+
+	req := reviewRequest{Rating: intp(4), Content: strp("  trimmed \n")}
+	req.validate(true)
+	if *req.Content != "trimmed" {
+		t.Errorf("content not trimmed: %q", *req.Content)
+	}
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"database/sql"
+	"html"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/lib/pq"
+)
+
+// ── 전문 검색 공용 (상품 /products/search, 커뮤니티 /community/search) ─────────
+// 색인: search_vector (database.go 트리거 — 'simple' 구성 + 한글 바이그램)
+// 질의: buildSearchTSQuery로 만든 tsquery와 pg_trgm word_similarity(오타/조사)를 OR로 결합,
+// 순위는 ts_rank_cd + word_similarity. 스니펫은 ts_headline 결과를 HTML 이스케이프한 뒤
+// 일치 구간만 <mark>로 감싼다 (원문 HTML은 그대로 출력되지 않는다 — CWE-79).
+
+const maxSearchTerms = 8
+
+// ts_headline 구분자 — 이스케이프 후 <mark> 태그로 치환한다
+const (
+	headlineStart = "⟪"
+	headlineStop  = "⟫"
+)
+
+const searchHeadlineOptions = `StartSel="` + headlineStart + `", StopSel="` + headlineStop + `", MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=" … "`
+
+func isHangul(r rune) bool {
+	return unicode.Is(unicode.Hangul, r)
+}
+
+// searchTokens — 질의를 문자/숫자 토큰으로 분리 (tsquery 연산자 주입 불가)
+func searchTokens(q string) []string {
+	tokens := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(tokens) > maxSearchTerms {
+		tokens = tokens[:maxSearchTerms]
+	}
+	return tokens
+}
+
+// buildSearchTSQuery — 사용자 질의 → to_tsquery('simple', …) 입력 (순수 함수).
+// 한글 2글자 이상 구간은 색인과 같은 바이그램의 AND, 나머지 토큰은 접두 일치(:*).
+// 검색어가 없으면 "".
+func buildSearchTSQuery(q string) string {
+	var parts []string
+	for _, tok := range searchTokens(q) {
+		runes := []rune(tok)
+		var run []rune
+		flush := func() {
+			if len(run) == 0 {
+				return
+			}
+			if len(run) == 1 || !isHangul(run[0]) {
+				parts = append(parts, "'"+string(run)+"':*")
+			} else {
+				for i := 0; i+1 < len(run); i++ {
+					parts = append(parts, "'"+string(run[i:i+2])+"'")
+				}
+			}
+			run = nil
+		}
+		// 한글/비한글 경계에서 나눈다 ("kospi200지수" → kospi200 + 지수)
+		for i, r := range runes {
+			if i > 0 && isHangul(r) != isHangul(runes[i-1]) {
+				flush()
+			}
+			run = append(run, r)
+		}
+		flush()
+	}
+	return strings.Join(parts, " & ")
+}
+
+// buildHeadlineTSQuery — 스니펫 강조용 tsquery. ts_headline은 원문 어절 단위로 비교하므로
+// 바이그램 대신 토큰 접두 일치의 OR를 쓴다 ("삼성전자" → "삼성전자를"도 강조).
+func buildHeadlineTSQuery(q string) string {
+	tokens := searchTokens(q)
+	parts := make([]string, 0, len(tokens))
+	for _, tok := range tokens {
+		parts = append(parts, "'"+tok+"':*")
+	}
+	return strings.Join(parts, " | ")
+}
+
+// highlightSnippet — ts_headline 출력 → 안전한 HTML (<mark>만 허용)
+func highlightSnippet(raw string) string {
+	escaped := html.EscapeString(raw)
+	return strings.NewReplacer(headlineStart, "<mark>", headlineStop, "</mark>").Replace(escaped)
+}
+
+var (
+	trigramOnce      sync.Once
+	trigramAvailable bool
+)
+
+// hasTrigramSupport — pg_trgm 설치 여부 (최초 1회 조회). 없으면 tsvector 검색만 사용한다.
+func hasTrigramSupport(db *sql.DB) bool {
+	trigramOnce.Do(func() {
+		var n int
+		if err := db.QueryRow("SELECT COUNT(*) FROM pg_extension WHERE extname = 'pg_trgm'").Scan(&n); err == nil {
+			trigramAvailable = n > 0
+		}
+	})
+	return trigramAvailable
+}
+
+// textSearch — 한 요청의 전문 검색 조건 (WHERE/순위 식 생성기)
+type textSearch struct {
+	raw        string // 원 질의 (trigram 비교용)
+	tsquery    string // buildSearchTSQuery 결과
+	headline   string // buildHeadlineTSQuery 결과
+	trigram    bool
+	nameColumn string // trigram 비교 대상 (상품 name / 게시글 title)
+}
+
+func newTextSearch(db *sql.DB, q, nameColumn string) *textSearch {
+	return &textSearch{
+		raw:        strings.TrimSpace(q),
+		tsquery:    buildSearchTSQuery(q),
+		headline:   buildHeadlineTSQuery(q),
+		trigram:    hasTrigramSupport(db),
+		nameColumn: nameColumn,
+	}
+}
+
+func appendArg(args *[]interface{}, v interface{}) string {
+	*args = append(*args, v)
+	return "$" + strconv.Itoa(len(*args))
+}
+
+// where — 일치 조건 (p = 대상 테이블 별칭)
+func (s *textSearch) where(args *[]interface{}) string {
+	cond := "p.search_vector @@ to_tsquery('simple', " + appendArg(args, s.tsquery) + ")"
+	if s.trigram {
+		cond += " OR " + appendArg(args, s.raw) + " <% p." + s.nameColumn
+	}
+	return "(" + cond + ")"
+}
+
+// rank — 관련도 점수 식 (float8)
+func (s *textSearch) rank(args *[]interface{}) string {
+	expr := "ts_rank_cd(p.search_vector, to_tsquery('simple', " + appendArg(args, s.tsquery) + "), 32)"
+	if s.trigram {
+		expr += " + word_similarity(" + appendArg(args, s.raw) + ", p." + s.nameColumn + ")"
+	}
+	return "(" + expr + ")::float8"
+}
+
+// highlights — 페이지 결과 id들의 관련도/스니펫 (목록 쿼리와 분리해 페이지 크기만큼만 계산)
+func (s *textSearch) highlights(db *sql.DB, table, textColumn string, ids []int) (map[int]float64, map[int]string, error) {
+	ranks := map[int]float64{}
+	snippets := map[int]string{}
+	if len(ids) == 0 {
+		return ranks, snippets, nil
+	}
+	args := []interface{}{}
+	rank := s.rank(&args)
+	query := "SELECT p.id, " + rank +
+		", ts_headline('simple', COALESCE(p." + textColumn + ", ''), to_tsquery('simple', " + appendArg(&args, s.headline) + "), " + appendArg(&args, searchHeadlineOptions) + ")" +
+		" FROM " + table + " p WHERE p.id = ANY(" + appendArg(&args, pq.Array(ids)) + ")"
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var rank float64
+		var headline string
+		if err := rows.Scan(&id, &rank, &headline); err != nil {
+			return nil, nil, err
+		}
+		ranks[id] = rank
+		snippets[id] = highlightSnippet(headline)
+	}
+	return ranks, snippets, rows.Err()
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import "testing"
+
+func TestBuildSearchTSQuery(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"삼성전자", "'삼성' & '성전' & '전자'"},
+		{"  Swing 매매 ", "'swing':* & '매매'"},
+		{"kospi200지수", "'kospi200':* & '지수'"},
+		{"주", "'주':*"},
+		{"a' | b:* & !c", "'a':* & 'b':* & 'c':*"}, // tsquery 연산자/따옴표는 토큰에서 제거
+		{"!!! ---", ""},
+	}
+	for _, c := range cases {
+		if got := buildSearchTSQuery(c.in); got != c.want {
+			t.Errorf("buildSearchTSQuery(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+	if got := buildHeadlineTSQuery("삼성전자 swing"); got != "'삼성전자':* | 'swing':*" {
+		t.Errorf("buildHeadlineTSQuery = %q", got)
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	got := highlightSnippet(`<script>alert(1)</script> ` + headlineStart + `삼성전자` + headlineStop + `를 매수`)
+	want := `&lt;script&gt;alert(1)&lt;/script&gt; <mark>삼성전자</mark>를 매수`
+	if got != want {
+		t.Errorf("highlightSnippet = %q, want %q", got, want)
+	}
+}
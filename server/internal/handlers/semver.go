@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+)
+
+// semver — Semantic Versioning 2.0 (major.minor.patch[-prerelease][+build]).
+// 선행 "v"는 허용한다 (데스크톱 프로그램이 "v1.2.0"을 보내는 경우). build 메타데이터는 비교에서 무시.
+type semver struct {
+	major, minor, patch int
+	pre                 []string
+}
+
+func parseSemver(raw string) (semver, bool) {
+	s := strings.TrimPrefix(strings.TrimSpace(raw), "v")
+	s, _, _ = strings.Cut(s, "+")
+	core, pre, hasPre := strings.Cut(s, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return semver{}, false
+	}
+	var nums [3]int
+	for i, p := range parts {
+		if p == "" || (len(p) > 1 && p[0] == '0') {
+			return semver{}, false
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return semver{}, false
+		}
+		nums[i] = n
+	}
+	v := semver{major: nums[0], minor: nums[1], patch: nums[2]}
+	if hasPre {
+		for _, id := range strings.Split(pre, ".") {
+			if id == "" || strings.IndexFunc(id, func(r rune) bool {
+				return !(r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+			}) >= 0 {
+				return semver{}, false
+			}
+			v.pre = append(v.pre, id)
+		}
+	}
+	return v, true
+}
+
+// compareSemver — a<b: -1, a==b: 0, a>b: 1 (prerelease는 정식보다 낮다)
+func compareSemver(a, b semver) int {
+	for _, d := range [3]int{a.major - b.major, a.minor - b.minor, a.patch - b.patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	switch {
+	case len(a.pre) == 0 && len(b.pre) == 0:
+		return 0
+	case len(a.pre) == 0:
+		return 1
+	case len(b.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(a.pre) && i < len(b.pre); i++ {
+		x, y := a.pre[i], b.pre[i]
+		xn, xErr := strconv.Atoi(x)
+		yn, yErr := strconv.Atoi(y)
+		switch {
+		case xErr == nil && yErr == nil:
+			if xn != yn {
+				return sign(xn - yn)
+			}
+		case xErr == nil: // 숫자 식별자가 문자 식별자보다 낮다
+			return -1
+		case yErr == nil:
+			return 1
+		case x != y:
+			return sign(strings.Compare(x, y))
+		}
+	}
+	return sign(len(a.pre) - len(b.pre))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import "testing"
+
+func TestCompareSemver(t *testing.T) {
+	// semver.org 예시 순서 + 선행 v / build 메타데이터
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "v1.0.1", "1.1.0+build.7", "2.0.0",
+	}
+	for i := 0; i+1 < len(ordered); i++ {
+		a, okA := parseSemver(ordered[i])
+		b, okB := parseSemver(ordered[i+1])
+		if !okA || !okB {
+			t.Fatalf("parse failed: %q %q", ordered[i], ordered[i+1])
+		}
+		if compareSemver(a, b) != -1 || compareSemver(b, a) != 1 {
+			t.Errorf("expected %s < %s", ordered[i], ordered[i+1])
+		}
+	}
+	a, _ := parseSemver("1.2.3+x")
+	b, _ := parseSemver("v1.2.3")
+	if compareSemver(a, b) != 0 {
+		t.Error("build metadata must not affect precedence")
+	}
+	for _, bad := range []string{"", "1.2", "1.2.3.4", "01.2.3", "1.2.x", "1.2.3-", "1.2.3-a..b", "1.2.3-a|b"} {
+		if _, ok := parseSemver(bad); ok {
+			t.Errorf("parseSemver(%q) accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cmall_dd/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ── 로그인 세션 / 리프레시 토큰 ─────────────────────────────────────────────
+// 액세스 토큰(JWT)은 ACCESS_TOKEN_TTL_MINUTES(기본 15분)만 유효하고, 로그인마다 sessions 행(기기 1개)과
+// 리프레시 토큰을 발급한다. 리프레시 토큰은 SHA-256 해시만 저장하며 POST /auth/refresh마다 새 토큰으로
+// 교체(rotation)된다. 교체된 토큰의 해시는 session_token_history에 남겨, 이미 쓴 토큰이 다시 오면
+// 탈취로 보고 그 세션(토큰 계열 전체)을 폐기한다. 단, 여러 탭이 같은 쿠키로 거의 동시에 갱신하는 경우를
+// 위해 마지막 교체 후 REFRESH_REUSE_GRACE_SECONDS(기본 30초) 안에 온 직전 토큰은 409로 재시도를 요청한다
+// (그 사이 쿠키는 이미 새 토큰으로 바뀌어 있다). GET /me/sessions로 기기 목록을, DELETE로 원격 로그아웃.
+// 세션마다 마지막으로 발급한 액세스 토큰의 jti(access_jti)를 기록해, 세션을 폐기하면 그 토큰도 폐기 목록에 넣는다.
+
+const refreshTokenCookie = "cmall_refresh"
+
+const (
+	sessionRevokedLogout = "logout"
+	sessionRevokedRemote = "remote_logout"
+	sessionRevokedReuse  = "token_reuse"
+)
+
+// UserSession — GET /me/sessions 응답 항목
+type UserSession struct {
+	ID         int       `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Current    bool      `json:"current"`
+}
+
+func accessTokenTTL() time.Duration {
+	return time.Duration(envInt("ACCESS_TOKEN_TTL_MINUTES", 15)) * time.Minute
+}
+
+func refreshTokenTTL() time.Duration {
+	return time.Duration(envInt("REFRESH_TOKEN_TTL_DAYS", 30)) * 24 * time.Hour
+}
+
+// refreshReuseGrace — 직전 토큰 재제시를 재사용으로 보지 않는 시간(초). 0이면 유예 없음, 최대 5분.
+func refreshReuseGrace() int {
+	secs := envInt("REFRESH_REUSE_GRACE_SECONDS", 30)
+	if secs < 0 {
+		return 0
+	}
+	if secs > 300 {
+		return 300
+	}
+	return secs
+}
+
+// hashRefreshToken — 저장/조회용 SHA-256 hex (토큰 자체가 256bit 난수라 솔트 불필요)
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// truncateRunes — 헤더 값 저장 길이 제한
+func truncateRunes(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max])
+}
+
+// setRefreshCookie — 브라우저용 HttpOnly 쿠키 (인증 라우트에만 전송)
+func setRefreshCookie(c *gin.Context, token string, maxAge int) {
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(refreshTokenCookie, token, maxAge, "/api/v1/auth", "", false, true)
+}
+
+// startSession — 로그인 성공 시 세션 생성 후 액세스/리프레시 토큰 발급. wallet은 지갑 로그인만.
+func startSession(c *gin.Context, db *sql.DB, user models.User, wallet string) (models.AuthTokens, error) {
+	refresh, err := randomHex(32)
+	if err != nil {
+		return models.AuthTokens{}, err
+	}
+	jti, err := newTokenID()
+	if err != nil {
+		return models.AuthTokens{}, err
+	}
+	var sessionID int
+	err = db.QueryRow(`
+		INSERT INTO sessions (user_id, refresh_token_hash, wallet_address, user_agent, ip, expires_at, access_jti)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, user.ID, hashRefreshToken(refresh), wallet, truncateRunes(c.Request.UserAgent(), 500), c.ClientIP(),
+		time.Now().Add(refreshTokenTTL()), jti).Scan(&sessionID)
+	if err != nil {
+		return models.AuthTokens{}, err
+	}
+	return sessionTokens(c, user, wallet, sessionID, refresh, jti)
+}
+
+// sessionTokens — 액세스 토큰 서명 (jti는 sessions.access_jti에 기록된 값) + 리프레시 쿠키 설정
+func sessionTokens(c *gin.Context, user models.User, wallet string, sessionID int, refresh, jti string) (models.AuthTokens, error) {
+	access, err := generateWalletToken(user, wallet, sessionID, jti)
+	if err != nil {
+		return models.AuthTokens{}, err
+	}
+	setRefreshCookie(c, refresh, int(refreshTokenTTL().Seconds()))
+	return models.AuthTokens{
+		Token:        access,
+		RefreshToken: refresh,
+		ExpiresIn:    int(accessTokenTTL().Seconds()),
+	}, nil
+}
+
+// requestRefreshToken — body의 refreshToken 우선, 없으면 쿠키
+func requestRefreshToken(c *gin.Context) string {
+	var req struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	_ = c.ShouldBindJSON(&req)
+	if t := strings.TrimSpace(req.RefreshToken); t != "" {
+		return t
+	}
+	t, _ := c.Cookie(refreshTokenCookie)
+	return t
+}
+
+// RefreshSession — POST /api/v1/auth/refresh. body: {refreshToken} (또는 cmall_refresh 쿠키)
+// 리프레시 토큰을 새 것으로 교체하고 새 액세스 토큰을 발급한다. 이미 교체된 토큰이면 세션을 폐기한다
+// (유예 시간 안의 직전 토큰이면 409 — 클라이언트는 갱신된 쿠키로 다시 시도한다).
+func RefreshSession(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		presented := requestRefreshToken(c)
+		if presented == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "refreshToken is required"})
+			return
+		}
+		oldHash := hashRefreshToken(presented)
+		next, err := randomHex(32)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+		jti, err := newTokenID()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer tx.Rollback()
+
+		// 현재 토큰이면 조건부 UPDATE로 교체 — 같은 토큰의 동시 요청은 하나만 성공한다
+		var sessionID, userID int
+		var wallet string
+		err = tx.QueryRow(`
+			UPDATE sessions
+			SET refresh_token_hash = $2, last_used_at = NOW(), ip = $3, user_agent = $4, expires_at = $5, access_jti = $6
+			WHERE refresh_token_hash = $1 AND revoked_at IS NULL AND expires_at > NOW()
+			RETURNING id, user_id, wallet_address
+		`, oldHash, hashRefreshToken(next), c.ClientIP(), truncateRunes(c.Request.UserAgent(), 500),
+			time.Now().Add(refreshTokenTTL()), jti).Scan(&sessionID, &userID, &wallet)
+		if err == sql.ErrNoRows {
+			// 교체된 적 있는 토큰의 재사용 → 탈취 가능성, 세션 전체 폐기.
+			// 활성 세션의 가장 최근 교체 토큰이 유예 시간 안에 다시 오면 다른 탭의 동시 갱신으로 본다.
+			var reusedSession int
+			var inGrace bool
+			herr := tx.QueryRow(`
+				SELECT h.session_id,
+				       h.rotated_at > NOW() - make_interval(secs => $2)
+				       AND s.revoked_at IS NULL AND s.expires_at > NOW()
+				       AND h.rotated_at = (SELECT MAX(rotated_at) FROM session_token_history WHERE session_id = h.session_id)
+				FROM session_token_history h
+				JOIN sessions s ON s.id = h.session_id
+				WHERE h.token_hash = $1
+			`, oldHash, refreshReuseGrace()).Scan(&reusedSession, &inGrace)
+			if herr == nil && inGrace {
+				c.JSON(http.StatusConflict, gin.H{"error": "Refresh token was just rotated; retry with the current token", "retry": true})
+				return
+			}
+			if herr == nil {
+				if _, err := tx.Exec(`
+					UPDATE sessions SET revoked_at = NOW(), revoked_reason = $2
+					WHERE id = $1 AND revoked_at IS NULL
+				`, reusedSession, sessionRevokedReuse); err != nil {
+					respondDBError(c, err)
+					return
+				}
+				if err := revokeSessionAccessToken(tx, reusedSession, sessionRevokedReuse); err != nil {
+					respondDBError(c, err)
+					return
+				}
+				if err := tx.Commit(); err != nil {
+					respondDBError(c, err)
+					return
+				}
+				log.Printf("[sessions] refresh token reuse detected; revoked session %d", reusedSession)
+			} else if herr != sql.ErrNoRows {
+				respondDBError(c, herr)
+				return
+			}
+			setRefreshCookie(c, "", -1)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO session_token_history (token_hash, session_id) VALUES ($1, $2)
+		`, oldHash, sessionID); err != nil {
+			respondDBError(c, err)
+			return
+		}
+
+		// 역할 변경 등을 반영하도록 사용자 정보를 다시 읽는다
+		var user models.User
+		err = tx.QueryRow(`
+			SELECT id, email, name, role, avatar, bio, created_at, updated_at FROM users WHERE id = $1
+		`, userID).Scan(&user.ID, &user.Email, &user.Name, &user.Role, &user.Avatar, &user.Bio,
+			&user.CreatedAt, &user.UpdatedAt)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			respondDBError(c, err)
+			return
+		}
+
+		tokens, err := sessionTokens(c, user, wallet, sessionID, next, jti)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+		c.JSON(http.StatusOK, models.AuthResponse{AuthTokens: tokens, User: user})
+	}
+}
+
+// Logout — POST /api/v1/auth/logout. body: {refreshToken} (또는 쿠키)
+// 해당 세션을 폐기한다. 토큰이 없거나 이미 폐기돼도 200 (멱등).
+func Logout(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if presented := requestRefreshToken(c); presented != "" {
+			var sessionID int
+			err := db.QueryRow(`
+				UPDATE sessions SET revoked_at = NOW(), revoked_reason = $2
+				WHERE refresh_token_hash = $1 AND revoked_at IS NULL
+				RETURNING id
+			`, hashRefreshToken(presented), sessionRevokedLogout).Scan(&sessionID)
+			if err == nil {
+				err = revokeSessionAccessToken(db, sessionID, sessionRevokedLogout)
+			}
+			if err != nil && err != sql.ErrNoRows {
+				respondDBError(c, err)
+				return
+			}
+		}
+		setRefreshCookie(c, "", -1)
+		c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+	}
+}
+
+// GetMySessions — GET /api/v1/me/sessions (JWT). 로그인된 기기 목록 (최근 사용 순)
+func GetMySessions(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		currentID, _ := c.Get("authSessionId")
+		rows, err := db.Query(`
+			SELECT id, user_agent, ip, created_at, last_used_at, expires_at
+			FROM sessions
+			WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+			ORDER BY last_used_at DESC, id DESC
+		`, userID)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer rows.Close()
+		sessions := []UserSession{}
+		for rows.Next() {
+			var s UserSession
+			if err := rows.Scan(&s.ID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt); err != nil {
+				respondDBError(c, err)
+				return
+			}
+			s.Current = currentID == s.ID
+			sessions = append(sessions, s)
+		}
+		c.JSON(http.StatusOK, sessions)
+	}
+}
+
+// DeleteMySession — DELETE /api/v1/me/sessions/:id (JWT). 원격 로그아웃
+func DeleteMySession(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+			return
+		}
+		res, err := db.Exec(`
+			UPDATE sessions SET revoked_at = NOW(), revoked_reason = $3
+			WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+		`, id, userID, sessionRevokedRemote)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+		if err := revokeSessionAccessToken(db, id, sessionRevokedRemote); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+	}
+}
+
+// revokeUserSessions — 사용자의 모든 활성 세션과 그 액세스 토큰 폐기 (비밀번호 재설정 등)
+func revokeUserSessions(ex execer, userID int, reason string) error {
+	if _, err := ex.Exec(`
+		INSERT INTO revoked_tokens (jti, user_id, expires_at, reason)
+		SELECT access_jti, user_id, $2, $3 FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND access_jti <> ''
+		ON CONFLICT (jti) DO NOTHING
+	`, userID, time.Now().Add(accessTokenTTL()), reason); err != nil {
+		return err
+	}
+	_, err := ex.Exec(`
+		UPDATE sessions SET revoked_at = NOW(), revoked_reason = $2
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID, reason)
+	return err
+}
+
+// runSessionCleanup — 백그라운드 잡: 만료되거나 폐기된 지 오래된 세션 삭제 (교체 이력은 CASCADE)
+func runSessionCleanup(db *sql.DB) error {
+	_, err := db.Exec(`
+		DELETE FROM sessions
+		WHERE expires_at < NOW() - INTERVAL '1 day'
+		   OR revoked_at < NOW() - INTERVAL '30 days'
+	`)
+	return err
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"cmall_dd/internal/models"
+)
+
+func TestAccessTokenCarriesSession(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("ACCESS_TOKEN_TTL_MINUTES", "10")
+
+	signed, err := generateWalletToken(models.User{ID: 7, Email: "a@example.com", Role: "buyer"}, "", 42, "jti-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := currentJWTKeyring().parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID != 7 || claims.SessionID != 42 || claims.ID != "jti-1" {
+		t.Errorf("claims = %+v", claims)
+	}
+	ttl := claims.ExpiresAt.Sub(claims.IssuedAt.Time)
+	if ttl != 10*time.Minute {
+		t.Errorf("access token lifetime = %v, want 10m", ttl)
+	}
+}
+
+func TestRefreshTokenHashing(t *testing.T) {
+	a, b := hashRefreshToken("token-a"), hashRefreshToken("token-b")
+	if len(a) != 64 || a == b || a != hashRefreshToken("token-a") {
+		t.Errorf("hashRefreshToken: %q, %q", a, b)
+	}
+	if got := truncateRunes("가나다라", 2); got != "가나" {
+		t.Errorf("truncateRunes = %q", got)
+	}
+}
+
+func TestRefreshReuseGrace(t *testing.T) {
+	cases := []struct {
+		env  string
+		want int
+	}{
+		{"", 30},
+		{"0", 0},
+		{"-5", 0},
+		{"45", 45},
+		{"3600", 300},
+	}
+	for _, c := range cases {
+		t.Setenv("REFRESH_REUSE_GRACE_SECONDS", c.env)
+		if got := refreshReuseGrace(); got != c.want {
+			t.Errorf("REFRESH_REUSE_GRACE_SECONDS=%q: got %d, want %d", c.env, got, c.want)
+		}
+	}
+}
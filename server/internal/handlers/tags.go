@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// ── 태그 ───────────────────────────────────────────────────────────────────
+// 관리자가 관리하는 태그 사전(tags) + 상품-태그 다대다(product_tags).
+// 판매자는 사전에 있는 태그만 자기 상품에 붙일 수 있다 (PUT /products/:id/tags).
+// 공개 목록/검색은 ?tag=slug로 거른다.
+
+const maxTagsPerProduct = 20
+
+// Tag — 태그 1건. productCount는 공개 상품 수.
+type Tag struct {
+	ID           int               `json:"id"`
+	Slug         string            `json:"slug"`
+	Name         string            `json:"name"`
+	Names        map[string]string `json:"names"`
+	ProductCount int               `json:"productCount"`
+}
+
+const tagSelect = `
+	SELECT t.id, t.slug, t.name, t.names,
+	       (SELECT COUNT(*) FROM product_tags pt JOIN products p ON p.id = pt.product_id
+	        WHERE pt.tag_id = t.id AND p.is_active = true)
+	FROM tags t
+`
+
+func scanTag(row interface{ Scan(...interface{}) error }, locale string) (*Tag, error) {
+	var t Tag
+	var names []byte
+	if err := row.Scan(&t.ID, &t.Slug, &t.Name, &names, &t.ProductCount); err != nil {
+		return nil, err
+	}
+	t.Names = map[string]string{}
+	_ = json.Unmarshal(names, &t.Names)
+	t.Name = localizedName(t.Name, t.Names, locale)
+	return &t, nil
+}
+
+// normalizeTagSlugs — 중복 제거 + 검증 (순서 유지)
+func normalizeTagSlugs(raw []string) ([]string, string) {
+	seen := map[string]bool{}
+	slugs := []string{}
+	for _, r := range raw {
+		slug, ok := normalizeSlug(r, 64)
+		if !ok {
+			return nil, "tags must be slugs of lowercase letters, digits and single hyphens (max 64)"
+		}
+		if !seen[slug] {
+			seen[slug] = true
+			slugs = append(slugs, slug)
+		}
+	}
+	if len(slugs) > maxTagsPerProduct {
+		return nil, "at most " + strconv.Itoa(maxTagsPerProduct) + " tags per product"
+	}
+	return slugs, ""
+}
+
+// GetTags — GET /api/v1/tags?lang= (공개). 상품 수 많은 순.
+func GetTags(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := requestLocale(c)
+		rows, err := db.Query(tagSelect + " ORDER BY 5 DESC, t.slug")
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer rows.Close()
+		tags := []*Tag{}
+		for rows.Next() {
+			t, err := scanTag(rows, locale)
+			if err != nil {
+				respondDBError(c, err)
+				return
+			}
+			tags = append(tags, t)
+		}
+		c.JSON(http.StatusOK, tags)
+	}
+}
+
+type tagRequest struct {
+	Slug  *string           `json:"slug"`
+	Name  *string           `json:"name"`
+	Names map[string]string `json:"names"`
+}
+
+// CreateTag — POST /api/v1/admin/tags (관리자)
+func CreateTag(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c, db) {
+			return
+		}
+		var req tagRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Slug == nil || req.Name == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "slug and name are required"})
+			return
+		}
+		slug, ok := normalizeSlug(*req.Slug, 64)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "slug must be lowercase letters, digits and single hyphens (max 64)"})
+			return
+		}
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || utf8.RuneCountInString(name) > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name must be 1-100 characters"})
+			return
+		}
+		names, msg := validateLocalizedNames(req.Names)
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		namesJSON, _ := json.Marshal(names)
+
+		var id int
+		err := db.QueryRow(`
+			INSERT INTO tags (slug, name, names) VALUES ($1, $2, $3)
+			ON CONFLICT (slug) DO NOTHING
+			RETURNING id
+		`, slug, name, namesJSON).Scan(&id)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusConflict, gin.H{"error": "tag slug already exists"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		t, err := scanTag(db.QueryRow(tagSelect+" WHERE t.id = $1", id), requestLocale(c))
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, t)
+	}
+}
+
+// UpdateTag — PUT /api/v1/admin/tags/:id (관리자)
+func UpdateTag(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c, db) {
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+			return
+		}
+		var req tagRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		sets := []string{}
+		args := []interface{}{}
+		if req.Slug != nil {
+			slug, ok := normalizeSlug(*req.Slug, 64)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "slug must be lowercase letters, digits and single hyphens (max 64)"})
+				return
+			}
+			sets = append(sets, "slug = "+appendArg(&args, slug))
+		}
+		if req.Name != nil {
+			name := strings.TrimSpace(*req.Name)
+			if name == "" || utf8.RuneCountInString(name) > 100 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "name must be 1-100 characters"})
+				return
+			}
+			sets = append(sets, "name = "+appendArg(&args, name))
+		}
+		if req.Names != nil {
+			names, msg := validateLocalizedNames(req.Names)
+			if msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+			namesJSON, _ := json.Marshal(names)
+			sets = append(sets, "names = "+appendArg(&args, namesJSON))
+		}
+		if len(sets) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "no fields to update"})
+			return
+		}
+		query := "UPDATE tags SET " + strings.Join(sets, ", ") + " WHERE id = " + appendArg(&args, id)
+
+		res, err := db.Exec(query, args...)
+		if isUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "tag slug already exists"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+			return
+		}
+		t, err := scanTag(db.QueryRow(tagSelect+" WHERE t.id = $1", id), requestLocale(c))
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, t)
+	}
+}
+
+// DeleteTag — DELETE /api/v1/admin/tags/:id (관리자). 상품 연결은 CASCADE로 함께 지워진다.
+func DeleteTag(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c, db) {
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+			return
+		}
+		res, err := db.Exec(`DELETE FROM tags WHERE id = $1`, id)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Tag deleted"})
+	}
+}
+
+// SetProductTags — PUT /api/v1/products/:id/tags (JWT, 판매자 본인 또는 관리자)
+// body: {tags: ["slug", ...]} — 목록 전체를 교체한다. 사전에 없는 slug는 400.
+func SetProductTags(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		productID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+		var req struct {
+			Tags []string `json:"tags"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		slugs, msg := normalizeTagSlugs(req.Tags)
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		if !isAdminUser(db, userID.(int)) && !requireProductSeller(c, db, productID, userID.(int)) {
+			return
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer tx.Rollback()
+
+		var found pq.StringArray
+		if err := tx.QueryRow(
+			`SELECT COALESCE(array_agg(slug), '{}') FROM tags WHERE slug = ANY($1)`, pq.Array(slugs),
+		).Scan(&found); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if len(found) != len(slugs) {
+			known := map[string]bool{}
+			for _, s := range found {
+				known[s] = true
+			}
+			unknown := []string{}
+			for _, s := range slugs {
+				if !known[s] {
+					unknown = append(unknown, s)
+				}
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown tags", "tags": unknown})
+			return
+		}
+		// 같은 상품에 대한 동시 교체를 직렬화
+		var locked int
+		err = tx.QueryRow(`SELECT id FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, productID).Scan(&locked)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if _, err := tx.Exec(`DELETE FROM product_tags WHERE product_id = $1`, productID); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO product_tags (product_id, tag_id)
+			SELECT $1, id FROM tags WHERE slug = ANY($2)
+		`, productID, pq.Array(slugs)); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"productId": productID, "tags": slugs})
+	}
+}
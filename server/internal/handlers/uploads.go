@@ -0,0 +1,866 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"cmall_dd/internal/storage"
+	"cmall_dd/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ── 판매자 업로드 ───────────────────────────────────────────────────────────
+// 상품 파일(kind=file)과 이미지(kind=image)를 스토리지에 저장하고 artifacts 행으로 참조한다.
+// 상품은 URL 문자열 대신 artifactId / imageArtifactId로 연결한다.
+//   - 멀티파트: POST /artifacts (file, kind)
+//   - 재개 가능: tus 1.0 core — POST /uploads → PATCH /uploads/:id 반복, HEAD로 오프셋 확인.
+//     조각은 스토리지의 uploads/<id>/ 아래 파트 객체로 쌓이므로 인스턴스가 여러 대여도
+//     이어 올릴 수 있다. 마지막 조각을 받으면 파트를 이어 붙여 아티팩트로 확정한다.
+// 모든 업로드는 ingestArtifact를 거친다: 내용 스니핑 → 크기 제한 → SHA-256 → 저장
+// (이미지는 디코드 검증 + JPEG 썸네일).
+
+const (
+	artifactKindFile  = "file"
+	artifactKindImage = "image"
+)
+
+// thumbnailMaxDim — 이미지 썸네일 긴 변 (px)
+const thumbnailMaxDim = 320
+
+const tusVersion = "1.0.0"
+
+// Artifact — 업로드된 파일 메타데이터 (storage_key는 노출하지 않는다)
+type Artifact struct {
+	ID           int       `json:"id"`
+	Kind         string    `json:"kind"`
+	Filename     string    `json:"filename"`
+	ContentType  string    `json:"contentType"`
+	SizeBytes    int64     `json:"sizeBytes"`
+	FileSize     string    `json:"fileSize"`
+	SHA256       string    `json:"sha256"`
+	ImageURL     string    `json:"imageUrl,omitempty"`
+	ThumbnailURL string    `json:"thumbnailUrl,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+var (
+	errUploadTooLarge   = errors.New("file exceeds the upload size limit")
+	errUploadType       = errors.New("unsupported file type")
+	errUploadIncomplete = errors.New("upload body is shorter than the declared size")
+)
+
+// uploadMaxBytes — kind별 업로드 크기 제한 (UPLOAD_MAX_FILE_MB 기본 500, UPLOAD_MAX_IMAGE_MB 기본 10)
+func uploadMaxBytes(kind string) int64 {
+	if kind == artifactKindImage {
+		return int64(envInt("UPLOAD_MAX_IMAGE_MB", 10)) << 20
+	}
+	return int64(envInt("UPLOAD_MAX_FILE_MB", 500)) << 20
+}
+
+func normalizeArtifactKind(raw string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", artifactKindFile:
+		return artifactKindFile, true
+	case artifactKindImage:
+		return artifactKindImage, true
+	}
+	return "", false
+}
+
+func respondUploadError(c *gin.Context, kind string, err error) {
+	switch {
+	case errors.Is(err, errUploadTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error(), "maxBytes": uploadMaxBytes(kind)})
+	case errors.Is(err, errUploadType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "지원하지 않는 파일 형식입니다 (이미지는 PNG/JPEG/GIF)"})
+	case errors.Is(err, utils.ErrImageTooLarge):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, errUploadIncomplete):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("[uploads] store failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store upload"})
+	}
+}
+
+// formatFileSize — 상품 fileSize 표시용 (예: "512 B", "1.5 MB")
+func formatFileSize(n int64) string {
+	if n < 1024 {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+	units := []string{"KB", "MB", "GB", "TB"}
+	v := float64(n) / 1024
+	i := 0
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+	return strconv.FormatFloat(v, 'f', 1, 64) + " " + units[i]
+}
+
+// sanitizeFilename — 클라이언트 파일명에서 경로·제어문자를 제거 (Content-Disposition/표시용)
+func sanitizeFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, name))
+	if name == "" || name == "." || name == "/" || name == ".." {
+		return "file"
+	}
+	for len(name) > 200 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+var safeExtPattern = regexp.MustCompile(`^\.[a-z0-9]{1,10}$`)
+
+// storageExt — 스토리지 키에 붙일 확장자 (로컬 백엔드의 content-type 추정용, 안전한 것만)
+func storageExt(filename string) string {
+	ext := strings.ToLower(path.Ext(filename))
+	if safeExtPattern.MatchString(ext) {
+		return ext
+	}
+	return ""
+}
+
+var imageExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+}
+
+// sniffedFileType — 내용 기반 content-type. 브라우저가 문서로 해석할 수 있는 타입은
+// octet-stream으로 낮춘다 (다운로드는 attachment + nosniff지만 이중 방어, CWE-79).
+func sniffedFileType(head []byte) string {
+	ct := http.DetectContentType(head)
+	if strings.HasPrefix(ct, "text/html") || strings.HasPrefix(ct, "text/xml") {
+		return "application/octet-stream"
+	}
+	return ct
+}
+
+func imageURL(id int) string {
+	return "/api/v1/images/" + strconv.Itoa(id)
+}
+
+// countingReader — 실제로 읽힌 바이트 수 (선언 크기와 비교)
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// ingestArtifact — 업로드 파이프라인. size는 선언 크기 (-1 = 모름, 로컬 백엔드만 가능).
+func ingestArtifact(db *sql.DB, ownerID int, kind, filename string, r io.Reader, size int64) (*Artifact, error) {
+	limit := uploadMaxBytes(kind)
+	if size > limit {
+		return nil, errUploadTooLarge
+	}
+	store, err := getArtifactStorage()
+	if err != nil {
+		return nil, err
+	}
+	token, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Artifact{Kind: kind, Filename: sanitizeFilename(filename)}
+	br := bufio.NewReaderSize(r, 512)
+	head, _ := br.Peek(512)
+	var key, thumbKey string
+
+	if kind == artifactKindImage {
+		ext, ok := imageExtensions[http.DetectContentType(head)]
+		if !ok {
+			return nil, errUploadType
+		}
+		// 이미지는 제한이 작으므로 메모리에서 디코드 검증 + 썸네일
+		data, err := io.ReadAll(io.LimitReader(br, limit+1))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) > limit {
+			return nil, errUploadTooLarge
+		}
+		if size >= 0 && int64(len(data)) != size {
+			return nil, errUploadIncomplete
+		}
+		thumb, err := utils.EncodeThumbnailJPEG(data, thumbnailMaxDim)
+		if errors.Is(err, utils.ErrImageTooLarge) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, errUploadType // 손상된 이미지
+		}
+		sum := sha256.Sum256(data)
+		a.SHA256 = hex.EncodeToString(sum[:])
+		a.ContentType = http.DetectContentType(head)
+		a.SizeBytes = int64(len(data))
+
+		key = fmt.Sprintf("images/%d/%s%s", ownerID, token, ext)
+		thumbKey = fmt.Sprintf("images/%d/%s_thumb.jpg", ownerID, token)
+		if err := store.Put(key, bytes.NewReader(data), a.SizeBytes, a.ContentType); err != nil {
+			return nil, err
+		}
+		if err := store.Put(thumbKey, bytes.NewReader(thumb), int64(len(thumb)), "image/jpeg"); err != nil {
+			store.Delete(key)
+			return nil, err
+		}
+	} else {
+		a.ContentType = sniffedFileType(head)
+		key = fmt.Sprintf("artifacts/%d/%s%s", ownerID, token, storageExt(a.Filename))
+		hasher := sha256.New()
+		counter := &countingReader{r: io.LimitReader(br, limit+1)}
+		if err := store.Put(key, io.TeeReader(counter, hasher), size, a.ContentType); err != nil {
+			return nil, err
+		}
+		if counter.n > limit {
+			store.Delete(key)
+			return nil, errUploadTooLarge
+		}
+		if size >= 0 && counter.n != size {
+			store.Delete(key)
+			return nil, errUploadIncomplete
+		}
+		a.SHA256 = hex.EncodeToString(hasher.Sum(nil))
+		a.SizeBytes = counter.n
+	}
+
+	err = db.QueryRow(`
+		INSERT INTO artifacts (owner_id, kind, storage_key, filename, content_type, size_bytes, sha256, thumbnail_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''))
+		RETURNING id, created_at
+	`, ownerID, kind, key, a.Filename, a.ContentType, a.SizeBytes, a.SHA256, thumbKey).Scan(&a.ID, &a.CreatedAt)
+	if err != nil {
+		store.Delete(key)
+		if thumbKey != "" {
+			store.Delete(thumbKey)
+		}
+		return nil, err
+	}
+	a.fillDerived()
+	return a, nil
+}
+
+func (a *Artifact) fillDerived() {
+	a.FileSize = formatFileSize(a.SizeBytes)
+	if a.Kind == artifactKindImage {
+		a.ImageURL = imageURL(a.ID)
+		a.ThumbnailURL = a.ImageURL + "?size=thumb"
+	}
+}
+
+const artifactColumns = `id, kind, filename, content_type, size_bytes, COALESCE(sha256, ''), created_at`
+
+func scanArtifact(row interface{ Scan(...interface{}) error }) (*Artifact, error) {
+	var a Artifact
+	if err := row.Scan(&a.ID, &a.Kind, &a.Filename, &a.ContentType, &a.SizeBytes, &a.SHA256, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+	a.fillDerived()
+	return &a, nil
+}
+
+// loadOwnedArtifact — 본인 소유 + kind 일치 아티팩트. 아니면 sql.ErrNoRows (IDOR 방지, CWE-639)
+func loadOwnedArtifact(db *sql.DB, ownerID, id int, kind string) (*Artifact, error) {
+	return scanArtifact(db.QueryRow(
+		`SELECT `+artifactColumns+` FROM artifacts WHERE id = $1 AND owner_id = $2 AND kind = $3`,
+		id, ownerID, kind))
+}
+
+// UploadArtifact — POST /api/v1/artifacts (JWT, multipart: file, kind=file|image)
+func UploadArtifact(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		// 파싱 전에 본문 상한 — 멀티파트 오버헤드 여유 1MB
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, uploadMaxBytes(artifactKindFile)+1<<20)
+
+		kind, ok := normalizeArtifactKind(c.PostForm("kind"))
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be file or image"})
+			return
+		}
+		fh, err := c.FormFile("file")
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				respondUploadError(c, kind, errUploadTooLarge)
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			respondUploadError(c, kind, err)
+			return
+		}
+		defer f.Close()
+
+		a, err := ingestArtifact(db, userID.(int), kind, fh.Filename, f, fh.Size)
+		if err != nil {
+			respondUploadError(c, kind, err)
+			return
+		}
+		c.JSON(http.StatusCreated, a)
+	}
+}
+
+// GetMyArtifacts — GET /api/v1/artifacts?kind= (JWT, 본인 업로드 최근 100건)
+func GetMyArtifacts(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		query := `SELECT ` + artifactColumns + ` FROM artifacts WHERE owner_id = $1`
+		args := []interface{}{userID}
+		if raw := c.Query("kind"); raw != "" {
+			kind, ok := normalizeArtifactKind(raw)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be file or image"})
+				return
+			}
+			query += " AND kind = " + appendArg(&args, kind)
+		}
+		rows, err := db.Query(query+" ORDER BY created_at DESC LIMIT 100", args...)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer rows.Close()
+
+		artifacts := []*Artifact{}
+		for rows.Next() {
+			a, err := scanArtifact(rows)
+			if err != nil {
+				respondDBError(c, err)
+				return
+			}
+			artifacts = append(artifacts, a)
+		}
+		c.JSON(http.StatusOK, artifacts)
+	}
+}
+
+// DeleteArtifact — DELETE /api/v1/artifacts/:id (JWT, 본인). 상품에 연결된 아티팩트는 409.
+func DeleteArtifact(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid artifact ID"})
+			return
+		}
+
+		var key, thumbKey string
+		var inUse bool
+		err = db.QueryRow(`
+			SELECT storage_key, COALESCE(thumbnail_key, ''),
+			       EXISTS (SELECT 1 FROM products WHERE artifact_id = $1 OR image_artifact_id = $1)
+			       OR EXISTS (SELECT 1 FROM product_releases WHERE artifact_id = $1)
+			FROM artifacts WHERE id = $1 AND owner_id = $2
+		`, id, userID).Scan(&key, &thumbKey, &inUse)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "artifact not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if inUse {
+			c.JSON(http.StatusConflict, gin.H{"error": "상품에 연결된 파일은 삭제할 수 없습니다"})
+			return
+		}
+		if _, err := db.Exec(`DELETE FROM artifacts WHERE id = $1 AND owner_id = $2`, id, userID); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if store, err := getArtifactStorage(); err == nil {
+			for _, k := range []string{key, thumbKey} {
+				if k == "" {
+					continue
+				}
+				if err := store.Delete(k); err != nil {
+					log.Printf("[uploads] delete object %s failed: %v", k, err)
+				}
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Artifact deleted successfully"})
+	}
+}
+
+// ServeImage — GET /api/v1/images/:id?size=thumb (공개). kind=image 아티팩트만 제공한다 —
+// 상품 파일은 구매 다운로드 경로로만 나간다.
+func ServeImage(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image ID"})
+			return
+		}
+		var key, thumbKey, contentType string
+		err = db.QueryRow(`
+			SELECT storage_key, COALESCE(thumbnail_key, ''), content_type
+			FROM artifacts WHERE id = $1 AND kind = 'image'
+		`, id).Scan(&key, &thumbKey, &contentType)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "image not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if c.Query("size") == "thumb" && thumbKey != "" {
+			key, contentType = thumbKey, "image/jpeg"
+		}
+
+		store, err := getArtifactStorage()
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "storage unavailable"})
+			return
+		}
+		body, info, err := store.Get(key)
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "image not found"})
+			return
+		}
+		if err != nil {
+			log.Printf("[uploads] get %s failed: %v", key, err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "failed to read image"})
+			return
+		}
+		defer body.Close()
+		// 아티팩트 내용은 불변 (교체 시 새 ID)
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+		c.DataFromReader(http.StatusOK, info.Size, contentType, body, map[string]string{
+			"X-Content-Type-Options": "nosniff",
+		})
+	}
+}
+
+// ── tus 재개 가능 업로드 ─────────────────────────────────────────────────────
+
+// parseTusMetadata — "key base64value,key2 base64value2" (tus Upload-Metadata)
+func parseTusMetadata(header string) map[string]string {
+	meta := map[string]string{}
+	for _, pair := range strings.Split(header, ",") {
+		key, enc, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+		val, err := base64.StdEncoding.DecodeString(strings.TrimSpace(enc))
+		if err != nil {
+			continue
+		}
+		meta[key] = string(val)
+	}
+	return meta
+}
+
+type pendingUpload struct {
+	ID         string
+	Kind       string
+	Filename   string
+	Length     int64
+	Offset     int64
+	ArtifactID sql.NullInt64
+}
+
+func loadPendingUpload(db *sql.DB, id string, ownerID int) (*pendingUpload, error) {
+	u := pendingUpload{ID: id}
+	err := db.QueryRow(`
+		SELECT kind, filename, upload_length, upload_offset, artifact_id
+		FROM uploads WHERE id = $1 AND owner_id = $2
+	`, id, ownerID).Scan(&u.Kind, &u.Filename, &u.Length, &u.Offset, &u.ArtifactID)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func setTusHeaders(c *gin.Context, u *pendingUpload) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(u.Length, 10))
+	if u.ArtifactID.Valid {
+		c.Header("Upload-Artifact-Id", strconv.FormatInt(u.ArtifactID.Int64, 10))
+	}
+}
+
+// CreateUpload — POST /api/v1/uploads (JWT, tus creation)
+// 헤더: Upload-Length (필수), Upload-Metadata (filename, kind=file|image)
+func CreateUpload(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		c.Header("Tus-Resumable", tusVersion)
+		length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+		if err != nil || length <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Length header must be a positive integer"})
+			return
+		}
+		meta := parseTusMetadata(c.GetHeader("Upload-Metadata"))
+		kind, ok := normalizeArtifactKind(meta["kind"])
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be file or image"})
+			return
+		}
+		if length > uploadMaxBytes(kind) {
+			respondUploadError(c, kind, errUploadTooLarge)
+			return
+		}
+		id, err := randomHex(16)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create upload"})
+			return
+		}
+		if _, err := db.Exec(`
+			INSERT INTO uploads (id, owner_id, kind, filename, upload_length)
+			VALUES ($1, $2, $3, $4, $5)
+		`, id, userID, kind, sanitizeFilename(meta["filename"]), length); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.Header("Location", "/api/v1/uploads/"+id)
+		c.JSON(http.StatusCreated, gin.H{"id": id, "uploadLength": length, "uploadOffset": 0})
+	}
+}
+
+// GetUpload — HEAD/GET /api/v1/uploads/:id (JWT). 오프셋은 헤더로, GET은 JSON도 반환.
+func GetUpload(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		u, err := loadPendingUpload(db, c.Param("id"), userID.(int))
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "upload not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		setTusHeaders(c, u)
+		resp := gin.H{"id": u.ID, "kind": u.Kind, "filename": u.Filename, "uploadLength": u.Length, "uploadOffset": u.Offset}
+		if u.ArtifactID.Valid {
+			resp["artifactId"] = u.ArtifactID.Int64
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// PatchUpload — PATCH /api/v1/uploads/:id (JWT, tus)
+// Content-Type: application/offset+octet-stream, Upload-Offset = 현재 오프셋 (불일치 409).
+// 마지막 조각이면 아티팩트로 확정하고 Upload-Artifact-Id 헤더를 돌려준다. 확정 단계에서
+// 검증(형식/크기)에 실패한 업로드는 폐기된다 — 처음부터 다시 올려야 한다.
+func PatchUpload(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		c.Header("Tus-Resumable", tusVersion)
+		if c.ContentType() != "application/offset+octet-stream" {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be application/offset+octet-stream"})
+			return
+		}
+		offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Offset header is required"})
+			return
+		}
+		u, err := loadPendingUpload(db, c.Param("id"), userID.(int))
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "upload not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if u.ArtifactID.Valid || offset != u.Offset {
+			setTusHeaders(c, u)
+			c.JSON(http.StatusConflict, gin.H{"error": "Upload-Offset does not match the current offset"})
+			return
+		}
+		n := c.Request.ContentLength
+		if n < 0 {
+			c.JSON(http.StatusLengthRequired, gin.H{"error": "Content-Length is required"})
+			return
+		}
+		if offset+n > u.Length {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "chunk exceeds Upload-Length"})
+			return
+		}
+
+		store, err := getArtifactStorage()
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "storage unavailable"})
+			return
+		}
+		if n > 0 {
+			partKey := fmt.Sprintf("uploads/%s/%020d", u.ID, offset)
+			counter := &countingReader{r: io.LimitReader(c.Request.Body, n)}
+			if err := store.Put(partKey, counter, n, "application/octet-stream"); err != nil {
+				store.Delete(partKey)
+				respondUploadError(c, u.Kind, err)
+				return
+			}
+			if counter.n != n {
+				store.Delete(partKey)
+				respondUploadError(c, u.Kind, errUploadIncomplete)
+				return
+			}
+			// 오프셋 선점 — 같은 오프셋으로 동시에 들어온 PATCH 중 하나만 반영된다
+			claimed, err := claimUploadPart(db, u.ID, offset, n, partKey)
+			if err != nil || !claimed {
+				store.Delete(partKey)
+				if err != nil {
+					respondDBError(c, err)
+					return
+				}
+				c.JSON(http.StatusConflict, gin.H{"error": "Upload-Offset does not match the current offset"})
+				return
+			}
+			u.Offset += n
+		}
+
+		if u.Offset == u.Length {
+			a, err := finalizeUpload(db, store, userID.(int), u)
+			if err != nil {
+				respondUploadError(c, u.Kind, err)
+				return
+			}
+			u.ArtifactID = sql.NullInt64{Int64: int64(a.ID), Valid: true}
+		}
+		setTusHeaders(c, u)
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func claimUploadPart(db *sql.DB, uploadID string, offset, n int64, partKey string) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(`
+		UPDATE uploads SET upload_offset = upload_offset + $3, updated_at = NOW()
+		WHERE id = $1 AND upload_offset = $2 AND artifact_id IS NULL
+	`, uploadID, offset, n)
+	if err != nil {
+		return false, err
+	}
+	if affected, _ := res.RowsAffected(); affected != 1 {
+		return false, nil
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO upload_parts (upload_id, part_offset, size_bytes, storage_key) VALUES ($1, $2, $3, $4)
+	`, uploadID, offset, n, partKey); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// partsReader — 파트 객체를 순서대로 하나씩 열어 이어 읽는다 (동시에 하나만 연다)
+type partsReader struct {
+	store storage.Storage
+	keys  []string
+	cur   io.ReadCloser
+}
+
+func (p *partsReader) Read(buf []byte) (int, error) {
+	for {
+		if p.cur == nil {
+			if len(p.keys) == 0 {
+				return 0, io.EOF
+			}
+			body, _, err := p.store.Get(p.keys[0])
+			if err != nil {
+				return 0, err
+			}
+			p.cur, p.keys = body, p.keys[1:]
+		}
+		n, err := p.cur.Read(buf)
+		if err == io.EOF {
+			p.cur.Close()
+			p.cur = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (p *partsReader) Close() error {
+	if p.cur != nil {
+		return p.cur.Close()
+	}
+	return nil
+}
+
+func uploadPartKeys(db *sql.DB, uploadID string) ([]string, error) {
+	rows, err := db.Query(`SELECT storage_key FROM upload_parts WHERE upload_id = $1 ORDER BY part_offset`, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// finalizeUpload — 파트를 이어 ingestArtifact로 확정하고 파트를 정리한다.
+func finalizeUpload(db *sql.DB, store storage.Storage, ownerID int, u *pendingUpload) (*Artifact, error) {
+	keys, err := uploadPartKeys(db, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	reader := &partsReader{store: store, keys: keys}
+	a, ingestErr := ingestArtifact(db, ownerID, u.Kind, u.Filename, reader, u.Length)
+	reader.Close()
+
+	if ingestErr == nil {
+		if _, err := db.Exec(`
+			UPDATE uploads SET artifact_id = $2, updated_at = NOW() WHERE id = $1
+		`, u.ID, a.ID); err != nil {
+			log.Printf("[uploads] mark %s complete failed: %v", u.ID, err)
+		}
+		if _, err := db.Exec(`DELETE FROM upload_parts WHERE upload_id = $1`, u.ID); err != nil {
+			log.Printf("[uploads] delete parts of %s failed: %v", u.ID, err)
+		}
+	} else if _, err := db.Exec(`DELETE FROM uploads WHERE id = $1`, u.ID); err != nil {
+		log.Printf("[uploads] discard %s failed: %v", u.ID, err)
+	}
+	deleteObjects(store, keys)
+	return a, ingestErr
+}
+
+func deleteObjects(store storage.Storage, keys []string) {
+	for _, k := range keys {
+		if err := store.Delete(k); err != nil {
+			log.Printf("[uploads] delete object %s failed: %v", k, err)
+		}
+	}
+}
+
+// CancelUpload — DELETE /api/v1/uploads/:id (JWT, tus termination)
+func CancelUpload(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		c.Header("Tus-Resumable", tusVersion)
+		id := c.Param("id")
+		keys, err := uploadPartKeys(db, id)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		res, err := db.Exec(`DELETE FROM uploads WHERE id = $1 AND owner_id = $2`, id, userID)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "upload not found"})
+			return
+		}
+		if store, err := getArtifactStorage(); err == nil {
+			deleteObjects(store, keys)
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// runUploadCleanup — 24시간 동안 진행 없는 미완료 업로드와 그 파트를 정리한다.
+// 완료된 업로드 행은 7일 뒤 삭제 (아티팩트는 남는다).
+func runUploadCleanup(db *sql.DB) error {
+	store, err := getArtifactStorage()
+	if err != nil {
+		return err
+	}
+	// DELETE ... RETURNING을 CTE로 — 같은 문장의 SELECT는 삭제 전 스냅샷의 파트를 본다
+	rows, err := db.Query(`
+		WITH expired AS (
+			DELETE FROM uploads
+			WHERE artifact_id IS NULL AND updated_at < NOW() - INTERVAL '24 hours'
+			RETURNING id
+		)
+		SELECT p.storage_key FROM upload_parts p JOIN expired e ON e.id = p.upload_id
+	`)
+	if err != nil {
+		return err
+	}
+	var keys []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			rows.Close()
+			return err
+		}
+		keys = append(keys, k)
+	}
+	rows.Close()
+	deleteObjects(store, keys)
+
+	_, err = db.Exec(`DELETE FROM uploads WHERE artifact_id IS NOT NULL AND updated_at < NOW() - INTERVAL '7 days'`)
+	return err
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatFileSize(t *testing.T) {
+	cases := map[int64]string{
+		0:                 "0 B",
+		1023:              "1023 B",
+		1536:              "1.5 KB",
+		15 << 20:          "15.0 MB",
+		3<<30 + 300<<20:   "3.3 GB",
+		int64(2048) << 40: "2048.0 TB",
+	}
+	for in, want := range cases {
+		if got := formatFileSize(in); got != want {
+			t.Errorf("formatFileSize(%d) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"../../etc/passwd", "passwd"},
+		{`C:\Users\me\매매일지.pdf`, "매매일지.pdf"},
+		{"a\r\nb.zip", "ab.zip"},
+		{"", "file"},
+		{"..", "file"},
+	}
+	for _, c := range cases {
+		if got := sanitizeFilename(c.in); got != c.want {
+			t.Errorf("sanitizeFilename(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+	if got := sanitizeFilename(strings.Repeat("가", 100)); len(got) > 200 || !strings.HasPrefix(got, "가") {
+		t.Errorf("long name not truncated on a rune boundary: %d bytes", len(got))
+	}
+	if storageExt("x.tar.GZ") != ".gz" || storageExt("x.p hp") != "" || storageExt("noext") != "" {
+		t.Error("storageExt mismatch")
+	}
+}
+
+func TestSniffedFileType(t *testing.T) {
+	if got := sniffedFileType([]byte("PK\x03\x04rest")); got != "application/zip" {
+		t.Errorf("zip sniffed as %q", got)
+	}
+	// HTML/SVG 업로드는 문서로 해석되지 않도록 octet-stream
+	for _, body := range []string{"<html><script>x</script>", `<?xml version="1.0"?><svg/>`} {
+		if got := sniffedFileType([]byte(body)); got != "application/octet-stream" {
+			t.Errorf("sniffedFileType(%q) = %q", body, got)
+		}
+	}
+}
+
+func TestParseTusMetadata(t *testing.T) {
+	// filename "리포트.pdf", kind "image", 값 없는 키, 잘못된 base64
+	meta := parseTusMetadata("filename 66as7Y+s7Yq4LnBkZg==, kind aW1hZ2U=,is_confidential,bad !!!")
+	if meta["filename"] != "리포트.pdf" || meta["kind"] != "image" {
+		t.Errorf("meta = %v", meta)
+	}
+	if _, ok := meta["is_confidential"]; !ok {
+		t.Error("value-less key dropped")
+	}
+	if _, ok := meta["bad"]; ok {
+		t.Error("invalid base64 accepted")
+	}
+}
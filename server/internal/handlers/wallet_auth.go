@@ -12,7 +12,6 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 // ── 지갑 인증 (M3) ────────────────────────────────────────────────────────
@@ -160,15 +159,15 @@ func WalletVerify(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		// ⑤ JWT 발급
-		token, err := generateWalletToken(user, wallet)
+		// ⑤ 세션 시작 — JWT(액세스) + 리프레시 토큰 발급 (sessions.go)
+		tokens, err := startSession(c, db, user, wallet)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
 			return
 		}
 
 		c.JSON(http.StatusOK, models.WalletAuthResponse{
-			Token:         token,
+			AuthTokens:    tokens,
 			WalletAddress: wallet,
 			User:          user,
 		})
@@ -236,11 +235,8 @@ func recoverAddress(message []byte, signature string) (string, error) {
 func resolveUserWithWalletBinding(c *gin.Context, db *sql.DB, wallet string) (models.User, error) {
 	// 1) 유효한 로그인 토큰 확인 (옵셔널 — /auth/verify는 public 라우트)
 	var loggedInID int
-	if authHeader := c.GetHeader("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
-		claims := &Claims{}
-		if token, err := jwt.ParseWithClaims(strings.TrimPrefix(authHeader, "Bearer "), claims, func(t *jwt.Token) (interface{}, error) {
-			return jwtSecret(), nil
-		}); err == nil && token.Valid {
+	if raw := bearerToken(c); raw != "" {
+		if claims, err := verifyAccessToken(db, raw); err == nil {
 			loggedInID = claims.UserID
 		}
 	}
@@ -321,14 +317,16 @@ func getOrCreateWalletUser(db *sql.DB, wallet string) (models.User, error) {
 	return user, err
 }
 
-// generateWalletToken — walletAddress claim 포함 JWT
-func generateWalletToken(user models.User, wallet string) (string, error) {
+// generateWalletToken — walletAddress/세션 ID claim 포함 JWT. tokenID(jti)가 ""이면 새로 만든다.
+func generateWalletToken(user models.User, wallet string, sessionID int, tokenID string) (string, error) {
 	claims := &Claims{
 		UserID:        user.ID,
 		Email:         user.Email,
 		Role:          user.Role,
 		WalletAddress: wallet,
+		SessionID:     sessionID,
 		RegisteredClaims: jwtRegisteredClaims(),
 	}
+	claims.ID = tokenID
 	return signClaims(claims)
 }
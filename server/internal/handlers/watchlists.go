@@ -0,0 +1,638 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cmall_dd/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// ── 관심종목 (2026-10) ───────────────────────────────────────────────────
+// 사용자 소유 관심종목 목록 CRUD + 일괄 분석(POST /watchlists/:id/analyze) +
+// 예약 분석(schedule_request_type/schedule_hour — 매일 KST 지정 시각, 백그라운드 잡).
+// 심볼은 저장 전 resolveAnalysisSymbol로 정규화/검증한다.
+
+const (
+	maxWatchlistsPerUser   = 20
+	maxSymbolsPerWatchlist = 50
+)
+
+// kst — 예약 분석 기준 시간대 (KRX 장 운영 기준). tzdata 없는 컨테이너에서도 동작하도록 고정 오프셋.
+var kst = time.FixedZone("KST", 9*60*60)
+
+// symbolScopedAnalysisTypes — 종목 단위로 실행되는 분석 유형.
+// 나머지(스크리너/팩터 등)는 전 종목 대상이라 관심종목당 1회만 제출한다.
+var symbolScopedAnalysisTypes = map[string]bool{
+	"stock_report": true,
+}
+
+// Watchlist — 관심종목 목록
+type Watchlist struct {
+	ID                  int        `json:"id"`
+	UserID              int        `json:"userId"`
+	Name                string     `json:"name"`
+	Symbols             []string   `json:"symbols"`
+	ScheduleRequestType *string    `json:"scheduleRequestType,omitempty"`
+	ScheduleHour        *int       `json:"scheduleHour,omitempty"`
+	LastScheduledAt     *time.Time `json:"lastScheduledAt,omitempty"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	UpdatedAt           time.Time  `json:"updatedAt"`
+}
+
+// WatchlistRequest — 생성/수정 공용 본문. 수정 시 nil 필드는 유지,
+// scheduleRequestType = "" 이면 예약을 해제한다.
+type WatchlistRequest struct {
+	Name                *string   `json:"name,omitempty"`
+	Symbols             *[]string `json:"symbols,omitempty"`
+	ScheduleRequestType *string   `json:"scheduleRequestType,omitempty"`
+	ScheduleHour        *int      `json:"scheduleHour,omitempty"`
+}
+
+// validateWatchlistSymbols — 정규화 + 마스터 대조 + 중복 제거 (입력 순서 유지)
+func validateWatchlistSymbols(db *sql.DB, raw []string) ([]string, string, error) {
+	if len(raw) > maxSymbolsPerWatchlist {
+		return nil, fmt.Sprintf("a watchlist can hold at most %d symbols", maxSymbolsPerWatchlist), nil
+	}
+	out := make([]string, 0, len(raw))
+	seen := map[string]bool{}
+	for _, s := range raw {
+		code, msg, err := resolveAnalysisSymbol(db, s)
+		if err != nil || msg != "" {
+			return nil, msg, err
+		}
+		if !seen[code] {
+			seen[code] = true
+			out = append(out, code)
+		}
+	}
+	return out, "", nil
+}
+
+// validateWatchlistSchedule — 예약 분석 설정 검증 (유형 allowlist + 0~23시)
+func validateWatchlistSchedule(requestType string, hour *int) string {
+	if !allowedAnalysisRequestTypes[requestType] {
+		return "unsupported scheduleRequestType"
+	}
+	if hour == nil || *hour < 0 || *hour > 23 {
+		return "scheduleHour must be between 0 and 23 (KST)"
+	}
+	return ""
+}
+
+// loadWatchlist — 소유자 조건으로 관심종목 조회 (타인 소유면 sql.ErrNoRows — IDOR 방지, CWE-639)
+func loadWatchlist(db *sql.DB, id int, userID interface{}) (Watchlist, error) {
+	var w Watchlist
+	err := db.QueryRow(`
+		SELECT id, user_id, name, schedule_request_type, schedule_hour, last_scheduled_at, created_at, updated_at
+		FROM watchlists WHERE id = $1 AND user_id = $2
+	`, id, userID).Scan(&w.ID, &w.UserID, &w.Name, &w.ScheduleRequestType, &w.ScheduleHour,
+		&w.LastScheduledAt, &w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		return w, err
+	}
+	w.Symbols, err = loadWatchlistSymbols(db, w.ID)
+	return w, err
+}
+
+func loadWatchlistSymbols(db *sql.DB, watchlistID int) ([]string, error) {
+	rows, err := db.Query(
+		"SELECT stock_code FROM watchlist_items WHERE watchlist_id = $1 ORDER BY position, stock_code", watchlistID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	symbols := []string{}
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, code)
+	}
+	return symbols, rows.Err()
+}
+
+// replaceWatchlistSymbols — 항목 전체 교체 (트랜잭션 내)
+func replaceWatchlistSymbols(tx *sql.Tx, watchlistID int, symbols []string) error {
+	if _, err := tx.Exec("DELETE FROM watchlist_items WHERE watchlist_id = $1", watchlistID); err != nil {
+		return err
+	}
+	for i, code := range symbols {
+		if _, err := tx.Exec(
+			"INSERT INTO watchlist_items (watchlist_id, stock_code, position) VALUES ($1, $2, $3)",
+			watchlistID, code, i,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetWatchlists — GET /api/v1/watchlists (JWT)
+func GetWatchlists(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		rows, err := db.Query(`
+			SELECT id, user_id, name, schedule_request_type, schedule_hour, last_scheduled_at, created_at, updated_at
+			FROM watchlists WHERE user_id = $1
+			ORDER BY created_at ASC
+		`, userID)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer rows.Close()
+
+		watchlists := []Watchlist{}
+		for rows.Next() {
+			var w Watchlist
+			if err := rows.Scan(&w.ID, &w.UserID, &w.Name, &w.ScheduleRequestType, &w.ScheduleHour,
+				&w.LastScheduledAt, &w.CreatedAt, &w.UpdatedAt); err != nil {
+				respondDBError(c, err)
+				return
+			}
+			watchlists = append(watchlists, w)
+		}
+		rows.Close()
+
+		for i := range watchlists {
+			symbols, err := loadWatchlistSymbols(db, watchlists[i].ID)
+			if err != nil {
+				respondDBError(c, err)
+				return
+			}
+			watchlists[i].Symbols = symbols
+		}
+		c.JSON(http.StatusOK, gin.H{"watchlists": watchlists})
+	}
+}
+
+// GetWatchlist — GET /api/v1/watchlists/:id (JWT, 소유자)
+func GetWatchlist(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid watchlist ID"})
+			return
+		}
+
+		w, err := loadWatchlist(db, id, userID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Watchlist not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, w)
+	}
+}
+
+// CreateWatchlist — POST /api/v1/watchlists (JWT)
+func CreateWatchlist(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		var req WatchlistRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Name == nil || strings.TrimSpace(*req.Name) == "" || utf8.RuneCountInString(*req.Name) > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name must be 1-100 characters"})
+			return
+		}
+		name := strings.TrimSpace(*req.Name)
+
+		var symbols []string
+		if req.Symbols != nil {
+			var msg string
+			var err error
+			symbols, msg, err = validateWatchlistSymbols(db, *req.Symbols)
+			if err != nil {
+				respondDBError(c, err)
+				return
+			}
+			if msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+		}
+
+		var scheduleType *string
+		var scheduleHour *int
+		if req.ScheduleRequestType != nil && *req.ScheduleRequestType != "" {
+			if msg := validateWatchlistSchedule(*req.ScheduleRequestType, req.ScheduleHour); msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+			scheduleType, scheduleHour = req.ScheduleRequestType, req.ScheduleHour
+		}
+
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM watchlists WHERE user_id = $1", userID).Scan(&count); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if count >= maxWatchlistsPerUser {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d watchlists per user", maxWatchlistsPerUser)})
+			return
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer tx.Rollback()
+
+		// 예약 설정 시 last_scheduled_at = NOW() — 오늘 이미 지난 시각이면 내일부터 실행
+		var w Watchlist
+		err = tx.QueryRow(`
+			INSERT INTO watchlists (user_id, name, schedule_request_type, schedule_hour, last_scheduled_at)
+			VALUES ($1, $2, $3, $4, CASE WHEN $3::varchar IS NULL THEN NULL ELSE NOW() END)
+			RETURNING id, user_id, name, schedule_request_type, schedule_hour, last_scheduled_at, created_at, updated_at
+		`, userID, name, scheduleType, scheduleHour).Scan(&w.ID, &w.UserID, &w.Name, &w.ScheduleRequestType,
+			&w.ScheduleHour, &w.LastScheduledAt, &w.CreatedAt, &w.UpdatedAt)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if err := replaceWatchlistSymbols(tx, w.ID, symbols); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			respondDBError(c, err)
+			return
+		}
+
+		w.Symbols = symbols
+		if w.Symbols == nil {
+			w.Symbols = []string{}
+		}
+		c.JSON(http.StatusCreated, w)
+	}
+}
+
+// UpdateWatchlist — PUT /api/v1/watchlists/:id (JWT, 소유자)
+func UpdateWatchlist(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid watchlist ID"})
+			return
+		}
+
+		var req WatchlistRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		current, err := loadWatchlist(db, id, userID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Watchlist not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+
+		query := "UPDATE watchlists SET updated_at = CURRENT_TIMESTAMP"
+		args := []interface{}{}
+		argIndex := 1
+
+		if req.Name != nil {
+			name := strings.TrimSpace(*req.Name)
+			if name == "" || utf8.RuneCountInString(name) > 100 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "name must be 1-100 characters"})
+				return
+			}
+			query += ", name = $" + strconv.Itoa(argIndex)
+			args = append(args, name)
+			argIndex++
+		}
+		if req.ScheduleRequestType != nil {
+			if *req.ScheduleRequestType == "" {
+				query += ", schedule_request_type = NULL, schedule_hour = NULL, last_scheduled_at = NULL"
+			} else {
+				hour := req.ScheduleHour
+				if hour == nil {
+					hour = current.ScheduleHour
+				}
+				if msg := validateWatchlistSchedule(*req.ScheduleRequestType, hour); msg != "" {
+					c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+					return
+				}
+				query += ", schedule_request_type = $" + strconv.Itoa(argIndex) + ", schedule_hour = $" + strconv.Itoa(argIndex+1)
+				query += ", last_scheduled_at = NOW()"
+				args = append(args, *req.ScheduleRequestType, *hour)
+				argIndex += 2
+			}
+		} else if req.ScheduleHour != nil {
+			if current.ScheduleRequestType == nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "scheduleRequestType is required to set scheduleHour"})
+				return
+			}
+			if msg := validateWatchlistSchedule(*current.ScheduleRequestType, req.ScheduleHour); msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+			query += ", schedule_hour = $" + strconv.Itoa(argIndex) + ", last_scheduled_at = NOW()"
+			args = append(args, *req.ScheduleHour)
+			argIndex++
+		}
+
+		var symbols []string
+		if req.Symbols != nil {
+			var msg string
+			symbols, msg, err = validateWatchlistSymbols(db, *req.Symbols)
+			if err != nil {
+				respondDBError(c, err)
+				return
+			}
+			if msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer tx.Rollback()
+
+		query += " WHERE id = $" + strconv.Itoa(argIndex) + " AND user_id = $" + strconv.Itoa(argIndex+1)
+		args = append(args, id, userID)
+		if _, err := tx.Exec(query, args...); err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if req.Symbols != nil {
+			if err := replaceWatchlistSymbols(tx, id, symbols); err != nil {
+				respondDBError(c, err)
+				return
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			respondDBError(c, err)
+			return
+		}
+
+		w, err := loadWatchlist(db, id, userID)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, w)
+	}
+}
+
+// DeleteWatchlist — DELETE /api/v1/watchlists/:id (JWT, 소유자)
+func DeleteWatchlist(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid watchlist ID"})
+			return
+		}
+
+		result, err := db.Exec("DELETE FROM watchlists WHERE id = $1 AND user_id = $2", id, userID)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Watchlist not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Watchlist deleted"})
+	}
+}
+
+// AnalyzeWatchlist — POST /api/v1/watchlists/:id/analyze (JWT, 소유자, 결제 필수)
+// 관심종목 전체에 대해 분석을 일괄 제출한다. 결과는 GET /analysis/:requestId로 폴링.
+func AnalyzeWatchlist(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid watchlist ID"})
+			return
+		}
+		var req struct {
+			RequestType string `json:"requestType" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !allowedAnalysisRequestTypes[req.RequestType] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported request type"})
+			return
+		}
+
+		w, err := loadWatchlist(db, id, userID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Watchlist not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if len(w.Symbols) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "watchlist has no symbols"})
+			return
+		}
+
+		// 결제 게이트 — CreateAnalysis와 동일 (CWE-862)
+		if !userHasAnalysisEntitlement(db, userID, req.RequestType) {
+			c.JSON(http.StatusPaymentRequired, gin.H{"error": "payment required — paid order for this analysis type needed"})
+			return
+		}
+
+		requests, err := runWatchlistAnalyses(db, w, req.RequestType)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"watchlistId": w.ID, "requests": requests})
+	}
+}
+
+// runWatchlistAnalyses — 관심종목 분석 요청 생성 + 제출 (일괄/예약 공용).
+// 종목 단위 유형은 심볼마다, 전 종목 대상 유형은 1회만 제출한다
+// (심볼 필드는 필수이므로 첫 종목을 사용 — 스크리너는 심볼을 무시한다).
+func runWatchlistAnalyses(db *sql.DB, w Watchlist, requestType string) ([]models.AnalysisRequest, error) {
+	targets := w.Symbols
+	if !symbolScopedAnalysisTypes[requestType] && len(targets) > 1 {
+		targets = targets[:1]
+	}
+
+	requests := []models.AnalysisRequest{}
+	for _, symbol := range targets {
+		var rec models.AnalysisRequest
+		err := db.QueryRow(`
+			INSERT INTO analysis_requests (user_id, request_type, symbol, status, watchlist_id)
+			VALUES ($1, $2, $3, 'queued', $4)
+			RETURNING id, user_id, request_type, symbol, status, COALESCE(result_json, '') AS result_json, COALESCE(internal_request_id, '') AS internal_request_id, COALESCE(error, '') AS error, created_at, updated_at
+		`, w.UserID, requestType, symbol, w.ID).Scan(
+			&rec.ID, &rec.UserID, &rec.RequestType, &rec.Symbol, &rec.Status,
+			&rec.ResultJSON, &rec.InternalRequestID, &rec.Error,
+			&rec.CreatedAt, &rec.UpdatedAt,
+		)
+		if err != nil {
+			return requests, err
+		}
+		submitAnalysisRequest(db, &rec)
+		requests = append(requests, rec)
+	}
+	return requests, nil
+}
+
+// scheduledSlot — 오늘(KST) 예약 시각. now가 그 이전이면 어제 슬롯을 반환한다.
+func scheduledSlot(now time.Time, hour int) time.Time {
+	local := now.In(kst)
+	slot := time.Date(local.Year(), local.Month(), local.Day(), hour, 0, 0, 0, kst)
+	if local.Before(slot) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+	return slot
+}
+
+// runScheduledWatchlistAnalyses — 백그라운드 잡: 예약 시각이 지난 관심종목의 분석을 제출하고,
+// 관심종목 분석 중 아직 끝나지 않은 요청의 상태를 갱신한다 (사용자가 폴링하지 않아도 완료되도록).
+// 다중 인스턴스에서도 중복 실행되지 않도록 last_scheduled_at 조건부 UPDATE로 선점한다.
+// last_scheduled_at은 TIMESTAMPTZ라 KST 슬롯과 시각(instant) 기준으로 비교된다.
+func runScheduledWatchlistAnalyses(db *sql.DB) error {
+	rows, err := db.Query(`
+		SELECT id, user_id, name, schedule_request_type, schedule_hour, last_scheduled_at, created_at, updated_at
+		FROM watchlists
+		WHERE schedule_request_type IS NOT NULL AND schedule_hour IS NOT NULL
+	`)
+	if err != nil {
+		return err
+	}
+	var due []Watchlist
+	now := time.Now()
+	for rows.Next() {
+		var w Watchlist
+		if err := rows.Scan(&w.ID, &w.UserID, &w.Name, &w.ScheduleRequestType, &w.ScheduleHour,
+			&w.LastScheduledAt, &w.CreatedAt, &w.UpdatedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		slot := scheduledSlot(now, *w.ScheduleHour)
+		if w.LastScheduledAt == nil || w.LastScheduledAt.Before(slot) {
+			due = append(due, w)
+		}
+	}
+	rows.Close()
+
+	for _, w := range due {
+		slot := scheduledSlot(now, *w.ScheduleHour)
+		res, err := db.Exec(
+			"UPDATE watchlists SET last_scheduled_at = NOW() WHERE id = $1 AND (last_scheduled_at IS NULL OR last_scheduled_at < $2)",
+			w.ID, slot,
+		)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n != 1 {
+			continue // 다른 인스턴스가 선점
+		}
+		if !userHasAnalysisEntitlement(db, w.UserID, *w.ScheduleRequestType) {
+			log.Printf("[watchlists] scheduled %s skipped (watchlist=%d): no entitlement", *w.ScheduleRequestType, w.ID)
+			continue
+		}
+		w.Symbols, err = loadWatchlistSymbols(db, w.ID)
+		if err != nil {
+			return err
+		}
+		if len(w.Symbols) == 0 {
+			continue
+		}
+		requests, err := runWatchlistAnalyses(db, w, *w.ScheduleRequestType)
+		if err != nil {
+			return err
+		}
+		log.Printf("[watchlists] scheduled %s submitted (watchlist=%d, requests=%d)", *w.ScheduleRequestType, w.ID, len(requests))
+	}
+
+	return refreshWatchlistAnalyses(db)
+}
+
+// refreshWatchlistAnalyses — 최근 24시간 내 관심종목 분석 중 미완료 요청의 상태를 갱신한다.
+func refreshWatchlistAnalyses(db *sql.DB) error {
+	rows, err := db.Query(`
+		SELECT id, user_id, request_type, symbol, status, COALESCE(result_json, '') AS result_json, COALESCE(internal_request_id, '') AS internal_request_id, COALESCE(error, '') AS error, created_at, updated_at
+		FROM analysis_requests
+		WHERE watchlist_id IS NOT NULL
+		  AND status IN ('queued', 'running', 'submitting')
+		  AND created_at > NOW() - INTERVAL '1 day'
+		ORDER BY id
+		LIMIT 100
+	`)
+	if err != nil {
+		return err
+	}
+	var pending []models.AnalysisRequest
+	for rows.Next() {
+		var rec models.AnalysisRequest
+		if err := rows.Scan(&rec.ID, &rec.UserID, &rec.RequestType, &rec.Symbol, &rec.Status,
+			&rec.ResultJSON, &rec.InternalRequestID, &rec.Error,
+			&rec.CreatedAt, &rec.UpdatedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		pending = append(pending, rec)
+	}
+	rows.Close()
+
+	for i := range pending {
+		refreshAnalysisRequest(db, &pending[i])
+	}
+	return nil
+}
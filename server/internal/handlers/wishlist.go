@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ── 위시리스트 ─────────────────────────────────────────────────────────────
+// 사용자가 관심 상품을 저장하고, 담을 때보다 가격이 내려가거나 판매가 재개되면 알림을 받는다.
+// 알림은 wishlist-alerts 잡이 조건부 UPDATE ... RETURNING으로 선점한 행에 대해서만 보낸다
+// (다중 인스턴스에서도 한 번만 발송).
+
+// WishlistItem — 위시리스트 항목 + 현재 상품 요약
+type WishlistItem struct {
+	ProductID        int       `json:"productId"`
+	Name             string    `json:"name"`
+	Image            string    `json:"image"`
+	Category         string    `json:"category"`
+	ProductType      string    `json:"productType"`
+	Price            int       `json:"price"`
+	CryptoPriceUsdc  int64     `json:"cryptoPriceUsdc"`
+	PriceAtAdd       int       `json:"priceAtAdd"`
+	CryptoPriceAtAdd int64     `json:"cryptoPriceAtAdd"`
+	PriceDropped     bool      `json:"priceDropped"`
+	IsActive         bool      `json:"isActive"`
+	AddedAt          time.Time `json:"addedAt"`
+}
+
+// formatKRW — 1234567 → "1,234,567원"
+func formatKRW(n int) string {
+	s := strconv.Itoa(n)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	var b strings.Builder
+	for i, ch := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(ch)
+	}
+	if neg {
+		return "-" + b.String() + "원"
+	}
+	return b.String() + "원"
+}
+
+// formatUSDC — 마이크로 단위 → "12.50 USDC"
+func formatUSDC(micro int64) string {
+	return fmt.Sprintf("%.2f USDC", float64(micro)/1e6)
+}
+
+// wishlistPriceBasis — 가격 인하 판단에 쓸 통화. price_sync 상품은 환율로 산출되는 쪽 가격이
+// 판매자 변경 없이도 움직이므로 판매자가 정한 쪽(krw → 원화, usdc → USDC)만 비교한다.
+func wishlistPriceBasis(priceSync string) (krw, usdc bool) {
+	switch priceSync {
+	case "krw":
+		return true, false
+	case "usdc":
+		return false, true
+	}
+	return true, true
+}
+
+// wishlistPriceDropped — 담을 때보다 (기준 통화) 가격이 내렸는지
+func wishlistPriceDropped(priceSync string, price, priceAtAdd int, cryptoPrice, cryptoAtAdd int64) bool {
+	krw, usdc := wishlistPriceBasis(priceSync)
+	return (krw && price < priceAtAdd) || (usdc && cryptoAtAdd > 0 && cryptoPrice > 0 && cryptoPrice < cryptoAtAdd)
+}
+
+// wishlistPriceDropMessage — 가격 인하 알림 제목/본문. USDC 가격은 담을 때 설정돼 있었고 내려간 경우만 적는다.
+func wishlistPriceDropMessage(name string, oldKRW, newKRW int, oldUSDC, newUSDC int64) (string, string) {
+	title := "위시리스트 상품 가격이 내렸습니다"
+	lines := []string{fmt.Sprintf("'%s'", name)}
+	if newKRW < oldKRW {
+		pct := (oldKRW - newKRW) * 100 / oldKRW
+		lines = append(lines, fmt.Sprintf("%s → %s (%d%% 할인)", formatKRW(oldKRW), formatKRW(newKRW), pct))
+	}
+	if oldUSDC > 0 && newUSDC > 0 && newUSDC < oldUSDC {
+		lines = append(lines, fmt.Sprintf("%s → %s", formatUSDC(oldUSDC), formatUSDC(newUSDC)))
+	}
+	return title, strings.Join(lines, "\n")
+}
+
+// AddToWishlist — POST /api/v1/wishlist (JWT). body: {productId}
+// 공개 중인 상품만 담을 수 있고 현재 가격을 알림 기준가로 기록한다. 이미 담긴 상품은 409.
+func AddToWishlist(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		var req struct {
+			ProductID int `json:"productId" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var price int
+		var cryptoPrice int64
+		err := db.QueryRow(
+			`SELECT price, crypto_price_usdc FROM products WHERE id = $1 AND is_active = true`, req.ProductID,
+		).Scan(&price, &cryptoPrice)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		var addedAt time.Time
+		err = db.QueryRow(`
+			INSERT INTO wishlist_items (user_id, product_id, price_at_add, crypto_price_at_add)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id, product_id) DO NOTHING
+			RETURNING created_at
+		`, userID, req.ProductID, price, cryptoPrice).Scan(&addedAt)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusConflict, gin.H{"error": "Product is already in your wishlist"})
+			return
+		}
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{
+			"productId": req.ProductID, "priceAtAdd": price, "cryptoPriceAtAdd": cryptoPrice, "addedAt": addedAt,
+		})
+	}
+}
+
+// GetWishlist — GET /api/v1/wishlist (JWT). 최근에 담은 순.
+// 삭제된 상품은 빠지고, 판매 중지 상품은 isActive=false로 남는다.
+func GetWishlist(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		rows, err := db.Query(`
+			SELECT p.id, p.name, COALESCE(p.image, ''), COALESCE(p.category, ''), p.product_type,
+			       p.price, p.crypto_price_usdc, w.price_at_add, w.crypto_price_at_add, p.is_active, w.created_at,
+			       p.price_sync
+			FROM wishlist_items w
+			JOIN products p ON p.id = w.product_id
+			WHERE w.user_id = $1 AND p.deleted_at IS NULL
+			ORDER BY w.created_at DESC, w.id DESC
+		`, userID)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		defer rows.Close()
+		items := []WishlistItem{}
+		for rows.Next() {
+			var it WishlistItem
+			var priceSync string
+			if err := rows.Scan(&it.ProductID, &it.Name, &it.Image, &it.Category, &it.ProductType,
+				&it.Price, &it.CryptoPriceUsdc, &it.PriceAtAdd, &it.CryptoPriceAtAdd, &it.IsActive, &it.AddedAt,
+				&priceSync); err != nil {
+				respondDBError(c, err)
+				return
+			}
+			it.PriceDropped = wishlistPriceDropped(priceSync, it.Price, it.PriceAtAdd, it.CryptoPriceUsdc, it.CryptoPriceAtAdd)
+			items = append(items, it)
+		}
+		c.JSON(http.StatusOK, items)
+	}
+}
+
+// RemoveFromWishlist — DELETE /api/v1/wishlist/:productId (JWT)
+func RemoveFromWishlist(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		productID, err := strconv.Atoi(c.Param("productId"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+		res, err := db.Exec(`DELETE FROM wishlist_items WHERE user_id = $1 AND product_id = $2`, userID, productID)
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product is not in your wishlist"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Removed from wishlist"})
+	}
+}
+
+// runWishlistAlerts — 백그라운드 잡: 위시리스트 가격 인하/재판매 알림.
+//  1. 판매 중지(archived/draft)된 항목은 was_active=false로 표시하고, 다시 공개된 항목을 선점해 재판매 알림.
+//     수정 후 심사 대기(pending_review)로 잠시 비공개가 되는 것은 판매 중지로 보지 않는다.
+//  2. 기준가(담을 때 가격) 이상으로 돌아온 항목은 notified_*를 초기화 (다음 할인 때 다시 알림)
+//  3. 기준가와 마지막 알림가보다 싸진 공개 상품을 선점해 가격 인하 알림
+func runWishlistAlerts(db *sql.DB) error {
+	if _, err := db.Exec(`
+		UPDATE wishlist_items w SET was_active = FALSE
+		FROM products p
+		WHERE p.id = w.product_id AND w.was_active AND p.status IN ('archived', 'draft')
+	`); err != nil {
+		return err
+	}
+	rows, err := db.Query(`
+		UPDATE wishlist_items w SET was_active = TRUE
+		FROM products p
+		WHERE p.id = w.product_id AND NOT w.was_active AND p.is_active
+		RETURNING w.user_id, p.id, p.name
+	`)
+	if err != nil {
+		return err
+	}
+	type restocked struct {
+		userID, productID int
+		name              string
+	}
+	var restocks []restocked
+	for rows.Next() {
+		var r restocked
+		if err := rows.Scan(&r.userID, &r.productID, &r.name); err != nil {
+			rows.Close()
+			return err
+		}
+		restocks = append(restocks, r)
+	}
+	rows.Close()
+	for _, r := range restocks {
+		notifyUser(db, r.userID, notificationWishlistRestocked, "위시리스트 상품 판매가 재개되었습니다",
+			fmt.Sprintf("'%s' 상품을 다시 구매할 수 있습니다.", r.name), fmt.Sprintf("/product/%d", r.productID))
+	}
+
+	if _, err := db.Exec(`
+		UPDATE wishlist_items w
+		SET notified_price = CASE WHEN p.price >= w.price_at_add THEN NULL ELSE w.notified_price END,
+		    notified_crypto_price = CASE WHEN p.crypto_price_usdc >= w.crypto_price_at_add
+		                                 THEN NULL ELSE w.notified_crypto_price END
+		FROM products p
+		WHERE p.id = w.product_id
+		  AND ((w.notified_price IS NOT NULL AND p.price >= w.price_at_add)
+		    OR (w.notified_crypto_price IS NOT NULL AND p.crypto_price_usdc >= w.crypto_price_at_add))
+	`); err != nil {
+		return err
+	}
+
+	// 환율 연동 상품은 판매자가 정한 쪽 가격만 비교한다 (wishlistPriceBasis) — 환율 변동만으로 알림이 가지 않도록
+	rows, err = db.Query(`
+		UPDATE wishlist_items w
+		SET notified_price = CASE WHEN p.price_sync <> 'usdc'
+		                          THEN LEAST(p.price, COALESCE(w.notified_price, w.price_at_add))
+		                          ELSE w.notified_price END,
+		    notified_crypto_price = CASE WHEN p.price_sync <> 'krw' AND w.crypto_price_at_add > 0 AND p.crypto_price_usdc > 0
+		                                 THEN LEAST(p.crypto_price_usdc, COALESCE(w.notified_crypto_price, w.crypto_price_at_add))
+		                                 ELSE w.notified_crypto_price END
+		FROM products p
+		WHERE p.id = w.product_id AND p.is_active
+		  AND ((p.price_sync <> 'usdc' AND p.price < COALESCE(w.notified_price, w.price_at_add))
+		    OR (p.price_sync <> 'krw' AND w.crypto_price_at_add > 0 AND p.crypto_price_usdc > 0
+		        AND p.crypto_price_usdc < COALESCE(w.notified_crypto_price, w.crypto_price_at_add)))
+		RETURNING w.user_id, p.id, p.name, w.price_at_add, p.price, w.crypto_price_at_add, p.crypto_price_usdc, p.price_sync
+	`)
+	if err != nil {
+		return err
+	}
+	type drop struct {
+		userID, productID, oldKRW, newKRW int
+		name                              string
+		oldUSDC, newUSDC                  int64
+	}
+	var drops []drop
+	for rows.Next() {
+		var d drop
+		var priceSync string
+		if err := rows.Scan(&d.userID, &d.productID, &d.name, &d.oldKRW, &d.newKRW, &d.oldUSDC, &d.newUSDC, &priceSync); err != nil {
+			rows.Close()
+			return err
+		}
+		// 산출된 쪽 가격 변화는 본문에 적지 않는다
+		if krw, usdc := wishlistPriceBasis(priceSync); !krw {
+			d.newKRW = d.oldKRW
+		} else if !usdc {
+			d.oldUSDC = 0
+		}
+		drops = append(drops, d)
+	}
+	rows.Close()
+	for _, d := range drops {
+		title, body := wishlistPriceDropMessage(d.name, d.oldKRW, d.newKRW, d.oldUSDC, d.newUSDC)
+		notifyUser(db, d.userID, notificationWishlistPriceDrop, title, body, fmt.Sprintf("/product/%d", d.productID))
+	}
+	if len(restocks) > 0 || len(drops) > 0 {
+		log.Printf("[wishlist] sent %d restock and %d price-drop notifications", len(restocks), len(drops))
+	}
+	return nil
+}
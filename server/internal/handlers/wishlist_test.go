@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatPrices(t *testing.T) {
+	cases := map[int]string{0: "0원", 900: "900원", 1000: "1,000원", 1234567: "1,234,567원", -5000: "-5,000원"}
+	for n, want := range cases {
+		if got := formatKRW(n); got != want {
+			t.Errorf("formatKRW(%d) = %q, want %q", n, got, want)
+		}
+	}
+	if got := formatUSDC(12500000); got != "12.50 USDC" {
+		t.Errorf("formatUSDC = %q", got)
+	}
+}
+
+func TestWishlistPriceDropMessage(t *testing.T) {
+	_, body := wishlistPriceDropMessage("퀀트 리포트", 10000, 7500, 5000000, 5000000)
+	if !strings.Contains(body, "10,000원 → 7,500원 (25% 할인)") || strings.Contains(body, "USDC") {
+		t.Errorf("KRW drop body = %q", body)
+	}
+	_, body = wishlistPriceDropMessage("퀀트 리포트", 10000, 10000, 5000000, 4000000)
+	if strings.Contains(body, "원 →") || !strings.Contains(body, "5.00 USDC → 4.00 USDC") {
+		t.Errorf("USDC drop body = %q", body)
+	}
+	// 담을 때 USDC 가격이 없었으면 USDC 변화는 적지 않는다
+	_, body = wishlistPriceDropMessage("퀀트 리포트", 10000, 9000, 0, 4000000)
+	if strings.Contains(body, "USDC") {
+		t.Errorf("unexpected USDC line: %q", body)
+	}
+}
+
+func TestWishlistPriceDroppedIgnoresFXSide(t *testing.T) {
+	cases := []struct {
+		sync                string
+		price, priceAtAdd   int
+		crypto, cryptoAtAdd int64
+		want                bool
+	}{
+		{"manual", 9000, 10000, 5000000, 5000000, true},
+		{"manual", 10000, 10000, 4000000, 5000000, true},
+		// 원화 기준 상품: 환율 변동으로 USDC만 내린 경우는 인하가 아니다
+		{"krw", 10000, 10000, 4000000, 5000000, false},
+		{"krw", 9000, 10000, 5500000, 5000000, true},
+		// USDC 기준 상품: 원화 환산가만 내린 경우는 인하가 아니다
+		{"usdc", 9000, 10000, 5000000, 5000000, false},
+		{"usdc", 11000, 10000, 4000000, 5000000, true},
+	}
+	for _, c := range cases {
+		if got := wishlistPriceDropped(c.sync, c.price, c.priceAtAdd, c.crypto, c.cryptoAtAdd); got != c.want {
+			t.Errorf("%+v: got %v", c, got)
+		}
+	}
+}
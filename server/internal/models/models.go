@@ -4,36 +4,61 @@ import "time"
 
 // User represents a registered user (seller)
 type User struct {
-	ID        int       `json:"id" db:"id"`
-	Email     string    `json:"email" db:"email"`
-	Password  string    `json:"-" db:"password"` // Never expose password in JSON
-	Name      string    `json:"name" db:"name"`
-	Role      string    `json:"role" db:"role"` // "seller", "admin"
-	Avatar    *string   `json:"avatar,omitempty" db:"avatar"`
-	Bio       *string   `json:"bio,omitempty" db:"bio"`
-	CreatedAt time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	ID              int        `json:"id" db:"id"`
+	Email           string     `json:"email" db:"email"`
+	Password        string     `json:"-" db:"password"` // Never expose password in JSON
+	Name            string     `json:"name" db:"name"`
+	Role            string     `json:"role" db:"role"` // "seller", "admin"
+	Avatar          *string    `json:"avatar,omitempty" db:"avatar"`
+	Bio             *string    `json:"bio,omitempty" db:"bio"`
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt,omitempty" db:"email_verified_at"` // 로그인 / GET /user 응답에만 채워짐
+	CreatedAt       time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updatedAt" db:"updated_at"`
 }
 
 // Product represents a trading product
 type Product struct {
-	ID            int       `json:"id" db:"id"`
-	SellerID      int       `json:"sellerId" db:"seller_id"`
-	Name          string    `json:"name" db:"name"`
-	Price         int       `json:"price" db:"price"`
-	OriginalPrice *int      `json:"originalPrice,omitempty" db:"original_price"`
-	Image         string    `json:"image" db:"image"`
-	Category      string    `json:"category" db:"category"`
-	ProductType   string    `json:"productType" db:"product_type"` // "program", "diary"
-	Version       *string   `json:"version,omitempty" db:"version"`
-	DownloadURL   *string   `json:"downloadUrl,omitempty" db:"download_url"`
-	FileSize      *string   `json:"fileSize,omitempty" db:"file_size"`
-	LicenseKey    *string   `json:"licenseKey,omitempty" db:"license_key"`
-	Description   string    `json:"description" db:"description"`
-	Features      *string   `json:"features,omitempty" db:"features"` // JSON array of features
-	SystemReq     *string   `json:"systemRequirements,omitempty" db:"system_requirements"`
-	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt     time.Time `json:"updatedAt" db:"updated_at"`
+	ID            int                 `json:"id" db:"id"`
+	SellerID      int                 `json:"sellerId" db:"seller_id"`
+	Name          string              `json:"name" db:"name"`
+	Price         int                 `json:"price" db:"price"`
+	OriginalPrice *int                `json:"originalPrice,omitempty" db:"original_price"`
+	Image         string              `json:"image" db:"image"`
+	Category      string              `json:"category" db:"category"`
+	CategoryID    *int                `json:"categoryId,omitempty" db:"category_id"`
+	ProductType   string              `json:"productType" db:"product_type"` // "program", "diary"
+	Version       *string             `json:"version,omitempty" db:"version"`
+	DownloadURL   *string             `json:"downloadUrl,omitempty" db:"download_url"`
+	ArtifactID    *int                `json:"artifactId,omitempty" db:"artifact_id"`
+	FileSize      *string             `json:"fileSize,omitempty" db:"file_size"`
+	LicenseKey    *string             `json:"licenseKey,omitempty" db:"license_key"`
+	Description   string              `json:"description" db:"description"`
+	Features      ProductFeatures     `json:"features,omitempty" db:"features"`
+	SystemReq     *SystemRequirements `json:"systemRequirements,omitempty" db:"system_requirements"`
+	PriceSync     string              `json:"priceSync,omitempty" db:"price_sync"` // manual | krw | usdc
+	CreatedAt     time.Time           `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time           `json:"updatedAt" db:"updated_at"`
+
+	// 카탈로그 목록/검색에서만 채워지는 필드 (정렬·표시용)
+	CryptoPriceUsdc int64   `json:"cryptoPriceUsdc,omitempty" db:"crypto_price_usdc"`
+	RatingAvg       float64 `json:"ratingAvg,omitempty" db:"rating_avg"`
+	RatingCount     int     `json:"ratingCount,omitempty" db:"rating_count"`
+	SalesCount      int     `json:"salesCount,omitempty"`
+	Relevance       float64 `json:"relevance,omitempty"` // 전문 검색 관련도
+	Highlight       string  `json:"highlight,omitempty"` // 설명 스니펫 (HTML, <mark>만 포함)
+
+	// 라이프사이클 (draft | pending_review | published | archived). 공개 목록은 published만.
+	Status     string     `json:"status,omitempty" db:"status"`
+	PublishAt  *time.Time `json:"publishAt,omitempty" db:"publish_at"`   // 예약 공개 시각 (draft)
+	ReviewNote *string    `json:"reviewNote,omitempty" db:"review_note"` // 최근 심사 결정 사유 (판매자/관리자 응답)
+
+	// 상세 조회에서만 채워지는 태그 slug 목록
+	Tags []string `json:"tags,omitempty"`
+
+	// 장바구니 수량 규칙 (상세 조회/생성/수정 응답에서만 채워짐).
+	// isSinglePurchase면 1개만, 아니면 maxQuantity까지 (nil은 제한 없음). 이미 구매한 상품은 다시 담을 수 없다.
+	IsSinglePurchase *bool `json:"isSinglePurchase,omitempty" db:"is_single_purchase"`
+	MaxQuantity      *int  `json:"maxQuantity,omitempty" db:"max_quantity"`
 }
 
 // CartItem represents an item in the shopping cart
@@ -46,6 +71,12 @@ type CartItem struct {
 	UserID    *int      `json:"userId,omitempty" db:"user_id"` // If logged in, use user_id
 	CreatedAt time.Time `json:"createdAt" db:"created_at"`
 	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+
+	// GetCart 검증 결과: 판매 중지/삭제된 상품이면 Unavailable, 담을 때보다 가격이 바뀌었으면 PriceChanged
+	PriceAtAdd       *int   `json:"priceAtAdd,omitempty" db:"price_at_add"`
+	CryptoPriceAtAdd *int64 `json:"cryptoPriceAtAdd,omitempty" db:"crypto_price_at_add"`
+	Unavailable      bool   `json:"unavailable,omitempty"`
+	PriceChanged     bool   `json:"priceChanged,omitempty"`
 }
 
 // ===== Request Models =====
@@ -65,36 +96,74 @@ type LoginRequest struct {
 
 // CreateProductRequest is the request body for creating a product
 type CreateProductRequest struct {
-	Name          string  `json:"name" binding:"required"`
-	Price         int     `json:"price" binding:"required,gte=0,lte=100000000"`
-	OriginalPrice *int    `json:"originalPrice,omitempty"`
-	Image         string  `json:"image"`
-	Category      string  `json:"category"`
-	ProductType   string  `json:"productType" binding:"required"` // "program" or "diary"
-	Version       *string `json:"version,omitempty"`
-	DownloadURL   *string `json:"downloadUrl,omitempty"`
-	FileSize      *string `json:"fileSize,omitempty"`
-	LicenseKey    *string `json:"licenseKey,omitempty"`
-	Description   string  `json:"description"`
-	Features      *string `json:"features,omitempty"`
-	SystemReq     *string `json:"systemRequirements,omitempty"`
+	Name          string              `json:"name" binding:"required"`
+	Price         int                 `json:"price" binding:"required,gte=0,lte=100000000"`
+	OriginalPrice *int                `json:"originalPrice,omitempty"`
+	Image         string              `json:"image"`
+	Category      string              `json:"category"`
+	ProductType   string              `json:"productType" binding:"required"` // "program" or "diary"
+	Version       *string             `json:"version,omitempty"`
+	DownloadURL   *string             `json:"downloadUrl,omitempty"`
+	FileSize      *string             `json:"fileSize,omitempty"`
+	LicenseKey    *string             `json:"licenseKey,omitempty"`
+	Description   string              `json:"description"`
+	Features      *ProductFeatures    `json:"features,omitempty"`
+	SystemReq     *SystemRequirements `json:"systemRequirements,omitempty"`
+
+	// 업로드된 아티팩트 참조 (POST /artifacts 또는 /uploads). 지정하면 fileSize는
+	// 아티팩트 크기로, image는 이미지 URL로 자동 설정된다. 수정 시 0은 연결 해제.
+	ArtifactID      *int `json:"artifactId,omitempty"`
+	ImageArtifactID *int `json:"imageArtifactId,omitempty"`
+
+	// USDC 결제가 (마이크로 단위)와 환율 자동 환산.
+	// priceSync: manual(기본) | krw (price 기준으로 cryptoPriceUsdc 산출) | usdc (cryptoPriceUsdc 기준으로 price 산출)
+	CryptoPriceUsdc *int64  `json:"cryptoPriceUsdc,omitempty" binding:"omitempty,gte=0,lte=1000000000000"`
+	PriceSync       *string `json:"priceSync,omitempty"`
+
+	// 라이프사이클: status draft | pending_review | published | archived.
+	// published + 미래 publishAt이면 draft로 저장되고 예약 시각에 공개된다.
+	Status    *string    `json:"status,omitempty"`
+	PublishAt *time.Time `json:"publishAt,omitempty"`
+
+	// 장바구니 수량 규칙: isSinglePurchase(기본 true)면 1개만, 아니면 maxQuantity까지 (0은 제한 없음)
+	IsSinglePurchase *bool `json:"isSinglePurchase,omitempty"`
+	MaxQuantity      *int  `json:"maxQuantity,omitempty" binding:"omitempty,gte=0,lte=10000"`
 }
 
 // UpdateProductRequest is the request body for updating a product
 type UpdateProductRequest struct {
-	Name          *string `json:"name,omitempty"`
-	Price         *int    `json:"price,omitempty" binding:"omitempty,gte=0,lte=100000000"`
-	OriginalPrice *int    `json:"originalPrice,omitempty"`
-	Image         *string `json:"image,omitempty"`
-	Category      *string `json:"category,omitempty"`
-	ProductType   *string `json:"productType,omitempty"`
-	Version       *string `json:"version,omitempty"`
-	DownloadURL   *string `json:"downloadUrl,omitempty"`
-	FileSize      *string `json:"fileSize,omitempty"`
-	LicenseKey    *string `json:"licenseKey,omitempty"`
-	Description   *string `json:"description,omitempty"`
-	Features      *string `json:"features,omitempty"`
-	SystemReq     *string `json:"systemRequirements,omitempty"`
+	Name          *string             `json:"name,omitempty"`
+	Price         *int                `json:"price,omitempty" binding:"omitempty,gte=0,lte=100000000"`
+	OriginalPrice *int                `json:"originalPrice,omitempty"`
+	Image         *string             `json:"image,omitempty"`
+	Category      *string             `json:"category,omitempty"`
+	ProductType   *string             `json:"productType,omitempty"`
+	Version       *string             `json:"version,omitempty"`
+	DownloadURL   *string             `json:"downloadUrl,omitempty"`
+	FileSize      *string             `json:"fileSize,omitempty"`
+	LicenseKey    *string             `json:"licenseKey,omitempty"`
+	Description   *string             `json:"description,omitempty"`
+	Features      *ProductFeatures    `json:"features,omitempty"`
+	SystemReq     *SystemRequirements `json:"systemRequirements,omitempty"`
+
+	// 업로드된 아티팩트 참조 (POST /artifacts 또는 /uploads). 지정하면 fileSize는
+	// 아티팩트 크기로, image는 이미지 URL로 자동 설정된다. 수정 시 0은 연결 해제.
+	ArtifactID      *int `json:"artifactId,omitempty"`
+	ImageArtifactID *int `json:"imageArtifactId,omitempty"`
+
+	// USDC 결제가 (마이크로 단위)와 환율 자동 환산.
+	// priceSync: manual(기본) | krw (price 기준으로 cryptoPriceUsdc 산출) | usdc (cryptoPriceUsdc 기준으로 price 산출)
+	CryptoPriceUsdc *int64  `json:"cryptoPriceUsdc,omitempty" binding:"omitempty,gte=0,lte=1000000000000"`
+	PriceSync       *string `json:"priceSync,omitempty"`
+
+	// 라이프사이클: status draft | pending_review | published | archived.
+	// published + 미래 publishAt이면 draft로 저장되고 예약 시각에 공개된다.
+	Status    *string    `json:"status,omitempty"`
+	PublishAt *time.Time `json:"publishAt,omitempty"`
+
+	// 장바구니 수량 규칙: isSinglePurchase(기본 true)면 1개만, 아니면 maxQuantity까지 (0은 제한 없음)
+	IsSinglePurchase *bool `json:"isSinglePurchase,omitempty"`
+	MaxQuantity      *int  `json:"maxQuantity,omitempty" binding:"omitempty,gte=0,lte=10000"`
 }
 
 // AddToCartRequest is the request body for adding to cart
@@ -104,10 +173,18 @@ type AddToCartRequest struct {
 	SessionID string `json:"sessionId,omitempty"`
 }
 
+// AuthTokens — 로그인/토큰 갱신 응답의 토큰 부분.
+// token은 단기 액세스 토큰(JWT), refreshToken은 POST /auth/refresh로 교체하는 세션 토큰 (expiresIn은 token 수명, 초).
+type AuthTokens struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	ExpiresIn    int    `json:"expiresIn,omitempty"`
+}
+
 // AuthResponse is the response for successful authentication
 type AuthResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	AuthTokens
+	User User `json:"user"`
 }
 
 // Lecture represents an educational lecture
@@ -256,7 +333,7 @@ type VerifyRequest struct {
 }
 
 type WalletAuthResponse struct {
-	Token         string `json:"token"`
+	AuthTokens
 	WalletAddress string `json:"walletAddress"`
 	User          User   `json:"user"`
 }
@@ -266,12 +343,23 @@ type CreatePaymentRequest struct {
 	// PayerMode "operator": MetaMask 없는 사용자 — 운영자 지갑이 결제 대행 (dev 전용).
 	// create 시 payer=운영자로 등록되며, dev-pay로 운영자 키가 approve+pay를 실행한다.
 	PayerMode string `json:"payerMode"`
+	// CouponCode — 관리자 발급 쿠폰 (대소문자 무시). 할인 후 금액이 amount_usdc로 기록된다.
+	CouponCode string `json:"couponCode"`
 }
 
 type PaymentResponse struct {
 	Payment
 	ContractAddress string `json:"contractAddress,omitempty"`
 	TokenAddress    string `json:"tokenAddress,omitempty"`
+
+	// 쿠폰 적용 시에만
+	CouponCode         string `json:"couponCode,omitempty"`
+	OriginalAmountUsdc int64  `json:"originalAmountUsdc,omitempty"`
+	DiscountUsdc       int64  `json:"discountUsdc,omitempty"`
+
+	// 결제 생성 시점 환율과 KRW 환산액 (환율을 구하지 못했으면 생략)
+	KRWPerUSDC float64 `json:"krwPerUsdc,omitempty"`
+	AmountKRW  int64   `json:"amountKrw,omitempty"`
 }
 
 type CreateAnalysisRequest struct {
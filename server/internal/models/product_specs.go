@@ -0,0 +1,217 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ProductFeature — 주요 기능 1개
+type ProductFeature struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+// ProductFeatures — products.features (JSONB 배열).
+// 요청에서는 하위 호환을 위해 문자열도 받는다: JSON 배열 문자열이거나 한 줄에 기능 하나인 텍스트.
+// 배열 원소도 문자열이면 title로 취급한다.
+type ProductFeatures []ProductFeature
+
+// SystemRequirements — products.system_requirements (JSONB 객체).
+// OS/CPU/RAM/증권사 API 호환 매트릭스. 허용 값 검증은 handlers/product_specs.go.
+type SystemRequirements struct {
+	OS          []string `json:"os,omitempty"`      // windows | macos | linux | android | ios | web
+	CPUArch     []string `json:"cpuArch,omitempty"` // x86 | x64 | arm64
+	MinCPUCores int      `json:"minCpuCores,omitempty"`
+	MinRAMMB    int      `json:"minRamMb,omitempty"`
+	MinDiskMB   int      `json:"minDiskMb,omitempty"`
+	Brokers     []string `json:"brokers,omitempty"` // 지원 증권사 API (예: kiwoom-openapi)
+	Notes       string   `json:"notes,omitempty"`
+}
+
+// ParseProductFeatures — 문자열 입력 (JSON 배열 또는 줄 단위 텍스트) 파싱
+func ParseProductFeatures(s string) (ProductFeatures, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ProductFeatures{}, nil
+	}
+	if strings.HasPrefix(s, "[") {
+		var f ProductFeatures
+		if err := f.UnmarshalJSON([]byte(s)); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+	f := ProductFeatures{}
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "-*•"))
+		if line != "" {
+			f = append(f, ProductFeature{Title: line})
+		}
+	}
+	return f, nil
+}
+
+func (f *ProductFeatures) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		parsed, err := ParseProductFeatures(s)
+		if err != nil {
+			return err
+		}
+		*f = parsed
+		return nil
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf("features must be an array: %w", err)
+	}
+	out := make(ProductFeatures, 0, len(items))
+	for i, raw := range items {
+		var title string
+		if err := json.Unmarshal(raw, &title); err == nil {
+			out = append(out, ProductFeature{Title: title})
+			continue
+		}
+		var item ProductFeature
+		if err := json.Unmarshal(raw, &item); err != nil {
+			return fmt.Errorf("features[%d] must be a string or {title, description}", i)
+		}
+		out = append(out, item)
+	}
+	*f = out
+	return nil
+}
+
+// Scan — JSONB 컬럼 읽기 (NULL은 빈 목록).
+// 스키마에 맞지 않는 예전 값도 오류 대신 원소 원문을 title로 읽는다 (조회 전체가 500이 되지 않도록).
+func (f *ProductFeatures) Scan(src interface{}) error {
+	*f = nil
+	b, err := jsonbBytes(src)
+	if err != nil || b == nil {
+		return err
+	}
+	if err := json.Unmarshal(b, (*[]ProductFeature)(f)); err == nil {
+		return nil
+	}
+	*f = lenientFeatures(b)
+	return nil
+}
+
+// lenientFeatures — 문자열/{title} 원소는 그대로, 나머지는 JSON 원문을 title로
+func lenientFeatures(b []byte) ProductFeatures {
+	var items []json.RawMessage
+	if err := json.Unmarshal(b, &items); err != nil {
+		return ProductFeatures{{Title: jsonText(b)}}
+	}
+	out := ProductFeatures{}
+	for _, raw := range items {
+		var item ProductFeature
+		if err := json.Unmarshal(raw, &item); err == nil && item.Title != "" {
+			out = append(out, item)
+		} else if t := jsonText(raw); t != "" && t != "null" {
+			out = append(out, ProductFeature{Title: t})
+		}
+	}
+	return out
+}
+
+// jsonText — JSON 문자열이면 그 값, 아니면 원문
+func jsonText(raw []byte) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return strings.TrimSpace(string(raw))
+}
+
+// Value — JSONB 컬럼 쓰기 (빈 목록은 NULL)
+func (f ProductFeatures) Value() (driver.Value, error) {
+	if len(f) == 0 {
+		return nil, nil
+	}
+	return json.Marshal([]ProductFeature(f))
+}
+
+// ParseSystemRequirements — 문자열 입력 파싱 (JSON 객체가 아니면 notes로 보존)
+func ParseSystemRequirements(s string) (*SystemRequirements, error) {
+	s = strings.TrimSpace(s)
+	r := &SystemRequirements{}
+	if strings.HasPrefix(s, "{") {
+		type plain SystemRequirements
+		if err := json.Unmarshal([]byte(s), (*plain)(r)); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+	r.Notes = s
+	return r, nil
+}
+
+func (r *SystemRequirements) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		parsed, err := ParseSystemRequirements(s)
+		if err != nil {
+			return err
+		}
+		*r = *parsed
+		return nil
+	}
+	type plain SystemRequirements
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return fmt.Errorf("systemRequirements must be an object: %w", err)
+	}
+	return nil
+}
+
+// IsZero — 아무 항목도 없으면 true (NULL로 저장)
+func (r *SystemRequirements) IsZero() bool {
+	return r == nil || (len(r.OS) == 0 && len(r.CPUArch) == 0 && r.MinCPUCores == 0 && r.MinRAMMB == 0 &&
+		r.MinDiskMB == 0 && len(r.Brokers) == 0 && r.Notes == "")
+}
+
+// Scan — JSONB 컬럼 읽기. 스키마에 맞지 않는 예전 값은 오류 대신 원문을 notes로 읽는다.
+func (r *SystemRequirements) Scan(src interface{}) error {
+	*r = SystemRequirements{}
+	b, err := jsonbBytes(src)
+	if err != nil || b == nil {
+		return err
+	}
+	type plain SystemRequirements
+	if err := json.Unmarshal(b, (*plain)(r)); err != nil {
+		*r = SystemRequirements{Notes: jsonText(b)}
+	}
+	return nil
+}
+
+// Value — JSONB 컬럼 쓰기 (빈 값은 NULL)
+func (r *SystemRequirements) Value() (driver.Value, error) {
+	if r.IsZero() {
+		return nil, nil
+	}
+	type plain SystemRequirements
+	return json.Marshal((*plain)(r))
+}
+
+func jsonbBytes(src interface{}) ([]byte, error) {
+	switch v := src.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+	return nil, fmt.Errorf("unsupported JSONB source type %T", src)
+}
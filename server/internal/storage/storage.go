@@ -0,0 +1,80 @@
+// Package storage provides object storage for product artifacts and images.
+// Two backends are available: a local-disk store (development, single node)
+// and an S3-compatible store (AWS S3, MinIO) signed with AWS Signature V4.
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when an object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// ErrInvalidKey is returned for keys that are empty or try to escape the
+// store (absolute paths, "..", backslashes).
+var ErrInvalidKey = errors.New("invalid object key")
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+}
+
+// Storage is the object store used for artifacts.
+type Storage interface {
+	// Put stores r under key. size may be -1 when unknown.
+	Put(key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object for reading. The caller closes the reader.
+	Get(key string) (io.ReadCloser, ObjectInfo, error)
+	Delete(key string) error
+	// PresignGet returns a URL that downloads the object directly for ttl,
+	// or "" when the backend cannot serve objects itself (local disk).
+	PresignGet(key string, ttl time.Duration, downloadName string) (string, error)
+}
+
+// FromEnv builds the configured backend.
+//
+//	STORAGE_BACKEND=local (default)  STORAGE_LOCAL_DIR (default ./data/storage)
+//	STORAGE_BACKEND=s3               S3_ENDPOINT, S3_BUCKET, S3_REGION (default us-east-1),
+//	                                 S3_ACCESS_KEY, S3_SECRET_KEY, S3_PATH_STYLE (default true)
+func FromEnv() (Storage, error) {
+	switch strings.ToLower(os.Getenv("STORAGE_BACKEND")) {
+	case "", "local":
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+		if dir == "" {
+			dir = "./data/storage"
+		}
+		return NewLocal(dir)
+	case "s3":
+		region := os.Getenv("S3_REGION")
+		if region == "" {
+			region = "us-east-1"
+		}
+		return NewS3(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Region:    region,
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") != "false",
+		})
+	}
+	return nil, errors.New("STORAGE_BACKEND must be local or s3")
+}
+
+// validKey reports whether key is a safe relative object key.
+func validKey(key string) bool {
+	if key == "" || len(key) > 512 || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -1,73 +1,186 @@
 package utils
 
 import (
-	"crypto/sha256"
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
 	"math"
+	"net/http"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 	"unicode"
 )
 
-// GenerateEmbedding creates a 1536-dimensional vector from text using hash-based approach
-func GenerateEmbedding(text string) []float32 {
-	// Normalize text: lowercase and remove special characters
-	normalized := strings.ToLower(text)
-	normalized = strings.Map(func(r rune) rune {
-		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) {
-			return r
+// defaultEmbeddingDim matches common hosted embedding models (e.g. 1536-dim)
+// and the products.embedding column width.
+const defaultEmbeddingDim = 1536
+
+// EmbeddingDimension returns the configured vector width (EMBEDDING_DIM,
+// default 1536). The products.embedding column is created with this width,
+// so changing it requires re-creating the column.
+func EmbeddingDimension() int {
+	if v := os.Getenv("EMBEDDING_DIM"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 2000 {
+			return n
 		}
-		return ' '
-	}, normalized)
+	}
+	return defaultEmbeddingDim
+}
 
-	// Split into words
-	words := strings.Fields(normalized)
+// Embedder turns text into a fixed-width vector.
+type Embedder interface {
+	Embed(text string) ([]float32, error)
+	// Model identifies the embedding space; vectors from different models
+	// must not be compared, so it is stored next to each embedding.
+	Model() string
+	Dimension() int
+}
 
-	// Create word frequency map
-	wordFreq := make(map[string]int)
-	for _, word := range words {
-		if len(word) > 2 { // Ignore very short words
-			wordFreq[word]++
+// NewEmbedderFromEnv selects the embedder from EMBEDDING_PROVIDER:
+// "http" uses an OpenAI-compatible /embeddings endpoint (EMBEDDING_API_URL,
+// EMBEDDING_API_KEY, EMBEDDING_MODEL); anything else uses the deterministic
+// local embedder.
+func NewEmbedderFromEnv() Embedder {
+	dim := EmbeddingDimension()
+	if strings.ToLower(os.Getenv("EMBEDDING_PROVIDER")) == "http" && os.Getenv("EMBEDDING_API_URL") != "" {
+		model := os.Getenv("EMBEDDING_MODEL")
+		if model == "" {
+			model = "text-embedding-3-small"
+		}
+		return &HTTPEmbedder{
+			URL:    os.Getenv("EMBEDDING_API_URL"),
+			APIKey: os.Getenv("EMBEDDING_API_KEY"),
+			Name:   model,
+			Dim:    dim,
+			Client: &http.Client{Timeout: 15 * time.Second},
 		}
 	}
+	return LocalEmbedder{Dim: dim}
+}
 
-	// Generate 1536-dimensional vector
-	dimension := 1536
-	embedding := make([]float32, dimension)
+// LocalEmbedder is a deterministic feature-hashing embedder. Each word and
+// each character bigram (so Korean compounds like "자동매매" and "자동 매매"
+// share features) is hashed to a signed dimension, then the vector is L2
+// normalized. It needs no external service, which makes it suitable for
+// tests and offline development; similarity is lexical, not semantic.
+type LocalEmbedder struct {
+	Dim int
+}
 
-	// Use hash-based approach to fill the vector
-	hash := sha256.Sum256([]byte(text))
+func (e LocalEmbedder) Model() string  { return fmt.Sprintf("local-hash-%d", e.Dim) }
+func (e LocalEmbedder) Dimension() int { return e.Dim }
 
-	// Fill vector using hash and word frequencies
-	for i := 0; i < dimension; i++ {
-		// Use different parts of the hash for different dimensions
-		hashIndex := i % len(hash)
-		hashValue := float32(hash[hashIndex]) / 255.0 // Normalize to 0-1
+func (e LocalEmbedder) Embed(text string) ([]float32, error) {
+	vec := make([]float32, e.Dim)
+	for _, word := range tokenize(text) {
+		addFeature(vec, "w:"+word, 1.0)
+		runes := []rune(word)
+		for i := 0; i+1 < len(runes); i++ {
+			addFeature(vec, "b:"+string(runes[i:i+2]), 0.5)
+		}
+	}
+	return normalize(vec), nil
+}
 
-		// Add word frequency influence
-		wordIndex := i % len(words)
-		if wordIndex < len(words) && len(words[wordIndex]) > 2 {
-			wordHash := sha256.Sum256([]byte(words[wordIndex]))
-			wordValue := float32(wordHash[hashIndex]) / 255.0
-			hashValue = (hashValue + wordValue) / 2.0
+func tokenize(text string) []string {
+	normalized := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
 		}
+		return ' '
+	}, text)
+	return strings.Fields(normalized)
+}
 
-		// Normalize to -1 to 1 range
-		embedding[i] = (hashValue - 0.5) * 2.0
+func addFeature(vec []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+	idx := int(sum % uint64(len(vec)))
+	if sum>>63 == 1 {
+		weight = -weight
 	}
+	vec[idx] += weight
+}
 
-	// L2 normalization
+func normalize(vec []float32) []float32 {
 	var sum float64
-	for _, val := range embedding {
-		sum += float64(val * val)
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	if norm := float32(math.Sqrt(sum)); norm > 0 {
+		for i := range vec {
+			vec[i] /= norm
+		}
 	}
-	norm := float32(math.Sqrt(sum))
-	if norm > 0 {
-		for i := range embedding {
-			embedding[i] /= norm
+	return vec
+}
+
+// HTTPEmbedder calls an OpenAI-compatible embeddings endpoint
+// (POST {"model", "input"} → {"data": [{"embedding": [...]}]}).
+type HTTPEmbedder struct {
+	URL    string
+	APIKey string
+	Name   string
+	Dim    int
+	Client *http.Client
+}
+
+func (e *HTTPEmbedder) Model() string  { return e.Name }
+func (e *HTTPEmbedder) Dimension() int { return e.Dim }
+
+func (e *HTTPEmbedder) Embed(text string) ([]float32, error) {
+	body, _ := json.Marshal(map[string]interface{}{"model": e.Name, "input": text})
+	req, err := http.NewRequest(http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.APIKey)
+	}
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding provider unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if resp.StatusCode != http.StatusOK {
+		snippet := string(respBody)
+		if len(snippet) > 200 {
+			snippet = snippet[:200]
 		}
+		return nil, fmt.Errorf("embedding provider returned %d: %s", resp.StatusCode, snippet)
 	}
 
-	return embedding
+	var out struct {
+		Data []struct {
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &out); err != nil {
+		return nil, fmt.Errorf("invalid embedding response: %w", err)
+	}
+	if len(out.Data) == 0 {
+		return nil, errors.New("embedding response has no data")
+	}
+	if len(out.Data[0].Embedding) != e.Dim {
+		return nil, fmt.Errorf("embedding dimension %d does not match configured %d", len(out.Data[0].Embedding), e.Dim)
+	}
+	return out.Data[0].Embedding, nil
+}
+
+// GenerateEmbedding creates a deterministic embedding of the default width
+// using LocalEmbedder.
+func GenerateEmbedding(text string) []float32 {
+	vec, _ := LocalEmbedder{Dim: defaultEmbeddingDim}.Embed(text)
+	return vec
 }
 
 // BuildEmbeddingText creates a text string from product information for embedding
@@ -89,14 +202,14 @@ func BuildEmbeddingText(name, category, description string, brand, color, materi
 
 // EmbeddingToString converts embedding vector to PostgreSQL vector format
 func EmbeddingToString(embedding []float32) string {
-	embeddingStr := "["
+	var sb strings.Builder
+	sb.WriteString("[")
 	for i, val := range embedding {
 		if i > 0 {
-			embeddingStr += ","
+			sb.WriteString(",")
 		}
-		embeddingStr += fmt.Sprintf("%.6f", val)
+		sb.WriteString(strconv.FormatFloat(float64(val), 'f', 6, 32))
 	}
-	embeddingStr += "]"
-	return embeddingStr
+	sb.WriteString("]")
+	return sb.String()
 }
-
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func cosine(a, b []float32) float64 {
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
+
+func TestLocalEmbedder(t *testing.T) {
+	e := LocalEmbedder{Dim: 256}
+
+	a, _ := e.Embed("자동매매 프로그램 KOSPI 스윙")
+	again, _ := e.Embed("자동매매 프로그램 KOSPI 스윙")
+	for i := range a {
+		if a[i] != again[i] {
+			t.Fatal("embedding is not deterministic")
+		}
+	}
+
+	var norm float64
+	for _, v := range a {
+		norm += float64(v) * float64(v)
+	}
+	if math.Abs(norm-1) > 1e-4 {
+		t.Errorf("embedding not L2-normalized: |v|² = %f", norm)
+	}
+
+	// 띄어쓰기만 다른 한국어 질의가 무관한 텍스트보다 가까워야 한다
+	near, _ := e.Embed("자동 매매 프로그램")
+	far, _ := e.Embed("trading diary notebook")
+	if cosine(a, near) <= cosine(a, far) {
+		t.Errorf("expected related text closer: near=%f far=%f", cosine(a, near), cosine(a, far))
+	}
+}
+
+func TestEmbeddingToString(t *testing.T) {
+	got := EmbeddingToString([]float32{0.5, -0.25, 0})
+	if got != "[0.500000,-0.250000,0.000000]" {
+		t.Errorf("EmbeddingToString = %q", got)
+	}
+	if !strings.HasPrefix(EmbeddingToString(GenerateEmbedding("x")), "[") {
+		t.Error("GenerateEmbedding output not formatted as vector")
+	}
+}
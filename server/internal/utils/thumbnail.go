@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+
+	// 업로드 이미지 디코더 등록
+	_ "image/gif"
+	_ "image/png"
+)
+
+// MaxThumbnailPixels is the upper bound on width*height accepted for
+// decoding, so a small compressed file cannot expand into gigabytes of pixels
+// (a decoded 24MP image is still ~96MB as RGBA).
+const MaxThumbnailPixels = 24_000_000
+
+// ErrImageTooLarge is returned when an image exceeds MaxThumbnailPixels.
+var ErrImageTooLarge = errors.New("image dimensions too large")
+
+// decodeImage checks the header with image.DecodeConfig before decoding so
+// oversized images are rejected without allocating their pixels.
+func decodeImage(data []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxThumbnailPixels {
+		return nil, ErrImageTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// Thumbnail downscales img so neither side exceeds maxDim, averaging every
+// source pixel that falls into a destination pixel (box filter). Images that
+// already fit are copied unchanged. Transparent areas are flattened onto white
+// because thumbnails are encoded as JPEG. Source rows are composited one at a
+// time, so memory stays proportional to the source width, not its area.
+func Thumbnail(img image.Image, maxDim int) *image.RGBA {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dw, dh := sw, sh
+	if sw > maxDim || sh > maxDim {
+		if sw >= sh {
+			dw, dh = maxDim, max(1, sh*maxDim/sw)
+		} else {
+			dw, dh = max(1, sw*maxDim/sh), maxDim
+		}
+	}
+
+	// 목적지 열 x가 평균내는 원본 열 범위 [xs[x], xs[x+1]) — 축소가 없으면 1:1
+	xs := make([]int, dw+1)
+	for x := 0; x < dw; x++ {
+		xs[x] = x * sw / dw
+	}
+	xs[dw] = sw
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	row := image.NewRGBA(image.Rect(0, 0, sw, 1))
+	sums := make([]uint32, dw*3)
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*sh/dh, max((y+1)*sh/dh, y*sh/dh+1)
+		clear(sums)
+		for sy := y0; sy < y1; sy++ {
+			draw.Draw(row, row.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+			draw.Draw(row, row.Bounds(), img, image.Point{X: b.Min.X, Y: b.Min.Y + sy}, draw.Over)
+			for x := 0; x < dw; x++ {
+				for sx := xs[x]; sx < max(xs[x+1], xs[x]+1); sx++ {
+					p := row.Pix[sx*4:]
+					sums[x*3] += uint32(p[0])
+					sums[x*3+1] += uint32(p[1])
+					sums[x*3+2] += uint32(p[2])
+				}
+			}
+		}
+		for x := 0; x < dw; x++ {
+			n := uint32((y1 - y0) * max(xs[x+1]-xs[x], 1))
+			o := dst.PixOffset(x, y)
+			dst.Pix[o] = uint8(sums[x*3] / n)
+			dst.Pix[o+1] = uint8(sums[x*3+1] / n)
+			dst.Pix[o+2] = uint8(sums[x*3+2] / n)
+			dst.Pix[o+3] = 0xff
+		}
+	}
+	return dst
+}
+
+// EncodeThumbnailJPEG decodes data (up to MaxThumbnailPixels) and returns a JPEG
+// thumbnail bounded by maxDim.
+func EncodeThumbnailJPEG(data []byte, maxDim int) ([]byte, error) {
+	img, err := decodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, Thumbnail(img, maxDim), &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestEncodeThumbnailJPEG(t *testing.T) {
+	// 왼쪽 절반 빨강, 오른쪽 절반 투명 (→ 흰색으로 평탄화)
+	src := image.NewNRGBA(image.Rect(0, 0, 800, 400))
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 400; x++ {
+			src.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	var in bytes.Buffer
+	if err := png.Encode(&in, src); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := EncodeThumbnailJPEG(in.Bytes(), 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumb, err := jpeg.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := thumb.Bounds(); b.Dx() != 200 || b.Dy() != 100 {
+		t.Fatalf("thumbnail size = %v, want 200x100", b.Size())
+	}
+	if r, g, _, _ := thumb.At(20, 50).RGBA(); r>>8 < 200 || g>>8 > 60 {
+		t.Errorf("left pixel not red: r=%d g=%d", r>>8, g>>8)
+	}
+	if r, g, b, _ := thumb.At(180, 50).RGBA(); r>>8 < 230 || g>>8 < 230 || b>>8 < 230 {
+		t.Errorf("transparent area not flattened to white: %d %d %d", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestEncodeThumbnailJPEGRejectsHugeDimensions(t *testing.T) {
+	// 헤더만 거대한 PNG (25MP, 압축 폭탄 방지 — 디코드 전에 거부)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 5000, 5000))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EncodeThumbnailJPEG(buf.Bytes(), 200); err != ErrImageTooLarge {
+		t.Errorf("err = %v, want ErrImageTooLarge", err)
+	}
+	if _, err := EncodeThumbnailJPEG([]byte("not an image"), 200); err == nil {
+		t.Error("garbage decoded without error")
+	}
+}
+
+func TestThumbnailAveragesOddSizes(t *testing.T) {
+	// 3x1 원본 → 2x1: 경계 열이 누락/중복 없이 평균되어야 한다
+	src := image.NewRGBA(image.Rect(10, 10, 13, 11))
+	src.Set(10, 10, color.RGBA{R: 255, A: 255})
+	src.Set(11, 10, color.RGBA{G: 255, A: 255})
+	src.Set(12, 10, color.RGBA{B: 255, A: 255})
+	got := Thumbnail(src, 2)
+	if b := got.Bounds(); b.Dx() != 2 || b.Dy() != 1 {
+		t.Fatalf("size = %v", b.Size())
+	}
+	if c := got.RGBAAt(0, 0); c.R != 255 || c.G != 0 || c.B != 0 {
+		t.Errorf("pixel 0 = %v", c)
+	}
+	if c := got.RGBAAt(1, 0); c.R != 0 || c.G != 127 || c.B != 127 {
+		t.Errorf("pixel 1 = %v", c)
+	}
+	if same := Thumbnail(src, 10); same.RGBAAt(1, 0) != (color.RGBA{G: 255, A: 255}) {
+		t.Errorf("unscaled copy = %v", same.RGBAAt(1, 0))
+	}
+}
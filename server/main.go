@@ -19,6 +19,11 @@ func main() {
 		log.Println("No .env file found, using environment variables")
 	}
 
+	// 서명 키 설정 오류는 기동 시 바로 실패시킨다
+	if err := handlers.LoadSigningKeys(); err != nil {
+		log.Fatalf("Failed to load signing keys: %v", err)
+	}
+
 	// Initialize database
 	db, err := database.InitDB()
 	if err != nil {
@@ -31,17 +36,26 @@ func main() {
 		log.Fatalf("Failed to create tables: %v", err)
 	}
 
+	// 백그라운드 잡 (관심종목 예약 분석 등)
+	handlers.StartBackgroundJobs(db)
+
 	// Setup router
 	r := gin.Default()
 
 	// CORS configuration
 	config := cors.DefaultConfig()
 	config.AllowOrigins = splitEnv(os.Getenv("CORS_ORIGINS"), []string{"http://localhost:3000", "http://localhost:5173", "http://127.0.0.1:5173"})
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization",
+		"Tus-Resumable", "Upload-Length", "Upload-Offset", "Upload-Metadata", "X-License-Key", "X-Guest-Token"}
+	config.ExposeHeaders = []string{"Location", "Tus-Resumable", "Upload-Offset", "Upload-Length",
+		"Upload-Artifact-Id", "X-Downloads-Remaining", "X-Guest-Token"}
 	config.AllowCredentials = true
 	r.Use(cors.New(config))
 
+	// JWT 검증 공개키 (다른 서비스용)
+	r.GET("/.well-known/jwks.json", handlers.JWKS())
+
 	// API routes
 	api := r.Group("/api/v1")
 	{
@@ -50,6 +64,11 @@ func main() {
 		{
 			auth.POST("/register", handlers.Register(db))
 			auth.POST("/login", handlers.Login(db))
+			auth.POST("/refresh", handlers.RefreshSession(db))
+			auth.POST("/logout", handlers.Logout(db))
+			auth.POST("/email/verify", handlers.VerifyEmail(db))
+			auth.POST("/password/forgot", handlers.ForgotPassword(db))
+			auth.POST("/password/reset", handlers.ResetPassword(db))
 			// 지갑 인증 (M3 — ZK Smart Wallet 흐름의 Web2 진입점)
 			auth.POST("/nonce", handlers.WalletNonce(db))
 			auth.POST("/verify", handlers.WalletVerify(db))
@@ -58,7 +77,26 @@ func main() {
 		// Products routes (public)
 		api.GET("/products", handlers.GetProducts(db))
 		api.GET("/products/search", handlers.SearchProducts(db))
-		api.GET("/products/:id", handlers.OptionalAuthMiddleware(), handlers.GetProduct(db))
+		api.GET("/products/compatibility", handlers.GetCompatibilityOptions(db))
+		api.GET("/categories", handlers.GetCategories(db))
+		api.GET("/tags", handlers.GetTags(db))
+		api.GET("/products/:id", handlers.OptionalAuthMiddleware(db), handlers.GetProduct(db))
+		api.GET("/products/:id/similar", handlers.GetSimilarProducts(db))
+		api.GET("/products/:id/releases", handlers.OptionalAuthMiddleware(db), handlers.GetProductReleases(db))
+		api.GET("/products/:id/reviews", handlers.GetProductReviews(db))
+		api.GET("/exchange-rate", handlers.GetExchangeRate(db))
+		api.GET("/products/:id/latest", handlers.OptionalAuthMiddleware(db), handlers.CheckProductUpdate(db))
+		api.GET("/downloads/:token", handlers.RedeemDownloadToken(db))
+		api.GET("/images/:id", handlers.ServeImage(db))
+
+		// License activation (desktop programs, authenticated by license key)
+		api.GET("/licenses/public-key", handlers.GetLicensePublicKey())
+		api.POST("/licenses/activate", handlers.ActivateLicense(db))
+		api.POST("/licenses/deactivate", handlers.DeactivateLicense(db))
+		api.POST("/licenses/validate", handlers.ValidateLicense(db))
+
+		// KRX 종목 자동완성 (public)
+		api.GET("/instruments", handlers.SearchInstruments(db))
 
 		// Lecture routes (public GET, protected CRUD)
 		api.GET("/lectures", handlers.GetLectures(db))
@@ -69,25 +107,50 @@ func main() {
 		api.GET("/notices/:id", handlers.GetNotice(db))
 
 		// Cart routes (optional auth - uses session if not logged in)
-		api.GET("/cart", handlers.OptionalAuthMiddleware(), handlers.GetCart(db))
-		api.POST("/cart", handlers.OptionalAuthMiddleware(), handlers.AddToCart(db))
-		api.PUT("/cart/:id", handlers.OptionalAuthMiddleware(), handlers.UpdateCartItem(db))
-		api.DELETE("/cart/:id", handlers.OptionalAuthMiddleware(), handlers.RemoveFromCart(db))
-		api.POST("/cart/merge", handlers.OptionalAuthMiddleware(), handlers.MergeCart(db))
+		api.GET("/cart", handlers.OptionalAuthMiddleware(db), handlers.GetCart(db))
+		api.POST("/cart", handlers.OptionalAuthMiddleware(db), handlers.AddToCart(db))
+		api.PUT("/cart/:id", handlers.OptionalAuthMiddleware(db), handlers.UpdateCartItem(db))
+		api.DELETE("/cart/:id", handlers.OptionalAuthMiddleware(db), handlers.RemoveFromCart(db))
+		api.POST("/cart/merge", handlers.OptionalAuthMiddleware(db), handlers.MergeCart(db))
 
 		// Protected routes (require authentication)
 		protected := api.Group("")
-		protected.Use(handlers.AuthMiddleware())
+		protected.Use(handlers.AuthMiddleware(db))
 		{
 			// User profile
 			protected.GET("/user", handlers.GetCurrentUser(db))
 			protected.PUT("/user", handlers.UpdateUser(db))
+			protected.GET("/me/sessions", handlers.GetMySessions(db))
+			protected.DELETE("/me/sessions/:id", handlers.DeleteMySession(db))
+			protected.POST("/me/email/verification", handlers.ResendVerificationEmail(db))
 
 			// Seller products (CRUD)
 			protected.POST("/products", handlers.CreateProduct(db))
 			protected.PUT("/products/:id", handlers.UpdateProduct(db))
 			protected.DELETE("/products/:id", handlers.DeleteProduct(db))
 			protected.GET("/my-products", handlers.GetMyProducts(db))
+			protected.POST("/products/:id/releases", handlers.CreateProductRelease(db))
+			protected.PUT("/products/:id/releases/:releaseId", handlers.UpdateProductRelease(db))
+			protected.DELETE("/products/:id/releases/:releaseId", handlers.DeleteProductRelease(db))
+
+			// Reviews (verified buyers; seller reply; admin moderation)
+			protected.POST("/products/:id/reviews", handlers.CreateProductReview(db))
+			protected.PUT("/reviews/:id", handlers.UpdateProductReview(db))
+			protected.DELETE("/reviews/:id", handlers.DeleteProductReview(db))
+			protected.PUT("/reviews/:id/reply", handlers.ReplyToProductReview(db))
+			protected.GET("/admin/reviews", handlers.GetReviewsForModeration(db))
+			protected.PUT("/admin/reviews/:id", handlers.ModerateProductReview(db))
+
+			// 판매자 업로드 (멀티파트 + tus 재개 가능 업로드)
+			protected.POST("/artifacts", handlers.UploadArtifact(db))
+			protected.GET("/artifacts", handlers.GetMyArtifacts(db))
+			protected.DELETE("/artifacts/:id", handlers.DeleteArtifact(db))
+			protected.POST("/uploads", handlers.CreateUpload(db))
+			protected.HEAD("/uploads/:id", handlers.GetUpload(db))
+			protected.GET("/uploads/:id", handlers.GetUpload(db))
+			protected.PATCH("/uploads/:id", handlers.PatchUpload(db))
+			protected.DELETE("/uploads/:id", handlers.CancelUpload(db))
+			protected.GET("/recommendations", handlers.GetRecommendations(db))
 
 			// Diary (protected)
 			protected.GET("/diaries", handlers.GetDiaries(db))
@@ -112,6 +175,9 @@ func main() {
 			// Admin: Set user as admin (for testing)
 			protected.POST("/admin/set-admin", handlers.SetUserAsAdmin(db))
 
+			// Admin: 액세스 토큰 폐기 (jti / 토큰 / 세션 단위)
+			protected.POST("/admin/tokens/revoke", handlers.RevokeAccessToken(db))
+
 			// ── 결제 플랫폼 (M3 — 지갑/USDC 결제 + 분석) ──
 			protected.POST("/wallet/connect", handlers.WalletConnect(db))
 			// M2-1: World ID 인간 증명 (설계: M2-zk-auth-design.md §3.1)
@@ -121,6 +187,49 @@ func main() {
 			protected.POST("/wallet/zkpassport", handlers.ZKPassportVerify(db))
 			// 구매 내역 (paid 결제 + 분석 결과) — My Products
 			protected.GET("/my-purchases", handlers.MyPurchases(db))
+			protected.GET("/purchases/:referenceId/download", handlers.DownloadPurchase(db))
+			protected.GET("/me/licenses", handlers.GetMyLicenses(db))
+			protected.DELETE("/me/licenses/:id/activations/:activationId", handlers.DeleteMyLicenseActivation(db))
+			protected.POST("/admin/payments/:referenceId/refund", handlers.RefundPayment(db))
+
+			// Coupons (admin-managed; applied via couponCode on /payments/create)
+			protected.POST("/coupons/preview", handlers.PreviewCoupon(db))
+			protected.GET("/admin/coupons", handlers.GetCoupons(db))
+			protected.POST("/admin/coupons", handlers.CreateCoupon(db))
+			protected.PUT("/admin/coupons/:id", handlers.UpdateCoupon(db))
+
+			// Exchange rates (KRW per USDC; manual override)
+			protected.GET("/admin/exchange-rates", handlers.GetExchangeRateHistory(db))
+			protected.PUT("/admin/exchange-rate/override", handlers.SetExchangeRateOverride(db))
+			protected.DELETE("/admin/exchange-rate/override", handlers.ClearExchangeRateOverride(db))
+
+			// Product moderation queue (admin) + seller-visible history and notifications
+			protected.GET("/admin/moderation/products", handlers.GetModerationQueue(db))
+			protected.POST("/admin/moderation/products/:id/approve", handlers.DecideProductModeration(db, "approved"))
+			protected.POST("/admin/moderation/products/:id/request-changes", handlers.DecideProductModeration(db, "changes_requested"))
+			protected.POST("/admin/moderation/products/:id/reject", handlers.DecideProductModeration(db, "rejected"))
+			protected.GET("/products/:id/moderation", handlers.GetProductModerationHistory(db))
+			protected.GET("/admin/moderation/releases", handlers.GetReleaseModerationQueue(db))
+			protected.POST("/admin/moderation/releases/:releaseId/approve", handlers.DecideReleaseModeration(db, "approved"))
+			protected.POST("/admin/moderation/releases/:releaseId/reject", handlers.DecideReleaseModeration(db, "rejected"))
+			protected.GET("/me/notifications", handlers.GetMyNotifications(db))
+			protected.PUT("/me/notifications/read", handlers.MarkNotificationsRead(db))
+			// Catalog export/import (admin; SKU upsert, dry-run by default, large files as jobs)
+			protected.GET("/admin/products/export", handlers.ExportProducts(db))
+			protected.POST("/admin/products/import", handlers.ImportProducts(db))
+			protected.GET("/admin/products/import/:jobId", handlers.GetProductImportJob(db))
+			// Categories (hierarchical) and tags (admin CRUD; sellers assign existing tags)
+			protected.POST("/admin/categories", handlers.CreateCategory(db))
+			protected.PUT("/admin/categories/:id", handlers.UpdateCategory(db))
+			protected.DELETE("/admin/categories/:id", handlers.DeleteCategory(db))
+			protected.POST("/admin/tags", handlers.CreateTag(db))
+			protected.PUT("/admin/tags/:id", handlers.UpdateTag(db))
+			protected.DELETE("/admin/tags/:id", handlers.DeleteTag(db))
+			protected.PUT("/products/:id/tags", handlers.SetProductTags(db))
+			// Wishlist (price-drop / restock notifications via the wishlist-alerts job)
+			protected.POST("/wishlist", handlers.AddToWishlist(db))
+			protected.GET("/wishlist", handlers.GetWishlist(db))
+			protected.DELETE("/wishlist/:productId", handlers.RemoveFromWishlist(db))
 			// 운영자 대행 결제 (MetaMask 없는 주소 연결 사용자 — dev 전용)
 			protected.POST("/payments/:referenceId/dev-pay", handlers.DevPayPayment(db))
 			protected.POST("/payments/create", handlers.CreatePayment(db))
@@ -133,6 +242,22 @@ func main() {
 			protected.POST("/analysis", handlers.CreateAnalysis(db))
 			protected.GET("/analysis/:requestId", handlers.GetAnalysis(db))
 			protected.GET("/analysis/:requestId/download", handlers.DownloadAnalysisResult(db))
+			// 관심종목 (CRUD + 일괄 분석, 예약 분석은 백그라운드 잡)
+			protected.GET("/watchlists", handlers.GetWatchlists(db))
+			protected.POST("/watchlists", handlers.CreateWatchlist(db))
+			protected.GET("/watchlists/:id", handlers.GetWatchlist(db))
+			protected.PUT("/watchlists/:id", handlers.UpdateWatchlist(db))
+			protected.DELETE("/watchlists/:id", handlers.DeleteWatchlist(db))
+			protected.POST("/watchlists/:id/analyze", handlers.AnalyzeWatchlist(db))
+			// 분석 결과 알림 규칙 / 알림 이력
+			protected.GET("/alert-rules", handlers.GetAlertRules(db))
+			protected.POST("/alert-rules", handlers.CreateAlertRule(db))
+			protected.PUT("/alert-rules/:id", handlers.UpdateAlertRule(db))
+			protected.DELETE("/alert-rules/:id", handlers.DeleteAlertRule(db))
+			protected.GET("/alerts", handlers.GetAlerts(db))
+			protected.POST("/alerts/:id/read", handlers.MarkAlertRead(db))
+			// Admin: KRX 종목 마스터 CSV 임포트
+			protected.POST("/admin/instruments/import", handlers.ImportInstruments(db))
 			// 커뮤니티 (2026-08-21) — 글/댓글 작성·삭제 (삭제: 작성자 OR 관리자)
 			protected.POST("/community/posts", handlers.CreateCommunityPost(db))
 			protected.DELETE("/community/posts/:id", handlers.DeleteCommunityPost(db))
@@ -142,6 +267,7 @@ func main() {
 
 		// 커뮤니티 조회 (공개 — 로그인 없이 읽기 가능)
 		api.GET("/community/posts", handlers.GetCommunityPosts(db))
+		api.GET("/community/search", handlers.SearchCommunityPosts(db))
 		api.GET("/community/posts/:id", handlers.GetCommunityPost(db))
 
 		// AI 에이전트 상품 목록 (public)
@@ -153,7 +279,7 @@ func main() {
 		openclawBaseURL := os.Getenv("OPENCLAW_BASE_URL")
 		openclaw := openclawHandler.NewHandler(db, openclawBaseURL)
 		openclawGroup := api.Group("/openclaw")
-		openclawGroup.Use(handlers.AuthMiddleware())
+		openclawGroup.Use(handlers.AuthMiddleware(db))
 		{
 			openclawGroup.GET("/health", openclaw.HealthCheck)
 			openclawGroup.POST("/click", openclaw.ClickElement)
@@ -9,16 +9,16 @@ INSERT INTO products
 VALUES
   (179, 212, 'AI 주식 분석 리포트 (analyist_dd)', 500, 0, '', 'AI 분석', 'software',
    'stock_report', '1.0.0', '', 0, '',
-   'KRX 종목 분석 리포트 — ML 예측/감성/시세 합성 (USDC 결제)', '', '', 5000000),
+   'KRX 종목 분석 리포트 — ML 예측/감성/시세 합성 (USDC 결제)', NULL, NULL, 5000000),
   (180, 212, '스윙종목 스크리너 (analyist_dd)', 90, 0, '', 'AI 분석', 'software',
    'swing_screener', '1.0.0', '', 0, '',
-   '전 종목 스윙 스크리닝 리포트 — ML 예측/신뢰도 (USDC 결제)', '', '', 900000),
+   '전 종목 스윙 스크리닝 리포트 — ML 예측/신뢰도 (USDC 결제)', NULL, NULL, 900000),
   -- 181 (모델 백테스트 리포트) — 2026-08-15 사용자 요청으로 상품 삭제
   (182, 212, '강환국 투자팩터 5종 리포트', 90, 0, '', 'AI 분석', 'software',
    'factor_report', '1.0.0', '', 0, '',
-   '강환국『하면 된다! 퀀트투자』팩터 전략 5종 결과 (USDC 결제)', '', '', 900000),
+   '강환국『하면 된다! 퀀트투자』팩터 전략 5종 결과 (USDC 결제)', NULL, NULL, 900000),
   -- 190: 월 $5 올액세스 구독 (M6 — 전 서비스 무제한, billing_interval_days=30)
   (190, 212, '퀀트 트레이더 올액세스 구독', 500, 0, '', 'AI 분석', 'software',
    'subscription_bundle', '1.0.0', '', 0, '',
-   '월 5 USDC — 스크리너/팩터/리포트 전 서비스 무제한 (자동 갱신)', '', '', 5000000)
+   '월 5 USDC — 스크리너/팩터/리포트 전 서비스 무제한 (자동 갱신)', NULL, NULL, 5000000)
 ON CONFLICT (id) DO NOTHING;
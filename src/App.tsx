@@ -16,6 +16,8 @@ import LecturePage from './pages/LecturePage';
 import NoticePage from './pages/NoticePage';
 import { CommunityPage } from './pages/CommunityPage';
 import ProductPage from './pages/ProductPage';
+import ResetPasswordPage from './pages/ResetPasswordPage';
+import VerifyEmailPage from './pages/VerifyEmailPage';
 import { Button } from './components/ui/button';
 import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from './components/ui/select';
 import { Grid, List } from 'lucide-react';
@@ -466,6 +468,8 @@ export default function App() {
         <Routes>
           <Route path="/" element={<HomePage />} />
           <Route path="/auth" element={<AuthPage />} />
+          <Route path="/reset-password" element={<ResetPasswordPage />} />
+          <Route path="/verify-email" element={<VerifyEmailPage />} />
           <Route path="/seller" element={<SellerDashboard />} />
           <Route path="/diary" element={<DiaryPage />} />
           <Route path="/admin" element={<AdminPage />} />
@@ -1,5 +1,5 @@
 import React, { createContext, useContext, useState, useEffect, ReactNode } from 'react';
-import { User, login as apiLogin, register as apiRegister, logout as apiLogout, getCurrentUser, setCurrentUser as saveCurrentUser, removeCurrentUser, getToken, setToken, removeToken, mergeCart } from '../lib/api';
+import { User, login as apiLogin, register as apiRegister, logout as apiLogout, getCurrentUser, setCurrentUser as saveCurrentUser, removeCurrentUser, getToken, setToken, removeToken, mergeCart, refreshSession, tokenExpiresAt } from '../lib/api';
 
 interface AuthContextType {
   user: User | null;
@@ -16,15 +16,55 @@ export function AuthProvider({ children }: { children: ReactNode }) {
   const [isLoading, setIsLoading] = useState(true);
 
   useEffect(() => {
-    // Check for existing session
+    // Check for existing session — 만료된 액세스 토큰은 리프레시 토큰으로 갱신을 시도한다
     const token = getToken();
     const storedUser = getCurrentUser();
     if (token && storedUser) {
+      const expiresAt = tokenExpiresAt(token);
+      if (expiresAt !== null && expiresAt <= Date.now()) {
+        refreshSession()
+          .then((data) => {
+            if (data) {
+              setUser(data.user);
+            } else {
+              removeToken();
+              removeCurrentUser();
+            }
+          })
+          .finally(() => setIsLoading(false));
+        return;
+      }
       setUser(storedUser);
     }
     setIsLoading(false);
   }, []);
 
+  // 액세스 토큰 만료 1분 전에 갱신 (실패하면 로그아웃 상태로)
+  useEffect(() => {
+    if (!user) return;
+    const token = getToken();
+    const expiresAt = token ? tokenExpiresAt(token) : null;
+    if (expiresAt === null) return;
+    const timer = setTimeout(async () => {
+      // 다른 탭이 먼저 갱신했으면 저장된 새 토큰 기준으로 다시 예약한다
+      const latest = getToken();
+      const latestUser = getCurrentUser();
+      if (latest && latest !== token && latestUser) {
+        setUser(latestUser);
+        return;
+      }
+      const data = await refreshSession().catch(() => null);
+      if (data) {
+        setUser(data.user);
+      } else {
+        removeToken();
+        removeCurrentUser();
+        setUser(null);
+      }
+    }, Math.max(expiresAt - Date.now() - 60_000, 0));
+    return () => clearTimeout(timer);
+  }, [user]);
+
   const login = async (email: string, password: string) => {
     const response = await apiLogin(email, password);
     setToken(response.token);
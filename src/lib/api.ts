@@ -9,12 +9,15 @@ export interface User {
   role: string;
   avatar?: string;
   bio?: string;
+  emailVerifiedAt?: string; // 로그인 / GET /user 응답에만 포함
   createdAt: string;
   updatedAt: string;
 }
 
 export interface AuthResponse {
-  token: string;
+  token: string; // 단기 액세스 토큰
+  refreshToken?: string; // 브라우저는 HttpOnly cmall_refresh 쿠키를 쓰므로 저장하지 않는다
+  expiresIn?: number;
   user: User;
 }
 
@@ -32,12 +35,30 @@ export interface Product {
   fileSize?: string;
   licenseKey?: string;
   description: string;
-  features?: string;
-  systemRequirements?: string;
+  features?: ProductFeature[];
+  systemRequirements?: SystemRequirements;
+  isSinglePurchase?: boolean; // true면 장바구니에 1개만
+  maxQuantity?: number; // 여러 개 구매 가능 상품의 수량 상한 (없으면 제한 없음)
   createdAt: string;
   updatedAt: string;
 }
 
+export interface ProductFeature {
+  title: string;
+  description?: string;
+}
+
+// OS/CPU/RAM/증권사 API 호환 매트릭스 (허용 값: GET /products/compatibility)
+export interface SystemRequirements {
+  os?: string[];
+  cpuArch?: string[];
+  minCpuCores?: number;
+  minRamMb?: number;
+  minDiskMb?: number;
+  brokers?: string[];
+  notes?: string;
+}
+
 export interface CartItem {
   id: number;
   productId: number;
@@ -47,16 +68,18 @@ export interface CartItem {
   userId?: number;
   createdAt: string;
   updatedAt: string;
+  priceAtAdd?: number;
+  cryptoPriceAtAdd?: number;
+  unavailable?: boolean; // 판매 중지/삭제된 상품
+  priceChanged?: boolean; // 담을 때보다 가격이 바뀜
 }
 
-// Session ID management
-export function getSessionId(): string {
-  let sessionId = localStorage.getItem('sessionId');
-  if (!sessionId) {
-    sessionId = `session_${Date.now()}_${Math.random().toString(36).substr(2, 9)}`;
-    localStorage.setItem('sessionId', sessionId);
-  }
-  return sessionId;
+// Guest cart session
+// 비로그인 장바구니는 서버가 발급하는 HttpOnly cmall_guest 쿠키(서명 토큰)로 식별한다.
+// 예전 클라이언트가 만든 sessionId는 서버가 토큰으로 이전할 수 있도록 남아 있을 때만 함께 보낸다.
+function legacySessionQuery(): string {
+  const sessionId = localStorage.getItem('sessionId');
+  return sessionId ? `?sessionId=${encodeURIComponent(sessionId)}` : '';
 }
 
 // Token management
@@ -85,12 +108,138 @@ export function removeCurrentUser(): void {
   localStorage.removeItem('user');
 }
 
-// Logout function
+// Logout function — 서버 세션(리프레시 토큰)도 폐기한다
 export function logout(): void {
+  fetch(`${API_BASE_URL}/auth/logout`, { method: 'POST', credentials: 'include' }).catch(() => {});
   removeToken();
   removeCurrentUser();
 }
 
+// 액세스 토큰은 단기(기본 15분)라 만료 전에 리프레시 토큰(HttpOnly 쿠키)으로 갱신한다.
+export function tokenExpiresAt(token: string): number | null {
+  try {
+    const payload = JSON.parse(atob(token.split('.')[1].replace(/-/g, '+').replace(/_/g, '/')));
+    return typeof payload.exp === 'number' ? payload.exp * 1000 : null;
+  } catch {
+    return null;
+  }
+}
+
+// 리프레시 토큰은 1회용(rotation)이라 탭마다 따로 갱신하면 서버가 재사용으로 보고 세션을 폐기한다.
+// 탭 사이에서는 localStorage 잠금으로 한 탭만 /auth/refresh를 호출하고, 나머지 탭은 그 탭이
+// localStorage에 저장한 새 액세스 토큰을 그대로 쓴다. 같은 탭 안의 동시 호출은 하나의 Promise를 공유한다.
+const REFRESH_LOCK_KEY = 'refreshLock';
+const REFRESH_LOCK_TTL_MS = 10_000;
+let refreshInFlight: Promise<AuthResponse | null> | null = null;
+
+const sleep = (ms: number) => new Promise((resolve) => setTimeout(resolve, ms));
+
+function tryAcquireRefreshLock(owner: string): boolean {
+  const held = localStorage.getItem(REFRESH_LOCK_KEY);
+  if (held && Number(held.split(':')[0]) > Date.now()) {
+    return held.endsWith(`:${owner}`);
+  }
+  localStorage.setItem(REFRESH_LOCK_KEY, `${Date.now() + REFRESH_LOCK_TTL_MS}:${owner}`);
+  return true;
+}
+
+function releaseRefreshLock(owner: string): void {
+  if (localStorage.getItem(REFRESH_LOCK_KEY)?.endsWith(`:${owner}`)) {
+    localStorage.removeItem(REFRESH_LOCK_KEY);
+  }
+}
+
+async function postRefresh(): Promise<AuthResponse | null> {
+  for (let attempt = 0; attempt < 3; attempt++) {
+    const response = await fetch(`${API_BASE_URL}/auth/refresh`, {
+      method: 'POST',
+      credentials: 'include',
+    });
+    // 409: 다른 탭이 방금 교체함 — 쿠키가 새 토큰으로 바뀌었으니 잠시 후 다시 시도
+    if (response.status === 409) {
+      await sleep(300 * (attempt + 1));
+      continue;
+    }
+    if (!response.ok) {
+      return null;
+    }
+    const data: AuthResponse = await response.json();
+    setToken(data.token);
+    setCurrentUser(data.user);
+    return data;
+  }
+  return null;
+}
+
+async function refreshAcrossTabs(): Promise<AuthResponse | null> {
+  const before = getToken();
+  const owner = Math.random().toString(36).slice(2);
+  const deadline = Date.now() + REFRESH_LOCK_TTL_MS;
+  while (Date.now() < deadline) {
+    // 다른 탭이 이미 갱신했으면 그 토큰을 쓴다
+    const current = getToken();
+    const user = getCurrentUser();
+    if (current && current !== before && user) {
+      return { token: current, user };
+    }
+    if (tryAcquireRefreshLock(owner)) {
+      // 동시에 잠금을 쓴 탭이 있으면 마지막으로 쓴 탭만 남는다
+      await sleep(50);
+      if (tryAcquireRefreshLock(owner)) {
+        try {
+          return await postRefresh();
+        } finally {
+          releaseRefreshLock(owner);
+        }
+      }
+    }
+    await sleep(200);
+  }
+  return postRefresh();
+}
+
+export function refreshSession(): Promise<AuthResponse | null> {
+  if (!refreshInFlight) {
+    refreshInFlight = refreshAcrossTabs().finally(() => {
+      refreshInFlight = null;
+    });
+  }
+  return refreshInFlight;
+}
+
+// 로그인된 기기 (GET /me/sessions)
+export interface UserSession {
+  id: number;
+  userAgent: string;
+  ip: string;
+  createdAt: string;
+  lastUsedAt: string;
+  expiresAt: string;
+  current: boolean;
+}
+
+export async function fetchMySessions(): Promise<UserSession[]> {
+  const token = getToken();
+  const response = await fetch(`${API_BASE_URL}/me/sessions`, {
+    headers: { Authorization: `Bearer ${token}` },
+  });
+  if (!response.ok) {
+    throw new Error('Failed to fetch sessions');
+  }
+  return response.json();
+}
+
+export async function revokeSession(id: number): Promise<void> {
+  const token = getToken();
+  const response = await fetch(`${API_BASE_URL}/me/sessions/${id}`, {
+    method: 'DELETE',
+    headers: { Authorization: `Bearer ${token}` },
+  });
+  if (!response.ok) {
+    throw new Error('Failed to revoke session');
+  }
+}
+
 // Auth API
 export async function register(email: string, password: string, name: string): Promise<AuthResponse> {
   const response = await fetch(`${API_BASE_URL}/auth/register`, {
@@ -111,6 +260,7 @@ export async function login(email: string, password: string): Promise<AuthRespon
   const response = await fetch(`${API_BASE_URL}/auth/login`, {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
+    credentials: 'include',
     body: JSON.stringify({ email, password }),
   });
   
@@ -122,6 +272,46 @@ export async function login(email: string, password: string): Promise<AuthRespon
   return response.json();
 }
 
+// 비밀번호 재설정 / 이메일 인증 — 메일 링크의 token을 페이지에서 POST로 제출한다
+async function postAccountToken(path: string, body: Record<string, string>, fallback: string): Promise<string> {
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(body),
+  });
+  const data = await response.json().catch(() => ({}));
+  if (!response.ok) {
+    throw new Error(data.error || fallback);
+  }
+  return data.message || '';
+}
+
+// 계정 유무와 관계없이 같은 안내 메시지가 온다
+export function requestPasswordReset(email: string): Promise<string> {
+  return postAccountToken('/auth/password/forgot', { email }, 'Failed to request password reset');
+}
+
+export function resetPassword(token: string, password: string): Promise<string> {
+  return postAccountToken('/auth/password/reset', { token, password }, 'Failed to reset password');
+}
+
+export function verifyEmail(token: string): Promise<string> {
+  return postAccountToken('/auth/email/verify', { token }, 'Failed to verify email');
+}
+
+export async function resendVerificationEmail(): Promise<string> {
+  const token = getToken();
+  const response = await fetch(`${API_BASE_URL}/me/email/verification`, {
+    method: 'POST',
+    headers: { Authorization: `Bearer ${token}` },
+  });
+  const data = await response.json().catch(() => ({}));
+  if (!response.ok) {
+    throw new Error(data.error || 'Failed to send verification email');
+  }
+  return data.message || '';
+}
+
 export async function getCurrentUserFromAPI(): Promise<User> {
   const token = getToken();
   const response = await fetch(`${API_BASE_URL}/user`, {
@@ -205,8 +395,9 @@ export async function createProduct(data: {
   version?: string;
   downloadUrl?: string;
   fileSize?: string;
-  features?: string;
-  systemRequirements?: string;
+  // 문자열(한 줄에 기능 하나 / 요구사항 메모)도 서버가 받아 구조화한다
+  features?: string | ProductFeature[];
+  systemRequirements?: string | SystemRequirements;
   originalPrice?: number;
 }): Promise<Product> {
   const token = getToken();
@@ -255,16 +446,15 @@ export async function deleteProduct(id: number): Promise<void> {
 // Cart API
 export async function fetchCart(): Promise<CartItem[]> {
   const token = getToken();
-  const sessionId = getSessionId();
   
   const headers: HeadersInit = {};
   if (token) headers.Authorization = `Bearer ${token}`;
   
   const url = token 
     ? `${API_BASE_URL}/cart` 
-    : `${API_BASE_URL}/cart?sessionId=${sessionId}`;
+    : `${API_BASE_URL}/cart${legacySessionQuery()}`;
   
-  const response = await fetch(url, { headers });
+  const response = await fetch(url, { headers, credentials: 'include' });
   if (!response.ok) {
     return []; // Return empty array if cart doesn't exist
   }
@@ -274,7 +464,7 @@ export async function fetchCart(): Promise<CartItem[]> {
 
 export async function addToCart(productId: number, quantity: number = 1): Promise<CartItem> {
   const token = getToken();
-  const sessionId = getSessionId();
+  const sessionId = localStorage.getItem('sessionId') || undefined;
   
   const headers: HeadersInit = { 'Content-Type': 'application/json' };
   if (token) headers.Authorization = `Bearer ${token}`;
@@ -282,6 +472,7 @@ export async function addToCart(productId: number, quantity: number = 1): Promis
   const response = await fetch(`${API_BASE_URL}/cart`, {
     method: 'POST',
     headers,
+    credentials: 'include',
     body: JSON.stringify({ productId, quantity, sessionId }),
   });
   if (!response.ok) {
@@ -292,8 +483,9 @@ export async function addToCart(productId: number, quantity: number = 1): Promis
 
 export async function updateCartItem(id: number, quantity: number): Promise<CartItem> {
   const token = getToken();
-  const response = await fetch(`${API_BASE_URL}/cart/${id}`, {
+  const response = await fetch(`${API_BASE_URL}/cart/${id}${legacySessionQuery()}`, {
     method: 'PUT',
+    credentials: 'include',
     headers: {
       'Content-Type': 'application/json',
       ...(token ? { Authorization: `Bearer ${token}` } : {}),
@@ -308,8 +500,9 @@ export async function updateCartItem(id: number, quantity: number): Promise<Cart
 
 export async function removeFromCart(id: number): Promise<void> {
   const token = getToken();
-  const response = await fetch(`${API_BASE_URL}/cart/${id}`, {
+  const response = await fetch(`${API_BASE_URL}/cart/${id}${legacySessionQuery()}`, {
     method: 'DELETE',
+    credentials: 'include',
     headers: token ? { Authorization: `Bearer ${token}` } : {},
   });
   if (!response.ok) {
@@ -319,14 +512,16 @@ export async function removeFromCart(id: number): Promise<void> {
 
 export async function mergeCart(): Promise<void> {
   const token = getToken();
-  const sessionId = getSessionId();
-  const response = await fetch(`${API_BASE_URL}/cart/merge?sessionId=${sessionId}`, {
+  const response = await fetch(`${API_BASE_URL}/cart/merge${legacySessionQuery()}`, {
     method: 'POST',
     headers: { Authorization: `Bearer ${token}` },
+    credentials: 'include',
   });
   if (!response.ok) {
     throw new Error('Failed to merge cart');
   }
+  // 합친 게스트 세션은 서버에서 폐기되므로 예전 sessionId도 더 보낼 필요가 없다
+  localStorage.removeItem('sessionId');
 }
 
 // Diary API
@@ -180,6 +180,7 @@ export async function verifyWallet(
   const response = await fetch(`${API_BASE_URL}/auth/verify`, {
     method: 'POST',
     headers: { 'Content-Type': 'application/json', ...(token ? { Authorization: `Bearer ${token}` } : {}) },
+    credentials: 'include',
     body: JSON.stringify({ walletAddress, signature, nonce }),
   });
   if (!response.ok) {
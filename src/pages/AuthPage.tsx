@@ -198,7 +198,10 @@ export default function AuthPage() {
             </button>
           </div>
           {isLogin && (
-            <div className="text-sm text-center">
+            <div className="text-sm text-center space-x-4">
+              <Link to="/reset-password" className="text-muted-foreground hover:text-primary">
+                Forgot password?
+              </Link>
               <Link to="/" className="text-muted-foreground hover:text-primary">
                 Continue as guest
               </Link>
@@ -1,6 +1,9 @@
 import { useState, useEffect } from 'react';
 import { useParams, useNavigate, Link } from 'react-router-dom';
-import { fetchProduct, addToCart as addToCartAPI, type Product as APIProduct } from '../lib/api';
+import {
+  fetchProduct, addToCart as addToCartAPI, type Product as APIProduct,
+  type ProductFeature, type SystemRequirements,
+} from '../lib/api';
 import { getAgents, type Agent } from '../lib/paymentApi';
 import AnalysisPurchase from '../components/AnalysisPurchase';
 import { useCart } from '../contexts/CartContext';
@@ -25,8 +28,8 @@ interface Product {
   downloadUrl?: string;
   fileSize?: string;
   licenseKey?: string;
-  features?: string;
-  systemRequirements?: string;
+  features?: ProductFeature[];
+  systemRequirements?: SystemRequirements;
 }
 
 // Financial dark theme colors
@@ -44,6 +47,20 @@ const theme = {
   gradient: 'bg-gradient-to-r from-[#a9823a] to-[#8f6d2c]',
 };
 
+// 요구사항 매트릭스를 목록 표시용 문장으로
+function describeRequirements(req?: SystemRequirements): string[] {
+  if (!req) return [];
+  const lines: string[] = [];
+  if (req.os?.length) lines.push(`OS: ${req.os.join(', ')}`);
+  if (req.cpuArch?.length) lines.push(`CPU: ${req.cpuArch.join(', ')}${req.minCpuCores ? ` (${req.minCpuCores}코어 이상)` : ''}`);
+  else if (req.minCpuCores) lines.push(`CPU: ${req.minCpuCores}코어 이상`);
+  if (req.minRamMb) lines.push(`RAM: ${req.minRamMb >= 1024 ? `${+(req.minRamMb / 1024).toFixed(1)}GB` : `${req.minRamMb}MB`} 이상`);
+  if (req.minDiskMb) lines.push(`디스크: ${req.minDiskMb}MB 이상`);
+  if (req.brokers?.length) lines.push(`증권사 API: ${req.brokers.join(', ')}`);
+  if (req.notes) lines.push(req.notes);
+  return lines;
+}
+
 export default function ProductPage() {
   const { id } = useParams<{ id: string }>();
   const navigate = useNavigate();
@@ -152,24 +169,10 @@ export default function ProductPage() {
     );
   }
 
-  let features: string[] = [];
-  let requirements: string[] = [];
-  
-  try {
-    if (product.features) {
-      features = JSON.parse(product.features);
-    }
-  } catch (e) {
-    features = product.features ? [product.features] : [];
-  }
-  
-  try {
-    if (product.systemRequirements) {
-      requirements = JSON.parse(product.systemRequirements);
-    }
-  } catch (e) {
-    requirements = product.systemRequirements ? [product.systemRequirements] : [];
-  }
+  const features: string[] = (product.features ?? []).map((f) =>
+    f.description ? `${f.title} — ${f.description}` : f.title
+  );
+  const requirements: string[] = describeRequirements(product.systemRequirements);
 
   return (
     <div className={`min-h-screen ${theme.bg}`}>
@@ -0,0 +1,48 @@
+import { useEffect, useRef, useState } from 'react';
+import { Link, useSearchParams } from 'react-router-dom';
+import { Button } from '../components/ui/button';
+import { Card, CardDescription, CardFooter, CardHeader, CardTitle } from '../components/ui/card';
+import { verifyEmail } from '../lib/api';
+
+// 인증 메일 링크(?token=) 도착 페이지 — 토큰은 1회용이라 한 번만 제출한다
+export default function VerifyEmailPage() {
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get('token') || '';
+  const [status, setStatus] = useState<'pending' | 'done' | 'failed'>(token ? 'pending' : 'failed');
+  const [message, setMessage] = useState(token ? 'Verifying your email...' : 'The verification link is missing a token.');
+  const submitted = useRef(false);
+
+  useEffect(() => {
+    if (!token || submitted.current) return;
+    submitted.current = true;
+    verifyEmail(token)
+      .then(() => {
+        setStatus('done');
+        setMessage('Your email address has been verified.');
+      })
+      .catch((err) => {
+        setStatus('failed');
+        setMessage(err instanceof Error ? err.message : 'Verification failed');
+      });
+  }, [token]);
+
+  return (
+    <div className="min-h-screen bg-background flex items-center justify-center px-4">
+      <Card className="w-full max-w-md">
+        <CardHeader className="space-y-1">
+          <CardTitle className="text-2xl font-bold text-center">
+            {status === 'done' ? '✅ Email Verified' : status === 'failed' ? 'Verification Failed' : 'Email Verification'}
+          </CardTitle>
+          <CardDescription className="text-center">{message}</CardDescription>
+        </CardHeader>
+        <CardFooter className="flex justify-center">
+          <Link to={status === 'done' ? '/' : '/auth'}>
+            <Button variant={status === 'done' ? 'default' : 'outline'}>
+              {status === 'done' ? 'Go to shop' : 'Back to sign in'}
+            </Button>
+          </Link>
+        </CardFooter>
+      </Card>
+    </div>
+  );
+}